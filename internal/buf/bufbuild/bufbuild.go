@@ -136,6 +136,24 @@ type ProtoFileSet interface {
 	RealFilePaths() []string
 }
 
+// NewProtoFileSet returns a new ProtoFileSet for the roots and the map from
+// root file path to real file path.
+//
+// This is for callers that resolve files themselves, such as protoc compatibility.
+// Unlike a ProtoFileSet from a Provider, the roots may overlap, in which case
+// imports resolve against the first root that contains them, which matches protoc.
+//
+// The roots and paths must be normalized and validated, and the mapping must be 1-1.
+func NewProtoFileSet(roots []string, rootFilePathToRealFilePath map[string]string) (ProtoFileSet, error) {
+	if len(roots) == 0 {
+		return nil, errs.NewInvalidArgument("no roots specified")
+	}
+	if len(rootFilePathToRealFilePath) == 0 {
+		return nil, errs.NewInvalidArgument("no input files specified")
+	}
+	return newProtoFileSet(roots, rootFilePathToRealFilePath)
+}
+
 // Provider is a provider.
 type Provider interface {
 	// GetProtoFileSetForBucket gets the set for the bucket and config.
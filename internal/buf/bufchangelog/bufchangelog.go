@@ -0,0 +1,173 @@
+// Package bufchangelog produces changelogs between two Images.
+package bufchangelog
+
+import (
+	"context"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/bufbuild/buf/internal/buf/bufbuild"
+	"github.com/bufbuild/buf/internal/buf/bufpb"
+	"go.uber.org/zap"
+)
+
+const (
+	// ChangeTypeAdded says the element was added.
+	ChangeTypeAdded = "added"
+	// ChangeTypeRemoved says the element was removed.
+	ChangeTypeRemoved = "removed"
+	// ChangeTypeModified says the element was modified.
+	ChangeTypeModified = "modified"
+)
+
+const (
+	// FormatText is the text format.
+	FormatText Format = 1
+	// FormatJSON is the JSON format, with one Change per line.
+	FormatJSON Format = 2
+	// FormatMarkdown is the Markdown format, grouped by change type.
+	FormatMarkdown Format = 3
+)
+
+var (
+	formatToString = map[Format]string{
+		FormatText:     "text",
+		FormatJSON:     "json",
+		FormatMarkdown: "markdown",
+	}
+	stringToFormat = map[string]Format{
+		"text":     FormatText,
+		"json":     FormatJSON,
+		"markdown": FormatMarkdown,
+	}
+)
+
+// Format is a changelog format.
+type Format int
+
+// String implements fmt.Stringer.
+func (f Format) String() string {
+	s, ok := formatToString[f]
+	if !ok {
+		return strconv.Itoa(int(f))
+	}
+	return s
+}
+
+// ParseFormat parses the format.
+//
+// The empty string is parsed as FormatText.
+// Returns false if the format is unknown.
+func ParseFormat(s string) (Format, bool) {
+	s = strings.TrimSpace(strings.ToLower(s))
+	if s == "" {
+		return FormatText, true
+	}
+	format, ok := stringToFormat[s]
+	return format, ok
+}
+
+// Change is a single change between two Images.
+type Change struct {
+	// Type is the type of change, one of ChangeTypeAdded, ChangeTypeRemoved,
+	// or ChangeTypeModified.
+	Type string `json:"type,omitempty" yaml:"type,omitempty"`
+	// Kind is the kind of element that changed, ie "message" or "field".
+	Kind string `json:"kind,omitempty" yaml:"kind,omitempty"`
+	// Name is the fully-qualified name of the element that changed.
+	//
+	// For files, this is the file path.
+	Name string `json:"name,omitempty" yaml:"name,omitempty"`
+	// Message describes the modification.
+	//
+	// This is only set for ChangeTypeModified.
+	Message string `json:"message,omitempty" yaml:"message,omitempty"`
+	// PreviousLocation is the location of the element within the previous Image.
+	//
+	// This is not set for ChangeTypeAdded.
+	PreviousLocation *Location `json:"previous_location,omitempty" yaml:"previous_location,omitempty"`
+	// Location is the location of the element within the current Image.
+	//
+	// This is not set for ChangeTypeRemoved.
+	Location *Location `json:"location,omitempty" yaml:"location,omitempty"`
+}
+
+// Location is a location within a file.
+type Location struct {
+	// Filename is the filename. This is always set.
+	Filename string `json:"filename,omitempty" yaml:"filename,omitempty"`
+	// Line is the line. If the Image did not have source info, this will be 0.
+	Line int `json:"line,omitempty" yaml:"line,omitempty"`
+	// Column is the column. If the Image did not have source info, this will be 0.
+	Column int `json:"column,omitempty" yaml:"column,omitempty"`
+}
+
+// String returns a basic string representation of l.
+func (l *Location) String() string {
+	if l.Line == 0 {
+		return l.Filename
+	}
+	return l.Filename + ":" + strconv.Itoa(l.Line) + ":" + strconv.Itoa(l.Column)
+}
+
+// Handler handles changelogs.
+type Handler interface {
+	// Changelog returns the changes from previousImage to image.
+	//
+	// Imports are included in the changelog if they are in the Images.
+	// Filenames will be relative to the roots, use FixChangeFilenames to
+	// make them into real file paths.
+	//
+	// Changes are sorted by name.
+	Changelog(
+		ctx context.Context,
+		previousImage bufpb.Image,
+		image bufpb.Image,
+	) ([]*Change, error)
+}
+
+// NewHandler returns a new Handler.
+func NewHandler(logger *zap.Logger) Handler {
+	return newHandler(logger)
+}
+
+// FixChangeFilenames attempts to make all filenames into real file paths.
+//
+// previousResolver is applied to previous locations, resolver is applied to locations.
+// If either resolver is nil, the respective locations are not modified.
+func FixChangeFilenames(
+	previousResolver bufbuild.ProtoFilePathResolver,
+	resolver bufbuild.ProtoFilePathResolver,
+	changes []*Change,
+) error {
+	for _, change := range changes {
+		if err := fixLocationFilename(previousResolver, change.PreviousLocation); err != nil {
+			return err
+		}
+		if err := fixLocationFilename(resolver, change.Location); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// PrintChanges prints the changes to the writer in the given format.
+func PrintChanges(writer io.Writer, changes []*Change, format Format) error {
+	return printChanges(writer, changes, format)
+}
+
+func fixLocationFilename(resolver bufbuild.ProtoFilePathResolver, location *Location) error {
+	if resolver == nil || location == nil || location.Filename == "" {
+		return nil
+	}
+	filePath, err := resolver.GetFilePath(location.Filename)
+	if err != nil {
+		if err == bufbuild.ErrFilePathUnknown {
+			return nil
+		}
+		return err
+	}
+	location.Filename = filePath
+	return nil
+}
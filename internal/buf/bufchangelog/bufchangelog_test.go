@@ -0,0 +1,139 @@
+package bufchangelog_test
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bufbuild/buf/internal/buf/bufchangelog"
+	"github.com/bufbuild/buf/internal/buf/buftesting"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestChangelog(t *testing.T) {
+	t.Parallel()
+	changes := testGetChanges(t)
+	assert.Equal(
+		t,
+		[]*bufchangelog.Change{
+			newChange(bufchangelog.ChangeTypeModified, "method", "a.Four.Five", "server streaming changed from false to true", newLocation(21, 26), newLocation(21, 33)),
+			newChange(bufchangelog.ChangeTypeAdded, "method", "a.Four.Six", "", nil, newLocation(22, 7)),
+			newChange(bufchangelog.ChangeTypeModified, "field", "a.One.bar", `type changed from "int32" to "int64"`, newLocation(9, 3), newLocation(9, 3)),
+			newChange(bufchangelog.ChangeTypeModified, "field", "a.One.bat", `field 3 renamed from "baz" to "bat"`, newLocation(10, 9), newLocation(10, 9)),
+			newChange(bufchangelog.ChangeTypeModified, "field", "a.One.bat", `json_name changed from "baz" to "bat"`, newLocation(10, 9), newLocation(10, 9)),
+			newChange(bufchangelog.ChangeTypeModified, "field", "a.One.foo", "deprecated", newLocation(8, 10), newLocation(8, 10)),
+			newChange(bufchangelog.ChangeTypeAdded, "field", "a.One.qux", "", nil, newLocation(11, 8)),
+			newChange(bufchangelog.ChangeTypeModified, "enum_value", "a.Three.THREE_ONE", "number changed from 1 to 2", newLocation(17, 15), newLocation(16, 15)),
+			newChange(bufchangelog.ChangeTypeAdded, "enum_value", "a.Three.THREE_TWO", "", nil, newLocation(17, 3)),
+			newChange(bufchangelog.ChangeTypeRemoved, "message", "a.Two", "", newLocation(13, 9), nil),
+			newChange(bufchangelog.ChangeTypeModified, "file", "a.proto", `option go_package changed from "apb" to "apbv2"`, newLocation(5, 1), newLocation(5, 1)),
+		},
+		changes,
+	)
+}
+
+func TestPrintChanges(t *testing.T) {
+	t.Parallel()
+	changes := []*bufchangelog.Change{
+		newChange(bufchangelog.ChangeTypeAdded, "enum_value", "a.Three.THREE_TWO", "", nil, newLocation(18, 3)),
+		newChange(bufchangelog.ChangeTypeRemoved, "message", "a.Two", "", newLocation(13, 9), nil),
+		newChange(bufchangelog.ChangeTypeModified, "field", "a.One.foo", "deprecated", newLocation(8, 10), newLocation(8, 10)),
+	}
+	testPrintChanges(
+		t,
+		changes,
+		bufchangelog.FormatText,
+		`added enum value a.Three.THREE_TWO (a.proto:18:3)
+removed message a.Two (a.proto:13:9)
+modified field a.One.foo: deprecated (a.proto:8:10 -> a.proto:8:10)
+`,
+	)
+	testPrintChanges(
+		t,
+		changes,
+		bufchangelog.FormatJSON,
+		`{"type":"added","kind":"enum_value","name":"a.Three.THREE_TWO","location":{"filename":"a.proto","line":18,"column":3}}
+{"type":"removed","kind":"message","name":"a.Two","previous_location":{"filename":"a.proto","line":13,"column":9}}
+{"type":"modified","kind":"field","name":"a.One.foo","message":"deprecated","previous_location":{"filename":"a.proto","line":8,"column":10},"location":{"filename":"a.proto","line":8,"column":10}}
+`,
+	)
+	testPrintChanges(
+		t,
+		changes,
+		bufchangelog.FormatMarkdown,
+		`## Added
+
+- enum value a.Three.THREE_TWO (a.proto:18:3)
+
+## Removed
+
+- message a.Two (a.proto:13:9)
+
+## Modified
+
+- field a.One.foo: deprecated (a.proto:8:10 -> a.proto:8:10)
+`,
+	)
+}
+
+func TestParseFormat(t *testing.T) {
+	t.Parallel()
+	for s, expected := range map[string]bufchangelog.Format{
+		"":         bufchangelog.FormatText,
+		"text":     bufchangelog.FormatText,
+		"JSON":     bufchangelog.FormatJSON,
+		"markdown": bufchangelog.FormatMarkdown,
+	} {
+		format, ok := bufchangelog.ParseFormat(s)
+		assert.True(t, ok, s)
+		assert.Equal(t, expected, format, s)
+	}
+	_, ok := bufchangelog.ParseFormat("yaml")
+	assert.False(t, ok)
+}
+
+func testPrintChanges(t *testing.T, changes []*bufchangelog.Change, format bufchangelog.Format, expected string) {
+	buffer := bytes.NewBuffer(nil)
+	require.NoError(t, bufchangelog.PrintChanges(buffer, changes, format))
+	assert.Equal(t, expected, buffer.String())
+}
+
+func testGetChanges(t *testing.T) []*bufchangelog.Change {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	previousImage := buftesting.BuildImage(t, filepath.Join("testdata", "previous"), false, true)
+	image := buftesting.BuildImage(t, filepath.Join("testdata", "current"), false, true)
+	changes, err := bufchangelog.NewHandler(zap.NewNop()).Changelog(ctx, previousImage, image)
+	require.NoError(t, err)
+	return changes
+}
+
+func newChange(
+	changeType string,
+	kind string,
+	name string,
+	message string,
+	previousLocation *bufchangelog.Location,
+	location *bufchangelog.Location,
+) *bufchangelog.Change {
+	return &bufchangelog.Change{
+		Type:             changeType,
+		Kind:             kind,
+		Name:             name,
+		Message:          message,
+		PreviousLocation: previousLocation,
+		Location:         location,
+	}
+}
+
+func newLocation(line int, column int) *bufchangelog.Location {
+	return &bufchangelog.Location{
+		Filename: "a.proto",
+		Line:     line,
+		Column:   column,
+	}
+}
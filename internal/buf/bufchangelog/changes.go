@@ -0,0 +1,670 @@
+package bufchangelog
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/bufbuild/buf/internal/pkg/protodesc"
+)
+
+const (
+	kindFile      = "file"
+	kindMessage   = "message"
+	kindField     = "field"
+	kindEnum      = "enum"
+	kindEnumValue = "enum_value"
+	kindService   = "service"
+	kindMethod    = "method"
+)
+
+func getChanges(previousFiles []protodesc.File, files []protodesc.File) ([]*Change, error) {
+	c := newChangeBuilder()
+	if err := c.addFileChanges(previousFiles, files); err != nil {
+		return nil, err
+	}
+	if err := c.addMessageChanges(previousFiles, files); err != nil {
+		return nil, err
+	}
+	if err := c.addEnumChanges(previousFiles, files); err != nil {
+		return nil, err
+	}
+	if err := c.addServiceChanges(previousFiles, files); err != nil {
+		return nil, err
+	}
+	if err := c.addMethodChanges(previousFiles, files); err != nil {
+		return nil, err
+	}
+	sort.Stable(sortChanges(c.changes))
+	return c.changes, nil
+}
+
+type changeBuilder struct {
+	changes []*Change
+}
+
+func newChangeBuilder() *changeBuilder {
+	return &changeBuilder{}
+}
+
+func (c *changeBuilder) addFileChanges(previousFiles []protodesc.File, files []protodesc.File) error {
+	previousFilePathToFile, err := protodesc.FilePathToFile(previousFiles...)
+	if err != nil {
+		return err
+	}
+	filePathToFile, err := protodesc.FilePathToFile(files...)
+	if err != nil {
+		return err
+	}
+	for filePath, previousFile := range previousFilePathToFile {
+		if _, ok := filePathToFile[filePath]; !ok {
+			c.addRemoved(kindFile, filePath, newFileLocation(previousFile, nil))
+		}
+	}
+	for filePath, file := range filePathToFile {
+		previousFile, ok := previousFilePathToFile[filePath]
+		if !ok {
+			c.addAdded(kindFile, filePath, newFileLocation(file, nil))
+			continue
+		}
+		c.addFileModified(previousFile, file)
+	}
+	return nil
+}
+
+func (c *changeBuilder) addFileModified(previousFile protodesc.File, file protodesc.File) {
+	filePath := file.FilePath()
+	if previousFile.Package() != file.Package() {
+		c.addModified(
+			kindFile,
+			filePath,
+			fmt.Sprintf("package changed from %q to %q", previousFile.Package(), file.Package()),
+			newFileLocation(previousFile, previousFile.PackageLocation()),
+			newFileLocation(file, file.PackageLocation()),
+		)
+	}
+	if previousFile.Syntax() != file.Syntax() {
+		c.addModified(
+			kindFile,
+			filePath,
+			fmt.Sprintf("syntax changed from %q to %q", previousFile.Syntax().String(), file.Syntax().String()),
+			newFileLocation(previousFile, previousFile.SyntaxLocation()),
+			newFileLocation(file, file.SyntaxLocation()),
+		)
+	}
+	for _, fileOption := range []struct {
+		name        string
+		getValue    func(protodesc.File) string
+		getLocation func(protodesc.File) protodesc.Location
+	}{
+		{
+			name:        "csharp_namespace",
+			getValue:    func(f protodesc.File) string { return strconv.Quote(f.CsharpNamespace()) },
+			getLocation: protodesc.File.CsharpNamespaceLocation,
+		},
+		{
+			name:        "go_package",
+			getValue:    func(f protodesc.File) string { return strconv.Quote(f.GoPackage()) },
+			getLocation: protodesc.File.GoPackageLocation,
+		},
+		{
+			name:        "java_multiple_files",
+			getValue:    func(f protodesc.File) string { return strconv.FormatBool(f.JavaMultipleFiles()) },
+			getLocation: protodesc.File.JavaMultipleFilesLocation,
+		},
+		{
+			name:        "java_outer_classname",
+			getValue:    func(f protodesc.File) string { return strconv.Quote(f.JavaOuterClassname()) },
+			getLocation: protodesc.File.JavaOuterClassnameLocation,
+		},
+		{
+			name:        "java_package",
+			getValue:    func(f protodesc.File) string { return strconv.Quote(f.JavaPackage()) },
+			getLocation: protodesc.File.JavaPackageLocation,
+		},
+		{
+			name:        "java_string_check_utf8",
+			getValue:    func(f protodesc.File) string { return strconv.FormatBool(f.JavaStringCheckUtf8()) },
+			getLocation: protodesc.File.JavaStringCheckUtf8Location,
+		},
+		{
+			name:        "objc_class_prefix",
+			getValue:    func(f protodesc.File) string { return strconv.Quote(f.ObjcClassPrefix()) },
+			getLocation: protodesc.File.ObjcClassPrefixLocation,
+		},
+		{
+			name:        "php_class_prefix",
+			getValue:    func(f protodesc.File) string { return strconv.Quote(f.PhpClassPrefix()) },
+			getLocation: protodesc.File.PhpClassPrefixLocation,
+		},
+		{
+			name:        "php_namespace",
+			getValue:    func(f protodesc.File) string { return strconv.Quote(f.PhpNamespace()) },
+			getLocation: protodesc.File.PhpNamespaceLocation,
+		},
+		{
+			name:        "php_metadata_namespace",
+			getValue:    func(f protodesc.File) string { return strconv.Quote(f.PhpMetadataNamespace()) },
+			getLocation: protodesc.File.PhpMetadataNamespaceLocation,
+		},
+		{
+			name:        "ruby_package",
+			getValue:    func(f protodesc.File) string { return strconv.Quote(f.RubyPackage()) },
+			getLocation: protodesc.File.RubyPackageLocation,
+		},
+		{
+			name:        "swift_prefix",
+			getValue:    func(f protodesc.File) string { return strconv.Quote(f.SwiftPrefix()) },
+			getLocation: protodesc.File.SwiftPrefixLocation,
+		},
+		{
+			name:        "optimize_for",
+			getValue:    func(f protodesc.File) string { return f.OptimizeFor().String() },
+			getLocation: protodesc.File.OptimizeForLocation,
+		},
+		{
+			name:        "cc_generic_services",
+			getValue:    func(f protodesc.File) string { return strconv.FormatBool(f.CcGenericServices()) },
+			getLocation: protodesc.File.CcGenericServicesLocation,
+		},
+		{
+			name:        "java_generic_services",
+			getValue:    func(f protodesc.File) string { return strconv.FormatBool(f.JavaGenericServices()) },
+			getLocation: protodesc.File.JavaGenericServicesLocation,
+		},
+		{
+			name:        "py_generic_services",
+			getValue:    func(f protodesc.File) string { return strconv.FormatBool(f.PyGenericServices()) },
+			getLocation: protodesc.File.PyGenericServicesLocation,
+		},
+		{
+			name:        "php_generic_services",
+			getValue:    func(f protodesc.File) string { return strconv.FormatBool(f.PhpGenericServices()) },
+			getLocation: protodesc.File.PhpGenericServicesLocation,
+		},
+		{
+			name:        "cc_enable_arenas",
+			getValue:    func(f protodesc.File) string { return strconv.FormatBool(f.CcEnableArenas()) },
+			getLocation: protodesc.File.CcEnableArenasLocation,
+		},
+	} {
+		previousValue := fileOption.getValue(previousFile)
+		value := fileOption.getValue(file)
+		if previousValue != value {
+			c.addModified(
+				kindFile,
+				filePath,
+				fmt.Sprintf("option %s changed from %s to %s", fileOption.name, previousValue, value),
+				newFileLocation(previousFile, fileOption.getLocation(previousFile)),
+				newFileLocation(file, fileOption.getLocation(file)),
+			)
+		}
+	}
+	c.addDeprecatedModified(kindFile, filePath, previousFile, file, newFileLocation(previousFile, nil), newFileLocation(file, nil))
+}
+
+func (c *changeBuilder) addMessageChanges(previousFiles []protodesc.File, files []protodesc.File) error {
+	previousFullNameToMessage, err := protodesc.FullNameToMessage(previousFiles...)
+	if err != nil {
+		return err
+	}
+	fullNameToMessage, err := protodesc.FullNameToMessage(files...)
+	if err != nil {
+		return err
+	}
+	for fullName, previousMessage := range previousFullNameToMessage {
+		// map entries are reported as changes to their fields
+		if previousMessage.IsMapEntry() {
+			continue
+		}
+		if _, ok := fullNameToMessage[fullName]; !ok {
+			c.addRemoved(kindMessage, fullName, newNamedLocation(previousMessage))
+		}
+	}
+	for fullName, message := range fullNameToMessage {
+		if message.IsMapEntry() {
+			continue
+		}
+		previousMessage, ok := previousFullNameToMessage[fullName]
+		if !ok {
+			c.addAdded(kindMessage, fullName, newNamedLocation(message))
+			continue
+		}
+		if err := c.addMessageModified(previousMessage, message); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *changeBuilder) addMessageModified(previousMessage protodesc.Message, message protodesc.Message) error {
+	fullName := message.FullName()
+	c.addMovedModified(kindMessage, fullName, previousMessage, message)
+	if previousMessage.MessageSetWireFormat() != message.MessageSetWireFormat() {
+		c.addModified(
+			kindMessage,
+			fullName,
+			fmt.Sprintf("option message_set_wire_format changed from %t to %t", previousMessage.MessageSetWireFormat(), message.MessageSetWireFormat()),
+			newLocation(previousMessage.FilePath(), previousMessage.MessageSetWireFormatLocation(), previousMessage.NameLocation()),
+			newLocation(message.FilePath(), message.MessageSetWireFormatLocation(), message.NameLocation()),
+		)
+	}
+	if previousMessage.NoStandardDescriptorAccessor() != message.NoStandardDescriptorAccessor() {
+		c.addModified(
+			kindMessage,
+			fullName,
+			fmt.Sprintf("option no_standard_descriptor_accessor changed from %t to %t", previousMessage.NoStandardDescriptorAccessor(), message.NoStandardDescriptorAccessor()),
+			newLocation(previousMessage.FilePath(), previousMessage.NoStandardDescriptorAccessorLocation(), previousMessage.NameLocation()),
+			newLocation(message.FilePath(), message.NoStandardDescriptorAccessorLocation(), message.NameLocation()),
+		)
+	}
+	c.addDeprecatedModified(kindMessage, fullName, previousMessage, message, newNamedLocation(previousMessage), newNamedLocation(message))
+
+	previousNumberToField, err := protodesc.NumberToMessageField(previousMessage)
+	if err != nil {
+		return err
+	}
+	numberToField, err := protodesc.NumberToMessageField(message)
+	if err != nil {
+		return err
+	}
+	for number, previousField := range previousNumberToField {
+		if _, ok := numberToField[number]; !ok {
+			c.addRemoved(kindField, previousField.FullName(), newNamedLocation(previousField))
+		}
+	}
+	for number, field := range numberToField {
+		previousField, ok := previousNumberToField[number]
+		if !ok {
+			c.addAdded(kindField, field.FullName(), newNamedLocation(field))
+			continue
+		}
+		c.addFieldModified(previousField, field)
+	}
+	return nil
+}
+
+func (c *changeBuilder) addFieldModified(previousField protodesc.Field, field protodesc.Field) {
+	fullName := field.FullName()
+	if previousField.Name() != field.Name() {
+		c.addModified(
+			kindField,
+			fullName,
+			fmt.Sprintf("field %d renamed from %q to %q", field.Number(), previousField.Name(), field.Name()),
+			newNamedLocation(previousField),
+			newNamedLocation(field),
+		)
+	}
+	if previousField.Label() != field.Label() {
+		c.addModified(
+			kindField,
+			fullName,
+			fmt.Sprintf("label changed from %q to %q", previousField.Label().String(), field.Label().String()),
+			newNamedLocation(previousField),
+			newNamedLocation(field),
+		)
+	}
+	if previousType, typ := getFieldTypeString(previousField), getFieldTypeString(field); previousType != typ {
+		c.addModified(
+			kindField,
+			fullName,
+			fmt.Sprintf("type changed from %q to %q", previousType, typ),
+			newLocation(previousField.FilePath(), previousField.TypeLocation(), previousField.TypeNameLocation(), previousField.NameLocation()),
+			newLocation(field.FilePath(), field.TypeLocation(), field.TypeNameLocation(), field.NameLocation()),
+		)
+	}
+	if previousField.JSONName() != field.JSONName() {
+		c.addModified(
+			kindField,
+			fullName,
+			fmt.Sprintf("json_name changed from %q to %q", previousField.JSONName(), field.JSONName()),
+			newLocation(previousField.FilePath(), previousField.JSONNameLocation(), previousField.NameLocation()),
+			newLocation(field.FilePath(), field.JSONNameLocation(), field.NameLocation()),
+		)
+	}
+	if previousField.JSType() != field.JSType() {
+		c.addModified(
+			kindField,
+			fullName,
+			fmt.Sprintf("option jstype changed from %s to %s", previousField.JSType().String(), field.JSType().String()),
+			newLocation(previousField.FilePath(), previousField.JSTypeLocation(), previousField.NameLocation()),
+			newLocation(field.FilePath(), field.JSTypeLocation(), field.NameLocation()),
+		)
+	}
+	if previousField.CType() != field.CType() {
+		c.addModified(
+			kindField,
+			fullName,
+			fmt.Sprintf("option ctype changed from %s to %s", previousField.CType().String(), field.CType().String()),
+			newLocation(previousField.FilePath(), previousField.CTypeLocation(), previousField.NameLocation()),
+			newLocation(field.FilePath(), field.CTypeLocation(), field.NameLocation()),
+		)
+	}
+	if previousPacked, packed := getPackedString(previousField.Packed()), getPackedString(field.Packed()); previousPacked != packed {
+		c.addModified(
+			kindField,
+			fullName,
+			fmt.Sprintf("option packed changed from %s to %s", previousPacked, packed),
+			newLocation(previousField.FilePath(), previousField.PackedLocation(), previousField.NameLocation()),
+			newLocation(field.FilePath(), field.PackedLocation(), field.NameLocation()),
+		)
+	}
+	c.addDeprecatedModified(kindField, fullName, previousField, field, newNamedLocation(previousField), newNamedLocation(field))
+}
+
+func (c *changeBuilder) addEnumChanges(previousFiles []protodesc.File, files []protodesc.File) error {
+	previousFullNameToEnum, err := protodesc.FullNameToEnum(previousFiles...)
+	if err != nil {
+		return err
+	}
+	fullNameToEnum, err := protodesc.FullNameToEnum(files...)
+	if err != nil {
+		return err
+	}
+	for fullName, previousEnum := range previousFullNameToEnum {
+		if _, ok := fullNameToEnum[fullName]; !ok {
+			c.addRemoved(kindEnum, fullName, newNamedLocation(previousEnum))
+		}
+	}
+	for fullName, enum := range fullNameToEnum {
+		previousEnum, ok := previousFullNameToEnum[fullName]
+		if !ok {
+			c.addAdded(kindEnum, fullName, newNamedLocation(enum))
+			continue
+		}
+		if err := c.addEnumModified(previousEnum, enum); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *changeBuilder) addEnumModified(previousEnum protodesc.Enum, enum protodesc.Enum) error {
+	fullName := enum.FullName()
+	c.addMovedModified(kindEnum, fullName, previousEnum, enum)
+	if previousEnum.AllowAlias() != enum.AllowAlias() {
+		c.addModified(
+			kindEnum,
+			fullName,
+			fmt.Sprintf("option allow_alias changed from %t to %t", previousEnum.AllowAlias(), enum.AllowAlias()),
+			newLocation(previousEnum.FilePath(), previousEnum.AllowAliasLocation(), previousEnum.NameLocation()),
+			newLocation(enum.FilePath(), enum.AllowAliasLocation(), enum.NameLocation()),
+		)
+	}
+	c.addDeprecatedModified(kindEnum, fullName, previousEnum, enum, newNamedLocation(previousEnum), newNamedLocation(enum))
+
+	previousNameToEnumValue, err := protodesc.NameToEnumValue(previousEnum)
+	if err != nil {
+		return err
+	}
+	nameToEnumValue, err := protodesc.NameToEnumValue(enum)
+	if err != nil {
+		return err
+	}
+	for name, previousEnumValue := range previousNameToEnumValue {
+		if _, ok := nameToEnumValue[name]; !ok {
+			c.addRemoved(kindEnumValue, previousEnumValue.FullName(), newNamedLocation(previousEnumValue))
+		}
+	}
+	for name, enumValue := range nameToEnumValue {
+		previousEnumValue, ok := previousNameToEnumValue[name]
+		if !ok {
+			c.addAdded(kindEnumValue, enumValue.FullName(), newNamedLocation(enumValue))
+			continue
+		}
+		if previousEnumValue.Number() != enumValue.Number() {
+			c.addModified(
+				kindEnumValue,
+				enumValue.FullName(),
+				fmt.Sprintf("number changed from %d to %d", previousEnumValue.Number(), enumValue.Number()),
+				newLocation(previousEnumValue.FilePath(), previousEnumValue.NumberLocation(), previousEnumValue.NameLocation()),
+				newLocation(enumValue.FilePath(), enumValue.NumberLocation(), enumValue.NameLocation()),
+			)
+		}
+		c.addDeprecatedModified(kindEnumValue, enumValue.FullName(), previousEnumValue, enumValue, newNamedLocation(previousEnumValue), newNamedLocation(enumValue))
+	}
+	return nil
+}
+
+func (c *changeBuilder) addServiceChanges(previousFiles []protodesc.File, files []protodesc.File) error {
+	previousFullNameToService, err := protodesc.FullNameToService(previousFiles...)
+	if err != nil {
+		return err
+	}
+	fullNameToService, err := protodesc.FullNameToService(files...)
+	if err != nil {
+		return err
+	}
+	for fullName, previousService := range previousFullNameToService {
+		if _, ok := fullNameToService[fullName]; !ok {
+			c.addRemoved(kindService, fullName, newNamedLocation(previousService))
+		}
+	}
+	for fullName, service := range fullNameToService {
+		previousService, ok := previousFullNameToService[fullName]
+		if !ok {
+			c.addAdded(kindService, fullName, newNamedLocation(service))
+			continue
+		}
+		c.addMovedModified(kindService, fullName, previousService, service)
+		c.addDeprecatedModified(kindService, fullName, previousService, service, newNamedLocation(previousService), newNamedLocation(service))
+	}
+	return nil
+}
+
+func (c *changeBuilder) addMethodChanges(previousFiles []protodesc.File, files []protodesc.File) error {
+	previousFullNameToMethod, err := protodesc.FullNameToMethod(previousFiles...)
+	if err != nil {
+		return err
+	}
+	fullNameToMethod, err := protodesc.FullNameToMethod(files...)
+	if err != nil {
+		return err
+	}
+	for fullName, previousMethod := range previousFullNameToMethod {
+		if _, ok := fullNameToMethod[fullName]; !ok {
+			c.addRemoved(kindMethod, fullName, newNamedLocation(previousMethod))
+		}
+	}
+	for fullName, method := range fullNameToMethod {
+		previousMethod, ok := previousFullNameToMethod[fullName]
+		if !ok {
+			c.addAdded(kindMethod, fullName, newNamedLocation(method))
+			continue
+		}
+		c.addMethodModified(previousMethod, method)
+	}
+	return nil
+}
+
+func (c *changeBuilder) addMethodModified(previousMethod protodesc.Method, method protodesc.Method) {
+	fullName := method.FullName()
+	if previousMethod.InputTypeName() != method.InputTypeName() {
+		c.addModified(
+			kindMethod,
+			fullName,
+			fmt.Sprintf("input type changed from %q to %q", previousMethod.InputTypeName(), method.InputTypeName()),
+			newLocation(previousMethod.FilePath(), previousMethod.InputTypeLocation(), previousMethod.NameLocation()),
+			newLocation(method.FilePath(), method.InputTypeLocation(), method.NameLocation()),
+		)
+	}
+	if previousMethod.OutputTypeName() != method.OutputTypeName() {
+		c.addModified(
+			kindMethod,
+			fullName,
+			fmt.Sprintf("output type changed from %q to %q", previousMethod.OutputTypeName(), method.OutputTypeName()),
+			newLocation(previousMethod.FilePath(), previousMethod.OutputTypeLocation(), previousMethod.NameLocation()),
+			newLocation(method.FilePath(), method.OutputTypeLocation(), method.NameLocation()),
+		)
+	}
+	if previousMethod.ClientStreaming() != method.ClientStreaming() {
+		c.addModified(
+			kindMethod,
+			fullName,
+			fmt.Sprintf("client streaming changed from %t to %t", previousMethod.ClientStreaming(), method.ClientStreaming()),
+			newLocation(previousMethod.FilePath(), previousMethod.InputTypeLocation(), previousMethod.NameLocation()),
+			newLocation(method.FilePath(), method.InputTypeLocation(), method.NameLocation()),
+		)
+	}
+	if previousMethod.ServerStreaming() != method.ServerStreaming() {
+		c.addModified(
+			kindMethod,
+			fullName,
+			fmt.Sprintf("server streaming changed from %t to %t", previousMethod.ServerStreaming(), method.ServerStreaming()),
+			newLocation(previousMethod.FilePath(), previousMethod.OutputTypeLocation(), previousMethod.NameLocation()),
+			newLocation(method.FilePath(), method.OutputTypeLocation(), method.NameLocation()),
+		)
+	}
+	if previousMethod.IdempotencyLevel() != method.IdempotencyLevel() {
+		c.addModified(
+			kindMethod,
+			fullName,
+			fmt.Sprintf("option idempotency_level changed from %s to %s", previousMethod.IdempotencyLevel().String(), method.IdempotencyLevel().String()),
+			newLocation(previousMethod.FilePath(), previousMethod.IdempotencyLevelLocation(), previousMethod.NameLocation()),
+			newLocation(method.FilePath(), method.IdempotencyLevelLocation(), method.NameLocation()),
+		)
+	}
+	c.addDeprecatedModified(kindMethod, fullName, previousMethod, method, newNamedLocation(previousMethod), newNamedLocation(method))
+}
+
+func (c *changeBuilder) addMovedModified(
+	kind string,
+	fullName string,
+	previousNamedDescriptor protodesc.NamedDescriptor,
+	namedDescriptor protodesc.NamedDescriptor,
+) {
+	if previousNamedDescriptor.FilePath() != namedDescriptor.FilePath() {
+		c.addModified(
+			kind,
+			fullName,
+			fmt.Sprintf("moved from file %q to %q", previousNamedDescriptor.FilePath(), namedDescriptor.FilePath()),
+			newNamedLocation(previousNamedDescriptor),
+			newNamedLocation(namedDescriptor),
+		)
+	}
+}
+
+func (c *changeBuilder) addDeprecatedModified(
+	kind string,
+	name string,
+	previousDeprecatedDescriptor protodesc.DeprecatedDescriptor,
+	deprecatedDescriptor protodesc.DeprecatedDescriptor,
+	previousLocation *Location,
+	location *Location,
+) {
+	previousDeprecated := previousDeprecatedDescriptor.Deprecated()
+	deprecated := deprecatedDescriptor.Deprecated()
+	switch {
+	case !previousDeprecated && deprecated:
+		c.addModified(kind, name, "deprecated", previousLocation, location)
+	case previousDeprecated && !deprecated:
+		c.addModified(kind, name, "no longer deprecated", previousLocation, location)
+	}
+}
+
+func (c *changeBuilder) addAdded(kind string, name string, location *Location) {
+	c.changes = append(
+		c.changes,
+		&Change{
+			Type:     ChangeTypeAdded,
+			Kind:     kind,
+			Name:     name,
+			Location: location,
+		},
+	)
+}
+
+func (c *changeBuilder) addRemoved(kind string, name string, previousLocation *Location) {
+	c.changes = append(
+		c.changes,
+		&Change{
+			Type:             ChangeTypeRemoved,
+			Kind:             kind,
+			Name:             name,
+			PreviousLocation: previousLocation,
+		},
+	)
+}
+
+func (c *changeBuilder) addModified(
+	kind string,
+	name string,
+	message string,
+	previousLocation *Location,
+	location *Location,
+) {
+	c.changes = append(
+		c.changes,
+		&Change{
+			Type:             ChangeTypeModified,
+			Kind:             kind,
+			Name:             name,
+			Message:          message,
+			PreviousLocation: previousLocation,
+			Location:         location,
+		},
+	)
+}
+
+func newFileLocation(file protodesc.File, location protodesc.Location) *Location {
+	return newLocation(file.FilePath(), location)
+}
+
+func newNamedLocation(namedDescriptor protodesc.NamedDescriptor) *Location {
+	return newLocation(namedDescriptor.FilePath(), namedDescriptor.NameLocation(), namedDescriptor.Location())
+}
+
+// newLocation returns a new Location for the first non-nil location.
+//
+// If all locations are nil, only the filename is set.
+func newLocation(filePath string, locations ...protodesc.Location) *Location {
+	for _, location := range locations {
+		if location != nil {
+			return &Location{
+				Filename: filePath,
+				Line:     location.StartLine(),
+				Column:   location.StartColumn(),
+			}
+		}
+	}
+	return &Location{
+		Filename: filePath,
+	}
+}
+
+func getFieldTypeString(field protodesc.Field) string {
+	if typeName := field.TypeName(); typeName != "" {
+		return typeName
+	}
+	return field.Type().String()
+}
+
+func getPackedString(packed *bool) string {
+	if packed == nil {
+		return "unset"
+	}
+	return strconv.FormatBool(*packed)
+}
+
+var changeTypeToSortOrder = map[string]int{
+	ChangeTypeAdded:    1,
+	ChangeTypeRemoved:  2,
+	ChangeTypeModified: 3,
+}
+
+type sortChanges []*Change
+
+func (a sortChanges) Len() int          { return len(a) }
+func (a sortChanges) Swap(i int, j int) { a[i], a[j] = a[j], a[i] }
+func (a sortChanges) Less(i int, j int) bool {
+	if a[i].Name != a[j].Name {
+		return a[i].Name < a[j].Name
+	}
+	if a[i].Kind != a[j].Kind {
+		return a[i].Kind < a[j].Kind
+	}
+	if a[i].Type != a[j].Type {
+		return changeTypeToSortOrder[a[i].Type] < changeTypeToSortOrder[a[j].Type]
+	}
+	return a[i].Message < a[j].Message
+}
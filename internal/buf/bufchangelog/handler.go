@@ -0,0 +1,38 @@
+package bufchangelog
+
+import (
+	"context"
+
+	"github.com/bufbuild/buf/internal/buf/bufpb"
+	"github.com/bufbuild/buf/internal/pkg/logutil"
+	"github.com/bufbuild/buf/internal/pkg/protodesc"
+	"go.uber.org/zap"
+)
+
+type handler struct {
+	logger *zap.Logger
+}
+
+func newHandler(logger *zap.Logger) *handler {
+	return &handler{
+		logger: logger.Named("bufchangelog"),
+	}
+}
+
+func (h *handler) Changelog(
+	ctx context.Context,
+	previousImage bufpb.Image,
+	image bufpb.Image,
+) (_ []*Change, retErr error) {
+	defer logutil.DeferWithError(h.logger, "changelog", &retErr)()
+
+	previousFiles, err := protodesc.NewFiles(previousImage.GetFile()...)
+	if err != nil {
+		return nil, err
+	}
+	files, err := protodesc.NewFiles(image.GetFile()...)
+	if err != nil {
+		return nil, err
+	}
+	return getChanges(previousFiles, files)
+}
@@ -0,0 +1,134 @@
+package bufchangelog
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"strings"
+
+	"github.com/bufbuild/buf/internal/pkg/errs"
+)
+
+var markdownSections = []struct {
+	changeType string
+	title      string
+}{
+	{
+		changeType: ChangeTypeAdded,
+		title:      "Added",
+	},
+	{
+		changeType: ChangeTypeRemoved,
+		title:      "Removed",
+	},
+	{
+		changeType: ChangeTypeModified,
+		title:      "Modified",
+	},
+}
+
+func printChanges(writer io.Writer, changes []*Change, format Format) error {
+	if len(changes) == 0 {
+		return nil
+	}
+	bufWriter := bufio.NewWriter(writer)
+	var err error
+	switch format {
+	case FormatText:
+		err = printChangesText(bufWriter, changes)
+	case FormatJSON:
+		err = printChangesJSON(bufWriter, changes)
+	case FormatMarkdown:
+		err = printChangesMarkdown(bufWriter, changes)
+	default:
+		err = errs.NewInternalf("unknown changelog format: %v", format)
+	}
+	if err != nil {
+		return err
+	}
+	return bufWriter.Flush()
+}
+
+func printChangesText(writer *bufio.Writer, changes []*Change) error {
+	for _, change := range changes {
+		if _, err := writer.WriteString(change.Type + " " + getChangeDescription(change) + "\n"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func printChangesJSON(writer *bufio.Writer, changes []*Change) error {
+	for _, change := range changes {
+		data, err := json.Marshal(change)
+		if err != nil {
+			return err
+		}
+		if _, err := writer.Write(append(data, '\n')); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func printChangesMarkdown(writer *bufio.Writer, changes []*Change) error {
+	wroteSection := false
+	for _, markdownSection := range markdownSections {
+		var sectionChanges []*Change
+		for _, change := range changes {
+			if change.Type == markdownSection.changeType {
+				sectionChanges = append(sectionChanges, change)
+			}
+		}
+		if len(sectionChanges) == 0 {
+			continue
+		}
+		if wroteSection {
+			if _, err := writer.WriteString("\n"); err != nil {
+				return err
+			}
+		}
+		wroteSection = true
+		if _, err := writer.WriteString("## " + markdownSection.title + "\n\n"); err != nil {
+			return err
+		}
+		for _, change := range sectionChanges {
+			if _, err := writer.WriteString("- " + getChangeDescription(change) + "\n"); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// getChangeDescription returns the description of the change without the type.
+//
+// ie "message foo.Bar (a.proto:3:9)" or
+// "field foo.Bar.baz: deprecated (a.proto:4:10 -> a.proto:4:10)".
+func getChangeDescription(change *Change) string {
+	var builder strings.Builder
+	_, _ = builder.WriteString(strings.Replace(change.Kind, "_", " ", -1))
+	_, _ = builder.WriteString(" ")
+	_, _ = builder.WriteString(change.Name)
+	if change.Message != "" {
+		_, _ = builder.WriteString(": ")
+		_, _ = builder.WriteString(change.Message)
+	}
+	switch {
+	case change.PreviousLocation != nil && change.Location != nil:
+		_, _ = builder.WriteString(" (")
+		_, _ = builder.WriteString(change.PreviousLocation.String())
+		_, _ = builder.WriteString(" -> ")
+		_, _ = builder.WriteString(change.Location.String())
+		_, _ = builder.WriteString(")")
+	case change.PreviousLocation != nil:
+		_, _ = builder.WriteString(" (")
+		_, _ = builder.WriteString(change.PreviousLocation.String())
+		_, _ = builder.WriteString(")")
+	case change.Location != nil:
+		_, _ = builder.WriteString(" (")
+		_, _ = builder.WriteString(change.Location.String())
+		_, _ = builder.WriteString(")")
+	}
+	return builder.String()
+}
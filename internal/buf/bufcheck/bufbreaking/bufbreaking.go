@@ -115,6 +115,13 @@ func (b ConfigBuilder) NewConfig() (*Config, error) {
 	return internalConfigToConfig(internalConfig), nil
 }
 
+// GetDefaultCategories gets the default categories.
+//
+// Should only be used for printing.
+func GetDefaultCategories() []string {
+	return append([]string(nil), v1DefaultCategories...)
+}
+
 // GetAllCheckers gets all known checkers for the given categories.
 //
 // If categories is empty, this returns all checkers as bufcheck.Checkers.
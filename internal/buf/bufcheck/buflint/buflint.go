@@ -6,11 +6,13 @@ package buflint
 import (
 	"context"
 
+	"github.com/bufbuild/buf/internal/buf/bufbuild"
 	"github.com/bufbuild/buf/internal/buf/bufcheck"
 	"github.com/bufbuild/buf/internal/buf/bufcheck/internal"
 	"github.com/bufbuild/buf/internal/buf/bufpb"
 	"github.com/bufbuild/buf/internal/pkg/analysis"
 	"github.com/bufbuild/buf/internal/pkg/protodesc"
+	"github.com/bufbuild/buf/internal/pkg/storage"
 	"go.uber.org/zap"
 )
 
@@ -29,6 +31,25 @@ type Handler interface {
 		lintConfig *Config,
 		image bufpb.Image,
 	) ([]*analysis.Annotation, error)
+	// LintFix runs the lint checks and applies the suggested fixes to the source files.
+	//
+	// The image should have source code info and should not include imports. The
+	// resolver must resolve the image file paths to the real file paths within
+	// the bucket the image was built from.
+	//
+	// Every reference to a renamed package or type within the image is also rewritten,
+	// so that the fixed files still compile.
+	//
+	// Returns the fixed data for each real file path that changed, and the annotations
+	// that could not be fixed. Annotations will use the image file paths, if these
+	// should be relative, use FixAnnotationFilenames.
+	LintFix(
+		ctx context.Context,
+		lintConfig *Config,
+		image bufpb.Image,
+		bucket storage.ReadBucket,
+		resolver bufbuild.ProtoFilePathResolver,
+	) (map[string][]byte, []*analysis.Annotation, error)
 }
 
 // NewHandler returns a new Handler.
@@ -121,6 +142,13 @@ func (b ConfigBuilder) NewConfig() (*Config, error) {
 	return internalConfigToConfig(internalConfig), nil
 }
 
+// GetDefaultCategories gets the default categories.
+//
+// Should only be used for printing.
+func GetDefaultCategories() []string {
+	return append([]string(nil), v1DefaultCategories...)
+}
+
 // GetAllCheckers gets all known checkers for the given categories.
 //
 // If categories is empty, this returns all checkers as bufcheck.Checkers.
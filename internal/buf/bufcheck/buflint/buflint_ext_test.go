@@ -723,6 +723,124 @@ func TestRunIgnores3(t *testing.T) {
 	)
 }
 
+func TestLintFix(t *testing.T) {
+	t.Parallel()
+	logger := zap.NewNop()
+	segList := bytepool.NewSegList()
+	defer bytepooltesting.AssertAllRecycled(t, segList)
+
+	bucket, err := storageos.NewReadBucket(filepath.Join("testdata", "fix"))
+	require.NoError(t, err)
+	config := testGetConfig(t, bufconfig.NewProvider(logger), bucket)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	image, resolver, annotations, err := bufbuild.NewHandler(
+		logger,
+		segList,
+		bufbuild.NewProvider(logger),
+		bufbuild.NewRunner(logger),
+	).BuildImage(
+		ctx,
+		bucket,
+		config.Build,
+		nil,
+		false,
+		false,
+		true,
+	)
+	require.NoError(t, err)
+	require.Empty(t, annotations)
+
+	realFilePathToData, annotations, err := buflint.NewHandler(
+		logger,
+		buflint.NewRunner(logger),
+	).LintFix(
+		ctx,
+		config.Lint,
+		image,
+		bucket,
+		resolver,
+	)
+	require.NoError(t, err)
+	analysistesting.AssertAnnotationsEqual(
+		t,
+		[]*analysis.Annotation{
+			analysistesting.NewAnnotation("a.proto", 6, 3, 6, 7, "ENUM_ZERO_VALUE_SUFFIX"),
+			analysistesting.NewAnnotation("a.proto", 7, 3, 7, 6, "ENUM_VALUE_UPPER_SNAKE_CASE"),
+			analysistesting.NewAnnotation("a.proto", 18, 11, 18, 14, "RPC_REQUEST_STANDARD_NAME"),
+			// packages that declare extensions are not renamed, as custom option names are not rewritten
+			analysistesting.NewAnnotation("d.proto", 3, 1, 3, 16, "PACKAGE_LOWER_SNAKE_CASE"),
+		},
+		annotations,
+	)
+	assert.Equal(
+		t,
+		map[string]string{
+			"a.proto": `syntax = "proto3";
+
+package foo.bar;
+
+enum Baz {
+  BAZ_NONE = 0;
+  BAZ_TWO = 1;
+  BAZ_THREE = 2;
+}
+
+message One {
+  int32 foo_bar = 1;
+  Baz baz = 2;
+	int64	tab_bed = 3;
+}
+
+service HelloService {
+  rpc Get(One) returns (foo.bar.One);
+}
+`,
+			"b.proto": `syntax = "proto3";
+
+package other;
+
+import "a.proto";
+
+message Two {
+  foo.bar.One one = 1;
+  .foo.bar.Baz baz = 2;
+}
+`,
+			// default values that refer to renamed enum values, and extendees, are rewritten
+			"c.proto": `syntax = "proto2";
+
+package other;
+
+import "e.proto";
+
+enum Color {
+  COLOR_UNSPECIFIED = 0;
+  COLOR_RED = 1;
+}
+
+message Paint {
+  optional Color color = 1 [default = COLOR_RED];
+}
+
+extend foo.bar.Extendable {
+  optional Color extended_color = 100 [default = COLOR_RED];
+}
+`,
+			"e.proto": `syntax = "proto2";
+
+package foo.bar;
+
+message Extendable {
+  extensions 100 to 200;
+}
+`,
+		},
+		testBytesMapToStringMap(realFilePathToData),
+	)
+}
+
 func testLint(
 	t *testing.T,
 	dirPath string,
@@ -816,3 +934,11 @@ func testGetConfig(
 	require.NoError(t, err)
 	return config
 }
+
+func testBytesMapToStringMap(m map[string][]byte) map[string]string {
+	s := make(map[string]string, len(m))
+	for key, value := range m {
+		s[key] = string(value)
+	}
+	return s
+}
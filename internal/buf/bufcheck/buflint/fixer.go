@@ -0,0 +1,462 @@
+package buflint
+
+import (
+	"bytes"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/bufbuild/buf/internal/pkg/analysis"
+	"github.com/bufbuild/buf/internal/pkg/protodesc"
+)
+
+// protoparse and protoc both advance columns to the next multiple of 8 on tabs
+const tabWidth = 8
+
+type textEdit struct {
+	start   int
+	end     int
+	newText string
+}
+
+// applyFixes applies the fixes of the annotations to the data of the files.
+//
+// filePathToData must contain the data for every file, keyed by file path.
+// Returns the new data for the file paths that changed, and the annotations
+// that could not be fixed.
+func applyFixes(
+	files []protodesc.File,
+	annotations []*analysis.Annotation,
+	filePathToData map[string][]byte,
+) (map[string][]byte, []*analysis.Annotation) {
+	filePathToEdits := make(map[string][]*textEdit)
+	annotationToEdit := make(map[*analysis.Annotation]*textEdit)
+	var fixedAnnotations []*analysis.Annotation
+	var unfixedAnnotations []*analysis.Annotation
+	for _, annotation := range annotations {
+		edit := getFixEdit(annotation, filePathToData[annotation.Filename])
+		if edit == nil || !addEdit(filePathToEdits, annotation.Filename, edit) {
+			unfixedAnnotations = append(unfixedAnnotations, annotation)
+			continue
+		}
+		annotationToEdit[annotation] = edit
+		fixedAnnotations = append(fixedAnnotations, annotation)
+	}
+	// enum values that are referenced by default values that cannot be rewritten,
+	// and names that extensions are declared under, are not renamed, as the files
+	// would no longer compile
+	_, blockedNames := getDefaultValueEdits(files, getRenames(fixedAnnotations), filePathToData)
+	for name := range getExtensionBlockedNames(files, getRenames(fixedAnnotations)) {
+		blockedNames[name] = struct{}{}
+	}
+	if len(blockedNames) > 0 {
+		var stillFixedAnnotations []*analysis.Annotation
+		for _, annotation := range fixedAnnotations {
+			if _, ok := blockedNames[annotation.Fix.OldName]; ok {
+				removeEdit(filePathToEdits, annotation.Filename, annotationToEdit[annotation])
+				unfixedAnnotations = append(unfixedAnnotations, annotation)
+				continue
+			}
+			stillFixedAnnotations = append(stillFixedAnnotations, annotation)
+		}
+		fixedAnnotations = stillFixedAnnotations
+		analysis.SortAnnotations(unfixedAnnotations)
+	}
+	if renames := getRenames(fixedAnnotations); len(renames) > 0 {
+		for _, file := range files {
+			addReferenceEdits(filePathToEdits, file, renames, filePathToData[file.FilePath()])
+		}
+		defaultValueEdits, _ := getDefaultValueEdits(files, renames, filePathToData)
+		for filePath, edits := range defaultValueEdits {
+			for _, edit := range edits {
+				// if this overlaps with another edit, the other edit wins
+				_ = addEdit(filePathToEdits, filePath, edit)
+			}
+		}
+	}
+	filePathToFixedData := make(map[string][]byte, len(filePathToEdits))
+	for filePath, edits := range filePathToEdits {
+		if len(edits) == 0 {
+			// all edits for this file were removed
+			continue
+		}
+		filePathToFixedData[filePath] = applyEdits(filePathToData[filePath], edits)
+	}
+	return filePathToFixedData, unfixedAnnotations
+}
+
+// getRenames returns the renames of the fixed annotations.
+//
+// The renames are sorted by the length of OldName, longest first, as for
+// example both the packages Foo and Foo.Bar may be renamed.
+func getRenames(fixedAnnotations []*analysis.Annotation) []*analysis.Fix {
+	var renames []*analysis.Fix
+	for _, annotation := range fixedAnnotations {
+		if annotation.Fix.OldName != "" && annotation.Fix.NewName != "" {
+			renames = append(renames, annotation.Fix)
+		}
+	}
+	sort.Slice(
+		renames,
+		func(i int, j int) bool {
+			return len(renames[i].OldName) > len(renames[j].OldName)
+		},
+	)
+	return renames
+}
+
+// getExtensionBlockedNames returns the old names of the renames that would
+// change the full name of an extension.
+//
+// Extensions may be referenced by custom option names such as (foo.v1.bar),
+// which are not rewritten.
+func getExtensionBlockedNames(files []protodesc.File, renames []*analysis.Fix) map[string]struct{} {
+	blockedNames := make(map[string]struct{})
+	addExtension := func(extension protodesc.Field) {
+		name := extension.FullName()
+		for _, rename := range renames {
+			if name == rename.OldName || strings.HasPrefix(name, rename.OldName+".") {
+				blockedNames[rename.OldName] = struct{}{}
+			}
+		}
+	}
+	for _, file := range files {
+		for _, extension := range file.Extensions() {
+			addExtension(extension)
+		}
+		_ = protodesc.ForEachMessage(
+			func(message protodesc.Message) error {
+				for _, extension := range message.Extensions() {
+					addExtension(extension)
+				}
+				return nil
+			},
+			file,
+		)
+	}
+	return blockedNames
+}
+
+// getDefaultValueEdits returns the edits for every proto2 default value that
+// refers to a renamed enum value.
+//
+// Default values are the simple name of the enum value, so only renames of the
+// enum value itself need edits. Returns the full names of the renamed enum values
+// that are referenced by a default value that could not be rewritten.
+func getDefaultValueEdits(
+	files []protodesc.File,
+	renames []*analysis.Fix,
+	filePathToData map[string][]byte,
+) (map[string][]*textEdit, map[string]struct{}) {
+	oldNameToRename := make(map[string]*analysis.Fix, len(renames))
+	for _, rename := range renames {
+		oldNameToRename[rename.OldName] = rename
+	}
+	filePathToEdits := make(map[string][]*textEdit)
+	blockedNames := make(map[string]struct{})
+	addDefaultValueEdit := func(file protodesc.File, field protodesc.Field) {
+		if field.Type() != protodesc.FieldDescriptorProtoTypeEnum || field.DefaultValue() == "" {
+			return
+		}
+		name := strings.TrimPrefix(field.TypeName(), ".") + "." + field.DefaultValue()
+		rename, ok := oldNameToRename[name]
+		if !ok {
+			return
+		}
+		newValue := rename.NewName[strings.LastIndex(rename.NewName, ".")+1:]
+		var edit *textEdit
+		if location := field.DefaultValueLocation(); location != nil {
+			edit = getNameEdit(
+				filePathToData[file.FilePath()],
+				location.StartLine(),
+				location.StartColumn(),
+				location.EndLine(),
+				location.EndColumn(),
+				field.DefaultValue(),
+				newValue,
+			)
+		}
+		if edit == nil {
+			blockedNames[name] = struct{}{}
+			return
+		}
+		filePathToEdits[file.FilePath()] = append(filePathToEdits[file.FilePath()], edit)
+	}
+	for _, file := range files {
+		for _, field := range file.Extensions() {
+			addDefaultValueEdit(file, field)
+		}
+		_ = protodesc.ForEachMessage(
+			func(message protodesc.Message) error {
+				for _, field := range message.Fields() {
+					addDefaultValueEdit(file, field)
+				}
+				for _, field := range message.Extensions() {
+					addDefaultValueEdit(file, field)
+				}
+				return nil
+			},
+			file,
+		)
+	}
+	return filePathToEdits, blockedNames
+}
+
+// addReferenceEdits adds edits for every type reference in the file that refers to a renamed name.
+//
+// This includes the extendees of extensions.
+func addReferenceEdits(
+	filePathToEdits map[string][]*textEdit,
+	file protodesc.File,
+	renames []*analysis.Fix,
+	data []byte,
+) {
+	addReferenceEdit := func(typeName string, location protodesc.Location) {
+		if typeName == "" || location == nil {
+			return
+		}
+		if edit := getReferenceEdit(typeName, renames, location, data); edit != nil {
+			// if this overlaps with another edit, the other edit wins
+			_ = addEdit(filePathToEdits, file.FilePath(), edit)
+		}
+	}
+	for _, field := range file.Extensions() {
+		addReferenceEdit(field.TypeName(), field.TypeNameLocation())
+		addReferenceEdit(field.Extendee(), field.ExtendeeLocation())
+	}
+	_ = protodesc.ForEachMessage(
+		func(message protodesc.Message) error {
+			for _, field := range message.Fields() {
+				addReferenceEdit(field.TypeName(), field.TypeNameLocation())
+			}
+			for _, field := range message.Extensions() {
+				addReferenceEdit(field.TypeName(), field.TypeNameLocation())
+				addReferenceEdit(field.Extendee(), field.ExtendeeLocation())
+			}
+			return nil
+		},
+		file,
+	)
+	for _, service := range file.Services() {
+		for _, method := range service.Methods() {
+			addReferenceEdit(method.InputTypeName(), method.InputTypeLocation())
+			addReferenceEdit(method.OutputTypeName(), method.OutputTypeLocation())
+		}
+	}
+}
+
+// getFixEdit returns the edit for the annotation's fix, or nil if the fix cannot be applied.
+func getFixEdit(annotation *analysis.Annotation, data []byte) *textEdit {
+	fix := annotation.Fix
+	if fix == nil || fix.OldText == "" || annotation.StartLine == 0 {
+		return nil
+	}
+	return getNameEdit(
+		data,
+		annotation.StartLine,
+		annotation.StartColumn,
+		annotation.EndLine,
+		annotation.EndColumn,
+		fix.OldText,
+		fix.NewText,
+	)
+}
+
+// getNameEdit returns the edit that replaces the first occurrence of oldText
+// within the span with newText, or nil if oldText is not within the span.
+//
+// Occurrences that are part of another name are skipped.
+func getNameEdit(
+	data []byte,
+	startLine int,
+	startColumn int,
+	endLine int,
+	endColumn int,
+	oldText string,
+	newText string,
+) *textEdit {
+	if data == nil || oldText == "" {
+		return nil
+	}
+	start, ok := getOffset(data, startLine, startColumn)
+	if !ok {
+		return nil
+	}
+	end, ok := getOffset(data, endLine, endColumn)
+	if !ok || end < start {
+		return nil
+	}
+	span := data[start:end]
+	oldTextData := []byte(oldText)
+	for i := 0; i+len(oldTextData) <= len(span); i++ {
+		index := bytes.Index(span[i:], oldTextData)
+		if index < 0 {
+			return nil
+		}
+		index += i
+		// make sure we do not match part of another identifier, such as the
+		// package keyword for a package named "age"
+		if (index == 0 || !isNameByte(span[index-1])) &&
+			(index+len(oldTextData) == len(span) || !isNameByte(span[index+len(oldTextData)])) {
+			return &textEdit{
+				start:   start + index,
+				end:     start + index + len(oldTextData),
+				newText: newText,
+			}
+		}
+		i = index
+	}
+	return nil
+}
+
+// getReferenceEdit returns the edit for the type reference, or nil if the
+// reference does not need to be rewritten.
+//
+// typeName is the fully-qualified type name with a leading period, while the
+// source text at the location may be relative to the current scope. Renames
+// do not change the number of components of a name, so we replace the source
+// text with the same number of trailing components of the new name.
+func getReferenceEdit(
+	typeName string,
+	renames []*analysis.Fix,
+	location protodesc.Location,
+	data []byte,
+) *textEdit {
+	name := strings.TrimPrefix(typeName, ".")
+	newName := getRenamedName(name, renames)
+	if newName == name {
+		return nil
+	}
+	start, ok := getOffset(data, location.StartLine(), location.StartColumn())
+	if !ok {
+		return nil
+	}
+	end, ok := getOffset(data, location.EndLine(), location.EndColumn())
+	if !ok || end < start {
+		return nil
+	}
+	text := string(data[start:end])
+	prefix := ""
+	if strings.HasPrefix(text, ".") {
+		prefix = "."
+	}
+	textComponents := strings.Split(strings.TrimPrefix(text, "."), ".")
+	nameComponents := strings.Split(name, ".")
+	newNameComponents := strings.Split(newName, ".")
+	numComponents := len(textComponents)
+	if numComponents > len(nameComponents) || len(nameComponents) != len(newNameComponents) {
+		return nil
+	}
+	if strings.Join(nameComponents[len(nameComponents)-numComponents:], ".") != strings.Join(textComponents, ".") {
+		// the source text is not a plain reference, for example it contains whitespace
+		return nil
+	}
+	newText := prefix + strings.Join(newNameComponents[len(newNameComponents)-numComponents:], ".")
+	if newText == text {
+		return nil
+	}
+	return &textEdit{
+		start:   start,
+		end:     end,
+		newText: newText,
+	}
+}
+
+// getRenamedName returns the name with the first matching rename applied.
+//
+// The renames must be sorted by the length of OldName, longest first.
+func getRenamedName(name string, renames []*analysis.Fix) string {
+	for _, rename := range renames {
+		if name == rename.OldName {
+			return rename.NewName
+		}
+		if strings.HasPrefix(name, rename.OldName+".") {
+			return rename.NewName + strings.TrimPrefix(name, rename.OldName)
+		}
+	}
+	return name
+}
+
+// addEdit adds the edit for the file path if it does not overlap with an existing edit.
+//
+// Returns false if the edit was not added.
+func addEdit(filePathToEdits map[string][]*textEdit, filePath string, edit *textEdit) bool {
+	for _, existingEdit := range filePathToEdits[filePath] {
+		if edit.start < existingEdit.end && existingEdit.start < edit.end {
+			return false
+		}
+	}
+	filePathToEdits[filePath] = append(filePathToEdits[filePath], edit)
+	return true
+}
+
+// removeEdit removes the edit for the file path.
+func removeEdit(filePathToEdits map[string][]*textEdit, filePath string, edit *textEdit) {
+	edits := filePathToEdits[filePath]
+	for i, existingEdit := range edits {
+		if existingEdit == edit {
+			filePathToEdits[filePath] = append(edits[:i], edits[i+1:]...)
+			return
+		}
+	}
+}
+
+// applyEdits applies the non-overlapping edits to the data.
+func applyEdits(data []byte, edits []*textEdit) []byte {
+	sort.Slice(
+		edits,
+		func(i int, j int) bool {
+			return edits[i].start < edits[j].start
+		},
+	)
+	buffer := bytes.NewBuffer(nil)
+	offset := 0
+	for _, edit := range edits {
+		_, _ = buffer.Write(data[offset:edit.start])
+		_, _ = buffer.WriteString(edit.newText)
+		offset = edit.end
+	}
+	_, _ = buffer.Write(data[offset:])
+	return buffer.Bytes()
+}
+
+// getOffset returns the byte offset within data for the 1-based line and column.
+//
+// Returns false if the line and column are not within data.
+func getOffset(data []byte, line int, column int) (int, bool) {
+	if line < 1 || column < 1 {
+		return 0, false
+	}
+	offset := 0
+	for i := 1; i < line; i++ {
+		index := bytes.IndexByte(data[offset:], '\n')
+		if index < 0 {
+			return 0, false
+		}
+		offset += index + 1
+	}
+	for currentColumn := 0; currentColumn < column-1; {
+		if offset >= len(data) {
+			return 0, false
+		}
+		r, size := utf8.DecodeRune(data[offset:])
+		switch r {
+		case '\n':
+			return 0, false
+		case '\t':
+			currentColumn += tabWidth - currentColumn%tabWidth
+		default:
+			currentColumn++
+		}
+		offset += size
+		if currentColumn > column-1 {
+			// the column was in the middle of a tab
+			return 0, false
+		}
+	}
+	return offset, true
+}
+
+func isNameByte(b byte) bool {
+	return b == '_' || b == '.' || ('a' <= b && b <= 'z') || ('A' <= b && b <= 'Z') || ('0' <= b && b <= '9')
+}
@@ -3,9 +3,12 @@ package buflint
 import (
 	"context"
 
+	"github.com/bufbuild/buf/internal/buf/bufbuild"
 	"github.com/bufbuild/buf/internal/buf/bufpb"
 	"github.com/bufbuild/buf/internal/pkg/analysis"
 	"github.com/bufbuild/buf/internal/pkg/protodesc"
+	"github.com/bufbuild/buf/internal/pkg/storage"
+	"github.com/bufbuild/buf/internal/pkg/storage/storageutil"
 	"go.uber.org/zap"
 )
 
@@ -35,3 +38,46 @@ func (h *handler) LintCheck(
 	}
 	return h.lintRunner.Check(ctx, lintConfig, files)
 }
+
+func (h *handler) LintFix(
+	ctx context.Context,
+	lintConfig *Config,
+	image bufpb.Image,
+	bucket storage.ReadBucket,
+	resolver bufbuild.ProtoFilePathResolver,
+) (map[string][]byte, []*analysis.Annotation, error) {
+	files, err := protodesc.NewFiles(image.GetFile()...)
+	if err != nil {
+		return nil, nil, err
+	}
+	annotations, err := h.lintRunner.Check(ctx, lintConfig, files)
+	if err != nil {
+		return nil, nil, err
+	}
+	filePathToData := make(map[string][]byte, len(files))
+	filePathToRealFilePath := make(map[string]string, len(files))
+	for _, file := range files {
+		realFilePath, err := resolver.GetFilePath(file.FilePath())
+		if err != nil {
+			return nil, nil, err
+		}
+		data, err := storageutil.ReadPath(ctx, bucket, realFilePath)
+		if err != nil {
+			return nil, nil, err
+		}
+		filePathToData[file.FilePath()] = data
+		filePathToRealFilePath[file.FilePath()] = realFilePath
+	}
+	filePathToFixedData, unfixedAnnotations := applyFixes(files, annotations, filePathToData)
+	realFilePathToFixedData := make(map[string][]byte, len(filePathToFixedData))
+	for filePath, fixedData := range filePathToFixedData {
+		realFilePathToFixedData[filePathToRealFilePath[filePath]] = fixedData
+	}
+	h.logger.Debug(
+		"fix",
+		zap.Int("num_annotations", len(annotations)),
+		zap.Int("num_unfixed_annotations", len(unfixedAnnotations)),
+		zap.Int("num_files_changed", len(realFilePathToFixedData)),
+	)
+	return realFilePathToFixedData, unfixedAnnotations, nil
+}
@@ -113,53 +113,77 @@ func checkEnumPascalCase(add addFunc, enum protodesc.Enum) error {
 }
 
 // CheckEnumValuePrefix is a check function.
-var CheckEnumValuePrefix = newEnumValueCheckFunc(checkEnumValuePrefix)
+var CheckEnumValuePrefix = newEnumValueFixCheckFunc(checkEnumValuePrefix)
 
-func checkEnumValuePrefix(add addFunc, enumValue protodesc.EnumValue) error {
+func checkEnumValuePrefix(add addFixFunc, enumValue protodesc.EnumValue) error {
 	name := enumValue.Name()
 	expectedPrefix := fieldToUpperSnakeCase(enumValue.Enum().Name()) + "_"
 	if !strings.HasPrefix(name, expectedPrefix) {
-		add(enumValue, enumValue.NameLocation(), "Enum value name %q should be prefixed with %q.", name, expectedPrefix)
+		// we also fix the case so that the fix does not result in an UPPER_SNAKE_CASE failure
+		expectedName := fieldToUpperSnakeCase(name)
+		if !strings.HasPrefix(expectedName, expectedPrefix) {
+			expectedName = expectedPrefix + expectedName
+		}
+		var fix *analysis.Fix
+		if enumValueNameAvailable(enumValue.Enum(), expectedName) {
+			fix = newRenameFix(enumValue, expectedName)
+		}
+		add(enumValue, enumValue.NameLocation(), fix, "Enum value name %q should be prefixed with %q.", name, expectedPrefix)
 	}
 	return nil
 }
 
 // CheckEnumValueUpperSnakeCase is a check function.
-var CheckEnumValueUpperSnakeCase = newEnumValueCheckFunc(checkEnumValueUpperSnakeCase)
+var CheckEnumValueUpperSnakeCase = newEnumValueFixCheckFunc(checkEnumValueUpperSnakeCase)
 
-func checkEnumValueUpperSnakeCase(add addFunc, enumValue protodesc.EnumValue) error {
+func checkEnumValueUpperSnakeCase(add addFixFunc, enumValue protodesc.EnumValue) error {
 	name := enumValue.Name()
 	expectedName := fieldToUpperSnakeCase(name)
 	if name != expectedName {
-		add(enumValue, enumValue.NameLocation(), "Enum value name %q should be UPPER_SNAKE_CASE, such as %q.", name, expectedName)
+		var fix *analysis.Fix
+		if enumValueNameAvailable(enumValue.Enum(), expectedName) {
+			fix = newRenameFix(enumValue, expectedName)
+		}
+		add(enumValue, enumValue.NameLocation(), fix, "Enum value name %q should be UPPER_SNAKE_CASE, such as %q.", name, expectedName)
 	}
 	return nil
 }
 
 // CheckEnumZeroValueSuffix is a check function.
 var CheckEnumZeroValueSuffix = func(id string, files []protodesc.File, suffix string) ([]*analysis.Annotation, error) {
-	return newEnumValueCheckFunc(
-		func(add addFunc, enumValue protodesc.EnumValue) error {
+	return newEnumValueFixCheckFunc(
+		func(add addFixFunc, enumValue protodesc.EnumValue) error {
 			return checkEnumZeroValueSuffix(add, enumValue, suffix)
 		},
 	)(id, files)
 }
 
-func checkEnumZeroValueSuffix(add addFunc, enumValue protodesc.EnumValue, suffix string) error {
+func checkEnumZeroValueSuffix(add addFixFunc, enumValue protodesc.EnumValue, suffix string) error {
 	if enumValue.Number() != 0 {
 		return nil
 	}
 	name := enumValue.Name()
 	if !strings.HasSuffix(name, suffix) {
-		add(enumValue, enumValue.NameLocation(), "Enum zero value name %q should be suffixed with %q.", name, suffix)
+		// the existing name is usually something like FOO_UNKNOWN or FOO_NONE, so
+		// we replace the name entirely instead of appending the suffix
+		expectedName := fieldToUpperSnakeCase(enumValue.Enum().Name())
+		if !strings.HasPrefix(suffix, "_") {
+			expectedName += "_"
+		}
+		expectedName += suffix
+		var fix *analysis.Fix
+		if enumValueNameAvailable(enumValue.Enum(), expectedName) {
+			fix = newRenameFix(enumValue, expectedName)
+		}
+		add(enumValue, enumValue.NameLocation(), fix, "Enum zero value name %q should be suffixed with %q.", name, suffix)
 	}
 	return nil
 }
 
 // CheckFieldLowerSnakeCase is a check function.
-var CheckFieldLowerSnakeCase = newFieldCheckFunc(checkFieldLowerSnakeCase)
+var CheckFieldLowerSnakeCase = newFieldFixCheckFunc(checkFieldLowerSnakeCase)
 
-func checkFieldLowerSnakeCase(add addFunc, field protodesc.Field) error {
+func checkFieldLowerSnakeCase(add addFixFunc, field protodesc.Field) error {
 	message := field.Message()
 	if message == nil {
 		// just a sanity check
@@ -172,7 +196,11 @@ func checkFieldLowerSnakeCase(add addFunc, field protodesc.Field) error {
 	name := field.Name()
 	expectedName := fieldToLowerSnakeCase(name)
 	if name != expectedName {
-		add(field, field.NameLocation(), "Field name %q should be lower_snake_case, such as %q.", name, expectedName)
+		var fix *analysis.Fix
+		if fieldNameAvailable(message, expectedName) {
+			fix = newRenameFix(field, expectedName)
+		}
+		add(field, field.NameLocation(), fix, "Field name %q should be lower_snake_case, such as %q.", name, expectedName)
 	}
 	return nil
 }
@@ -282,9 +310,9 @@ func checkPackageDirectoryMatch(add addFunc, file protodesc.File) error {
 }
 
 // CheckPackageLowerSnakeCase is a check function.
-var CheckPackageLowerSnakeCase = newFileCheckFunc(checkPackageLowerSnakeCase)
+var CheckPackageLowerSnakeCase = newFileFixCheckFunc(checkPackageLowerSnakeCase)
 
-func checkPackageLowerSnakeCase(add addFunc, file protodesc.File) error {
+func checkPackageLowerSnakeCase(add addFixFunc, file protodesc.File) error {
 	pkg := file.Package()
 	if pkg == "" {
 		return nil
@@ -295,7 +323,13 @@ func checkPackageLowerSnakeCase(add addFunc, file protodesc.File) error {
 	}
 	expectedPkg := strings.Join(split, ".")
 	if pkg != expectedPkg {
-		add(file, file.PackageLocation(), "Package name %q should be lower_snake.case, such as %q.", pkg, expectedPkg)
+		fix := &analysis.Fix{
+			OldText: pkg,
+			NewText: expectedPkg,
+			OldName: pkg,
+			NewName: expectedPkg,
+		}
+		add(file, file.PackageLocation(), fix, "Package name %q should be lower_snake.case, such as %q.", pkg, expectedPkg)
 	}
 	return nil
 }
@@ -619,17 +653,27 @@ func checkServicePascalCase(add addFunc, service protodesc.Service) error {
 
 // CheckServiceSuffix is a check function.
 var CheckServiceSuffix = func(id string, files []protodesc.File, suffix string) ([]*analysis.Annotation, error) {
-	return newServiceCheckFunc(
-		func(add addFunc, service protodesc.Service) error {
-			return checkServiceSuffix(add, service, suffix)
+	return newFileFixCheckFunc(
+		func(add addFixFunc, file protodesc.File) error {
+			for _, service := range file.Services() {
+				if err := checkServiceSuffix(add, file, service, suffix); err != nil {
+					return err
+				}
+			}
+			return nil
 		},
 	)(id, files)
 }
 
-func checkServiceSuffix(add addFunc, service protodesc.Service, suffix string) error {
+func checkServiceSuffix(add addFixFunc, file protodesc.File, service protodesc.Service, suffix string) error {
 	name := service.Name()
 	if !strings.HasSuffix(name, suffix) {
-		add(service, service.NameLocation(), "Service name %q should be suffixed with %q.", name, suffix)
+		expectedName := name + suffix
+		var fix *analysis.Fix
+		if topLevelNameAvailable(file, expectedName) {
+			fix = newRenameFix(service, expectedName)
+		}
+		add(service, service.NameLocation(), fix, "Service name %q should be suffixed with %q.", name, suffix)
 	}
 	return nil
 }
@@ -15,6 +15,11 @@ import (
 // Both the Descriptor and Location can be nil.
 type addFunc func(protodesc.Descriptor, protodesc.Location, string, ...interface{})
 
+// addFixFunc adds an annotation with a suggested fix.
+//
+// The Descriptor, Location, and Fix can all be nil.
+type addFixFunc func(protodesc.Descriptor, protodesc.Location, *analysis.Fix, string, ...interface{})
+
 func fieldToLowerSnakeCase(s string) string {
 	// Try running this on googleapis and watch
 	// We allow both effectively by not passing the option
@@ -29,6 +34,60 @@ func fieldToUpperSnakeCase(s string) string {
 	return stringutil.ToUpperSnakeCase(s)
 }
 
+// newRenameFix returns a Fix that renames the descriptor to newName.
+func newRenameFix(descriptor protodesc.NamedDescriptor, newName string) *analysis.Fix {
+	name := descriptor.Name()
+	fullName := descriptor.FullName()
+	return &analysis.Fix{
+		OldText: name,
+		NewText: newName,
+		OldName: fullName,
+		NewName: strings.TrimSuffix(fullName, name) + newName,
+	}
+}
+
+// enumValueNameAvailable returns true if a value of the enum can be renamed to name.
+func enumValueNameAvailable(enum protodesc.Enum, name string) bool {
+	for _, enumValue := range enum.Values() {
+		if enumValue.Name() == name {
+			return false
+		}
+	}
+	return !protodesc.NameInReservedNames(name, enum.ReservedNames()...)
+}
+
+// fieldNameAvailable returns true if a field of the message can be renamed to name.
+func fieldNameAvailable(message protodesc.Message, name string) bool {
+	for _, field := range message.Fields() {
+		if field.Name() == name {
+			return false
+		}
+	}
+	return !protodesc.NameInReservedNames(name, message.ReservedNames()...)
+}
+
+// topLevelNameAvailable returns true if a top-level descriptor of the file can be renamed to name.
+//
+// This only checks the file itself, not other files in the same package.
+func topLevelNameAvailable(file protodesc.File, name string) bool {
+	for _, service := range file.Services() {
+		if service.Name() == name {
+			return false
+		}
+	}
+	for _, message := range file.Messages() {
+		if message.Name() == name {
+			return false
+		}
+	}
+	for _, enum := range file.Enums() {
+		if enum.Name() == name {
+			return false
+		}
+	}
+	return true
+}
+
 // https://cloud.google.com/apis/design/versioning
 //
 // All Proto Package values pass.
@@ -289,3 +348,75 @@ func newMethodCheckFunc(
 		},
 	)
 }
+
+func newFilesFixCheckFunc(
+	f func(addFixFunc, []protodesc.File) error,
+) func(string, []protodesc.File) ([]*analysis.Annotation, error) {
+	return func(id string, files []protodesc.File) ([]*analysis.Annotation, error) {
+		helper := internal.NewHelper(id)
+		if err := f(helper.AddAnnotationWithFixf, files); err != nil {
+			return nil, err
+		}
+		return helper.Annotations(), nil
+	}
+}
+
+func newFileFixCheckFunc(
+	f func(addFixFunc, protodesc.File) error,
+) func(string, []protodesc.File) ([]*analysis.Annotation, error) {
+	return newFilesFixCheckFunc(
+		func(add addFixFunc, files []protodesc.File) error {
+			for _, file := range files {
+				if err := f(add, file); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	)
+}
+
+func newEnumValueFixCheckFunc(
+	f func(addFixFunc, protodesc.EnumValue) error,
+) func(string, []protodesc.File) ([]*analysis.Annotation, error) {
+	return newFileFixCheckFunc(
+		func(add addFixFunc, file protodesc.File) error {
+			return protodesc.ForEachEnum(
+				func(enum protodesc.Enum) error {
+					for _, enumValue := range enum.Values() {
+						if err := f(add, enumValue); err != nil {
+							return err
+						}
+					}
+					return nil
+				},
+				file,
+			)
+		},
+	)
+}
+
+func newFieldFixCheckFunc(
+	f func(addFixFunc, protodesc.Field) error,
+) func(string, []protodesc.File) ([]*analysis.Annotation, error) {
+	return newFileFixCheckFunc(
+		func(add addFixFunc, file protodesc.File) error {
+			return protodesc.ForEachMessage(
+				func(message protodesc.Message) error {
+					for _, field := range message.Fields() {
+						if err := f(add, field); err != nil {
+							return err
+						}
+					}
+					for _, field := range message.Extensions() {
+						if err := f(add, field); err != nil {
+							return err
+						}
+					}
+					return nil
+				},
+				file,
+			)
+		},
+	)
+}
@@ -42,6 +42,29 @@ func (h *Helper) AddAnnotationf(
 	)
 }
 
+// AddAnnotationWithFixf adds an annotation with the id as the Type and the given suggested fix.
+//
+// If descriptor is nil, no filename information is added.
+// If location is nil, no line or column information will be added.
+// If fix is nil, this is equivalent to AddAnnotationf.
+func (h *Helper) AddAnnotationWithFixf(
+	descriptor protodesc.Descriptor,
+	location protodesc.Location,
+	fix *analysis.Fix,
+	format string,
+	args ...interface{},
+) {
+	annotation := newAnnotationf(
+		h.id,
+		descriptor,
+		location,
+		format,
+		args...,
+	)
+	annotation.Fix = fix
+	h.annotations = append(h.annotations, annotation)
+}
+
 // Annotations returns the added annotations.
 func (h *Helper) Annotations() []*analysis.Annotation {
 	return h.annotations
@@ -7,6 +7,8 @@ import (
 	"github.com/bufbuild/buf/internal/buf/bufbuild"
 	"github.com/bufbuild/buf/internal/buf/bufcheck/bufbreaking"
 	"github.com/bufbuild/buf/internal/buf/bufcheck/buflint"
+	"github.com/bufbuild/buf/internal/buf/bufgen"
+	"github.com/bufbuild/buf/internal/buf/bufgraph"
 	"github.com/bufbuild/buf/internal/pkg/storage"
 	"go.uber.org/zap"
 )
@@ -25,6 +27,8 @@ type Config struct {
 	Build    *bufbuild.Config
 	Breaking *bufbreaking.Config
 	Lint     *buflint.Config
+	Generate *bufgen.Config
+	Graph    *bufgraph.Config
 }
 
 // Provider is a provider.
@@ -64,6 +68,8 @@ type ExternalConfig struct {
 	Build    ExternalBuildConfig    `json:"build,omitempty" yaml:"build,omitempty"`
 	Breaking ExternalBreakingConfig `json:"breaking,omitempty" yaml:"breaking,omitempty"`
 	Lint     ExternalLintConfig     `json:"lint,omitempty" yaml:"lint,omitempty"`
+	Generate ExternalGenerateConfig `json:"generate,omitempty" yaml:"generate,omitempty"`
+	Graph    ExternalGraphConfig    `json:"graph,omitempty" yaml:"graph,omitempty"`
 }
 
 // ExternalBuildConfig is an external config.
@@ -98,3 +104,27 @@ type ExternalLintConfig struct {
 	RPCAllowGoogleProtobufEmptyResponses bool                `json:"rpc_allow_google_protobuf_empty_responses,omitempty" yaml:"rpc_allow_google_protobuf_empty_responses,omitempty"`
 	ServiceSuffix                        string              `json:"service_suffix,omitempty" yaml:"service_suffix,omitempty"`
 }
+
+// ExternalGenerateConfig is an external config.
+//
+// Should only be used outside this package for testing.
+type ExternalGenerateConfig struct {
+	Plugins []ExternalPluginConfig `json:"plugins,omitempty" yaml:"plugins,omitempty"`
+}
+
+// ExternalPluginConfig is an external config.
+//
+// Should only be used outside this package for testing.
+type ExternalPluginConfig struct {
+	Name string `json:"name,omitempty" yaml:"name,omitempty"`
+	Path string `json:"path,omitempty" yaml:"path,omitempty"`
+	Out  string `json:"out,omitempty" yaml:"out,omitempty"`
+	Opt  string `json:"opt,omitempty" yaml:"opt,omitempty"`
+}
+
+// ExternalGraphConfig is an external config.
+//
+// Should only be used outside this package for testing.
+type ExternalGraphConfig struct {
+	AllowedDependencies map[string][]string `json:"allowed_dependencies,omitempty" yaml:"allowed_dependencies,omitempty"`
+}
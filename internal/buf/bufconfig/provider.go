@@ -7,6 +7,8 @@ import (
 	"github.com/bufbuild/buf/internal/buf/bufbuild"
 	"github.com/bufbuild/buf/internal/buf/bufcheck/bufbreaking"
 	"github.com/bufbuild/buf/internal/buf/bufcheck/buflint"
+	"github.com/bufbuild/buf/internal/buf/bufgen"
+	"github.com/bufbuild/buf/internal/buf/bufgraph"
 	"github.com/bufbuild/buf/internal/pkg/encodingutil"
 	"github.com/bufbuild/buf/internal/pkg/errs"
 	"github.com/bufbuild/buf/internal/pkg/logutil"
@@ -99,9 +101,32 @@ func (p *provider) newConfig(externalConfig *ExternalConfig) (*Config, error) {
 	if err != nil {
 		return nil, err
 	}
+	pluginConfigBuilders := make([]bufgen.PluginConfigBuilder, len(externalConfig.Generate.Plugins))
+	for i, externalPluginConfig := range externalConfig.Generate.Plugins {
+		pluginConfigBuilders[i] = bufgen.PluginConfigBuilder{
+			Name: externalPluginConfig.Name,
+			Path: externalPluginConfig.Path,
+			Out:  externalPluginConfig.Out,
+			Opt:  externalPluginConfig.Opt,
+		}
+	}
+	generateConfig, err := bufgen.ConfigBuilder{
+		Plugins: pluginConfigBuilders,
+	}.NewConfig()
+	if err != nil {
+		return nil, err
+	}
+	graphConfig, err := bufgraph.ConfigBuilder{
+		AllowedDependencies: externalConfig.Graph.AllowedDependencies,
+	}.NewConfig()
+	if err != nil {
+		return nil, err
+	}
 	return &Config{
 		Build:    buildConfig,
 		Breaking: breakingConfig,
 		Lint:     lintConfig,
+		Generate: generateConfig,
+		Graph:    graphConfig,
 	}, nil
 }
@@ -0,0 +1,80 @@
+// Package bufconvert converts messages between the binary, JSON, and text formats using Images.
+package bufconvert
+
+import (
+	"context"
+	"io"
+	"strings"
+
+	"github.com/bufbuild/buf/internal/buf/bufpb"
+	"github.com/bufbuild/buf/internal/pkg/errs"
+	"go.uber.org/zap"
+)
+
+const (
+	// FormatBin is the binary wire format.
+	FormatBin = "bin"
+	// FormatJSON is the JSON mapping.
+	FormatJSON = "json"
+	// FormatText is the text format.
+	FormatText = "text"
+)
+
+var (
+	// AllFormats are all formats.
+	AllFormats = []string{
+		FormatBin,
+		FormatJSON,
+		FormatText,
+	}
+
+	formatToStruct = map[string]struct{}{
+		FormatBin:  {},
+		FormatJSON: {},
+		FormatText: {},
+	}
+)
+
+// Handler handles conversions.
+type Handler interface {
+	// Convert reads messages of the type with the fully-qualified name in the from
+	// format from the reader, and writes them in the to format to the writer.
+	//
+	// The name may have a leading period.
+	// The Image must include imports. google.protobuf.Any values are resolved
+	// against all messages in the Image.
+	//
+	// If delimited is false, the whole reader is a single message. If delimited is
+	// true, the reader is a stream of messages. In the binary format, every message is
+	// prefixed with its length as a varint, and in the JSON and text formats, messages
+	// are separated by newlines.
+	//
+	// Messages are written as they are read. JSON is written on a single line,
+	// and text is indented unless delimited is true.
+	//
+	// Returns a user error if the name is not a message within the Image, or
+	// the input is not valid.
+	Convert(
+		ctx context.Context,
+		image bufpb.Image,
+		typeName string,
+		writer io.Writer,
+		reader io.Reader,
+		from string,
+		to string,
+		delimited bool,
+	) error
+}
+
+// NewHandler returns a new Handler.
+func NewHandler(logger *zap.Logger) Handler {
+	return newHandler(logger)
+}
+
+// ValidateFormat returns a user error if the format is not in AllFormats.
+func ValidateFormat(format string) error {
+	if _, ok := formatToStruct[format]; !ok {
+		return errs.NewInvalidArgumentf("unknown format %q, must be one of [%s]", format, strings.Join(AllFormats, ","))
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+package bufconvert_test
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bufbuild/buf/internal/buf/bufconvert"
+	"github.com/bufbuild/buf/internal/buf/bufpb"
+	"github.com/bufbuild/buf/internal/buf/buftesting"
+	"github.com/bufbuild/buf/internal/pkg/errs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+const testEventJSON = `{"id":"foo","count":"2","payload":{"@type":"type.googleapis.com/acme.v1.Payload","value":"bar"}}`
+
+func TestConvertAny(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	image := buftesting.BuildImage(t, "testdata", true, false)
+	handler := bufconvert.NewHandler(zap.NewNop())
+
+	// acme.v1.Payload is not imported by acme/v1/event.proto
+	bin := testConvert(t, ctx, handler, image, testEventJSON, bufconvert.FormatJSON, bufconvert.FormatBin, false)
+	json := testConvert(t, ctx, handler, image, bin, bufconvert.FormatBin, bufconvert.FormatJSON, false)
+	assert.Equal(t, testEventJSON+"\n", json)
+	text := testConvert(t, ctx, handler, image, bin, bufconvert.FormatBin, bufconvert.FormatText, false)
+	assert.Contains(t, text, `id: "foo"`)
+	bin2 := testConvert(t, ctx, handler, image, text, bufconvert.FormatText, bufconvert.FormatBin, false)
+	assert.Equal(t, bin, bin2)
+}
+
+func TestConvertDelimited(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	image := buftesting.BuildImage(t, "testdata", true, false)
+	handler := bufconvert.NewHandler(zap.NewNop())
+
+	jsonStream := testEventJSON + "\n\n" + `{"id":"baz"}` + "\n"
+	binStream := testConvert(t, ctx, handler, image, jsonStream, bufconvert.FormatJSON, bufconvert.FormatBin, true)
+	textStream := testConvert(t, ctx, handler, image, binStream, bufconvert.FormatBin, bufconvert.FormatText, true)
+	assert.Len(t, strings.Split(strings.TrimSpace(textStream), "\n"), 2)
+	assert.Equal(
+		t,
+		testEventJSON+"\n"+`{"id":"baz"}`+"\n",
+		testConvert(t, ctx, handler, image, textStream, bufconvert.FormatText, bufconvert.FormatJSON, true),
+	)
+
+	// truncated stream
+	err := handler.Convert(
+		ctx,
+		image,
+		"acme.v1.Event",
+		bytes.NewBuffer(nil),
+		strings.NewReader(binStream[:len(binStream)-1]),
+		bufconvert.FormatBin,
+		bufconvert.FormatJSON,
+		true,
+	)
+	assert.Equal(t, errs.CodeInvalidArgument, errs.GetCode(err))
+}
+
+func TestConvertErrors(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	image := buftesting.BuildImage(t, "testdata", true, false)
+	handler := bufconvert.NewHandler(zap.NewNop())
+
+	convert := func(typeName string, input string, from string) error {
+		return handler.Convert(ctx, image, typeName, bytes.NewBuffer(nil), strings.NewReader(input), from, bufconvert.FormatJSON, false)
+	}
+	assert.Equal(t, errs.CodeNotFound, errs.GetCode(convert("acme.v1.Nope", "", bufconvert.FormatBin)))
+	assert.Equal(t, errs.CodeInvalidArgument, errs.GetCode(convert("acme.v1.Event.id", "", bufconvert.FormatBin)))
+	assert.Equal(t, errs.CodeInvalidArgument, errs.GetCode(convert("acme.v1.Event", "{", bufconvert.FormatJSON)))
+	assert.Equal(t, errs.CodeInvalidArgument, errs.GetCode(convert("acme.v1.Event", "", "yaml")))
+	assert.NoError(t, convert(".acme.v1.Event", "", bufconvert.FormatBin))
+}
+
+func testConvert(
+	t *testing.T,
+	ctx context.Context,
+	handler bufconvert.Handler,
+	image bufpb.Image,
+	input string,
+	from string,
+	to string,
+	delimited bool,
+) string {
+	buffer := bytes.NewBuffer(nil)
+	require.NoError(
+		t,
+		handler.Convert(
+			ctx,
+			image,
+			"acme.v1.Event",
+			buffer,
+			strings.NewReader(input),
+			from,
+			to,
+			delimited,
+		),
+	)
+	return buffer.String()
+}
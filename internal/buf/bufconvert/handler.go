@@ -0,0 +1,240 @@
+package bufconvert
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"io"
+	"io/ioutil"
+	"strings"
+
+	"github.com/bufbuild/buf/internal/buf/bufpb"
+	"github.com/bufbuild/buf/internal/pkg/errs"
+	"github.com/bufbuild/buf/internal/pkg/logutil"
+	"github.com/golang/protobuf/jsonpb"
+	"github.com/jhump/protoreflect/desc"
+	"github.com/jhump/protoreflect/dynamic"
+	"go.uber.org/zap"
+)
+
+// maxTextLineSize is the maximum size of a single delimited text or JSON message.
+const maxTextLineSize = 64 * 1024 * 1024
+
+type handler struct {
+	logger *zap.Logger
+}
+
+func newHandler(logger *zap.Logger) *handler {
+	return &handler{
+		logger: logger.Named("bufconvert"),
+	}
+}
+
+func (h *handler) Convert(
+	ctx context.Context,
+	image bufpb.Image,
+	typeName string,
+	writer io.Writer,
+	reader io.Reader,
+	from string,
+	to string,
+	delimited bool,
+) (retErr error) {
+	defer logutil.DeferWithError(h.logger, "convert", &retErr)()
+
+	if err := ValidateFormat(from); err != nil {
+		return err
+	}
+	if err := ValidateFormat(to); err != nil {
+		return err
+	}
+	typeName = strings.TrimPrefix(typeName, ".")
+	if typeName == "" {
+		return errs.NewInvalidArgument("type name is empty")
+	}
+	descFileDescriptors, err := bufpb.ImageToDescFileDescriptors(image)
+	if err != nil {
+		return err
+	}
+	// iterate in image order so that the result is deterministic
+	fileDescriptors := make([]*desc.FileDescriptor, 0, len(descFileDescriptors))
+	for _, file := range image.GetFile() {
+		fileDescriptor, ok := descFileDescriptors[file.GetName()]
+		if !ok {
+			return errs.NewInternalf("no FileDescriptor for %q", file.GetName())
+		}
+		fileDescriptors = append(fileDescriptors, fileDescriptor)
+	}
+	messageDescriptor, err := findMessageDescriptor(fileDescriptors, typeName)
+	if err != nil {
+		return err
+	}
+	extensionRegistry := dynamic.NewExtensionRegistryWithDefaults()
+	for _, fileDescriptor := range fileDescriptors {
+		extensionRegistry.AddExtensionsFromFile(fileDescriptor)
+	}
+	messageFactory := dynamic.NewMessageFactoryWithExtensionRegistry(extensionRegistry)
+	anyResolver := dynamic.AnyResolver(messageFactory, fileDescriptors...)
+	codec := &codec{
+		jsonMarshaler: &jsonpb.Marshaler{
+			AnyResolver: anyResolver,
+		},
+		jsonUnmarshaler: &jsonpb.Unmarshaler{
+			AnyResolver: anyResolver,
+		},
+		delimited: delimited,
+	}
+
+	readMessageData := newReadMessageDataFunc(reader, from, delimited)
+	for i := 0; ; i++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		data, err := readMessageData()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return newInputError(i, from, delimited, err)
+		}
+		message := messageFactory.NewDynamicMessage(messageDescriptor)
+		if err := codec.unmarshal(message, from, data); err != nil {
+			return newInputError(i, from, delimited, err)
+		}
+		data, err = codec.marshal(message, to)
+		if err != nil {
+			return err
+		}
+		if err := writeMessageData(writer, to, delimited, data); err != nil {
+			return err
+		}
+	}
+}
+
+type codec struct {
+	jsonMarshaler   *jsonpb.Marshaler
+	jsonUnmarshaler *jsonpb.Unmarshaler
+	delimited       bool
+}
+
+func (c *codec) unmarshal(message *dynamic.Message, format string, data []byte) error {
+	switch format {
+	case FormatBin:
+		return message.Unmarshal(data)
+	case FormatJSON:
+		return message.UnmarshalJSONPB(c.jsonUnmarshaler, data)
+	case FormatText:
+		return message.UnmarshalText(data)
+	default:
+		return errs.NewInternalf("unknown format: %q", format)
+	}
+}
+
+func (c *codec) marshal(message *dynamic.Message, format string) ([]byte, error) {
+	switch format {
+	case FormatBin:
+		return message.Marshal()
+	case FormatJSON:
+		return message.MarshalJSONPB(c.jsonMarshaler)
+	case FormatText:
+		if c.delimited {
+			// a delimited message must be on a single line
+			return message.MarshalText()
+		}
+		return message.MarshalTextIndent()
+	default:
+		return nil, errs.NewInternalf("unknown format: %q", format)
+	}
+}
+
+// newReadMessageDataFunc returns a function that reads the data of the next message.
+//
+// The function returns io.EOF if there are no more messages.
+func newReadMessageDataFunc(reader io.Reader, format string, delimited bool) func() ([]byte, error) {
+	if !delimited {
+		read := false
+		return func() ([]byte, error) {
+			if read {
+				return nil, io.EOF
+			}
+			read = true
+			return ioutil.ReadAll(reader)
+		}
+	}
+	switch format {
+	case FormatBin:
+		bufioReader := bufio.NewReader(reader)
+		return func() ([]byte, error) {
+			size, err := binary.ReadUvarint(bufioReader)
+			if err != nil {
+				// io.EOF is only returned if no bytes were read
+				return nil, err
+			}
+			data := make([]byte, size)
+			if _, err := io.ReadFull(bufioReader, data); err != nil {
+				if err == io.EOF {
+					return nil, io.ErrUnexpectedEOF
+				}
+				return nil, err
+			}
+			return data, nil
+		}
+	default:
+		scanner := bufio.NewScanner(reader)
+		scanner.Buffer(nil, maxTextLineSize)
+		return func() ([]byte, error) {
+			for scanner.Scan() {
+				if line := bytes.TrimSpace(scanner.Bytes()); len(line) > 0 {
+					return line, nil
+				}
+			}
+			if err := scanner.Err(); err != nil {
+				return nil, err
+			}
+			return nil, io.EOF
+		}
+	}
+}
+
+func writeMessageData(writer io.Writer, format string, delimited bool, data []byte) error {
+	switch format {
+	case FormatBin:
+		if delimited {
+			sizeData := make([]byte, binary.MaxVarintLen64)
+			n := binary.PutUvarint(sizeData, uint64(len(data)))
+			if _, err := writer.Write(sizeData[:n]); err != nil {
+				return err
+			}
+		}
+		_, err := writer.Write(data)
+		return err
+	default:
+		data = append(bytes.TrimRight(data, "\n"), '\n')
+		_, err := writer.Write(data)
+		return err
+	}
+}
+
+// findMessageDescriptor finds the message with the fully-qualified name.
+func findMessageDescriptor(fileDescriptors []*desc.FileDescriptor, typeName string) (*desc.MessageDescriptor, error) {
+	for _, fileDescriptor := range fileDescriptors {
+		descriptor := fileDescriptor.FindSymbol(typeName)
+		if descriptor == nil {
+			continue
+		}
+		messageDescriptor, ok := descriptor.(*desc.MessageDescriptor)
+		if !ok {
+			return nil, errs.NewInvalidArgumentf("%q is not a message", typeName)
+		}
+		return messageDescriptor, nil
+	}
+	return nil, errs.NewNotFoundf("%q not found", typeName)
+}
+
+func newInputError(i int, format string, delimited bool, err error) error {
+	if delimited {
+		return errs.NewInvalidArgumentf("could not read message %d as %s: %v", i+1, format, err)
+	}
+	return errs.NewInvalidArgumentf("could not read input as %s: %v", format, err)
+}
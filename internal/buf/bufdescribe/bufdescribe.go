@@ -0,0 +1,125 @@
+// Package bufdescribe describes single named elements of Images.
+package bufdescribe
+
+import (
+	"context"
+	"io"
+	"strconv"
+
+	"github.com/bufbuild/buf/internal/buf/bufbuild"
+	"github.com/bufbuild/buf/internal/buf/bufformat"
+	"github.com/bufbuild/buf/internal/buf/bufpb"
+	"go.uber.org/zap"
+)
+
+const (
+	// KindMessage is the kind for messages.
+	KindMessage = "message"
+	// KindField is the kind for fields, including extensions.
+	KindField = "field"
+	// KindOneof is the kind for oneofs.
+	KindOneof = "oneof"
+	// KindEnum is the kind for enums.
+	KindEnum = "enum"
+	// KindEnumValue is the kind for enum values.
+	KindEnumValue = "enum_value"
+	// KindService is the kind for services.
+	KindService = "service"
+	// KindMethod is the kind for methods, ie RPCs.
+	KindMethod = "method"
+)
+
+// Description is the description of a single named element.
+type Description struct {
+	// Reference is the described element itself.
+	*Reference
+	// Source is the element printed as canonical Protobuf source, including
+	// leading comments and options.
+	Source string `json:"source,omitempty" yaml:"source,omitempty"`
+	// Dependencies are the messages and enums that the element uses, sorted by name.
+	//
+	// For messages, this includes the dependencies of nested messages, but not
+	// the nested messages and enums themselves.
+	Dependencies []*Reference `json:"dependencies,omitempty" yaml:"dependencies,omitempty"`
+	// Dependents are the fields and methods that use the element, sorted by name.
+	//
+	// This is only set for messages and enums.
+	Dependents []*Reference `json:"dependents,omitempty" yaml:"dependents,omitempty"`
+}
+
+// Reference is a reference to a named element.
+type Reference struct {
+	// Kind is the kind of the element, ie "message" or "field".
+	Kind string `json:"kind,omitempty" yaml:"kind,omitempty"`
+	// Name is the fully-qualified name of the element.
+	Name string `json:"name,omitempty" yaml:"name,omitempty"`
+	// Filename is the file the element is defined in.
+	Filename string `json:"filename,omitempty" yaml:"filename,omitempty"`
+	// Line is the line the element starts on. If the Image did not have
+	// source info, this will be 0.
+	Line int `json:"line,omitempty" yaml:"line,omitempty"`
+	// Column is the column the element starts on. If the Image did not have
+	// source info, this will be 0.
+	Column int `json:"column,omitempty" yaml:"column,omitempty"`
+}
+
+func (r *Reference) location() string {
+	if r.Line == 0 {
+		return r.Filename
+	}
+	return r.Filename + ":" + strconv.Itoa(r.Line) + ":" + strconv.Itoa(r.Column)
+}
+
+// Handler handles descriptions.
+type Handler interface {
+	// Describe describes the element with the fully-qualified name.
+	//
+	// The name may have a leading period.
+	// The Image must include imports, and should include source code info, otherwise
+	// comments and locations will be lost.
+	//
+	// Filenames will be relative to the roots, use FixDescriptionFilenames to
+	// make them into real file paths.
+	//
+	// Returns a user error if the name is not within the Image.
+	Describe(
+		ctx context.Context,
+		image bufpb.Image,
+		name string,
+	) (*Description, error)
+}
+
+// NewHandler returns a new Handler.
+func NewHandler(logger *zap.Logger, formatter bufformat.Formatter) Handler {
+	return newHandler(logger, formatter)
+}
+
+// FixDescriptionFilenames attempts to make all filenames into real file paths.
+//
+// If the resolver is nil, this has no effect.
+func FixDescriptionFilenames(resolver bufbuild.ProtoFilePathResolver, description *Description) error {
+	if resolver == nil {
+		return nil
+	}
+	references := []*Reference{description.Reference}
+	references = append(references, description.Dependencies...)
+	references = append(references, description.Dependents...)
+	for _, reference := range references {
+		filePath, err := resolver.GetFilePath(reference.Filename)
+		if err != nil {
+			if err == bufbuild.ErrFilePathUnknown {
+				continue
+			}
+			return err
+		}
+		reference.Filename = filePath
+	}
+	return nil
+}
+
+// PrintDescription prints the description to the writer.
+//
+// The JSON format is the Description on a single line.
+func PrintDescription(writer io.Writer, description *Description, asJSON bool) error {
+	return printDescription(writer, description, asJSON)
+}
@@ -0,0 +1,115 @@
+package bufdescribe_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/bufbuild/buf/internal/buf/bufdescribe"
+	"github.com/bufbuild/buf/internal/buf/bufformat"
+	"github.com/bufbuild/buf/internal/buf/buftesting"
+	"github.com/bufbuild/buf/internal/pkg/errs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestDescribeMessage(t *testing.T) {
+	t.Parallel()
+	description, err := testDescribe(t, ".a.One")
+	require.NoError(t, err)
+	buffer := bytes.NewBuffer(nil)
+	require.NoError(t, bufdescribe.PrintDescription(buffer, description, false))
+	assert.Equal(
+		t,
+		`message a.One (a.proto:8:1)
+
+// One is a one.
+message One {
+  // Nested is nested.
+  message Nested {
+    Two two = 1;
+  }
+
+  Nested nested = 1;
+  google.protobuf.Timestamp time = 2 [deprecated = true];
+  map<string, Three> threes = 3;
+}
+
+Dependencies:
+  enum a.Three (a.proto:20:1)
+  message a.Two (a.proto:18:1)
+  message google.protobuf.Timestamp (google/protobuf/timestamp.proto)
+
+Dependents:
+  method b.Five.Six (b.proto:12:3)
+`,
+		buffer.String(),
+	)
+}
+
+func TestDescribeDependents(t *testing.T) {
+	t.Parallel()
+	description, err := testDescribe(t, "a.Two")
+	require.NoError(t, err)
+	assert.Empty(t, description.Dependencies)
+	assert.Equal(
+		t,
+		[]*bufdescribe.Reference{
+			{
+				Kind:     bufdescribe.KindField,
+				Name:     "a.One.Nested.two",
+				Filename: "a.proto",
+				Line:     11,
+				Column:   5,
+			},
+			{
+				Kind:     bufdescribe.KindMethod,
+				Name:     "b.Five.Six",
+				Filename: "b.proto",
+				Line:     12,
+				Column:   3,
+			},
+			{
+				Kind:     bufdescribe.KindField,
+				Name:     "b.Four.two",
+				Filename: "b.proto",
+				Line:     8,
+				Column:   3,
+			},
+		},
+		description.Dependents,
+	)
+}
+
+func TestDescribeMethodJSON(t *testing.T) {
+	t.Parallel()
+	description, err := testDescribe(t, "b.Five.Six")
+	require.NoError(t, err)
+	buffer := bytes.NewBuffer(nil)
+	require.NoError(t, bufdescribe.PrintDescription(buffer, description, true))
+	assert.Equal(
+		t,
+		`{"kind":"method","name":"b.Five.Six","filename":"b.proto","line":12,"column":3,"source":"rpc Six(a.Two) returns (a.One);\n","dependencies":[{"kind":"message","name":"a.One","filename":"a.proto","line":8,"column":1},{"kind":"message","name":"a.Two","filename":"a.proto","line":18,"column":1}]}
+`,
+		buffer.String(),
+	)
+}
+
+func TestDescribeNotFound(t *testing.T) {
+	t.Parallel()
+	_, err := testDescribe(t, "a.Seven")
+	assert.Equal(t, errs.CodeNotFound, errs.GetCode(err))
+}
+
+func testDescribe(t *testing.T, name string) (*bufdescribe.Description, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	logger := zap.NewNop()
+	return bufdescribe.NewHandler(logger, bufformat.NewFormatter(logger)).Describe(
+		ctx,
+		buftesting.BuildImage(t, "testdata", true, true),
+		name,
+	)
+}
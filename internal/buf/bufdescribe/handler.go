@@ -0,0 +1,238 @@
+package bufdescribe
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/bufbuild/buf/internal/buf/bufformat"
+	"github.com/bufbuild/buf/internal/buf/bufpb"
+	"github.com/bufbuild/buf/internal/pkg/errs"
+	"github.com/bufbuild/buf/internal/pkg/logutil"
+	"github.com/jhump/protoreflect/desc"
+	"go.uber.org/zap"
+)
+
+type handler struct {
+	logger    *zap.Logger
+	formatter bufformat.Formatter
+}
+
+func newHandler(logger *zap.Logger, formatter bufformat.Formatter) *handler {
+	return &handler{
+		logger:    logger.Named("bufdescribe"),
+		formatter: formatter,
+	}
+}
+
+func (h *handler) Describe(
+	ctx context.Context,
+	image bufpb.Image,
+	name string,
+) (_ *Description, retErr error) {
+	defer logutil.DeferWithError(h.logger, "describe", &retErr)()
+
+	name = strings.TrimPrefix(name, ".")
+	if name == "" {
+		return nil, errs.NewInvalidArgument("name is empty")
+	}
+	descFileDescriptors, err := bufpb.ImageToDescFileDescriptors(image)
+	if err != nil {
+		return nil, err
+	}
+	// iterate in image order so that the result is deterministic
+	fileDescriptors := make([]*desc.FileDescriptor, 0, len(descFileDescriptors))
+	for _, file := range image.GetFile() {
+		fileDescriptor, ok := descFileDescriptors[file.GetName()]
+		if !ok {
+			return nil, errs.NewInternalf("no FileDescriptor for %q", file.GetName())
+		}
+		fileDescriptors = append(fileDescriptors, fileDescriptor)
+	}
+	var descriptor desc.Descriptor
+	for _, fileDescriptor := range fileDescriptors {
+		if descriptor = fileDescriptor.FindSymbol(name); descriptor != nil {
+			break
+		}
+	}
+	if descriptor == nil {
+		return nil, errs.NewNotFoundf("%q not found", name)
+	}
+	reference, ok := newReference(descriptor)
+	if !ok {
+		return nil, errs.NewInvalidArgumentf("%q is not a message, field, oneof, enum, enum value, service, or method", name)
+	}
+	source, err := h.formatter.FormatDescriptor(descriptor)
+	if err != nil {
+		return nil, err
+	}
+	return &Description{
+		Reference:    reference,
+		Source:       source,
+		Dependencies: getDependencies(descriptor),
+		Dependents:   getDependents(fileDescriptors, descriptor),
+	}, nil
+}
+
+// getDependencies returns the messages and enums used by the descriptor.
+func getDependencies(descriptor desc.Descriptor) []*Reference {
+	nameToDependency := make(map[string]desc.Descriptor)
+	addField := func(fieldDescriptor *desc.FieldDescriptor) {
+		for _, typeDescriptor := range getFieldTypes(fieldDescriptor) {
+			nameToDependency[typeDescriptor.GetFullyQualifiedName()] = typeDescriptor
+		}
+	}
+	addMethod := func(methodDescriptor *desc.MethodDescriptor) {
+		nameToDependency[methodDescriptor.GetInputType().GetFullyQualifiedName()] = methodDescriptor.GetInputType()
+		nameToDependency[methodDescriptor.GetOutputType().GetFullyQualifiedName()] = methodDescriptor.GetOutputType()
+	}
+	switch typedDescriptor := descriptor.(type) {
+	case *desc.MessageDescriptor:
+		forEachMessageField(typedDescriptor, addField)
+		// nested messages and enums are part of the description itself
+		prefix := typedDescriptor.GetFullyQualifiedName() + "."
+		for name := range nameToDependency {
+			if name == typedDescriptor.GetFullyQualifiedName() || strings.HasPrefix(name, prefix) {
+				delete(nameToDependency, name)
+			}
+		}
+	case *desc.FieldDescriptor:
+		addField(typedDescriptor)
+	case *desc.OneOfDescriptor:
+		for _, fieldDescriptor := range typedDescriptor.GetChoices() {
+			addField(fieldDescriptor)
+		}
+	case *desc.ServiceDescriptor:
+		for _, methodDescriptor := range typedDescriptor.GetMethods() {
+			addMethod(methodDescriptor)
+		}
+	case *desc.MethodDescriptor:
+		addMethod(typedDescriptor)
+	}
+	return newSortedReferences(nameToDependency)
+}
+
+// getDependents returns the fields and methods that use the descriptor.
+//
+// Only messages and enums have dependents.
+func getDependents(fileDescriptors []*desc.FileDescriptor, descriptor desc.Descriptor) []*Reference {
+	switch descriptor.(type) {
+	case *desc.MessageDescriptor, *desc.EnumDescriptor:
+	default:
+		return nil
+	}
+	name := descriptor.GetFullyQualifiedName()
+	nameToDependent := make(map[string]desc.Descriptor)
+	addField := func(fieldDescriptor *desc.FieldDescriptor) {
+		for _, typeDescriptor := range getFieldTypes(fieldDescriptor) {
+			if typeDescriptor.GetFullyQualifiedName() == name {
+				nameToDependent[fieldDescriptor.GetFullyQualifiedName()] = fieldDescriptor
+			}
+		}
+	}
+	for _, fileDescriptor := range fileDescriptors {
+		for _, messageDescriptor := range fileDescriptor.GetMessageTypes() {
+			forEachMessageField(messageDescriptor, addField)
+		}
+		for _, fieldDescriptor := range fileDescriptor.GetExtensions() {
+			addField(fieldDescriptor)
+		}
+		for _, serviceDescriptor := range fileDescriptor.GetServices() {
+			for _, methodDescriptor := range serviceDescriptor.GetMethods() {
+				if methodDescriptor.GetInputType().GetFullyQualifiedName() == name ||
+					methodDescriptor.GetOutputType().GetFullyQualifiedName() == name {
+					nameToDependent[methodDescriptor.GetFullyQualifiedName()] = methodDescriptor
+				}
+			}
+		}
+	}
+	return newSortedReferences(nameToDependent)
+}
+
+// getFieldTypes returns the message and enum types of the field.
+//
+// For map fields, this is the type of the map value instead of the map entry.
+func getFieldTypes(fieldDescriptor *desc.FieldDescriptor) []desc.Descriptor {
+	if messageDescriptor := fieldDescriptor.GetMessageType(); messageDescriptor != nil {
+		if messageDescriptor.IsMapEntry() {
+			return getFieldTypes(fieldDescriptor.GetMapValueType())
+		}
+		return []desc.Descriptor{messageDescriptor}
+	}
+	if enumDescriptor := fieldDescriptor.GetEnumType(); enumDescriptor != nil {
+		return []desc.Descriptor{enumDescriptor}
+	}
+	return nil
+}
+
+// forEachMessageField calls f for every field and extension of the message
+// and its nested messages.
+//
+// Map entries are skipped as their fields are accounted for by getFieldTypes.
+func forEachMessageField(messageDescriptor *desc.MessageDescriptor, f func(*desc.FieldDescriptor)) {
+	for _, fieldDescriptor := range messageDescriptor.GetFields() {
+		f(fieldDescriptor)
+	}
+	for _, fieldDescriptor := range messageDescriptor.GetNestedExtensions() {
+		f(fieldDescriptor)
+	}
+	for _, nestedMessageDescriptor := range messageDescriptor.GetNestedMessageTypes() {
+		if !nestedMessageDescriptor.IsMapEntry() {
+			forEachMessageField(nestedMessageDescriptor, f)
+		}
+	}
+}
+
+func newSortedReferences(nameToDescriptor map[string]desc.Descriptor) []*Reference {
+	if len(nameToDescriptor) == 0 {
+		return nil
+	}
+	references := make([]*Reference, 0, len(nameToDescriptor))
+	for _, descriptor := range nameToDescriptor {
+		if reference, ok := newReference(descriptor); ok {
+			references = append(references, reference)
+		}
+	}
+	sort.Slice(
+		references,
+		func(i int, j int) bool {
+			return references[i].Name < references[j].Name
+		},
+	)
+	return references
+}
+
+// newReference returns a new Reference for the descriptor.
+//
+// Returns false if the descriptor is not a supported kind.
+func newReference(descriptor desc.Descriptor) (*Reference, bool) {
+	var kind string
+	switch descriptor.(type) {
+	case *desc.MessageDescriptor:
+		kind = KindMessage
+	case *desc.FieldDescriptor:
+		kind = KindField
+	case *desc.OneOfDescriptor:
+		kind = KindOneof
+	case *desc.EnumDescriptor:
+		kind = KindEnum
+	case *desc.EnumValueDescriptor:
+		kind = KindEnumValue
+	case *desc.ServiceDescriptor:
+		kind = KindService
+	case *desc.MethodDescriptor:
+		kind = KindMethod
+	default:
+		return nil, false
+	}
+	reference := &Reference{
+		Kind:     kind,
+		Name:     descriptor.GetFullyQualifiedName(),
+		Filename: descriptor.GetFile().GetName(),
+	}
+	if sourceInfo := descriptor.GetSourceInfo(); sourceInfo != nil && len(sourceInfo.Span) >= 3 {
+		reference.Line = int(sourceInfo.Span[0]) + 1
+		reference.Column = int(sourceInfo.Span[1]) + 1
+	}
+	return reference, true
+}
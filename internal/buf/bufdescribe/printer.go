@@ -0,0 +1,41 @@
+package bufdescribe
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+func printDescription(writer io.Writer, description *Description, asJSON bool) error {
+	if asJSON {
+		data, err := json.Marshal(description)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(writer, string(data))
+		return err
+	}
+	buffer := bytes.NewBuffer(nil)
+	_, _ = fmt.Fprintf(buffer, "%s %s (%s)\n", description.Kind, description.Name, description.location())
+	if description.Source != "" {
+		_, _ = buffer.WriteString("\n")
+		_, _ = buffer.WriteString(strings.TrimSuffix(description.Source, "\n"))
+		_, _ = buffer.WriteString("\n")
+	}
+	printReferences(buffer, "Dependencies", description.Dependencies)
+	printReferences(buffer, "Dependents", description.Dependents)
+	_, err := writer.Write(buffer.Bytes())
+	return err
+}
+
+func printReferences(buffer *bytes.Buffer, title string, references []*Reference) {
+	if len(references) == 0 {
+		return
+	}
+	_, _ = fmt.Fprintf(buffer, "\n%s:\n", title)
+	for _, reference := range references {
+		_, _ = fmt.Fprintf(buffer, "  %s %s (%s)\n", reference.Kind, reference.Name, reference.location())
+	}
+}
@@ -0,0 +1,235 @@
+// Package bufdocs generates documentation of Images from source comments.
+package bufdocs
+
+import (
+	"context"
+	"io"
+
+	"github.com/bufbuild/buf/internal/buf/bufpb"
+	"github.com/bufbuild/buf/internal/pkg/errs"
+	"github.com/bufbuild/buf/internal/pkg/storage"
+	"go.uber.org/zap"
+)
+
+const (
+	// FormatMarkdown is the Markdown format.
+	FormatMarkdown = "markdown"
+	// FormatHTML is the HTML format.
+	FormatHTML = "html"
+	// FormatJSON is the JSON format.
+	FormatJSON = "json"
+)
+
+var (
+	// AllFormats are all formats.
+	AllFormats = []string{
+		FormatMarkdown,
+		FormatHTML,
+		FormatJSON,
+	}
+
+	formatToExt = map[string]string{
+		FormatMarkdown: ".md",
+		FormatHTML:     ".html",
+		FormatJSON:     ".json",
+	}
+)
+
+// Package is the documentation of a single package.
+type Package struct {
+	// Name is the name of the package.
+	//
+	// Files without a package are documented under the empty package.
+	Name string `json:"name" yaml:"name"`
+	// Files are the files of the package, sorted by path.
+	Files []string `json:"files,omitempty" yaml:"files,omitempty"`
+	// Messages are the messages of the package, including nested messages.
+	//
+	// These are in declaration order by file. Map entries are not included.
+	Messages []*Message `json:"messages,omitempty" yaml:"messages,omitempty"`
+	// Enums are the enums of the package, including nested enums.
+	//
+	// These are in declaration order by file.
+	Enums []*Enum `json:"enums,omitempty" yaml:"enums,omitempty"`
+	// Services are the services of the package.
+	//
+	// These are in declaration order by file.
+	Services []*Service `json:"services,omitempty" yaml:"services,omitempty"`
+}
+
+// Message is the documentation of a message.
+type Message struct {
+	// Name is the fully-qualified name of the message.
+	Name string `json:"name" yaml:"name"`
+	// NestedName is the name of the message without the package.
+	NestedName string `json:"nested_name" yaml:"nested_name"`
+	// Description is the leading and trailing comments of the message.
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+	// Deprecated is true if the message is deprecated.
+	Deprecated bool `json:"deprecated,omitempty" yaml:"deprecated,omitempty"`
+	// Fields are the fields of the message, including extensions declared
+	// within the message, in declaration order.
+	Fields []*Field `json:"fields,omitempty" yaml:"fields,omitempty"`
+}
+
+// Field is the documentation of a field.
+type Field struct {
+	// Name is the name of the field.
+	Name string `json:"name" yaml:"name"`
+	// Type is the type of the field as it would be written in Protobuf source,
+	// ie "string", "acme.v1.Foo", or "map<string, acme.v1.Foo>".
+	Type string `json:"type" yaml:"type"`
+	// TypeReference is the message or enum type of the field.
+	//
+	// For map fields, this is the type of the map value. This is nil for
+	// scalar types.
+	TypeReference *TypeReference `json:"type_reference,omitempty" yaml:"type_reference,omitempty"`
+	// Label is the label of the field, ie "optional" or "repeated".
+	Label string `json:"label" yaml:"label"`
+	// Number is the field number.
+	Number int `json:"number" yaml:"number"`
+	// JSONName is the JSON name of the field.
+	JSONName string `json:"json_name" yaml:"json_name"`
+	// Description is the leading and trailing comments of the field.
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+	// Deprecated is true if the field is deprecated.
+	Deprecated bool `json:"deprecated,omitempty" yaml:"deprecated,omitempty"`
+}
+
+// Enum is the documentation of an enum.
+type Enum struct {
+	// Name is the fully-qualified name of the enum.
+	Name string `json:"name" yaml:"name"`
+	// NestedName is the name of the enum without the package.
+	NestedName string `json:"nested_name" yaml:"nested_name"`
+	// Description is the leading and trailing comments of the enum.
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+	// Deprecated is true if the enum is deprecated.
+	Deprecated bool `json:"deprecated,omitempty" yaml:"deprecated,omitempty"`
+	// Values are the values of the enum in declaration order.
+	Values []*EnumValue `json:"values,omitempty" yaml:"values,omitempty"`
+}
+
+// EnumValue is the documentation of an enum value.
+type EnumValue struct {
+	// Name is the name of the enum value.
+	Name string `json:"name" yaml:"name"`
+	// Number is the number of the enum value.
+	Number int `json:"number" yaml:"number"`
+	// Description is the leading and trailing comments of the enum value.
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+	// Deprecated is true if the enum value is deprecated.
+	Deprecated bool `json:"deprecated,omitempty" yaml:"deprecated,omitempty"`
+}
+
+// Service is the documentation of a service.
+type Service struct {
+	// Name is the fully-qualified name of the service.
+	Name string `json:"name" yaml:"name"`
+	// NestedName is the name of the service without the package.
+	NestedName string `json:"nested_name" yaml:"nested_name"`
+	// Description is the leading and trailing comments of the service.
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+	// Deprecated is true if the service is deprecated.
+	Deprecated bool `json:"deprecated,omitempty" yaml:"deprecated,omitempty"`
+	// Methods are the methods of the service in declaration order.
+	Methods []*Method `json:"methods,omitempty" yaml:"methods,omitempty"`
+}
+
+// Method is the documentation of a method, ie an RPC.
+type Method struct {
+	// Name is the name of the method.
+	Name string `json:"name" yaml:"name"`
+	// Request is the request type.
+	Request *TypeReference `json:"request" yaml:"request"`
+	// ClientStreaming is true if the request is streamed.
+	ClientStreaming bool `json:"client_streaming,omitempty" yaml:"client_streaming,omitempty"`
+	// Response is the response type.
+	Response *TypeReference `json:"response" yaml:"response"`
+	// ServerStreaming is true if the response is streamed.
+	ServerStreaming bool `json:"server_streaming,omitempty" yaml:"server_streaming,omitempty"`
+	// Description is the leading and trailing comments of the method.
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+	// Deprecated is true if the method is deprecated.
+	Deprecated bool `json:"deprecated,omitempty" yaml:"deprecated,omitempty"`
+	// HTTPRules are the HTTP mappings from the google.api.http option, including
+	// additional bindings. This is empty if the option is not set.
+	HTTPRules []*HTTPRule `json:"http_rules,omitempty" yaml:"http_rules,omitempty"`
+}
+
+// HTTPRule is a single HTTP mapping of a method.
+type HTTPRule struct {
+	// Method is the HTTP method, ie "GET", or the kind of a custom pattern.
+	Method string `json:"method" yaml:"method"`
+	// Path is the path template, ie "/v1/{name=messages/*}".
+	Path string `json:"path" yaml:"path"`
+	// Body is the request field mapped to the HTTP request body, if any.
+	Body string `json:"body,omitempty" yaml:"body,omitempty"`
+	// ResponseBody is the response field mapped to the HTTP response body, if any.
+	ResponseBody string `json:"response_body,omitempty" yaml:"response_body,omitempty"`
+}
+
+// TypeReference is a reference to a message or enum.
+type TypeReference struct {
+	// Name is the fully-qualified name of the type.
+	Name string `json:"name" yaml:"name"`
+	// Package is the package of the type.
+	//
+	// This is only set if Documented is true.
+	Package string `json:"package,omitempty" yaml:"package,omitempty"`
+	// Documented is true if the type is documented within the same Packages,
+	// in which case references to it are linked.
+	Documented bool `json:"documented,omitempty" yaml:"documented,omitempty"`
+}
+
+// Handler handles documentation.
+type Handler interface {
+	// Docs returns the documentation of the Image, one Package per package.
+	//
+	// Imports are documented if they are in the Image. The Image should include
+	// source code info, otherwise there will be no descriptions.
+	//
+	// Packages are sorted by name.
+	Docs(
+		ctx context.Context,
+		image bufpb.Image,
+	) ([]*Package, error)
+	// Generate writes one page per package of the Image in the format to the bucket.
+	//
+	// See GetPagePath for the paths of the pages.
+	Generate(
+		ctx context.Context,
+		bucket storage.Bucket,
+		image bufpb.Image,
+		format string,
+	) error
+}
+
+// NewHandler returns a new Handler.
+func NewHandler(logger *zap.Logger) Handler {
+	return newHandler(logger)
+}
+
+// ValidateFormat returns a user error if the format is not in AllFormats.
+func ValidateFormat(format string) error {
+	if _, ok := formatToExt[format]; !ok {
+		return errs.NewInvalidArgumentf("unknown format: %q", format)
+	}
+	return nil
+}
+
+// GetPagePath returns the path of the page for the package in the format.
+//
+// This is the package name with the extension of the format, ie "acme.v1.md".
+// The empty package is written to "default" with the extension.
+func GetPagePath(pkg string, format string) string {
+	if pkg == "" {
+		pkg = "default"
+	}
+	return pkg + formatToExt[format]
+}
+
+// PrintPackage prints the documentation of the package in the format to the writer.
+func PrintPackage(writer io.Writer, pkg *Package, format string) error {
+	return printPackage(writer, pkg, format)
+}
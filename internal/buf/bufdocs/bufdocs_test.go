@@ -0,0 +1,171 @@
+package bufdocs_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/bufbuild/buf/internal/buf/bufdocs"
+	"github.com/bufbuild/buf/internal/buf/buftesting"
+	"github.com/bufbuild/buf/internal/pkg/bytepool"
+	"github.com/bufbuild/buf/internal/pkg/storage/storagemem"
+	"github.com/bufbuild/buf/internal/pkg/storage/storageutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestDocs(t *testing.T) {
+	t.Parallel()
+	packages := testDocs(t)
+	require.Len(t, packages, 3)
+	assert.Equal(t, "acme.type", packages[0].Name)
+	assert.Equal(t, "acme.v1", packages[1].Name)
+	assert.Equal(t, "google.api", packages[2].Name)
+
+	pkg := packages[1]
+	assert.Equal(t, []string{"acme/v1/order.proto"}, pkg.Files)
+	require.Len(t, pkg.Messages, 3)
+	assert.Equal(t, "acme.v1.Order.Item", pkg.Messages[2].Name)
+	assert.Equal(t, "Order.Item", pkg.Messages[2].NestedName)
+	message := pkg.Messages[1]
+	assert.Equal(t, "Order is an order.\n\nOrders have | items.", message.Description)
+	require.Len(t, message.Fields, 5)
+	assert.Equal(
+		t,
+		&bufdocs.Field{
+			Name:        "name",
+			Type:        "string",
+			Label:       "optional",
+			Number:      1,
+			JSONName:    "name",
+			Description: "The name of the order.\nUnique.",
+		},
+		message.Fields[0],
+	)
+	assert.Equal(
+		t,
+		&bufdocs.Field{
+			Name: "total",
+			Type: "acme.type.Money",
+			TypeReference: &bufdocs.TypeReference{
+				Name:       "acme.type.Money",
+				Package:    "acme.type",
+				Documented: true,
+			},
+			Label:      "optional",
+			Number:     2,
+			JSONName:   "total",
+			Deprecated: true,
+		},
+		message.Fields[1],
+	)
+	assert.Equal(t, "map<string, acme.v1.Order.Item>", message.Fields[2].Type)
+	assert.Equal(t, "acme.v1.Order.Item", message.Fields[2].TypeReference.Name)
+	assert.Equal(t, "labels", message.Fields[4].JSONName)
+
+	require.Len(t, pkg.Enums, 1)
+	assert.Equal(
+		t,
+		[]*bufdocs.EnumValue{
+			{Name: "STATUS_UNSPECIFIED", Number: 0},
+			{Name: "STATUS_OLD", Number: 1, Description: "Removed.", Deprecated: true},
+		},
+		pkg.Enums[0].Values,
+	)
+
+	require.Len(t, pkg.Services, 1)
+	require.Len(t, pkg.Services[0].Methods, 2)
+	method := pkg.Services[0].Methods[0]
+	assert.Equal(t, "GetOrder gets an order.", method.Description)
+	assert.Equal(
+		t,
+		[]*bufdocs.HTTPRule{
+			{Method: "GET", Path: "/v1/{name=orders/*}"},
+			{Method: "POST", Path: "/v1/{name=orders/*}:get", Body: "*"},
+		},
+		method.HTTPRules,
+	)
+	method = pkg.Services[0].Methods[1]
+	assert.True(t, method.ServerStreaming)
+	assert.False(t, method.ClientStreaming)
+	assert.True(t, method.Deprecated)
+	assert.Empty(t, method.HTTPRules)
+}
+
+func TestPrintPackageMarkdown(t *testing.T) {
+	t.Parallel()
+	buffer := bytes.NewBuffer(nil)
+	require.NoError(t, bufdocs.PrintPackage(buffer, testDocs(t)[0], bufdocs.FormatMarkdown))
+	assert.Equal(
+		t,
+		`# acme.type
+
+Files:
+
+- `+"`acme/type/money.proto`"+`
+
+## Contents
+
+- Messages
+  - [Money](#acme.type.Money)
+
+## Messages
+
+<a name="acme.type.Money"></a>
+
+### Money
+
+Money is an amount of money.
+
+| Field | Type | Label | Number | JSON Name | Description |
+| ----- | ---- | ----- | ------ | --------- | ----------- |
+| currency_code | string | optional | 1 | currencyCode | The currency code. |
+| units | int64 | optional | 2 | units | The whole units. |
+`,
+		buffer.String(),
+	)
+}
+
+func TestGenerate(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	image := buftesting.BuildImage(t, "testdata", false, true)
+	for _, format := range bufdocs.AllFormats {
+		segList := bytepool.NewSegList()
+		bucket := storagemem.NewBucket(segList)
+		require.NoError(t, bufdocs.NewHandler(zap.NewNop()).Generate(ctx, bucket, image, format))
+		data, err := storageutil.ReadPath(ctx, bucket, bufdocs.GetPagePath("acme.v1", format))
+		require.NoError(t, err)
+		assert.Contains(t, string(data), "acme.v1.OrderService")
+		switch format {
+		case bufdocs.FormatMarkdown:
+			assert.Contains(t, string(data), "[acme.type.Money](acme.type.md#acme.type.Money)")
+			assert.Contains(t, string(data), "[map&lt;string, acme.v1.Order.Item&gt;](#acme.v1.Order.Item)")
+		case bufdocs.FormatHTML:
+			assert.Contains(t, string(data), `<a href="acme.type.html#acme.type.Money">acme.type.Money</a>`)
+			assert.Contains(t, string(data), "<li><code>GET /v1/{name=orders/*}</code></li>")
+		case bufdocs.FormatJSON:
+			assert.Contains(t, string(data), `"type": "map<string, acme.v1.Order.Item>"`)
+		}
+		_, err = storageutil.ReadPath(ctx, bucket, bufdocs.GetPagePath("acme.type", format))
+		require.NoError(t, err)
+		require.NoError(t, bucket.Close())
+	}
+}
+
+func TestGenerateUnknownFormat(t *testing.T) {
+	t.Parallel()
+	bucket := storagemem.NewBucket(bytepool.NewSegList())
+	assert.Error(t, bufdocs.NewHandler(zap.NewNop()).Generate(context.Background(), bucket, buftesting.BuildImage(t, "testdata", false, true), "foo"))
+}
+
+func testDocs(t *testing.T) []*bufdocs.Package {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	packages, err := bufdocs.NewHandler(zap.NewNop()).Docs(ctx, buftesting.BuildImage(t, "testdata", false, true))
+	require.NoError(t, err)
+	return packages
+}
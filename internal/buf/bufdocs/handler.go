@@ -0,0 +1,362 @@
+package bufdocs
+
+import (
+	"bytes"
+	"context"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/bufbuild/buf/internal/buf/bufpb"
+	"github.com/bufbuild/buf/internal/pkg/logutil"
+	"github.com/bufbuild/buf/internal/pkg/protodesc"
+	"github.com/bufbuild/buf/internal/pkg/storage"
+	"github.com/bufbuild/buf/internal/pkg/storage/storageutil"
+	"github.com/golang/protobuf/proto"
+	"go.uber.org/zap"
+	"google.golang.org/genproto/googleapis/api/annotations"
+)
+
+type handler struct {
+	logger *zap.Logger
+}
+
+func newHandler(logger *zap.Logger) *handler {
+	return &handler{
+		logger: logger.Named("bufdocs"),
+	}
+}
+
+func (h *handler) Docs(
+	ctx context.Context,
+	image bufpb.Image,
+) (_ []*Package, retErr error) {
+	defer logutil.DeferWithError(h.logger, "docs", &retErr)()
+
+	files, err := protodesc.NewFiles(image.GetFile()...)
+	if err != nil {
+		return nil, err
+	}
+	// sorting the files makes the declaration order deterministic across files
+	protodesc.SortFiles(files)
+	builder, err := newPackageBuilder(image, files)
+	if err != nil {
+		return nil, err
+	}
+	return builder.build()
+}
+
+func (h *handler) Generate(
+	ctx context.Context,
+	bucket storage.Bucket,
+	image bufpb.Image,
+	format string,
+) (retErr error) {
+	defer logutil.DeferWithError(h.logger, "generate", &retErr)()
+
+	if err := ValidateFormat(format); err != nil {
+		return err
+	}
+	packages, err := h.Docs(ctx, image)
+	if err != nil {
+		return err
+	}
+	for _, pkg := range packages {
+		buffer := bytes.NewBuffer(nil)
+		if err := printPackage(buffer, pkg, format); err != nil {
+			return err
+		}
+		if err := storageutil.WritePath(ctx, bucket, GetPagePath(pkg.Name, format), buffer.Bytes()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type packageBuilder struct {
+	files []protodesc.File
+	// fullNameToMessage includes map entries, which are needed for map fields.
+	fullNameToMessage map[string]protodesc.Message
+	// documentedNameToPackage contains all messages and enums.
+	documentedNameToPackage map[string]string
+	// methodNameToHTTPRules is keyed by the fully-qualified name of the method.
+	methodNameToHTTPRules map[string][]*HTTPRule
+}
+
+func newPackageBuilder(image bufpb.Image, files []protodesc.File) (*packageBuilder, error) {
+	fullNameToMessage, err := protodesc.FullNameToMessage(files...)
+	if err != nil {
+		return nil, err
+	}
+	fullNameToEnum, err := protodesc.FullNameToEnum(files...)
+	if err != nil {
+		return nil, err
+	}
+	documentedNameToPackage := make(map[string]string, len(fullNameToMessage)+len(fullNameToEnum))
+	for fullName, message := range fullNameToMessage {
+		documentedNameToPackage[fullName] = message.Package()
+	}
+	for fullName, enum := range fullNameToEnum {
+		documentedNameToPackage[fullName] = enum.Package()
+	}
+	methodNameToHTTPRules, err := getMethodNameToHTTPRules(image)
+	if err != nil {
+		return nil, err
+	}
+	return &packageBuilder{
+		files:                   files,
+		fullNameToMessage:       fullNameToMessage,
+		documentedNameToPackage: documentedNameToPackage,
+		methodNameToHTTPRules:   methodNameToHTTPRules,
+	}, nil
+}
+
+func (b *packageBuilder) build() ([]*Package, error) {
+	nameToPackage := make(map[string]*Package)
+	for _, file := range b.files {
+		pkg, ok := nameToPackage[file.Package()]
+		if !ok {
+			pkg = &Package{
+				Name: file.Package(),
+			}
+			nameToPackage[file.Package()] = pkg
+		}
+		pkg.Files = append(pkg.Files, file.FilePath())
+		if err := protodesc.ForEachMessage(
+			func(message protodesc.Message) error {
+				// map entries are generated by the compiler and are not declared
+				if !message.IsMapEntry() {
+					pkg.Messages = append(pkg.Messages, b.newMessage(message))
+				}
+				return nil
+			},
+			file,
+		); err != nil {
+			return nil, err
+		}
+		if err := protodesc.ForEachEnum(
+			func(enum protodesc.Enum) error {
+				pkg.Enums = append(pkg.Enums, newEnum(enum))
+				return nil
+			},
+			file,
+		); err != nil {
+			return nil, err
+		}
+		for _, service := range file.Services() {
+			pkg.Services = append(pkg.Services, b.newService(service))
+		}
+	}
+	packages := make([]*Package, 0, len(nameToPackage))
+	for _, pkg := range nameToPackage {
+		packages = append(packages, pkg)
+	}
+	sort.Slice(
+		packages,
+		func(i int, j int) bool {
+			return packages[i].Name < packages[j].Name
+		},
+	)
+	return packages, nil
+}
+
+func (b *packageBuilder) newMessage(message protodesc.Message) *Message {
+	docMessage := &Message{
+		Name:        message.FullName(),
+		NestedName:  message.NestedName(),
+		Description: getDescription(message),
+		Deprecated:  message.Deprecated(),
+	}
+	for _, field := range message.Fields() {
+		docMessage.Fields = append(docMessage.Fields, b.newField(field))
+	}
+	for _, field := range message.Extensions() {
+		docMessage.Fields = append(docMessage.Fields, b.newField(field))
+	}
+	return docMessage
+}
+
+func (b *packageBuilder) newField(field protodesc.Field) *Field {
+	docField := &Field{
+		Name:        field.Name(),
+		Label:       field.Label().String(),
+		Number:      field.Number(),
+		JSONName:    field.JSONName(),
+		Description: getDescription(field),
+		Deprecated:  field.Deprecated(),
+	}
+	docField.Type, docField.TypeReference = b.getFieldType(field)
+	return docField
+}
+
+// getFieldType returns the type of the field as it would be written in Protobuf
+// source, and the message or enum type of the field or the map value.
+func (b *packageBuilder) getFieldType(field protodesc.Field) (string, *TypeReference) {
+	typeName := strings.TrimPrefix(field.TypeName(), ".")
+	if typeName == "" {
+		return field.Type().String(), nil
+	}
+	if message, ok := b.fullNameToMessage[typeName]; ok && message.IsMapEntry() {
+		var keyField protodesc.Field
+		var valueField protodesc.Field
+		for _, mapEntryField := range message.Fields() {
+			switch mapEntryField.Number() {
+			case 1:
+				keyField = mapEntryField
+			case 2:
+				valueField = mapEntryField
+			}
+		}
+		if keyField != nil && valueField != nil {
+			valueType, valueTypeReference := b.getFieldType(valueField)
+			return "map<" + keyField.Type().String() + ", " + valueType + ">", valueTypeReference
+		}
+	}
+	return typeName, b.newTypeReference(typeName)
+}
+
+func (b *packageBuilder) newService(service protodesc.Service) *Service {
+	docService := &Service{
+		Name:        service.FullName(),
+		NestedName:  service.NestedName(),
+		Description: getDescription(service),
+		Deprecated:  service.Deprecated(),
+	}
+	for _, method := range service.Methods() {
+		docService.Methods = append(
+			docService.Methods,
+			&Method{
+				Name:            method.Name(),
+				Request:         b.newTypeReference(strings.TrimPrefix(method.InputTypeName(), ".")),
+				ClientStreaming: method.ClientStreaming(),
+				Response:        b.newTypeReference(strings.TrimPrefix(method.OutputTypeName(), ".")),
+				ServerStreaming: method.ServerStreaming(),
+				Description:     getDescription(method),
+				Deprecated:      method.Deprecated(),
+				HTTPRules:       b.methodNameToHTTPRules[method.FullName()],
+			},
+		)
+	}
+	return docService
+}
+
+func (b *packageBuilder) newTypeReference(fullName string) *TypeReference {
+	typeReference := &TypeReference{
+		Name: fullName,
+	}
+	if pkg, ok := b.documentedNameToPackage[fullName]; ok {
+		typeReference.Package = pkg
+		typeReference.Documented = true
+	}
+	return typeReference
+}
+
+func newEnum(enum protodesc.Enum) *Enum {
+	docEnum := &Enum{
+		Name:        enum.FullName(),
+		NestedName:  enum.NestedName(),
+		Description: getDescription(enum),
+		Deprecated:  enum.Deprecated(),
+	}
+	for _, enumValue := range enum.Values() {
+		docEnum.Values = append(
+			docEnum.Values,
+			&EnumValue{
+				Name:        enumValue.Name(),
+				Number:      enumValue.Number(),
+				Description: getDescription(enumValue),
+				Deprecated:  enumValue.Deprecated(),
+			},
+		)
+	}
+	return docEnum
+}
+
+// getMethodNameToHTTPRules returns the HTTP rules of all methods with the
+// google.api.http option.
+//
+// protodesc does not expose options, so these are read from the FileDescriptors.
+func getMethodNameToHTTPRules(image bufpb.Image) (map[string][]*HTTPRule, error) {
+	methodNameToHTTPRules := make(map[string][]*HTTPRule)
+	for _, fileDescriptor := range image.GetFile() {
+		prefix := ""
+		if pkg := fileDescriptor.GetPackage(); pkg != "" {
+			prefix = pkg + "."
+		}
+		for _, serviceDescriptorProto := range fileDescriptor.GetService() {
+			for _, methodDescriptorProto := range serviceDescriptorProto.GetMethod() {
+				options := methodDescriptorProto.GetOptions()
+				if options == nil || !proto.HasExtension(options, annotations.E_Http) {
+					continue
+				}
+				extension, err := proto.GetExtension(options, annotations.E_Http)
+				if err != nil {
+					return nil, err
+				}
+				httpRule, ok := extension.(*annotations.HttpRule)
+				if !ok {
+					continue
+				}
+				methodName := prefix + serviceDescriptorProto.GetName() + "." + methodDescriptorProto.GetName()
+				methodNameToHTTPRules[methodName] = newHTTPRules(httpRule)
+			}
+		}
+	}
+	return methodNameToHTTPRules, nil
+}
+
+func newHTTPRules(httpRule *annotations.HttpRule) []*HTTPRule {
+	docHTTPRule := &HTTPRule{
+		Body:         httpRule.GetBody(),
+		ResponseBody: httpRule.GetResponseBody(),
+	}
+	switch pattern := httpRule.GetPattern().(type) {
+	case *annotations.HttpRule_Get:
+		docHTTPRule.Method, docHTTPRule.Path = "GET", pattern.Get
+	case *annotations.HttpRule_Put:
+		docHTTPRule.Method, docHTTPRule.Path = "PUT", pattern.Put
+	case *annotations.HttpRule_Post:
+		docHTTPRule.Method, docHTTPRule.Path = "POST", pattern.Post
+	case *annotations.HttpRule_Delete:
+		docHTTPRule.Method, docHTTPRule.Path = "DELETE", pattern.Delete
+	case *annotations.HttpRule_Patch:
+		docHTTPRule.Method, docHTTPRule.Path = "PATCH", pattern.Patch
+	case *annotations.HttpRule_Custom:
+		docHTTPRule.Method, docHTTPRule.Path = pattern.Custom.GetKind(), pattern.Custom.GetPath()
+	}
+	var httpRules []*HTTPRule
+	if docHTTPRule.Method != "" {
+		httpRules = append(httpRules, docHTTPRule)
+	}
+	for _, additionalBinding := range httpRule.GetAdditionalBindings() {
+		httpRules = append(httpRules, newHTTPRules(additionalBinding)...)
+	}
+	return httpRules
+}
+
+// getDescription returns the leading and trailing comments of the descriptor,
+// separated by a blank line.
+//
+// The single space that usually follows the comment marker is removed from
+// every line.
+func getDescription(locationDescriptor protodesc.LocationDescriptor) string {
+	location := locationDescriptor.Location()
+	if location == nil {
+		return ""
+	}
+	var parts []string
+	for _, comments := range []string{location.LeadingComments(), location.TrailingComments()} {
+		if comments = cleanComments(comments); comments != "" {
+			parts = append(parts, comments)
+		}
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+func cleanComments(comments string) string {
+	lines := strings.Split(comments, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRightFunc(strings.TrimPrefix(line, " "), unicode.IsSpace)
+	}
+	return strings.Trim(strings.Join(lines, "\n"), "\n")
+}
@@ -0,0 +1,336 @@
+package bufdocs
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"strings"
+)
+
+func printPackage(writer io.Writer, pkg *Package, format string) error {
+	switch format {
+	case FormatMarkdown:
+		return printPackageMarkdown(writer, pkg)
+	case FormatHTML:
+		return printPackageHTML(writer, pkg)
+	case FormatJSON:
+		encoder := json.NewEncoder(writer)
+		// types such as map<string, string> should be readable
+		encoder.SetEscapeHTML(false)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(pkg)
+	default:
+		return ValidateFormat(format)
+	}
+}
+
+func printPackageMarkdown(writer io.Writer, pkg *Package) error {
+	buffer := bytes.NewBuffer(nil)
+	p := func(format string, args ...interface{}) {
+		_, _ = fmt.Fprintf(buffer, format, args...)
+	}
+	p("# %s\n", getPackageTitle(pkg.Name))
+	if len(pkg.Files) > 0 {
+		p("\nFiles:\n\n")
+		for _, file := range pkg.Files {
+			p("- `%s`\n", file)
+		}
+	}
+	printContentsMarkdown(buffer, pkg)
+	if len(pkg.Services) > 0 {
+		p("\n## Services\n")
+		for _, service := range pkg.Services {
+			p("\n<a name=\"%s\"></a>\n\n### %s\n", service.Name, service.NestedName)
+			printDescriptionMarkdown(buffer, service.Description, service.Deprecated)
+			for _, method := range service.Methods {
+				p("\n<a name=\"%s.%s\"></a>\n\n#### %s\n\n", service.Name, method.Name, method.Name)
+				p(
+					"rpc %s(%s) returns (%s)\n",
+					method.Name,
+					getMethodTypeMarkdown(pkg.Name, method.Request, method.ClientStreaming),
+					getMethodTypeMarkdown(pkg.Name, method.Response, method.ServerStreaming),
+				)
+				if len(method.HTTPRules) > 0 {
+					p("\n")
+					for _, httpRule := range method.HTTPRules {
+						p("- %s\n", getHTTPRuleMarkdown(httpRule))
+					}
+				}
+				printDescriptionMarkdown(buffer, method.Description, method.Deprecated)
+			}
+		}
+	}
+	if len(pkg.Messages) > 0 {
+		p("\n## Messages\n")
+		for _, message := range pkg.Messages {
+			p("\n<a name=\"%s\"></a>\n\n### %s\n", message.Name, message.NestedName)
+			printDescriptionMarkdown(buffer, message.Description, message.Deprecated)
+			if len(message.Fields) > 0 {
+				p("\n| Field | Type | Label | Number | JSON Name | Description |\n")
+				p("| ----- | ---- | ----- | ------ | --------- | ----------- |\n")
+				for _, field := range message.Fields {
+					p(
+						"| %s | %s | %s | %d | %s | %s |\n",
+						field.Name,
+						getLinkMarkdown(pkg.Name, field.TypeReference, escapeMarkdown(field.Type)),
+						field.Label,
+						field.Number,
+						field.JSONName,
+						getTableDescriptionMarkdown(field.Description, field.Deprecated),
+					)
+				}
+			}
+		}
+	}
+	if len(pkg.Enums) > 0 {
+		p("\n## Enums\n")
+		for _, enum := range pkg.Enums {
+			p("\n<a name=\"%s\"></a>\n\n### %s\n", enum.Name, enum.NestedName)
+			printDescriptionMarkdown(buffer, enum.Description, enum.Deprecated)
+			if len(enum.Values) > 0 {
+				p("\n| Name | Number | Description |\n")
+				p("| ---- | ------ | ----------- |\n")
+				for _, enumValue := range enum.Values {
+					p(
+						"| %s | %d | %s |\n",
+						enumValue.Name,
+						enumValue.Number,
+						getTableDescriptionMarkdown(enumValue.Description, enumValue.Deprecated),
+					)
+				}
+			}
+		}
+	}
+	_, err := writer.Write(buffer.Bytes())
+	return err
+}
+
+func printContentsMarkdown(buffer *bytes.Buffer, pkg *Package) {
+	if len(pkg.Services) == 0 && len(pkg.Messages) == 0 && len(pkg.Enums) == 0 {
+		return
+	}
+	_, _ = buffer.WriteString("\n## Contents\n\n")
+	printContentsSectionMarkdown(buffer, "Services", len(pkg.Services), func(i int) (string, string) {
+		return pkg.Services[i].Name, pkg.Services[i].NestedName
+	})
+	printContentsSectionMarkdown(buffer, "Messages", len(pkg.Messages), func(i int) (string, string) {
+		return pkg.Messages[i].Name, pkg.Messages[i].NestedName
+	})
+	printContentsSectionMarkdown(buffer, "Enums", len(pkg.Enums), func(i int) (string, string) {
+		return pkg.Enums[i].Name, pkg.Enums[i].NestedName
+	})
+}
+
+func printContentsSectionMarkdown(buffer *bytes.Buffer, title string, n int, get func(int) (string, string)) {
+	if n == 0 {
+		return
+	}
+	_, _ = fmt.Fprintf(buffer, "- %s\n", title)
+	for i := 0; i < n; i++ {
+		name, nestedName := get(i)
+		_, _ = fmt.Fprintf(buffer, "  - [%s](#%s)\n", nestedName, name)
+	}
+}
+
+func printDescriptionMarkdown(buffer *bytes.Buffer, description string, deprecated bool) {
+	if deprecated {
+		_, _ = buffer.WriteString("\n**Deprecated.**\n")
+	}
+	if description != "" {
+		_, _ = fmt.Fprintf(buffer, "\n%s\n", description)
+	}
+}
+
+func getMethodTypeMarkdown(pkg string, typeReference *TypeReference, streaming bool) string {
+	link := getLinkMarkdown(pkg, typeReference, typeReference.Name)
+	if streaming {
+		return "stream " + link
+	}
+	return link
+}
+
+func getHTTPRuleMarkdown(httpRule *HTTPRule) string {
+	s := "`" + httpRule.Method + " " + httpRule.Path + "`"
+	if httpRule.Body != "" {
+		s += " (body: `" + httpRule.Body + "`)"
+	}
+	if httpRule.ResponseBody != "" {
+		s += " (response body: `" + httpRule.ResponseBody + "`)"
+	}
+	return s
+}
+
+func getLinkMarkdown(pkg string, typeReference *TypeReference, text string) string {
+	href := getHref(pkg, typeReference, FormatMarkdown)
+	if href == "" {
+		return text
+	}
+	return "[" + text + "](" + href + ")"
+}
+
+// getTableDescriptionMarkdown returns the description for a table cell, which
+// must be on a single line.
+func getTableDescriptionMarkdown(description string, deprecated bool) string {
+	description = strings.Replace(description, "|", `\|`, -1)
+	description = strings.Replace(description, "\n", "<br>", -1)
+	if deprecated {
+		if description == "" {
+			return "**Deprecated.**"
+		}
+		return "**Deprecated.** " + description
+	}
+	return description
+}
+
+// escapeMarkdown escapes the characters of types that would otherwise be
+// interpreted as HTML.
+func escapeMarkdown(s string) string {
+	s = strings.Replace(s, "<", "&lt;", -1)
+	return strings.Replace(s, ">", "&gt;", -1)
+}
+
+func printPackageHTML(writer io.Writer, pkg *Package) error {
+	tmpl, err := template.New("package").Funcs(
+		template.FuncMap{
+			"href": func(typeReference *TypeReference) string {
+				return getHref(pkg.Name, typeReference, FormatHTML)
+			},
+			"paragraphs": func(description string) []string {
+				if description == "" {
+					return nil
+				}
+				return strings.Split(description, "\n\n")
+			},
+		},
+	).Parse(htmlTemplate)
+	if err != nil {
+		return err
+	}
+	return tmpl.Execute(
+		writer,
+		struct {
+			*Package
+			Title string
+		}{
+			Package: pkg,
+			Title:   getPackageTitle(pkg.Name),
+		},
+	)
+}
+
+// getHref returns the link to the type, or empty if the type is not documented.
+func getHref(pkg string, typeReference *TypeReference, format string) string {
+	if typeReference == nil || !typeReference.Documented {
+		return ""
+	}
+	if typeReference.Package == pkg {
+		return "#" + typeReference.Name
+	}
+	return GetPagePath(typeReference.Package, format) + "#" + typeReference.Name
+}
+
+func getPackageTitle(pkg string) string {
+	if pkg == "" {
+		return "default"
+	}
+	return pkg
+}
+
+const htmlTemplate = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: sans-serif; }
+table { border-collapse: collapse; }
+th, td { border: 1px solid #ccc; padding: 4px 8px; text-align: left; vertical-align: top; }
+p, td { white-space: pre-wrap; }
+.deprecated { color: #b00; font-weight: bold; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+{{- if .Files}}
+<p>Files:</p>
+<ul>
+{{- range .Files}}
+<li><code>{{.}}</code></li>
+{{- end}}
+</ul>
+{{- end}}
+{{- if .Services}}
+<h2>Services</h2>
+{{- range $service := .Services}}
+<h3 id="{{$service.Name}}">{{$service.NestedName}}</h3>
+{{- if $service.Deprecated}}
+<p class="deprecated">Deprecated.</p>
+{{- end}}
+{{- range paragraphs $service.Description}}
+<p>{{.}}</p>
+{{- end}}
+{{- range $service.Methods}}
+<h4 id="{{$service.Name}}.{{.Name}}">{{.Name}}</h4>
+<p><code>rpc {{.Name}}({{if .ClientStreaming}}stream {{end}}{{template "type" .Request}}) returns ({{if .ServerStreaming}}stream {{end}}{{template "type" .Response}})</code></p>
+{{- if .HTTPRules}}
+<ul>
+{{- range .HTTPRules}}
+<li><code>{{.Method}} {{.Path}}</code>{{if .Body}} (body: <code>{{.Body}}</code>){{end}}{{if .ResponseBody}} (response body: <code>{{.ResponseBody}}</code>){{end}}</li>
+{{- end}}
+</ul>
+{{- end}}
+{{- if .Deprecated}}
+<p class="deprecated">Deprecated.</p>
+{{- end}}
+{{- range paragraphs .Description}}
+<p>{{.}}</p>
+{{- end}}
+{{- end}}
+{{- end}}
+{{- end}}
+{{- if .Messages}}
+<h2>Messages</h2>
+{{- range .Messages}}
+<h3 id="{{.Name}}">{{.NestedName}}</h3>
+{{- if .Deprecated}}
+<p class="deprecated">Deprecated.</p>
+{{- end}}
+{{- range paragraphs .Description}}
+<p>{{.}}</p>
+{{- end}}
+{{- if .Fields}}
+<table>
+<tr><th>Field</th><th>Type</th><th>Label</th><th>Number</th><th>JSON Name</th><th>Description</th></tr>
+{{- range .Fields}}
+<tr><td>{{.Name}}</td><td>{{with href .TypeReference}}<a href="{{.}}">{{end}}{{.Type}}{{if href .TypeReference}}</a>{{end}}</td><td>{{.Label}}</td><td>{{.Number}}</td><td>{{.JSONName}}</td><td>{{if .Deprecated}}<span class="deprecated">Deprecated.</span> {{end}}{{.Description}}</td></tr>
+{{- end}}
+</table>
+{{- end}}
+{{- end}}
+{{- end}}
+{{- if .Enums}}
+<h2>Enums</h2>
+{{- range .Enums}}
+<h3 id="{{.Name}}">{{.NestedName}}</h3>
+{{- if .Deprecated}}
+<p class="deprecated">Deprecated.</p>
+{{- end}}
+{{- range paragraphs .Description}}
+<p>{{.}}</p>
+{{- end}}
+{{- if .Values}}
+<table>
+<tr><th>Name</th><th>Number</th><th>Description</th></tr>
+{{- range .Values}}
+<tr><td>{{.Name}}</td><td>{{.Number}}</td><td>{{if .Deprecated}}<span class="deprecated">Deprecated.</span> {{end}}{{.Description}}</td></tr>
+{{- end}}
+</table>
+{{- end}}
+{{- end}}
+{{- end}}
+</body>
+</html>
+{{define "type"}}{{with href .}}<a href="{{.}}">{{end}}{{.Name}}{{if href .}}</a>{{end}}{{end}}
+`
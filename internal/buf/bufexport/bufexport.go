@@ -0,0 +1,45 @@
+// Package bufexport exports the source files of Images.
+package bufexport
+
+import (
+	"context"
+
+	"github.com/bufbuild/buf/internal/buf/bufbuild"
+	"github.com/bufbuild/buf/internal/buf/bufpb"
+	"github.com/bufbuild/buf/internal/pkg/storage"
+	"go.uber.org/zap"
+)
+
+// Handler handles exports.
+type Handler interface {
+	// Export copies the original source files of the image from the bucket
+	// at from to the bucket at to.
+	//
+	// The image must have been built from the bucket at from with the build config,
+	// and should include imports so that the exported files are self-contained.
+	// Files are written to their paths relative to the roots, so that the bucket
+	// at to can be built with a single root of ".".
+	//
+	// Files of the image that are not within the bucket at from, such as the
+	// Well-Known Types, are skipped.
+	//
+	// Returns the number of files copied.
+	Export(
+		ctx context.Context,
+		from storage.ReadBucket,
+		buildConfig *bufbuild.Config,
+		image bufpb.Image,
+		to storage.Bucket,
+	) (int, error)
+}
+
+// NewHandler returns a new Handler.
+func NewHandler(
+	logger *zap.Logger,
+	buildProvider bufbuild.Provider,
+) Handler {
+	return newHandler(
+		logger,
+		buildProvider,
+	)
+}
@@ -0,0 +1,102 @@
+package bufexport_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/bufbuild/buf/internal/buf/bufbuild"
+	"github.com/bufbuild/buf/internal/buf/bufexport"
+	"github.com/bufbuild/buf/internal/pkg/bytepool"
+	"github.com/bufbuild/buf/internal/pkg/bytepool/bytepooltesting"
+	"github.com/bufbuild/buf/internal/pkg/storage/storagemem"
+	"github.com/bufbuild/buf/internal/pkg/storage/storageos"
+	"github.com/bufbuild/buf/internal/pkg/storage/storageutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestExportAll(t *testing.T) {
+	t.Parallel()
+	testExport(
+		t,
+		nil,
+		"acme/type/money.proto",
+		"acme/v1/customer.proto",
+		"acme/v1/item.proto",
+		"acme/v1/order.proto",
+	)
+}
+
+func TestExportSpecificFilePathsIncludesImports(t *testing.T) {
+	t.Parallel()
+	testExport(
+		t,
+		[]string{"proto/acme/v1/order.proto"},
+		"acme/type/money.proto",
+		"acme/v1/item.proto",
+		"acme/v1/order.proto",
+	)
+}
+
+func testExport(t *testing.T, specificRealFilePaths []string, expectedPaths ...string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	logger := zap.NewNop()
+	segList := bytepool.NewSegList()
+	defer bytepooltesting.AssertAllRecycled(t, segList)
+
+	from, err := storageos.NewReadBucket("testdata")
+	require.NoError(t, err)
+	config, err := bufbuild.ConfigBuilder{
+		Roots: []string{"proto", "vendor"},
+	}.NewConfig()
+	require.NoError(t, err)
+	image, _, annotations, err := bufbuild.NewHandler(
+		logger,
+		segList,
+		bufbuild.NewProvider(logger),
+		bufbuild.NewRunner(logger),
+	).BuildImage(
+		ctx,
+		from,
+		config,
+		specificRealFilePaths,
+		false,
+		true,
+		false,
+	)
+	require.NoError(t, err)
+	require.Empty(t, annotations)
+
+	to := storagemem.NewBucket(segList)
+	defer func() {
+		assert.NoError(t, to.Close())
+	}()
+	count, err := bufexport.NewHandler(
+		logger,
+		bufbuild.NewProvider(logger),
+	).Export(ctx, from, config, image, to)
+	require.NoError(t, err)
+	// google/protobuf/timestamp.proto is not within the bucket
+	assert.Equal(t, len(expectedPaths), count)
+	var paths []string
+	require.NoError(
+		t,
+		to.Walk(
+			ctx,
+			"",
+			func(path string) error {
+				paths = append(paths, path)
+				return nil
+			},
+		),
+	)
+	assert.ElementsMatch(t, expectedPaths, paths)
+	data, err := storageutil.ReadPath(ctx, to, "acme/type/money.proto")
+	require.NoError(t, err)
+	expectedData, err := storageutil.ReadPath(ctx, from, "vendor/acme/type/money.proto")
+	require.NoError(t, err)
+	assert.Equal(t, string(expectedData), string(data))
+}
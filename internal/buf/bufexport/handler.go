@@ -0,0 +1,122 @@
+package bufexport
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/bufbuild/buf/internal/buf/bufbuild"
+	"github.com/bufbuild/buf/internal/buf/bufpb"
+	"github.com/bufbuild/buf/internal/pkg/errs"
+	"github.com/bufbuild/buf/internal/pkg/logutil"
+	"github.com/bufbuild/buf/internal/pkg/storage"
+	"github.com/bufbuild/buf/internal/pkg/storage/storagepath"
+	"github.com/bufbuild/buf/internal/pkg/storage/storageutil"
+	"go.uber.org/zap"
+)
+
+type handler struct {
+	logger        *zap.Logger
+	buildProvider bufbuild.Provider
+}
+
+func newHandler(
+	logger *zap.Logger,
+	buildProvider bufbuild.Provider,
+) *handler {
+	return &handler{
+		logger:        logger.Named("bufexport"),
+		buildProvider: buildProvider,
+	}
+}
+
+func (h *handler) Export(
+	ctx context.Context,
+	from storage.ReadBucket,
+	buildConfig *bufbuild.Config,
+	image bufpb.Image,
+	to storage.Bucket,
+) (_ int, retErr error) {
+	defer logutil.DeferWithError(h.logger, "export", &retErr)()
+
+	// the image may have been built for specific files, in which case the
+	// resolver returned from the build does not know about the imports, so
+	// we resolve against all the files in the bucket instead
+	protoFileSet, err := h.buildProvider.GetProtoFileSetForBucket(ctx, from, buildConfig)
+	if err != nil {
+		return 0, err
+	}
+	rootToRootFilePaths, err := getRootToRootFilePaths(protoFileSet, image)
+	if err != nil {
+		return 0, err
+	}
+	roots := make([]string, 0, len(rootToRootFilePaths))
+	for root := range rootToRootFilePaths {
+		roots = append(roots, root)
+	}
+	sort.Strings(roots)
+	count := 0
+	for _, root := range roots {
+		rootFilePaths := rootToRootFilePaths[root]
+		var stripComponentCount uint32
+		if root != "." {
+			stripComponentCount = uint32(len(storagepath.Components(root)))
+		}
+		rootCount, err := storageutil.Copy(
+			ctx,
+			from,
+			to,
+			root,
+			storagepath.WithStripComponents(stripComponentCount),
+			storagepath.WithMatcher(
+				func(path string) bool {
+					_, ok := rootFilePaths[path]
+					return ok
+				},
+			),
+		)
+		count += rootCount
+		if err != nil {
+			return count, err
+		}
+		if rootCount != len(rootFilePaths) {
+			return count, errs.NewInternalf("expected to copy %d files from root %q but copied %d", len(rootFilePaths), root, rootCount)
+		}
+	}
+	return count, nil
+}
+
+// getRootToRootFilePaths groups the root file paths of the image by the root
+// they are within.
+//
+// Files that are not within the bucket are skipped.
+func getRootToRootFilePaths(
+	resolver bufbuild.ProtoFilePathResolver,
+	image bufpb.Image,
+) (map[string]map[string]struct{}, error) {
+	rootToRootFilePaths := make(map[string]map[string]struct{})
+	for _, file := range image.GetFile() {
+		rootFilePath := file.GetName()
+		realFilePath, err := resolver.GetFilePath(rootFilePath)
+		if err != nil {
+			if err == bufbuild.ErrFilePathUnknown {
+				continue
+			}
+			return nil, err
+		}
+		root := "."
+		if realFilePath != rootFilePath {
+			if !strings.HasSuffix(realFilePath, "/"+rootFilePath) {
+				return nil, errs.NewInternalf("real file path %q does not end with root file path %q", realFilePath, rootFilePath)
+			}
+			root = strings.TrimSuffix(realFilePath, "/"+rootFilePath)
+		}
+		rootFilePaths, ok := rootToRootFilePaths[root]
+		if !ok {
+			rootFilePaths = make(map[string]struct{})
+			rootToRootFilePaths[root] = rootFilePaths
+		}
+		rootFilePaths[rootFilePath] = struct{}{}
+	}
+	return rootToRootFilePaths, nil
+}
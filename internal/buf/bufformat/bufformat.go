@@ -0,0 +1,34 @@
+// Package bufformat formats Protobuf files.
+package bufformat
+
+import (
+	"context"
+
+	"github.com/bufbuild/buf/internal/buf/bufpb"
+	"github.com/jhump/protoreflect/desc"
+	"go.uber.org/zap"
+)
+
+// Formatter formats Protobuf files.
+type Formatter interface {
+	// FormatImage formats the non-import Files in the Image.
+	//
+	// Every File is printed in one canonical layout: declarations stay in the order
+	// they were defined in, but spacing, indentation, and the placement of options
+	// and comments are normalized. All comments from SourceCodeInfo are kept.
+	//
+	// The Image must include imports, and should include source code info, otherwise
+	// comments will be lost. If the Image was not built with buf, all Files are formatted.
+	//
+	// Returns a map from file name to formatted data.
+	FormatImage(ctx context.Context, image bufpb.Image) (map[string][]byte, error)
+	// FormatDescriptor formats a single descriptor in the same canonical layout.
+	//
+	// Leading comments of the descriptor are kept if the backing File has source code info.
+	FormatDescriptor(descriptor desc.Descriptor) (string, error)
+}
+
+// NewFormatter returns a new Formatter.
+func NewFormatter(logger *zap.Logger) Formatter {
+	return newFormatter(logger)
+}
@@ -0,0 +1,36 @@
+package bufformat_test
+
+import (
+	"context"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bufbuild/buf/internal/buf/bufformat"
+	"github.com/bufbuild/buf/internal/buf/buftesting"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestFormatImage(t *testing.T) {
+	t.Parallel()
+	data, err := ioutil.ReadFile(filepath.Join("testdata", "formatted", "acme", "v1", "ping.proto"))
+	require.NoError(t, err)
+	assert.Equal(t, string(data), testFormat(t, filepath.Join("testdata", "unformatted")))
+	// formatting is idempotent
+	assert.Equal(t, string(data), testFormat(t, filepath.Join("testdata", "formatted")))
+}
+
+func testFormat(t *testing.T, dirPath string) string {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	nameToData, err := bufformat.NewFormatter(zap.NewNop()).FormatImage(
+		ctx,
+		buftesting.BuildImage(t, dirPath, true, true),
+	)
+	require.NoError(t, err)
+	require.Len(t, nameToData, 1)
+	return string(nameToData["acme/v1/ping.proto"])
+}
@@ -0,0 +1,80 @@
+package bufformat
+
+import (
+	"bytes"
+	"context"
+
+	"github.com/bufbuild/buf/internal/buf/bufpb"
+	"github.com/bufbuild/buf/internal/pkg/errs"
+	"github.com/bufbuild/buf/internal/pkg/logutil"
+	"github.com/bufbuild/buf/internal/pkg/stringutil"
+	"github.com/jhump/protoreflect/desc"
+	"github.com/jhump/protoreflect/desc/protoprint"
+	"go.uber.org/zap"
+)
+
+type formatter struct {
+	logger  *zap.Logger
+	printer *protoprint.Printer
+}
+
+func newFormatter(logger *zap.Logger) *formatter {
+	return &formatter{
+		logger: logger.Named("format"),
+		printer: &protoprint.Printer{
+			Indent: "  ",
+			// blank lines are added back by fixSpacing
+			Compact: true,
+			// declarations stay in the order they were defined in
+			SortElements: false,
+		},
+	}
+}
+
+func (f *formatter) FormatImage(ctx context.Context, image bufpb.Image) (_ map[string][]byte, retErr error) {
+	defer logutil.DeferWithError(f.logger, "format_image", &retErr)()
+
+	importNames, err := image.ImportNames()
+	if err != nil {
+		return nil, err
+	}
+	importNameMap := stringutil.SliceToMap(importNames)
+	descFileDescriptors, err := bufpb.ImageToDescFileDescriptors(image)
+	if err != nil {
+		return nil, err
+	}
+	nameToData := make(map[string][]byte)
+	for _, file := range image.GetFile() {
+		select {
+		case <-ctx.Done():
+			err := ctx.Err()
+			if err == context.DeadlineExceeded {
+				return nil, errs.NewDeadlineExceeded(err.Error())
+			}
+			return nil, err
+		default:
+		}
+		name := file.GetName()
+		if _, isImport := importNameMap[name]; isImport {
+			continue
+		}
+		descFileDescriptor, ok := descFileDescriptors[name]
+		if !ok {
+			return nil, errs.NewInternalf("no FileDescriptor for %q", name)
+		}
+		buffer := bytes.NewBuffer(nil)
+		if err := f.printer.PrintProtoFile(descFileDescriptor, buffer); err != nil {
+			return nil, err
+		}
+		nameToData[name] = fixSpacing(buffer.Bytes())
+	}
+	return nameToData, nil
+}
+
+func (f *formatter) FormatDescriptor(descriptor desc.Descriptor) (string, error) {
+	s, err := f.printer.PrintProtoToString(descriptor)
+	if err != nil {
+		return "", err
+	}
+	return string(fixSpacing([]byte(s))), nil
+}
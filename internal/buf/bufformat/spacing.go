@@ -0,0 +1,158 @@
+package bufformat
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+)
+
+// protoprint always prints spaces within the parentheses of method signatures
+var methodSignatureRegexp = regexp.MustCompile(`^(\s*rpc \S+) \( (.+?) \) returns \( (.+?) \)`)
+
+// blockKeywords are the keywords of the declarations whose bodies contain other declarations.
+var blockKeywords = []string{
+	"message ",
+	"enum ",
+	"service ",
+	"oneof ",
+	"extend ",
+	"rpc ",
+}
+
+// declaration is a declaration with its leading comments.
+type declaration struct {
+	commentLines []string
+	lines        []string
+}
+
+// fixSpacing normalizes the spacing of the compact output of protoprint.
+//
+// Method signatures are printed as rpc Foo(Bar) returns (Baz). Blank lines are
+// added between top-level declarations, except between consecutive imports and
+// consecutive options. Within bodies, blank lines are added around declarations
+// that have comments or span multiple lines.
+func fixSpacing(data []byte) []byte {
+	lines := strings.Split(strings.TrimRight(string(data), "\n"), "\n")
+	for i, line := range lines {
+		lines[i] = methodSignatureRegexp.ReplaceAllString(line, "$1($2) returns ($3)")
+	}
+	buffer := bytes.NewBuffer(nil)
+	for _, line := range fixBodySpacing(lines, true) {
+		_, _ = buffer.WriteString(line)
+		_, _ = buffer.WriteString("\n")
+	}
+	return buffer.Bytes()
+}
+
+func fixBodySpacing(lines []string, topLevel bool) []string {
+	var result []string
+	var previous *declaration
+	for _, current := range getDeclarations(lines) {
+		if previous != nil && needsBlankLine(previous, current, topLevel) {
+			result = append(result, "")
+		}
+		result = append(result, current.commentLines...)
+		if len(current.lines) > 2 && hasBlockKeyword(current.lines[0]) {
+			result = append(result, current.lines[0])
+			result = append(result, fixBodySpacing(current.lines[1:len(current.lines)-1], false)...)
+			result = append(result, current.lines[len(current.lines)-1])
+		} else {
+			result = append(result, current.lines...)
+		}
+		previous = current
+	}
+	return result
+}
+
+// getDeclarations splits the lines of a body into declarations.
+//
+// A declaration ends on the line where all braces and brackets opened by the declaration are closed.
+func getDeclarations(lines []string) []*declaration {
+	var declarations []*declaration
+	current := &declaration{}
+	depth := 0
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if depth == 0 && len(current.lines) == 0 && (trimmed == "" || strings.HasPrefix(trimmed, "//")) {
+			if trimmed != "" {
+				current.commentLines = append(current.commentLines, line)
+			}
+			continue
+		}
+		current.lines = append(current.lines, line)
+		depth += getDepthDelta(line)
+		if depth <= 0 {
+			declarations = append(declarations, current)
+			current = &declaration{}
+			depth = 0
+		}
+	}
+	if len(current.commentLines) > 0 || len(current.lines) > 0 {
+		declarations = append(declarations, current)
+	}
+	return declarations
+}
+
+func needsBlankLine(previous *declaration, current *declaration, topLevel bool) bool {
+	if topLevel {
+		if len(current.commentLines) > 0 {
+			return true
+		}
+		for _, keyword := range []string{"import ", "option "} {
+			if hasKeyword(previous, keyword) && hasKeyword(current, keyword) {
+				return false
+			}
+		}
+		return true
+	}
+	return len(previous.commentLines) > 0 ||
+		len(current.commentLines) > 0 ||
+		len(previous.lines) > 1 ||
+		len(current.lines) > 1
+}
+
+func hasKeyword(d *declaration, keyword string) bool {
+	return len(d.lines) > 0 && strings.HasPrefix(strings.TrimSpace(d.lines[0]), keyword)
+}
+
+func hasBlockKeyword(line string) bool {
+	trimmed := strings.TrimSpace(line)
+	for _, keyword := range blockKeywords {
+		if strings.HasPrefix(trimmed, keyword) {
+			return true
+		}
+	}
+	return false
+}
+
+// getDepthDelta returns the number of braces and brackets opened minus the number
+// closed on the line, ignoring string literals and comments.
+func getDepthDelta(line string) int {
+	delta := 0
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		if quote != 0 {
+			switch c {
+			case '\\':
+				i++
+			case quote:
+				quote = 0
+			}
+			continue
+		}
+		switch c {
+		case '"', '\'':
+			quote = c
+		case '{', '[':
+			delta++
+		case '}', ']':
+			delta--
+		case '/':
+			if i+1 < len(line) && line[i+1] == '/' {
+				return delta
+			}
+		}
+	}
+	return delta
+}
@@ -0,0 +1,81 @@
+// Package bufgen runs protoc plugins against Images.
+package bufgen
+
+import (
+	"context"
+
+	"github.com/bufbuild/buf/internal/buf/bufpb"
+	"github.com/bufbuild/buf/internal/pkg/analysis"
+	"github.com/bufbuild/buf/internal/pkg/storage"
+	"go.uber.org/zap"
+)
+
+// Handler handles the main generation functionality.
+type Handler interface {
+	// Generate runs the plugins in the config against the image and writes
+	// the generated files to the bucket.
+	//
+	// The files to generate are all the files in the image that are not imports.
+	// Plugins are run in parallel, but responses, including insertion points,
+	// are applied in the order the plugins were specified in the config.
+	//
+	// Plugin errors are returned as annotations. If annotations or an error are
+	// returned, no files are written.
+	Generate(
+		ctx context.Context,
+		bucket storage.Bucket,
+		image bufpb.Image,
+		config *Config,
+	) ([]*analysis.Annotation, error)
+}
+
+// NewHandler returns a new Handler.
+func NewHandler(logger *zap.Logger) Handler {
+	return newHandler(logger)
+}
+
+// Config is the generation config.
+type Config struct {
+	// PluginConfigs are the plugins to run.
+	//
+	// These are in the order they were specified.
+	PluginConfigs []*PluginConfig
+}
+
+// PluginConfig is the config for a single plugin.
+type PluginConfig struct {
+	// Name is the name of the plugin, ie "go" for protoc-gen-go.
+	//
+	// This will never be empty.
+	Name string
+	// Path is the path to the plugin binary.
+	//
+	// If empty, protoc-gen-Name is searched for on the PATH.
+	Path string
+	// Out is the output directory within the bucket.
+	//
+	// This will be relative, normalized, and validated.
+	Out string
+	// Opt is the parameter to pass to the plugin.
+	//
+	// This may be empty.
+	Opt string
+}
+
+// ConfigBuilder is a config builder.
+type ConfigBuilder struct {
+	Plugins []PluginConfigBuilder
+}
+
+// NewConfig returns a new Config.
+func (b ConfigBuilder) NewConfig() (*Config, error) {
+	return newConfig(b)
+}
+
+// PluginConfigBuilder is a plugin config builder.
+type PluginConfigBuilder struct {
+	Name string
+	Path string
+	Out  string
+	Opt  string
+}
@@ -0,0 +1,40 @@
+package bufgen
+
+import (
+	"github.com/bufbuild/buf/internal/pkg/errs"
+	"github.com/bufbuild/buf/internal/pkg/storage/storagepath"
+)
+
+func newConfig(configBuilder ConfigBuilder) (*Config, error) {
+	pluginConfigs := make([]*PluginConfig, 0, len(configBuilder.Plugins))
+	for _, pluginConfigBuilder := range configBuilder.Plugins {
+		pluginConfig, err := newPluginConfig(pluginConfigBuilder)
+		if err != nil {
+			return nil, err
+		}
+		pluginConfigs = append(pluginConfigs, pluginConfig)
+	}
+	return &Config{
+		PluginConfigs: pluginConfigs,
+	}, nil
+}
+
+func newPluginConfig(pluginConfigBuilder PluginConfigBuilder) (*PluginConfig, error) {
+	if pluginConfigBuilder.Name == "" {
+		return nil, errs.NewInvalidArgument("plugin name is empty")
+	}
+	if pluginConfigBuilder.Out == "" {
+		return nil, errs.NewInvalidArgumentf("plugin %s has no out directory", pluginConfigBuilder.Name)
+	}
+	out, err := storagepath.NormalizeAndValidate(pluginConfigBuilder.Out)
+	if err != nil {
+		// user error
+		return nil, err
+	}
+	return &PluginConfig{
+		Name: pluginConfigBuilder.Name,
+		Path: pluginConfigBuilder.Path,
+		Out:  out,
+		Opt:  pluginConfigBuilder.Opt,
+	}, nil
+}
@@ -0,0 +1,303 @@
+package bufgen
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/bufbuild/buf/internal/buf/bufpb"
+	"github.com/bufbuild/buf/internal/pkg/analysis"
+	"github.com/bufbuild/buf/internal/pkg/errs"
+	"github.com/bufbuild/buf/internal/pkg/logutil"
+	"github.com/bufbuild/buf/internal/pkg/storage"
+	"github.com/bufbuild/buf/internal/pkg/storage/storagepath"
+	"github.com/bufbuild/buf/internal/pkg/storage/storageutil"
+	"github.com/bufbuild/buf/internal/pkg/stringutil"
+	"github.com/golang/protobuf/proto"
+	plugin_go "github.com/golang/protobuf/protoc-gen-go/plugin"
+	"go.uber.org/zap"
+)
+
+const pluginErrorType = "PLUGIN_ERROR"
+
+type handler struct {
+	logger *zap.Logger
+	// runPlugin is a field so that it can be replaced for testing.
+	runPlugin func(context.Context, *PluginConfig, *plugin_go.CodeGeneratorRequest) (*plugin_go.CodeGeneratorResponse, *analysis.Annotation, error)
+}
+
+func newHandler(logger *zap.Logger) *handler {
+	return &handler{
+		logger:    logger.Named("gen"),
+		runPlugin: runPlugin,
+	}
+}
+
+func (h *handler) Generate(
+	ctx context.Context,
+	bucket storage.Bucket,
+	image bufpb.Image,
+	config *Config,
+) (_ []*analysis.Annotation, retErr error) {
+	defer logutil.DeferWithError(h.logger, "generate", &retErr)()
+
+	if len(config.PluginConfigs) == 0 {
+		return nil, errs.NewInvalidArgument("no plugins specified in the generate config")
+	}
+	fileToGenerate, err := getFileToGenerate(image)
+	if err != nil {
+		return nil, err
+	}
+	responses, annotations, err := h.runPlugins(ctx, image, config.PluginConfigs, fileToGenerate)
+	if err != nil {
+		return nil, err
+	}
+	if len(annotations) > 0 {
+		analysis.SortAnnotations(annotations)
+		return annotations, nil
+	}
+	pathToData, paths, err := applyResponses(config.PluginConfigs, responses)
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range paths {
+		if err := storageutil.WritePath(ctx, bucket, path, pathToData[path]); err != nil {
+			return nil, err
+		}
+	}
+	return nil, nil
+}
+
+type pluginResult struct {
+	response   *plugin_go.CodeGeneratorResponse
+	annotation *analysis.Annotation
+	err        error
+}
+
+// runPlugins runs all plugins in parallel.
+//
+// The returned responses are in the same order as pluginConfigs.
+func (h *handler) runPlugins(
+	ctx context.Context,
+	image bufpb.Image,
+	pluginConfigs []*PluginConfig,
+	fileToGenerate []string,
+) ([]*plugin_go.CodeGeneratorResponse, []*analysis.Annotation, error) {
+	resultCs := make([]chan *pluginResult, len(pluginConfigs))
+	for i, pluginConfig := range pluginConfigs {
+		pluginConfig := pluginConfig
+		resultC := make(chan *pluginResult, 1)
+		resultCs[i] = resultC
+		go func() {
+			request, err := image.ToCodeGeneratorRequest(pluginConfig.Opt, fileToGenerate...)
+			if err != nil {
+				resultC <- &pluginResult{err: err}
+				return
+			}
+			response, annotation, err := h.runPlugin(ctx, pluginConfig, request)
+			resultC <- &pluginResult{
+				response:   response,
+				annotation: annotation,
+				err:        err,
+			}
+		}()
+	}
+	responses := make([]*plugin_go.CodeGeneratorResponse, len(pluginConfigs))
+	var annotations []*analysis.Annotation
+	var retErr error
+	for i, resultC := range resultCs {
+		result := <-resultC
+		retErr = errs.Append(retErr, result.err)
+		if result.annotation != nil {
+			annotations = append(annotations, result.annotation)
+		}
+		responses[i] = result.response
+	}
+	if retErr != nil {
+		return nil, nil, retErr
+	}
+	return responses, annotations, nil
+}
+
+// runPlugin runs the plugin binary over stdin and stdout.
+//
+// If the plugin fails or returns an error in its response, an annotation is returned.
+func runPlugin(
+	ctx context.Context,
+	pluginConfig *PluginConfig,
+	request *plugin_go.CodeGeneratorRequest,
+) (*plugin_go.CodeGeneratorResponse, *analysis.Annotation, error) {
+	pluginPath := pluginConfig.Path
+	if pluginPath == "" {
+		pluginPath = "protoc-gen-" + pluginConfig.Name
+	}
+	pluginPath, err := exec.LookPath(pluginPath)
+	if err != nil {
+		return nil, nil, errs.NewInvalidArgumentf("could not find plugin %s: %v", pluginConfig.Name, err)
+	}
+	requestData, err := proto.Marshal(request)
+	if err != nil {
+		return nil, nil, err
+	}
+	stdout := bytes.NewBuffer(nil)
+	stderr := bytes.NewBuffer(nil)
+	cmd := exec.CommandContext(ctx, pluginPath)
+	cmd.Stdin = bytes.NewReader(requestData)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	if err := cmd.Run(); err != nil {
+		if _, ok := err.(*exec.ExitError); !ok {
+			return nil, nil, err
+		}
+		message := strings.TrimSpace(stderr.String())
+		if message == "" {
+			message = err.Error()
+		}
+		return nil, newPluginAnnotation(pluginConfig, message), nil
+	}
+	response := &plugin_go.CodeGeneratorResponse{}
+	if err := proto.Unmarshal(stdout.Bytes(), response); err != nil {
+		return nil, nil, errs.NewInvalidArgumentf("plugin %s returned an invalid CodeGeneratorResponse: %v", pluginConfig.Name, err)
+	}
+	if response.Error != nil {
+		return nil, newPluginAnnotation(pluginConfig, response.GetError()), nil
+	}
+	return response, nil, nil
+}
+
+// applyResponses applies the responses in order.
+//
+// Returns the generated data by path within the bucket, and the paths in the order
+// they were first generated.
+func applyResponses(
+	pluginConfigs []*PluginConfig,
+	responses []*plugin_go.CodeGeneratorResponse,
+) (map[string][]byte, []string, error) {
+	pathToData := make(map[string][]byte)
+	var paths []string
+	for i, response := range responses {
+		pluginConfig := pluginConfigs[i]
+		files, err := mergeContinuations(pluginConfig, response.GetFile())
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, file := range files {
+			name, err := storagepath.NormalizeAndValidate(file.GetName())
+			if err != nil {
+				return nil, nil, errs.NewInvalidArgumentf("plugin %s returned an invalid file name: %v", pluginConfig.Name, err)
+			}
+			path := storagepath.Join(pluginConfig.Out, name)
+			if insertionPoint := file.GetInsertionPoint(); insertionPoint != "" {
+				data, ok := pathToData[path]
+				if !ok {
+					return nil, nil, errs.NewInvalidArgumentf("plugin %s tried to insert into file %s which was not generated", pluginConfig.Name, path)
+				}
+				data, err := applyInsertionPoint(data, insertionPoint, file.GetContent())
+				if err != nil {
+					return nil, nil, errs.NewInvalidArgumentf("plugin %s: %s: %v", pluginConfig.Name, path, err)
+				}
+				pathToData[path] = data
+				continue
+			}
+			if _, ok := pathToData[path]; ok {
+				return nil, nil, errs.NewInvalidArgumentf("plugin %s generated file %s which was already generated", pluginConfig.Name, path)
+			}
+			pathToData[path] = []byte(file.GetContent())
+			paths = append(paths, path)
+		}
+	}
+	return pathToData, paths, nil
+}
+
+// mergeContinuations merges files with no name into the previous file.
+//
+// Per the plugin protocol, a file with no name is a continuation of the previous
+// file, including of its insertion point, so the content must be merged before
+// any insertion point is applied.
+func mergeContinuations(
+	pluginConfig *PluginConfig,
+	files []*plugin_go.CodeGeneratorResponse_File,
+) ([]*plugin_go.CodeGeneratorResponse_File, error) {
+	merged := make([]*plugin_go.CodeGeneratorResponse_File, 0, len(files))
+	for _, file := range files {
+		if file.GetName() != "" {
+			merged = append(
+				merged,
+				&plugin_go.CodeGeneratorResponse_File{
+					Name:           file.Name,
+					InsertionPoint: file.InsertionPoint,
+					Content:        proto.String(file.GetContent()),
+				},
+			)
+			continue
+		}
+		if len(merged) == 0 {
+			return nil, errs.NewInvalidArgumentf("plugin %s returned a file with no name as its first file", pluginConfig.Name)
+		}
+		previous := merged[len(merged)-1]
+		previous.Content = proto.String(previous.GetContent() + file.GetContent())
+	}
+	return merged, nil
+}
+
+// applyInsertionPoint inserts content directly before the line containing
+// the @@protoc_insertion_point(insertionPoint) marker.
+//
+// Each inserted line is indented with the indentation of the marker line,
+// which matches the behavior of protoc.
+func applyInsertionPoint(data []byte, insertionPoint string, content string) ([]byte, error) {
+	marker := []byte(fmt.Sprintf("@@protoc_insertion_point(%s)", insertionPoint))
+	markerIndex := bytes.Index(data, marker)
+	if markerIndex < 0 {
+		return nil, fmt.Errorf("insertion point %q not found", insertionPoint)
+	}
+	lineStart := bytes.LastIndexByte(data[:markerIndex], '\n') + 1
+	indentEnd := lineStart
+	for indentEnd < markerIndex && (data[indentEnd] == ' ' || data[indentEnd] == '\t') {
+		indentEnd++
+	}
+	indent := data[lineStart:indentEnd]
+	buffer := bytes.NewBuffer(nil)
+	_, _ = buffer.Write(data[:lineStart])
+	if content != "" {
+		lines := strings.SplitAfter(content, "\n")
+		for _, line := range lines {
+			if line == "" {
+				continue
+			}
+			if line != "\n" {
+				_, _ = buffer.Write(indent)
+			}
+			_, _ = buffer.WriteString(line)
+		}
+		if !strings.HasSuffix(content, "\n") {
+			_, _ = buffer.WriteRune('\n')
+		}
+	}
+	_, _ = buffer.Write(data[lineStart:])
+	return buffer.Bytes(), nil
+}
+
+func getFileToGenerate(image bufpb.Image) ([]string, error) {
+	importNames, err := image.ImportNames()
+	if err != nil {
+		return nil, err
+	}
+	importNameMap := stringutil.SliceToMap(importNames)
+	var fileToGenerate []string
+	for _, file := range image.GetFile() {
+		if _, isImport := importNameMap[file.GetName()]; !isImport {
+			fileToGenerate = append(fileToGenerate, file.GetName())
+		}
+	}
+	return fileToGenerate, nil
+}
+
+func newPluginAnnotation(pluginConfig *PluginConfig, message string) *analysis.Annotation {
+	return &analysis.Annotation{
+		Type:    pluginErrorType,
+		Message: fmt.Sprintf("%s: %s", pluginConfig.Name, message),
+	}
+}
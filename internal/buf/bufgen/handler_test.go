@@ -0,0 +1,238 @@
+package bufgen
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bufbuild/buf/internal/buf/bufpb"
+	imagev1beta1 "github.com/bufbuild/buf/internal/gen/proto/bufbuild/buf/image/v1beta1"
+	"github.com/bufbuild/buf/internal/pkg/analysis"
+	"github.com/bufbuild/buf/internal/pkg/bytepool"
+	"github.com/bufbuild/buf/internal/pkg/storage"
+	"github.com/bufbuild/buf/internal/pkg/storage/storagemem"
+	"github.com/bufbuild/buf/internal/pkg/storage/storageutil"
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/protoc-gen-go/descriptor"
+	plugin_go "github.com/golang/protobuf/protoc-gen-go/plugin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestGenerate(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	config, err := ConfigBuilder{
+		Plugins: []PluginConfigBuilder{
+			{
+				Name: "one",
+				Out:  "gen",
+			},
+			{
+				Name: "two",
+				Out:  "gen",
+				Opt:  "foo=bar",
+			},
+		},
+	}.NewConfig()
+	require.NoError(t, err)
+	handler := newHandler(zap.NewNop())
+	handler.runPlugin = func(
+		ctx context.Context,
+		pluginConfig *PluginConfig,
+		request *plugin_go.CodeGeneratorRequest,
+	) (*plugin_go.CodeGeneratorResponse, *analysis.Annotation, error) {
+		// imports are not generated
+		assert.Equal(t, []string{"a.proto"}, request.GetFileToGenerate())
+		switch pluginConfig.Name {
+		case "one":
+			assert.Equal(t, "", request.GetParameter())
+			return &plugin_go.CodeGeneratorResponse{
+				File: []*plugin_go.CodeGeneratorResponse_File{
+					{
+						Name:    proto.String("a.txt"),
+						Content: proto.String("one\n  // @@protoc_insertion_point(foo)\n"),
+					},
+					{
+						Content: proto.String("three\n"),
+					},
+				},
+			}, nil, nil
+		case "two":
+			assert.Equal(t, "foo=bar", request.GetParameter())
+			return &plugin_go.CodeGeneratorResponse{
+				File: []*plugin_go.CodeGeneratorResponse_File{
+					{
+						Name:           proto.String("a.txt"),
+						InsertionPoint: proto.String("foo"),
+						Content:        proto.String("two\n"),
+					},
+				},
+			}, nil, nil
+		default:
+			return nil, nil, nil
+		}
+	}
+	segList := bytepool.NewSegList()
+	bucket := storagemem.NewBucket(segList)
+	annotations, err := handler.Generate(ctx, bucket, testNewImage(t), config)
+	require.NoError(t, err)
+	assert.Empty(t, annotations)
+	data, err := storageutil.ReadPath(ctx, bucket, "gen/a.txt")
+	require.NoError(t, err)
+	assert.Equal(t, "one\n  two\n  // @@protoc_insertion_point(foo)\nthree\n", string(data))
+}
+
+func TestGenerateAnnotations(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	config, err := ConfigBuilder{
+		Plugins: []PluginConfigBuilder{
+			{
+				Name: "one",
+				Out:  "gen",
+			},
+		},
+	}.NewConfig()
+	require.NoError(t, err)
+	handler := newHandler(zap.NewNop())
+	handler.runPlugin = func(
+		ctx context.Context,
+		pluginConfig *PluginConfig,
+		request *plugin_go.CodeGeneratorRequest,
+	) (*plugin_go.CodeGeneratorResponse, *analysis.Annotation, error) {
+		return nil, newPluginAnnotation(pluginConfig, "failure"), nil
+	}
+	segList := bytepool.NewSegList()
+	bucket := storagemem.NewBucket(segList)
+	annotations, err := handler.Generate(ctx, bucket, testNewImage(t), config)
+	require.NoError(t, err)
+	assert.Equal(
+		t,
+		[]*analysis.Annotation{
+			{
+				Type:    pluginErrorType,
+				Message: "one: failure",
+			},
+		},
+		annotations,
+	)
+	_, err = bucket.Stat(ctx, "gen/a.txt")
+	assert.True(t, storage.IsNotExist(err))
+}
+
+func TestApplyResponsesChunkedInsertion(t *testing.T) {
+	t.Parallel()
+	pluginConfigs := []*PluginConfig{
+		{
+			Name: "one",
+			Out:  "gen",
+		},
+		{
+			Name: "two",
+			Out:  "gen",
+		},
+	}
+	pathToData, paths, err := applyResponses(
+		pluginConfigs,
+		[]*plugin_go.CodeGeneratorResponse{
+			{
+				File: []*plugin_go.CodeGeneratorResponse_File{
+					{
+						Name:    proto.String("a.txt"),
+						Content: proto.String("one\n  // @@protoc_insertion_point(foo)\nfour\n"),
+					},
+				},
+			},
+			{
+				File: []*plugin_go.CodeGeneratorResponse_File{
+					{
+						Name:           proto.String("a.txt"),
+						InsertionPoint: proto.String("foo"),
+						Content:        proto.String("two\n"),
+					},
+					{
+						Content: proto.String("three\n"),
+					},
+				},
+			},
+		},
+	)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"gen/a.txt"}, paths)
+	assert.Equal(t, "one\n  two\n  three\n  // @@protoc_insertion_point(foo)\nfour\n", string(pathToData["gen/a.txt"]))
+
+	_, _, err = applyResponses(
+		pluginConfigs[:1],
+		[]*plugin_go.CodeGeneratorResponse{
+			{
+				File: []*plugin_go.CodeGeneratorResponse_File{
+					{
+						Content: proto.String("one\n"),
+					},
+				},
+			},
+		},
+	)
+	assert.Error(t, err)
+}
+
+func TestApplyInsertionPointNotFound(t *testing.T) {
+	t.Parallel()
+	_, err := applyInsertionPoint([]byte("foo\n"), "bar", "baz\n")
+	assert.Error(t, err)
+}
+
+func TestNewConfigErrors(t *testing.T) {
+	t.Parallel()
+	_, err := ConfigBuilder{
+		Plugins: []PluginConfigBuilder{
+			{
+				Out: "gen",
+			},
+		},
+	}.NewConfig()
+	assert.Error(t, err)
+	_, err = ConfigBuilder{
+		Plugins: []PluginConfigBuilder{
+			{
+				Name: "go",
+			},
+		},
+	}.NewConfig()
+	assert.Error(t, err)
+	_, err = ConfigBuilder{
+		Plugins: []PluginConfigBuilder{
+			{
+				Name: "go",
+				Out:  "../gen",
+			},
+		},
+	}.NewConfig()
+	assert.Error(t, err)
+}
+
+func testNewImage(t *testing.T) bufpb.Image {
+	image, err := bufpb.NewImage(
+		&imagev1beta1.Image{
+			File: []*descriptor.FileDescriptorProto{
+				{
+					Name: proto.String("b.proto"),
+				},
+				{
+					Name:       proto.String("a.proto"),
+					Dependency: []string{"b.proto"},
+				},
+			},
+			BufbuildImageExtension: &imagev1beta1.ImageExtension{
+				ImageImportRefs: []*imagev1beta1.ImageImportRef{
+					{
+						FileIndex: proto.Uint32(0),
+					},
+				},
+			},
+		},
+	)
+	require.NoError(t, err)
+	return image
+}
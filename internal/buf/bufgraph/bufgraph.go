@@ -0,0 +1,151 @@
+// Package bufgraph builds file and package import graphs of Images.
+package bufgraph
+
+import (
+	"context"
+	"io"
+
+	"github.com/bufbuild/buf/internal/buf/bufpb"
+	"github.com/bufbuild/buf/internal/pkg/analysis"
+	"github.com/bufbuild/buf/internal/pkg/errs"
+	"go.uber.org/zap"
+)
+
+const (
+	// ViewFile is the view where nodes are files.
+	ViewFile = "file"
+	// ViewPackage is the view where nodes are packages.
+	ViewPackage = "package"
+
+	// FormatDOT is the Graphviz DOT format.
+	FormatDOT = "dot"
+	// FormatMermaid is the Mermaid flowchart format.
+	FormatMermaid = "mermaid"
+	// FormatJSON is the JSON format.
+	FormatJSON = "json"
+
+	// AnnotationTypePackageImportCycle is the annotation type for imports that
+	// are part of a package import cycle.
+	AnnotationTypePackageImportCycle = "PACKAGE_IMPORT_CYCLE"
+	// AnnotationTypePackageDependencyNotAllowed is the annotation type for imports
+	// that are not allowed by the Config.
+	AnnotationTypePackageDependencyNotAllowed = "PACKAGE_DEPENDENCY_NOT_ALLOWED"
+)
+
+var (
+	// AllViews are all views.
+	AllViews = []string{
+		ViewFile,
+		ViewPackage,
+	}
+	// AllFormats are all formats.
+	AllFormats = []string{
+		FormatDOT,
+		FormatMermaid,
+		FormatJSON,
+	}
+)
+
+// Graph is an import graph.
+type Graph struct {
+	// Nodes are the file paths or packages, sorted.
+	Nodes []string `json:"nodes,omitempty" yaml:"nodes,omitempty"`
+	// Edges are the imports between nodes, sorted by from and then to.
+	//
+	// There is at most one edge for every from and to.
+	Edges []*Edge `json:"edges,omitempty" yaml:"edges,omitempty"`
+	// Cycles are the package import cycles.
+	//
+	// Each cycle is the sorted packages that import each other, directly or
+	// indirectly. Cycles are sorted by their first package. This is set for both views.
+	Cycles [][]string `json:"cycles,omitempty" yaml:"cycles,omitempty"`
+}
+
+// Edge is an import between two nodes.
+type Edge struct {
+	// From is the importing node.
+	From string `json:"from,omitempty" yaml:"from,omitempty"`
+	// To is the imported node.
+	To string `json:"to,omitempty" yaml:"to,omitempty"`
+	// Cycle is true if the edge is part of a package import cycle.
+	Cycle bool `json:"cycle,omitempty" yaml:"cycle,omitempty"`
+	// NotAllowed is true if the edge is a package dependency that is not
+	// allowed by the Config.
+	NotAllowed bool `json:"not_allowed,omitempty" yaml:"not_allowed,omitempty"`
+}
+
+// Handler handles graphs.
+type Handler interface {
+	// Graph returns the import graph of the image for the given view.
+	//
+	// The Image should include imports, otherwise the packages of imported
+	// files are not known, and imports of these files will not be checked.
+	// Files without a package are not part of the package view and are never checked.
+	//
+	// Package import cycles and package dependencies not allowed by the config
+	// are returned as annotations on the import statements of files that are not
+	// imports. Filenames will be relative to the roots, use bufbuild.FixAnnotationFilenames
+	// to make them into real file paths.
+	Graph(
+		ctx context.Context,
+		config *Config,
+		image bufpb.Image,
+		view string,
+	) (*Graph, []*analysis.Annotation, error)
+}
+
+// NewHandler returns a new Handler.
+func NewHandler(logger *zap.Logger) Handler {
+	return newHandler(logger)
+}
+
+// Config is the graph config.
+type Config struct {
+	// AllowedDependencies maps packages to the packages they are allowed to import.
+	//
+	// If a package is a key, files in the package may only import files in the same
+	// package, or in the packages of the value and their sub-packages, ie the
+	// value foo allows both foo and foo.bar. If a package is not a key, it may import
+	// any package.
+	//
+	// The values will be sorted and unique.
+	AllowedDependencies map[string][]string
+}
+
+// ConfigBuilder is a config builder.
+type ConfigBuilder struct {
+	AllowedDependencies map[string][]string
+}
+
+// NewConfig returns a new Config.
+func (b ConfigBuilder) NewConfig() (*Config, error) {
+	return newConfig(b)
+}
+
+// ValidateView returns a user error if the view is not in AllViews.
+func ValidateView(view string) error {
+	switch view {
+	case ViewFile, ViewPackage:
+		return nil
+	default:
+		return errs.NewInvalidArgumentf("unknown view: %q", view)
+	}
+}
+
+// ValidateFormat returns a user error if the format is not in AllFormats.
+func ValidateFormat(format string) error {
+	switch format {
+	case FormatDOT, FormatMermaid, FormatJSON:
+		return nil
+	default:
+		return errs.NewInvalidArgumentf("unknown format: %q", format)
+	}
+}
+
+// PrintGraph prints the graph to the writer in the given format.
+//
+// Edges that are part of a package import cycle or that are not allowed are highlighted.
+// The JSON format is the Graph on a single line.
+func PrintGraph(writer io.Writer, graph *Graph, format string) error {
+	return printGraph(writer, graph, format)
+}
@@ -0,0 +1,142 @@
+package bufgraph_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/bufbuild/buf/internal/buf/bufgraph"
+	"github.com/bufbuild/buf/internal/buf/buftesting"
+	"github.com/bufbuild/buf/internal/pkg/analysis"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestGraphPackage(t *testing.T) {
+	t.Parallel()
+	graph, annotations := testGraph(t, bufgraph.ViewPackage)
+	assert.Equal(
+		t,
+		&bufgraph.Graph{
+			Nodes: []string{
+				"a.v1",
+				"b.v1",
+				"c.v1",
+				"google.protobuf",
+			},
+			Edges: []*bufgraph.Edge{
+				{From: "a.v1", To: "b.v1", Cycle: true},
+				{From: "b.v1", To: "a.v1", Cycle: true},
+				{From: "c.v1", To: "a.v1", NotAllowed: true},
+				{From: "c.v1", To: "b.v1"},
+				{From: "c.v1", To: "google.protobuf"},
+			},
+			Cycles: [][]string{
+				{"a.v1", "b.v1"},
+			},
+		},
+		graph,
+	)
+	assert.Equal(
+		t,
+		[]*analysis.Annotation{
+			{
+				Filename:    "a/v1/a.proto",
+				StartLine:   5,
+				StartColumn: 1,
+				EndLine:     5,
+				EndColumn:   23,
+				Type:        bufgraph.AnnotationTypePackageImportCycle,
+				Message:     `Import "b/v1/b.proto" is part of the package import cycle a.v1 -> b.v1 -> a.v1.`,
+			},
+			{
+				Filename:    "b/v1/b.proto",
+				StartLine:   5,
+				StartColumn: 1,
+				EndLine:     5,
+				EndColumn:   27,
+				Type:        bufgraph.AnnotationTypePackageImportCycle,
+				Message:     `Import "a/v1/types.proto" is part of the package import cycle b.v1 -> a.v1 -> b.v1.`,
+			},
+			{
+				Filename:    "c/v1/c.proto",
+				StartLine:   5,
+				StartColumn: 1,
+				EndLine:     5,
+				EndColumn:   27,
+				Type:        bufgraph.AnnotationTypePackageDependencyNotAllowed,
+				Message:     `Import "a/v1/types.proto" of package "a.v1" is not allowed from package "c.v1".`,
+			},
+		},
+		annotations,
+	)
+}
+
+func TestGraphFile(t *testing.T) {
+	t.Parallel()
+	graph, _ := testGraph(t, bufgraph.ViewFile)
+	buffer := bytes.NewBuffer(nil)
+	require.NoError(t, bufgraph.PrintGraph(buffer, graph, bufgraph.FormatDOT))
+	assert.Equal(
+		t,
+		`digraph {
+  "a/v1/a.proto";
+  "a/v1/types.proto";
+  "b/v1/b.proto";
+  "c/v1/c.proto";
+  "google/protobuf/empty.proto";
+  "a/v1/a.proto" -> "b/v1/b.proto" [color=red, label="cycle"];
+  "b/v1/b.proto" -> "a/v1/types.proto" [color=red, label="cycle"];
+  "c/v1/c.proto" -> "a/v1/types.proto" [color=red, label="not allowed"];
+  "c/v1/c.proto" -> "b/v1/b.proto";
+  "c/v1/c.proto" -> "google/protobuf/empty.proto";
+}
+`,
+		buffer.String(),
+	)
+	buffer.Reset()
+	require.NoError(t, bufgraph.PrintGraph(buffer, graph, bufgraph.FormatMermaid))
+	assert.Equal(
+		t,
+		`graph LR
+  n0["a/v1/a.proto"]
+  n1["a/v1/types.proto"]
+  n2["b/v1/b.proto"]
+  n3["c/v1/c.proto"]
+  n4["google/protobuf/empty.proto"]
+  n0 -. cycle .-> n2
+  n2 -. cycle .-> n1
+  n3 -. not allowed .-> n1
+  n3 --> n2
+  n3 --> n4
+`,
+		buffer.String(),
+	)
+}
+
+func TestNewConfigError(t *testing.T) {
+	t.Parallel()
+	_, err := bufgraph.ConfigBuilder{
+		AllowedDependencies: map[string][]string{
+			"a.v1": {".b.v1"},
+		},
+	}.NewConfig()
+	assert.Error(t, err)
+}
+
+func testGraph(t *testing.T, view string) (*bufgraph.Graph, []*analysis.Annotation) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	image := buftesting.BuildImage(t, "testdata", true, true)
+	config, err := bufgraph.ConfigBuilder{
+		AllowedDependencies: map[string][]string{
+			"c.v1": {"b", "google.protobuf"},
+		},
+	}.NewConfig()
+	require.NoError(t, err)
+	graph, annotations, err := bufgraph.NewHandler(zap.NewNop()).Graph(ctx, config, image, view)
+	require.NoError(t, err)
+	return graph, annotations
+}
@@ -0,0 +1,62 @@
+package bufgraph
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/bufbuild/buf/internal/pkg/errs"
+)
+
+func newConfig(configBuilder ConfigBuilder) (*Config, error) {
+	allowedDependencies := make(map[string][]string, len(configBuilder.AllowedDependencies))
+	for pkg, dependencies := range configBuilder.AllowedDependencies {
+		if err := validatePackage(pkg); err != nil {
+			return nil, err
+		}
+		seen := make(map[string]struct{}, len(dependencies))
+		uniqueDependencies := make([]string, 0, len(dependencies))
+		for _, dependency := range dependencies {
+			if err := validatePackage(dependency); err != nil {
+				return nil, err
+			}
+			if _, ok := seen[dependency]; ok {
+				continue
+			}
+			seen[dependency] = struct{}{}
+			uniqueDependencies = append(uniqueDependencies, dependency)
+		}
+		sort.Strings(uniqueDependencies)
+		allowedDependencies[pkg] = uniqueDependencies
+	}
+	return &Config{
+		AllowedDependencies: allowedDependencies,
+	}, nil
+}
+
+func validatePackage(pkg string) error {
+	if pkg == "" {
+		return errs.NewInvalidArgument("allowed dependency package is empty")
+	}
+	if strings.HasPrefix(pkg, ".") || strings.HasSuffix(pkg, ".") {
+		return errs.NewInvalidArgumentf("allowed dependency package %q must not start or end with a period", pkg)
+	}
+	return nil
+}
+
+// isDependencyAllowed returns true if files in the package pkg may import files
+// in the package dependency.
+func isDependencyAllowed(config *Config, pkg string, dependency string) bool {
+	if pkg == dependency {
+		return true
+	}
+	allowedDependencies, ok := config.AllowedDependencies[pkg]
+	if !ok {
+		return true
+	}
+	for _, allowedDependency := range allowedDependencies {
+		if dependency == allowedDependency || strings.HasPrefix(dependency, allowedDependency+".") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,313 @@
+package bufgraph
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/bufbuild/buf/internal/buf/bufpb"
+	"github.com/bufbuild/buf/internal/pkg/analysis"
+	"github.com/bufbuild/buf/internal/pkg/logutil"
+	"github.com/bufbuild/buf/internal/pkg/protodesc"
+	"go.uber.org/zap"
+)
+
+type handler struct {
+	logger *zap.Logger
+}
+
+func newHandler(logger *zap.Logger) *handler {
+	return &handler{
+		logger: logger.Named("bufgraph"),
+	}
+}
+
+func (h *handler) Graph(
+	ctx context.Context,
+	config *Config,
+	image bufpb.Image,
+	view string,
+) (_ *Graph, _ []*analysis.Annotation, retErr error) {
+	defer logutil.DeferWithError(h.logger, "graph", &retErr)()
+
+	if err := ValidateView(view); err != nil {
+		return nil, nil, err
+	}
+	files, err := protodesc.NewFiles(image.GetFile()...)
+	if err != nil {
+		return nil, nil, err
+	}
+	protodesc.SortFiles(files)
+	importNames, err := image.ImportNames()
+	if err != nil {
+		return nil, nil, err
+	}
+	importNameMap := make(map[string]struct{}, len(importNames))
+	for _, importName := range importNames {
+		importNameMap[importName] = struct{}{}
+	}
+	filePathToPackage := make(map[string]string, len(files))
+	for _, file := range files {
+		filePathToPackage[file.FilePath()] = file.Package()
+	}
+
+	fileGraph := newDigraph()
+	packageGraph := newDigraph()
+	for _, file := range files {
+		fileGraph.addNode(file.FilePath())
+		if file.Package() != "" {
+			packageGraph.addNode(file.Package())
+		}
+		for _, fileImport := range file.FileImports() {
+			fileGraph.addEdge(file.FilePath(), fileImport.Import())
+			if importPackage := filePathToPackage[fileImport.Import()]; file.Package() != "" && importPackage != "" && file.Package() != importPackage {
+				packageGraph.addEdge(file.Package(), importPackage)
+			}
+		}
+	}
+	cycles := packageGraph.getCycles()
+	// the index is offset by one so that the zero value means no cycle
+	packageToCycleIndex := make(map[string]int)
+	for i, cycle := range cycles {
+		for _, pkg := range cycle {
+			packageToCycleIndex[pkg] = i + 1
+		}
+	}
+	// isCycleEdge and isNotAllowedEdge work on packages for both views
+	isCycleEdge := func(pkg string, importPackage string) bool {
+		return pkg != importPackage && packageToCycleIndex[pkg] != 0 && packageToCycleIndex[pkg] == packageToCycleIndex[importPackage]
+	}
+	isNotAllowedEdge := func(pkg string, importPackage string) bool {
+		return pkg != "" && importPackage != "" && !isDependencyAllowed(config, pkg, importPackage)
+	}
+
+	var annotations []*analysis.Annotation
+	for _, file := range files {
+		if _, ok := importNameMap[file.FilePath()]; ok {
+			continue
+		}
+		for _, fileImport := range file.FileImports() {
+			importPackage := filePathToPackage[fileImport.Import()]
+			if isCycleEdge(file.Package(), importPackage) {
+				annotations = append(
+					annotations,
+					newAnnotationf(
+						AnnotationTypePackageImportCycle,
+						fileImport,
+						`Import %q is part of the package import cycle %s.`,
+						fileImport.Import(),
+						strings.Join(packageGraph.getPath(importPackage, file.Package(), file.Package()), " -> "),
+					),
+				)
+			}
+			if isNotAllowedEdge(file.Package(), importPackage) {
+				annotations = append(
+					annotations,
+					newAnnotationf(
+						AnnotationTypePackageDependencyNotAllowed,
+						fileImport,
+						`Import %q of package %q is not allowed from package %q.`,
+						fileImport.Import(),
+						importPackage,
+						file.Package(),
+					),
+				)
+			}
+		}
+	}
+
+	graph := &Graph{
+		Cycles: cycles,
+	}
+	switch view {
+	case ViewFile:
+		graph.Nodes = fileGraph.getNodes()
+		for _, from := range graph.Nodes {
+			for _, to := range fileGraph.getEdges(from) {
+				pkg := filePathToPackage[from]
+				importPackage := filePathToPackage[to]
+				graph.Edges = append(
+					graph.Edges,
+					&Edge{
+						From:       from,
+						To:         to,
+						Cycle:      isCycleEdge(pkg, importPackage),
+						NotAllowed: isNotAllowedEdge(pkg, importPackage),
+					},
+				)
+			}
+		}
+	case ViewPackage:
+		graph.Nodes = packageGraph.getNodes()
+		for _, from := range graph.Nodes {
+			for _, to := range packageGraph.getEdges(from) {
+				graph.Edges = append(
+					graph.Edges,
+					&Edge{
+						From:       from,
+						To:         to,
+						Cycle:      isCycleEdge(from, to),
+						NotAllowed: isNotAllowedEdge(from, to),
+					},
+				)
+			}
+		}
+	}
+	return graph, annotations, nil
+}
+
+func newAnnotationf(
+	annotationType string,
+	descriptor protodesc.LocationDescriptor,
+	format string,
+	args ...interface{},
+) *analysis.Annotation {
+	annotation := &analysis.Annotation{
+		// this is a root file path
+		Filename: descriptor.FilePath(),
+		Type:     annotationType,
+		Message:  fmt.Sprintf(format, args...),
+	}
+	if location := descriptor.Location(); location != nil {
+		annotation.StartLine = location.StartLine()
+		annotation.StartColumn = location.StartColumn()
+		annotation.EndLine = location.EndLine()
+		annotation.EndColumn = location.EndColumn()
+	}
+	return annotation
+}
+
+// digraph is a directed graph of strings.
+type digraph struct {
+	nodeToEdges map[string]map[string]struct{}
+}
+
+func newDigraph() *digraph {
+	return &digraph{
+		nodeToEdges: make(map[string]map[string]struct{}),
+	}
+}
+
+func (d *digraph) addNode(node string) {
+	if _, ok := d.nodeToEdges[node]; !ok {
+		d.nodeToEdges[node] = make(map[string]struct{})
+	}
+}
+
+func (d *digraph) addEdge(from string, to string) {
+	d.addNode(from)
+	d.addNode(to)
+	d.nodeToEdges[from][to] = struct{}{}
+}
+
+// getNodes returns the sorted nodes.
+func (d *digraph) getNodes() []string {
+	nodes := make([]string, 0, len(d.nodeToEdges))
+	for node := range d.nodeToEdges {
+		nodes = append(nodes, node)
+	}
+	sort.Strings(nodes)
+	return nodes
+}
+
+// getEdges returns the sorted nodes that the node has edges to.
+func (d *digraph) getEdges(node string) []string {
+	edges := make([]string, 0, len(d.nodeToEdges[node]))
+	for edge := range d.nodeToEdges[node] {
+		edges = append(edges, edge)
+	}
+	sort.Strings(edges)
+	return edges
+}
+
+// getCycles returns the strongly connected components with more than one node.
+//
+// Each cycle is sorted, and the cycles are sorted by their first node.
+// This uses Tarjan's algorithm, iterating in sorted order so the result is deterministic.
+func (d *digraph) getCycles() [][]string {
+	index := 0
+	nodeToIndex := make(map[string]int)
+	nodeToLowLink := make(map[string]int)
+	onStack := make(map[string]bool)
+	var stack []string
+	var cycles [][]string
+	var strongConnect func(string)
+	strongConnect = func(node string) {
+		nodeToIndex[node] = index
+		nodeToLowLink[node] = index
+		index++
+		stack = append(stack, node)
+		onStack[node] = true
+		for _, edge := range d.getEdges(node) {
+			if _, ok := nodeToIndex[edge]; !ok {
+				strongConnect(edge)
+				if nodeToLowLink[edge] < nodeToLowLink[node] {
+					nodeToLowLink[node] = nodeToLowLink[edge]
+				}
+			} else if onStack[edge] && nodeToIndex[edge] < nodeToLowLink[node] {
+				nodeToLowLink[node] = nodeToIndex[edge]
+			}
+		}
+		if nodeToLowLink[node] != nodeToIndex[node] {
+			return
+		}
+		var component []string
+		for {
+			last := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			onStack[last] = false
+			component = append(component, last)
+			if last == node {
+				break
+			}
+		}
+		if len(component) > 1 {
+			sort.Strings(component)
+			cycles = append(cycles, component)
+		}
+	}
+	for _, node := range d.getNodes() {
+		if _, ok := nodeToIndex[node]; !ok {
+			strongConnect(node)
+		}
+	}
+	sort.Slice(
+		cycles,
+		func(i int, j int) bool {
+			return cycles[i][0] < cycles[j][0]
+		},
+	)
+	return cycles
+}
+
+// getPath returns the shortest path from one node to another, prefixed by prefix.
+//
+// If there are multiple shortest paths, the first in sorted order is returned.
+// Returns only the prefix if there is no path.
+func (d *digraph) getPath(from string, to string, prefix string) []string {
+	nodeToPrevious := map[string]string{from: ""}
+	queue := []string{from}
+	for len(queue) > 0 && queue[0] != to {
+		node := queue[0]
+		queue = queue[1:]
+		for _, edge := range d.getEdges(node) {
+			if _, ok := nodeToPrevious[edge]; !ok {
+				nodeToPrevious[edge] = node
+				queue = append(queue, edge)
+			}
+		}
+	}
+	if _, ok := nodeToPrevious[to]; !ok {
+		return []string{prefix}
+	}
+	var reversed []string
+	for node := to; node != ""; node = nodeToPrevious[node] {
+		reversed = append(reversed, node)
+	}
+	path := []string{prefix}
+	for i := len(reversed) - 1; i >= 0; i-- {
+		path = append(path, reversed[i])
+	}
+	return path
+}
@@ -0,0 +1,83 @@
+package bufgraph
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+func printGraph(writer io.Writer, graph *Graph, format string) error {
+	if err := ValidateFormat(format); err != nil {
+		return err
+	}
+	buffer := bytes.NewBuffer(nil)
+	switch format {
+	case FormatDOT:
+		printGraphDOT(buffer, graph)
+	case FormatMermaid:
+		printGraphMermaid(buffer, graph)
+	case FormatJSON:
+		data, err := json.Marshal(graph)
+		if err != nil {
+			return err
+		}
+		_, _ = buffer.Write(data)
+		_, _ = buffer.WriteString("\n")
+	}
+	_, err := writer.Write(buffer.Bytes())
+	return err
+}
+
+func printGraphDOT(buffer *bytes.Buffer, graph *Graph) {
+	_, _ = buffer.WriteString("digraph {\n")
+	for _, node := range graph.Nodes {
+		_, _ = fmt.Fprintf(buffer, "  %s;\n", strconv.Quote(node))
+	}
+	for _, edge := range graph.Edges {
+		_, _ = fmt.Fprintf(buffer, "  %s -> %s", strconv.Quote(edge.From), strconv.Quote(edge.To))
+		var attributes []string
+		if edge.Cycle || edge.NotAllowed {
+			attributes = append(attributes, "color=red")
+		}
+		if label := getEdgeLabel(edge); label != "" {
+			attributes = append(attributes, "label="+strconv.Quote(label))
+		}
+		if len(attributes) > 0 {
+			_, _ = fmt.Fprintf(buffer, " [%s]", strings.Join(attributes, ", "))
+		}
+		_, _ = buffer.WriteString(";\n")
+	}
+	_, _ = buffer.WriteString("}\n")
+}
+
+func printGraphMermaid(buffer *bytes.Buffer, graph *Graph) {
+	// node names are not valid Mermaid ids, so ids are assigned in order
+	nodeToID := make(map[string]string, len(graph.Nodes))
+	_, _ = buffer.WriteString("graph LR\n")
+	for i, node := range graph.Nodes {
+		id := "n" + strconv.Itoa(i)
+		nodeToID[node] = id
+		_, _ = fmt.Fprintf(buffer, "  %s[%s]\n", id, strconv.Quote(node))
+	}
+	for _, edge := range graph.Edges {
+		if label := getEdgeLabel(edge); label != "" {
+			_, _ = fmt.Fprintf(buffer, "  %s -. %s .-> %s\n", nodeToID[edge.From], label, nodeToID[edge.To])
+		} else {
+			_, _ = fmt.Fprintf(buffer, "  %s --> %s\n", nodeToID[edge.From], nodeToID[edge.To])
+		}
+	}
+}
+
+func getEdgeLabel(edge *Edge) string {
+	var labels []string
+	if edge.Cycle {
+		labels = append(labels, "cycle")
+	}
+	if edge.NotAllowed {
+		labels = append(labels, "not allowed")
+	}
+	return strings.Join(labels, ", ")
+}
@@ -0,0 +1,45 @@
+// Package bufinit infers configurations for existing trees of .proto files.
+package bufinit
+
+import (
+	"context"
+
+	"github.com/bufbuild/buf/internal/buf/bufbuild"
+	"github.com/bufbuild/buf/internal/pkg/analysis"
+	"github.com/bufbuild/buf/internal/pkg/storage"
+	"go.uber.org/zap"
+)
+
+// Handler infers configurations.
+type Handler interface {
+	// InferBuildConfig infers the build roots and excludes for the .proto files in the bucket.
+	//
+	// Roots are inferred from the import statements of each file, and are the
+	// smallest set of non-overlapping roots such that imports resolve relative to the roots.
+	// Excludes are proposed for directories that fail to compile with the inferred roots.
+	//
+	// The returned ConfigBuilder is valid.
+	// Annotations are returned for files that still fail to compile and that could not
+	// be excluded, for example files that are directly within a root.
+	InferBuildConfig(
+		ctx context.Context,
+		bucket storage.ReadBucket,
+	) (*bufbuild.ConfigBuilder, []*analysis.Annotation, error)
+}
+
+// NewHandler returns a new Handler.
+func NewHandler(
+	logger *zap.Logger,
+	buildHandler bufbuild.Handler,
+) Handler {
+	return newHandler(
+		logger,
+		buildHandler,
+	)
+}
+
+// GetConfigData returns the data for a commented buf.yaml for the build
+// configuration and the default lint and breaking configurations.
+func GetConfigData(buildConfigBuilder *bufbuild.ConfigBuilder) []byte {
+	return getConfigData(buildConfigBuilder)
+}
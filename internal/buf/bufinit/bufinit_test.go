@@ -0,0 +1,95 @@
+package bufinit_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bufbuild/buf/internal/buf/bufbuild"
+	"github.com/bufbuild/buf/internal/buf/bufinit"
+	"github.com/bufbuild/buf/internal/pkg/bytepool"
+	"github.com/bufbuild/buf/internal/pkg/bytepool/bytepooltesting"
+	"github.com/bufbuild/buf/internal/pkg/storage/storageos"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestInferBuildConfig(t *testing.T) {
+	t.Parallel()
+	configBuilder := testInferBuildConfig(t, "legacy")
+	assert.Equal(
+		t,
+		&bufbuild.ConfigBuilder{
+			Roots:    []string{"other", "proto", "vendor"},
+			Excludes: []string{"proto/legacy"},
+		},
+		configBuilder,
+	)
+	_, err := configBuilder.NewConfig()
+	assert.NoError(t, err)
+}
+
+func TestGetConfigData(t *testing.T) {
+	t.Parallel()
+	assert.Equal(
+		t,
+		`# Generated by buf init.
+build:
+  # The directories to use as include paths. Imports are relative to these
+  # directories, and each file path must be unique relative to the roots.
+  roots:
+    - proto
+  # The directories within the roots to exclude from the build.
+  # These directories failed to compile with the above roots.
+  excludes:
+    - proto/legacy
+lint:
+  # The lint checkers or categories to use.
+  # Run "buf check ls-lint-checkers --all" to list all checkers.
+  use:
+    - DEFAULT
+breaking:
+  # The breaking checkers or categories to use.
+  # Run "buf check ls-breaking-checkers --all" to list all checkers.
+  use:
+    - FILE
+`,
+		string(
+			bufinit.GetConfigData(
+				&bufbuild.ConfigBuilder{
+					Roots:    []string{"proto"},
+					Excludes: []string{"proto/legacy"},
+				},
+			),
+		),
+	)
+}
+
+func testInferBuildConfig(t *testing.T, dirPath string) *bufbuild.ConfigBuilder {
+	logger := zap.NewNop()
+	segList := bytepool.NewSegList()
+	defer bytepooltesting.AssertAllRecycled(t, segList)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	bucket, err := storageos.NewReadBucket(filepath.Join("testdata", dirPath))
+	require.NoError(t, err)
+	defer func() {
+		assert.NoError(t, bucket.Close())
+	}()
+	handler := bufinit.NewHandler(
+		logger,
+		bufbuild.NewHandler(
+			logger,
+			segList,
+			bufbuild.NewProvider(logger),
+			bufbuild.NewRunner(logger),
+		),
+	)
+	configBuilder, annotations, err := handler.InferBuildConfig(ctx, bucket)
+	require.NoError(t, err)
+	assert.Empty(t, annotations)
+	return configBuilder
+}
@@ -0,0 +1,44 @@
+package bufinit
+
+import (
+	"bytes"
+
+	"github.com/bufbuild/buf/internal/buf/bufbuild"
+	"github.com/bufbuild/buf/internal/buf/bufcheck/bufbreaking"
+	"github.com/bufbuild/buf/internal/buf/bufcheck/buflint"
+)
+
+func getConfigData(buildConfigBuilder *bufbuild.ConfigBuilder) []byte {
+	buffer := bytes.NewBuffer(nil)
+	_, _ = buffer.WriteString("# Generated by buf init.\n")
+	_, _ = buffer.WriteString("build:\n")
+	_, _ = buffer.WriteString("  # The directories to use as include paths. Imports are relative to these\n")
+	_, _ = buffer.WriteString("  # directories, and each file path must be unique relative to the roots.\n")
+	_, _ = buffer.WriteString("  roots:\n")
+	writeList(buffer, buildConfigBuilder.Roots)
+	if len(buildConfigBuilder.Excludes) > 0 {
+		_, _ = buffer.WriteString("  # The directories within the roots to exclude from the build.\n")
+		_, _ = buffer.WriteString("  # These directories failed to compile with the above roots.\n")
+		_, _ = buffer.WriteString("  excludes:\n")
+		writeList(buffer, buildConfigBuilder.Excludes)
+	}
+	_, _ = buffer.WriteString("lint:\n")
+	_, _ = buffer.WriteString("  # The lint checkers or categories to use.\n")
+	_, _ = buffer.WriteString("  # Run \"buf check ls-lint-checkers --all\" to list all checkers.\n")
+	_, _ = buffer.WriteString("  use:\n")
+	writeList(buffer, buflint.GetDefaultCategories())
+	_, _ = buffer.WriteString("breaking:\n")
+	_, _ = buffer.WriteString("  # The breaking checkers or categories to use.\n")
+	_, _ = buffer.WriteString("  # Run \"buf check ls-breaking-checkers --all\" to list all checkers.\n")
+	_, _ = buffer.WriteString("  use:\n")
+	writeList(buffer, bufbreaking.GetDefaultCategories())
+	return buffer.Bytes()
+}
+
+func writeList(buffer *bytes.Buffer, values []string) {
+	for _, value := range values {
+		_, _ = buffer.WriteString("    - ")
+		_, _ = buffer.WriteString(value)
+		_, _ = buffer.WriteString("\n")
+	}
+}
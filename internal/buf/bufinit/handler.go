@@ -0,0 +1,314 @@
+package bufinit
+
+import (
+	"context"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/bufbuild/buf/internal/buf/bufbuild"
+	"github.com/bufbuild/buf/internal/pkg/analysis"
+	"github.com/bufbuild/buf/internal/pkg/errs"
+	"github.com/bufbuild/buf/internal/pkg/logutil"
+	"github.com/bufbuild/buf/internal/pkg/storage"
+	"github.com/bufbuild/buf/internal/pkg/storage/storagepath"
+	"github.com/bufbuild/buf/internal/pkg/stringutil"
+	"github.com/jhump/protoreflect/desc/protoparse"
+	"go.uber.org/zap"
+)
+
+type handler struct {
+	logger       *zap.Logger
+	buildHandler bufbuild.Handler
+}
+
+func newHandler(
+	logger *zap.Logger,
+	buildHandler bufbuild.Handler,
+) *handler {
+	return &handler{
+		logger:       logger.Named("bufinit"),
+		buildHandler: buildHandler,
+	}
+}
+
+func (h *handler) InferBuildConfig(
+	ctx context.Context,
+	bucket storage.ReadBucket,
+) (_ *bufbuild.ConfigBuilder, _ []*analysis.Annotation, retErr error) {
+	defer logutil.DeferWithError(h.logger, "infer_build_config", &retErr)()
+
+	realFilePaths, err := getRealFilePaths(ctx, bucket)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(realFilePaths) == 0 {
+		return nil, nil, errs.NewInvalidArgument("no .proto files found")
+	}
+	realFilePathToImports, err := h.getRealFilePathToImports(ctx, bucket, realFilePaths)
+	if err != nil {
+		return nil, nil, err
+	}
+	roots := inferRoots(realFilePaths, realFilePathToImports)
+	for _, realFilePath := range realFilePaths {
+		if getContainingRoot(roots, realFilePath) == "" {
+			h.logger.Warn("file_not_within_roots", zap.String("path", realFilePath))
+		}
+	}
+	if err := checkUniqueRootFilePaths(roots, realFilePaths); err != nil {
+		return nil, nil, err
+	}
+	return h.inferExcludes(ctx, bucket, roots)
+}
+
+// getRealFilePathToImports parses the imports of each file.
+//
+// Files that fail to parse have no imports, they will fail to compile
+// and have excludes proposed later.
+func (h *handler) getRealFilePathToImports(
+	ctx context.Context,
+	bucket storage.ReadBucket,
+	realFilePaths []string,
+) (map[string][]string, error) {
+	parser := protoparse.Parser{
+		Accessor: func(filename string) (io.ReadCloser, error) {
+			return bucket.Get(ctx, filename)
+		},
+	}
+	realFilePathToImports := make(map[string][]string, len(realFilePaths))
+	for _, realFilePath := range realFilePaths {
+		fileDescriptorProtos, err := parser.ParseFilesButDoNotLink(realFilePath)
+		if err != nil {
+			if _, ok := err.(protoparse.ErrorWithPos); ok || err == protoparse.ErrInvalidSource {
+				h.logger.Debug("parse_error", zap.String("path", realFilePath), zap.Error(err))
+				continue
+			}
+			return nil, err
+		}
+		if len(fileDescriptorProtos) != 1 {
+			return nil, errs.NewInternalf("expected one FileDescriptorProto for %s but got %d", realFilePath, len(fileDescriptorProtos))
+		}
+		realFilePathToImports[realFilePath] = fileDescriptorProtos[0].GetDependency()
+	}
+	return realFilePathToImports, nil
+}
+
+// inferExcludes builds with the roots, and adds the directories of any files that
+// fail to compile as excludes until the build succeeds or no more directories
+// can be excluded.
+func (h *handler) inferExcludes(
+	ctx context.Context,
+	bucket storage.ReadBucket,
+	roots []string,
+) (*bufbuild.ConfigBuilder, []*analysis.Annotation, error) {
+	configBuilder := &bufbuild.ConfigBuilder{
+		Roots: roots,
+	}
+	for {
+		config, err := configBuilder.NewConfig()
+		if err != nil {
+			return nil, nil, err
+		}
+		_, _, annotations, err := h.buildHandler.BuildImage(
+			ctx,
+			bucket,
+			config,
+			nil,
+			false,
+			false,
+			false,
+		)
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(annotations) == 0 {
+			return configBuilder, nil, nil
+		}
+		excludes := configBuilder.Excludes
+		var remainingAnnotations []*analysis.Annotation
+		for _, annotation := range annotations {
+			if annotation.Filename == "" {
+				remainingAnnotations = append(remainingAnnotations, annotation)
+				continue
+			}
+			dirPath := storagepath.Dir(annotation.Filename)
+			if root := getContainingRoot(roots, annotation.Filename); root == "" || root == dirPath {
+				remainingAnnotations = append(remainingAnnotations, annotation)
+				continue
+			}
+			excludes = addExclude(excludes, dirPath)
+		}
+		if len(excludes) == len(configBuilder.Excludes) {
+			return configBuilder, remainingAnnotations, nil
+		}
+		h.logger.Debug("adding_excludes", zap.Strings("excludes", excludes))
+		configBuilder.Excludes = excludes
+	}
+}
+
+func getRealFilePaths(ctx context.Context, bucket storage.ReadBucket) ([]string, error) {
+	var realFilePaths []string
+	if err := bucket.Walk(
+		ctx,
+		"",
+		func(realFilePath string) error {
+			if storagepath.Ext(realFilePath) == ".proto" {
+				realFilePaths = append(realFilePaths, realFilePath)
+			}
+			return nil
+		},
+	); err != nil {
+		return nil, err
+	}
+	sort.Strings(realFilePaths)
+	return realFilePaths, nil
+}
+
+// inferRoots infers the roots from the imports.
+//
+// Every import that matches a file in realFilePaths requires the root that the
+// import is relative to. If required roots are nested, the outermost root is used,
+// and files that then fail to compile will be excluded later. Files not within a
+// required root get the outermost directory that does not contain another root.
+//
+// Imports that do not match a file, such as the well-known types, are ignored.
+func inferRoots(realFilePaths []string, realFilePathToImports map[string][]string) []string {
+	rootMap := make(map[string]struct{})
+	for _, realFilePath := range realFilePaths {
+		for _, importPath := range realFilePathToImports[realFilePath] {
+			if root, ok := getImportRoot(realFilePaths, realFilePath, importPath); ok {
+				rootMap[root] = struct{}{}
+			}
+		}
+	}
+	roots := removeNestedPaths(stringutil.MapToSortedSlice(rootMap))
+	if len(roots) == 0 {
+		return []string{"."}
+	}
+	if len(roots) == 1 && roots[0] == "." {
+		return roots
+	}
+	for _, realFilePath := range realFilePaths {
+		if getContainingRoot(roots, realFilePath) != "" {
+			continue
+		}
+		components := storagepath.Components(storagepath.Dir(realFilePath))
+		for i := range components {
+			dirPath := storagepath.Join(components[:i+1]...)
+			if dirPath == "." {
+				break
+			}
+			if !containsAnyPath(dirPath, roots) {
+				roots = append(roots, dirPath)
+				sort.Strings(roots)
+				break
+			}
+		}
+	}
+	return roots
+}
+
+// getImportRoot returns the root that the import is relative to.
+//
+// If multiple files match the import, the root that contains the importing file is preferred.
+func getImportRoot(realFilePaths []string, importingRealFilePath string, importPath string) (string, bool) {
+	var matchingRoots []string
+	for _, realFilePath := range realFilePaths {
+		switch {
+		case realFilePath == importPath:
+			matchingRoots = append(matchingRoots, ".")
+		case strings.HasSuffix(realFilePath, "/"+importPath):
+			matchingRoots = append(matchingRoots, strings.TrimSuffix(realFilePath, "/"+importPath))
+		}
+	}
+	if len(matchingRoots) == 0 {
+		return "", false
+	}
+	for _, matchingRoot := range matchingRoots {
+		if pathContains(matchingRoot, importingRealFilePath) {
+			return matchingRoot, true
+		}
+	}
+	return matchingRoots[0], true
+}
+
+func checkUniqueRootFilePaths(roots []string, realFilePaths []string) error {
+	rootFilePathToRealFilePath := make(map[string]string, len(realFilePaths))
+	for _, realFilePath := range realFilePaths {
+		root := getContainingRoot(roots, realFilePath)
+		if root == "" {
+			continue
+		}
+		rootFilePath, err := storagepath.Rel(root, realFilePath)
+		if err != nil {
+			return err
+		}
+		if otherRealFilePath, ok := rootFilePathToRealFilePath[rootFilePath]; ok {
+			return errs.NewInvalidArgumentf(
+				"could not infer roots %v: file with path %s is within multiple roots at %v",
+				roots,
+				rootFilePath,
+				[]string{otherRealFilePath, realFilePath},
+			)
+		}
+		rootFilePathToRealFilePath[rootFilePath] = realFilePath
+	}
+	return nil
+}
+
+// getContainingRoot returns the root that contains the path, or empty if no root contains the path.
+func getContainingRoot(roots []string, path string) string {
+	for _, root := range roots {
+		if pathContains(root, path) {
+			return root
+		}
+	}
+	return ""
+}
+
+// addExclude adds the exclude, removing any excludes within it.
+//
+// If the exclude is already within an exclude, this is a no-op.
+func addExclude(excludes []string, exclude string) []string {
+	for _, existing := range excludes {
+		if pathContains(existing, exclude) {
+			return excludes
+		}
+	}
+	newExcludes := []string{exclude}
+	for _, existing := range excludes {
+		if !pathContains(exclude, existing) {
+			newExcludes = append(newExcludes, existing)
+		}
+	}
+	sort.Strings(newExcludes)
+	return newExcludes
+}
+
+// removeNestedPaths removes paths that are within other paths.
+//
+// The input must be sorted.
+func removeNestedPaths(paths []string) []string {
+	var result []string
+	for _, path := range paths {
+		if getContainingRoot(result, path) == "" {
+			result = append(result, path)
+		}
+	}
+	return result
+}
+
+// containsAnyPath returns true if dirPath contains any of the paths.
+func containsAnyPath(dirPath string, paths []string) bool {
+	for _, path := range paths {
+		if pathContains(dirPath, path) {
+			return true
+		}
+	}
+	return false
+}
+
+// pathContains returns true if path is equal to or within dirPath.
+func pathContains(dirPath string, path string) bool {
+	return dirPath == "." || dirPath == path || strings.HasPrefix(path, dirPath+"/")
+}
@@ -0,0 +1,158 @@
+// Package bufinventory lists the packages, messages, enums, services, and methods of Images.
+package bufinventory
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/bufbuild/buf/internal/buf/bufbuild"
+	"github.com/bufbuild/buf/internal/buf/bufpb"
+	"github.com/bufbuild/buf/internal/pkg/errs"
+	"go.uber.org/zap"
+)
+
+const (
+	// KindPackage is the kind for packages.
+	KindPackage = "package"
+	// KindMessage is the kind for messages.
+	KindMessage = "message"
+	// KindEnum is the kind for enums.
+	KindEnum = "enum"
+	// KindService is the kind for services.
+	KindService = "service"
+	// KindMethod is the kind for methods, ie RPCs.
+	KindMethod = "method"
+)
+
+var (
+	// AllKinds are all kinds in the order they are listed.
+	AllKinds = []string{
+		KindPackage,
+		KindMessage,
+		KindEnum,
+		KindService,
+		KindMethod,
+	}
+
+	kindToOrder = map[string]int{
+		KindPackage: 1,
+		KindMessage: 2,
+		KindEnum:    3,
+		KindService: 4,
+		KindMethod:  5,
+	}
+)
+
+// Item is a single named element of an Image.
+type Item struct {
+	// Kind is the kind of the element, one of AllKinds.
+	Kind string `json:"kind,omitempty" yaml:"kind,omitempty"`
+	// Name is the fully-qualified name of the element.
+	Name string `json:"name,omitempty" yaml:"name,omitempty"`
+	// Filename is the file the element is defined in.
+	//
+	// For packages, this is the first file with the package.
+	Filename string `json:"filename,omitempty" yaml:"filename,omitempty"`
+	// Line is the line the element is defined on. If the Image did not have
+	// source info, this will be 0.
+	Line int `json:"line,omitempty" yaml:"line,omitempty"`
+}
+
+func (i *Item) location() string {
+	if i.Line == 0 {
+		return i.Filename
+	}
+	return i.Filename + ":" + strconv.Itoa(i.Line)
+}
+
+// Handler handles inventories.
+type Handler interface {
+	// Inventory returns the items of the image.
+	//
+	// If packagePrefix is not empty, only items within the package or its sub-packages
+	// are returned, ie the package prefix foo matches foo and foo.bar but not foobar.
+	// If kinds is empty, items of all kinds are returned.
+	//
+	// Imports are included in the inventory if they are in the Image.
+	// Filenames will be relative to the roots, use FixItemFilenames to
+	// make them into real file paths.
+	//
+	// Items are sorted by name, then by kind.
+	Inventory(
+		ctx context.Context,
+		image bufpb.Image,
+		packagePrefix string,
+		kinds ...string,
+	) ([]*Item, error)
+}
+
+// NewHandler returns a new Handler.
+func NewHandler(logger *zap.Logger) Handler {
+	return newHandler(logger)
+}
+
+// ValidateKinds returns a user error if any of the kinds is not in AllKinds.
+func ValidateKinds(kinds ...string) error {
+	for _, kind := range kinds {
+		if _, ok := kindToOrder[kind]; !ok {
+			return errs.NewInvalidArgumentf("unknown kind: %q", kind)
+		}
+	}
+	return nil
+}
+
+// FixItemFilenames attempts to make all filenames into real file paths.
+//
+// If the resolver is nil, this has no effect.
+func FixItemFilenames(resolver bufbuild.ProtoFilePathResolver, items []*Item) error {
+	if resolver == nil {
+		return nil
+	}
+	for _, item := range items {
+		filePath, err := resolver.GetFilePath(item.Filename)
+		if err != nil {
+			if err == bufbuild.ErrFilePathUnknown {
+				continue
+			}
+			return err
+		}
+		item.Filename = filePath
+	}
+	return nil
+}
+
+// PrintItems prints the items to the writer.
+//
+// The text format is aligned columns of kind, name, and location.
+// The JSON format is one Item per line.
+func PrintItems(writer io.Writer, items []*Item, asJSON bool) (retErr error) {
+	if len(items) == 0 {
+		return nil
+	}
+	if asJSON {
+		for _, item := range items {
+			data, err := json.Marshal(item)
+			if err != nil {
+				return err
+			}
+			if _, err := fmt.Fprintln(writer, string(data)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	tabWriter := tabwriter.NewWriter(writer, 0, 0, 2, ' ', 0)
+	defer func() {
+		retErr = errs.Append(retErr, tabWriter.Flush())
+	}()
+	for _, item := range items {
+		if _, err := fmt.Fprintf(tabWriter, "%s\t%s\t%s\n", item.Kind, item.Name, item.location()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
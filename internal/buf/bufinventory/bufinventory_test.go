@@ -0,0 +1,98 @@
+package bufinventory_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/bufbuild/buf/internal/buf/bufinventory"
+	"github.com/bufbuild/buf/internal/buf/buftesting"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestInventory(t *testing.T) {
+	t.Parallel()
+	items := testInventory(t, "")
+	assert.Equal(
+		t,
+		[]*bufinventory.Item{
+			newItem("package", "foo.v1", "foo/v1/foo.proto", 3),
+			newItem("enum", "foo.v1.Bar", "foo/v1/foo.proto", 10),
+			newItem("message", "foo.v1.Foo", "foo/v1/foo.proto", 5),
+			newItem("message", "foo.v1.Foo.Nested", "foo/v1/foo.proto", 6),
+			newItem("service", "foo.v1.FooService", "foo/v1/foo.proto", 14),
+			newItem("method", "foo.v1.FooService.GetFoo", "foo/v1/foo.proto", 15),
+			newItem("package", "foobar", "foobar/foobar.proto", 3),
+			newItem("message", "foobar.Baz", "foobar/foobar.proto", 5),
+		},
+		items,
+	)
+}
+
+func TestInventoryFilters(t *testing.T) {
+	t.Parallel()
+	items := testInventory(t, "foo", "service", "method")
+	assert.Equal(
+		t,
+		[]*bufinventory.Item{
+			newItem("service", "foo.v1.FooService", "foo/v1/foo.proto", 14),
+			newItem("method", "foo.v1.FooService.GetFoo", "foo/v1/foo.proto", 15),
+		},
+		items,
+	)
+	items = testInventory(t, "foobar", "message")
+	assert.Equal(
+		t,
+		[]*bufinventory.Item{
+			newItem("message", "foobar.Baz", "foobar/foobar.proto", 5),
+		},
+		items,
+	)
+}
+
+func TestPrintItems(t *testing.T) {
+	t.Parallel()
+	items := []*bufinventory.Item{
+		newItem("package", "foo.v1", "foo/v1/foo.proto", 3),
+		newItem("message", "foo.v1.Foo", "foo/v1/foo.proto", 0),
+	}
+	buffer := bytes.NewBuffer(nil)
+	require.NoError(t, bufinventory.PrintItems(buffer, items, false))
+	assert.Equal(
+		t,
+		`package  foo.v1      foo/v1/foo.proto:3
+message  foo.v1.Foo  foo/v1/foo.proto
+`,
+		buffer.String(),
+	)
+	buffer.Reset()
+	require.NoError(t, bufinventory.PrintItems(buffer, items, true))
+	assert.Equal(
+		t,
+		`{"kind":"package","name":"foo.v1","filename":"foo/v1/foo.proto","line":3}
+{"kind":"message","name":"foo.v1.Foo","filename":"foo/v1/foo.proto"}
+`,
+		buffer.String(),
+	)
+}
+
+func testInventory(t *testing.T, packagePrefix string, kinds ...string) []*bufinventory.Item {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	image := buftesting.BuildImage(t, "testdata", false, true)
+	items, err := bufinventory.NewHandler(zap.NewNop()).Inventory(ctx, image, packagePrefix, kinds...)
+	require.NoError(t, err)
+	return items
+}
+
+func newItem(kind string, name string, filename string, line int) *bufinventory.Item {
+	return &bufinventory.Item{
+		Kind:     kind,
+		Name:     name,
+		Filename: filename,
+		Line:     line,
+	}
+}
@@ -0,0 +1,155 @@
+package bufinventory
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/bufbuild/buf/internal/buf/bufpb"
+	"github.com/bufbuild/buf/internal/pkg/logutil"
+	"github.com/bufbuild/buf/internal/pkg/protodesc"
+	"go.uber.org/zap"
+)
+
+type handler struct {
+	logger *zap.Logger
+}
+
+func newHandler(logger *zap.Logger) *handler {
+	return &handler{
+		logger: logger.Named("bufinventory"),
+	}
+}
+
+func (h *handler) Inventory(
+	ctx context.Context,
+	image bufpb.Image,
+	packagePrefix string,
+	kinds ...string,
+) (_ []*Item, retErr error) {
+	defer logutil.DeferWithError(h.logger, "inventory", &retErr)()
+
+	if err := ValidateKinds(kinds...); err != nil {
+		return nil, err
+	}
+	if len(kinds) == 0 {
+		kinds = AllKinds
+	}
+	files, err := protodesc.NewFiles(image.GetFile()...)
+	if err != nil {
+		return nil, err
+	}
+	// sorting the files makes the file chosen for a package deterministic
+	protodesc.SortFiles(files)
+	var items []*Item
+	for _, kind := range kinds {
+		kindItems, err := getItems(files, kind)
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range kindItems {
+			if packageHasPrefix(item.pkg, packagePrefix) {
+				items = append(items, item.Item)
+			}
+		}
+	}
+	sort.Slice(
+		items,
+		func(i int, j int) bool {
+			if items[i].Name != items[j].Name {
+				return items[i].Name < items[j].Name
+			}
+			return kindToOrder[items[i].Kind] < kindToOrder[items[j].Kind]
+		},
+	)
+	return items, nil
+}
+
+type packageItem struct {
+	*Item
+
+	pkg string
+}
+
+func getItems(files []protodesc.File, kind string) ([]*packageItem, error) {
+	var items []*packageItem
+	switch kind {
+	case KindPackage:
+		seen := make(map[string]struct{})
+		for _, file := range files {
+			pkg := file.Package()
+			if pkg == "" {
+				continue
+			}
+			if _, ok := seen[pkg]; ok {
+				continue
+			}
+			seen[pkg] = struct{}{}
+			items = append(items, newPackageItem(kind, pkg, file, file.PackageLocation()))
+		}
+	case KindMessage:
+		fullNameToMessage, err := protodesc.FullNameToMessage(files...)
+		if err != nil {
+			return nil, err
+		}
+		for fullName, message := range fullNameToMessage {
+			// map entries are generated by the compiler and are not declared
+			if message.IsMapEntry() {
+				continue
+			}
+			items = append(items, newPackageItem(kind, fullName, message, message.NameLocation()))
+		}
+	case KindEnum:
+		fullNameToEnum, err := protodesc.FullNameToEnum(files...)
+		if err != nil {
+			return nil, err
+		}
+		for fullName, enum := range fullNameToEnum {
+			items = append(items, newPackageItem(kind, fullName, enum, enum.NameLocation()))
+		}
+	case KindService:
+		fullNameToService, err := protodesc.FullNameToService(files...)
+		if err != nil {
+			return nil, err
+		}
+		for fullName, service := range fullNameToService {
+			items = append(items, newPackageItem(kind, fullName, service, service.NameLocation()))
+		}
+	case KindMethod:
+		fullNameToMethod, err := protodesc.FullNameToMethod(files...)
+		if err != nil {
+			return nil, err
+		}
+		for fullName, method := range fullNameToMethod {
+			items = append(items, newPackageItem(kind, fullName, method, method.NameLocation()))
+		}
+	}
+	return items, nil
+}
+
+func newPackageItem(
+	kind string,
+	name string,
+	descriptor protodesc.Descriptor,
+	location protodesc.Location,
+) *packageItem {
+	item := &Item{
+		Kind:     kind,
+		Name:     name,
+		Filename: descriptor.FilePath(),
+	}
+	if location != nil {
+		item.Line = location.StartLine()
+	}
+	return &packageItem{
+		Item: item,
+		pkg:  descriptor.Package(),
+	}
+}
+
+func packageHasPrefix(pkg string, packagePrefix string) bool {
+	if packagePrefix == "" {
+		return true
+	}
+	return pkg == packagePrefix || strings.HasPrefix(pkg, packagePrefix+".")
+}
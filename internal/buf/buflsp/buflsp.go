@@ -0,0 +1,49 @@
+// Package buflsp implements a Language Server Protocol server for Protobuf files.
+package buflsp
+
+import (
+	"context"
+	"io"
+
+	"github.com/bufbuild/buf/internal/buf/bufbuild"
+	"github.com/bufbuild/buf/internal/buf/bufcheck/buflint"
+	"github.com/bufbuild/buf/internal/buf/bufconfig"
+	"github.com/bufbuild/buf/internal/buf/bufformat"
+	"go.uber.org/zap"
+)
+
+// Server is a Language Server Protocol server.
+//
+// The workspace is the root directory given by the client on initialize, and
+// is built with the buf.yaml in the root directory, if any. Open documents are
+// read from the client instead of the file system, so that unsaved changes are
+// reflected.
+//
+// Diagnostics are published on open and save. Compile errors are published
+// as errors. If there are no compile errors, lint failures are published as
+// warnings. Go to definition, hover, and document symbols are also provided.
+type Server interface {
+	// Serve serves a single client over the reader and writer, for example
+	// stdin and stdout, until the client exits, the reader is closed, or the
+	// context is done.
+	//
+	// Returns a user error if the client exits without a shutdown request.
+	Serve(ctx context.Context, reader io.Reader, writer io.Writer) error
+}
+
+// NewServer returns a new Server.
+func NewServer(
+	logger *zap.Logger,
+	buildHandler bufbuild.Handler,
+	lintHandler buflint.Handler,
+	configProvider bufconfig.Provider,
+	formatter bufformat.Formatter,
+) Server {
+	return newServer(
+		logger,
+		buildHandler,
+		lintHandler,
+		configProvider,
+		formatter,
+	)
+}
@@ -0,0 +1,280 @@
+package buflsp_test
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/textproto"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/bufbuild/buf/internal/buf/bufbuild"
+	"github.com/bufbuild/buf/internal/buf/bufcheck/buflint"
+	"github.com/bufbuild/buf/internal/buf/bufconfig"
+	"github.com/bufbuild/buf/internal/buf/bufformat"
+	"github.com/bufbuild/buf/internal/buf/buflsp"
+	"github.com/bufbuild/buf/internal/pkg/bytepool"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestServer(t *testing.T) {
+	t.Parallel()
+	rootDirPath, err := filepath.Abs("testdata")
+	require.NoError(t, err)
+	pingURI := testPathToURI(filepath.Join(rootDirPath, "proto", "acme", "v1", "ping.proto"))
+	pingServiceURI := testPathToURI(filepath.Join(rootDirPath, "proto", "acme", "v1", "ping_service.proto"))
+	pingData, err := ioutil.ReadFile(filepath.Join(rootDirPath, "proto", "acme", "v1", "ping.proto"))
+	require.NoError(t, err)
+
+	messages := testRunServer(
+		t,
+		map[string]interface{}{
+			"id":     1,
+			"method": "initialize",
+			"params": map[string]interface{}{
+				"rootUri": testPathToURI(rootDirPath),
+			},
+		},
+		map[string]interface{}{
+			"method": "initialized",
+			"params": map[string]interface{}{},
+		},
+		map[string]interface{}{
+			"method": "textDocument/didOpen",
+			"params": map[string]interface{}{
+				"textDocument": map[string]interface{}{
+					"uri":        pingURI,
+					"languageId": "proto",
+					"version":    1,
+					"text":       string(pingData),
+				},
+			},
+		},
+		// the type of pong
+		testPositionRequest(2, "textDocument/definition", pingServiceURI, 8, 34),
+		// the map value type of pongs
+		testPositionRequest(3, "textDocument/hover", pingURI, 8, 15),
+		// the name of Ping
+		testPositionRequest(4, "textDocument/hover", pingURI, 5, 10),
+		// whitespace
+		testPositionRequest(5, "textDocument/definition", pingURI, 1, 0),
+		map[string]interface{}{
+			"id":     6,
+			"method": "textDocument/documentSymbol",
+			"params": map[string]interface{}{
+				"textDocument": map[string]interface{}{
+					"uri": pingURI,
+				},
+			},
+		},
+		map[string]interface{}{
+			"method": "textDocument/didChange",
+			"params": map[string]interface{}{
+				"textDocument": map[string]interface{}{
+					"uri":     pingURI,
+					"version": 2,
+				},
+				"contentChanges": []interface{}{
+					map[string]interface{}{
+						"text": "syntax = \"proto3\";\n\npackage acme.v1;\n\nmessage Ping {\n  Foo foo = 1;\n}\n",
+					},
+				},
+			},
+		},
+		map[string]interface{}{
+			"method": "textDocument/didSave",
+			"params": map[string]interface{}{
+				"textDocument": map[string]interface{}{
+					"uri": pingURI,
+				},
+			},
+		},
+		// the last successful build is used
+		testPositionRequest(7, "textDocument/definition", pingServiceURI, 8, 34),
+		map[string]interface{}{
+			"id":     8,
+			"method": "unknown",
+		},
+		map[string]interface{}{
+			"id":     9,
+			"method": "shutdown",
+		},
+		map[string]interface{}{
+			"method": "exit",
+		},
+	)
+
+	require.Len(t, messages, 12)
+	assert.Equal(t, true, messages[0]["result"].(map[string]interface{})["capabilities"].(map[string]interface{})["hoverProvider"])
+
+	// lint warnings for the opened file
+	assert.Equal(t, "textDocument/publishDiagnostics", messages[1]["method"])
+	params := messages[1]["params"].(map[string]interface{})
+	assert.Equal(t, pingURI, params["uri"])
+	diagnostics := params["diagnostics"].([]interface{})
+	require.Len(t, diagnostics, 1)
+	diagnostic := diagnostics[0].(map[string]interface{})
+	assert.Equal(t, float64(2), diagnostic["severity"])
+	assert.Equal(t, "FIELD_LOWER_SNAKE_CASE", diagnostic["code"])
+	assert.Equal(t, testRange(13, 9, 13, 14), diagnostic["range"])
+
+	assert.Equal(
+		t,
+		map[string]interface{}{
+			"uri":   pingURI,
+			"range": testRange(12, 0, 14, 1),
+		},
+		messages[2]["result"],
+	)
+	hover := messages[3]["result"].(map[string]interface{})
+	assert.Equal(t, "```proto\n// Pong is a pong.\nmessage Pong {\n  string Value = 1;\n}\n```", hover["contents"].(map[string]interface{})["value"])
+	assert.Equal(t, testRange(8, 2, 8, 19), hover["range"])
+	hover = messages[4]["result"].(map[string]interface{})
+	assert.Contains(t, hover["contents"].(map[string]interface{})["value"], "message Ping {")
+	assert.Nil(t, messages[5]["result"])
+
+	documentSymbols := messages[6]["result"].([]interface{})
+	require.Len(t, documentSymbols, 2)
+	documentSymbol := documentSymbols[0].(map[string]interface{})
+	assert.Equal(t, "Ping", documentSymbol["name"])
+	assert.Equal(t, testRange(5, 0, 9, 1), documentSymbol["range"])
+	assert.Equal(t, testRange(5, 8, 5, 12), documentSymbol["selectionRange"])
+	children := documentSymbol["children"].([]interface{})
+	require.Len(t, children, 3)
+	assert.Equal(t, "pong", children[1].(map[string]interface{})["name"])
+	assert.Equal(t, "acme.v1.Pong", children[1].(map[string]interface{})["detail"])
+	assert.Equal(t, "Pong", documentSymbols[1].(map[string]interface{})["name"])
+
+	// compile errors for the unsaved changes
+	params = messages[7]["params"].(map[string]interface{})
+	assert.Equal(t, pingURI, params["uri"])
+	diagnostics = params["diagnostics"].([]interface{})
+	require.Len(t, diagnostics, 1)
+	diagnostic = diagnostics[0].(map[string]interface{})
+	assert.Equal(t, float64(1), diagnostic["severity"])
+	assert.Equal(t, testRange(5, 2, 5, 2), diagnostic["range"])
+	params = messages[8]["params"].(map[string]interface{})
+	assert.Equal(t, pingServiceURI, params["uri"])
+	assert.Len(t, params["diagnostics"], 1)
+
+	assert.Equal(t, messages[2]["result"], messages[9]["result"])
+	assert.Equal(t, float64(-32601), messages[10]["error"].(map[string]interface{})["code"])
+	assert.Equal(t, float64(9), messages[11]["id"])
+	assert.Nil(t, messages[11]["result"])
+}
+
+func TestServerExitWithoutShutdown(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	err := testNewServer().Serve(
+		ctx,
+		bytes.NewReader(testEncode(t, map[string]interface{}{"method": "exit"})),
+		ioutil.Discard,
+	)
+	assert.Error(t, err)
+}
+
+func testRunServer(t *testing.T, messages ...map[string]interface{}) []map[string]interface{} {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	input := bytes.NewBuffer(nil)
+	for _, message := range messages {
+		_, err := input.Write(testEncode(t, message))
+		require.NoError(t, err)
+	}
+	output := bytes.NewBuffer(nil)
+	require.NoError(t, testNewServer().Serve(ctx, input, output))
+	return testDecode(t, output)
+}
+
+func testNewServer() buflsp.Server {
+	logger := zap.NewNop()
+	return buflsp.NewServer(
+		logger,
+		bufbuild.NewHandler(
+			logger,
+			bytepool.NewSegList(),
+			bufbuild.NewProvider(logger),
+			bufbuild.NewRunner(logger),
+		),
+		buflint.NewHandler(
+			logger,
+			buflint.NewRunner(logger),
+		),
+		bufconfig.NewProvider(logger),
+		bufformat.NewFormatter(logger),
+	)
+}
+
+func testPositionRequest(id int, method string, uri string, line int, character int) map[string]interface{} {
+	return map[string]interface{}{
+		"id":     id,
+		"method": method,
+		"params": map[string]interface{}{
+			"textDocument": map[string]interface{}{
+				"uri": uri,
+			},
+			"position": map[string]interface{}{
+				"line":      line,
+				"character": character,
+			},
+		},
+	}
+}
+
+func testRange(startLine int, startCharacter int, endLine int, endCharacter int) map[string]interface{} {
+	return map[string]interface{}{
+		"start": map[string]interface{}{
+			"line":      float64(startLine),
+			"character": float64(startCharacter),
+		},
+		"end": map[string]interface{}{
+			"line":      float64(endLine),
+			"character": float64(endCharacter),
+		},
+	}
+}
+
+func testEncode(t *testing.T, message map[string]interface{}) []byte {
+	message["jsonrpc"] = "2.0"
+	data, err := json.Marshal(message)
+	require.NoError(t, err)
+	return append([]byte(fmt.Sprintf("Content-Length: %d\r\n\r\n", len(data))), data...)
+}
+
+func testDecode(t *testing.T, reader io.Reader) []map[string]interface{} {
+	bufioReader := bufio.NewReader(reader)
+	var messages []map[string]interface{}
+	for {
+		header, err := textproto.NewReader(bufioReader).ReadMIMEHeader()
+		if err == io.EOF {
+			return messages
+		}
+		require.NoError(t, err)
+		contentLength, err := strconv.Atoi(header.Get("Content-Length"))
+		require.NoError(t, err)
+		data := make([]byte, contentLength)
+		_, err = io.ReadFull(bufioReader, data)
+		require.NoError(t, err)
+		message := make(map[string]interface{})
+		require.NoError(t, json.Unmarshal(data, &message))
+		messages = append(messages, message)
+	}
+}
+
+func testPathToURI(path string) string {
+	return (&url.URL{
+		Scheme: "file",
+		Path:   filepath.ToSlash(path),
+	}).String()
+}
@@ -0,0 +1,85 @@
+package buflsp
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const contentLengthHeader = "Content-Length"
+
+// conn reads and writes JSON-RPC messages with the base protocol of LSP,
+// ie every message is prefixed with a Content-Length header.
+type conn struct {
+	reader *bufio.Reader
+	writer io.Writer
+	lock   sync.Mutex
+}
+
+func newConn(reader io.Reader, writer io.Writer) *conn {
+	return &conn{
+		reader: bufio.NewReader(reader),
+		writer: writer,
+	}
+}
+
+// read reads the data of the next message.
+//
+// Returns io.EOF if there are no more messages.
+func (c *conn) read() ([]byte, error) {
+	contentLength := -1
+	for {
+		line, err := c.reader.ReadString('\n')
+		if err != nil {
+			if err == io.EOF && line != "" {
+				return nil, io.ErrUnexpectedEOF
+			}
+			return nil, err
+		}
+		line = strings.TrimSpace(line)
+		if line == "" {
+			// the empty line ends the headers
+			break
+		}
+		split := strings.SplitN(line, ":", 2)
+		if len(split) != 2 {
+			return nil, fmt.Errorf("invalid header: %q", line)
+		}
+		if strings.EqualFold(strings.TrimSpace(split[0]), contentLengthHeader) {
+			contentLength, err = strconv.Atoi(strings.TrimSpace(split[1]))
+			if err != nil || contentLength < 0 {
+				return nil, fmt.Errorf("invalid %s: %q", contentLengthHeader, split[1])
+			}
+		}
+	}
+	if contentLength < 0 {
+		return nil, fmt.Errorf("no %s header", contentLengthHeader)
+	}
+	data := make([]byte, contentLength)
+	if _, err := io.ReadFull(c.reader, data); err != nil {
+		if err == io.EOF {
+			return nil, io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	return data, nil
+}
+
+// write writes the value as a message.
+func (c *conn) write(value interface{}) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if _, err := fmt.Fprintf(c.writer, "%s: %d\r\n\r\n", contentLengthHeader, len(data)); err != nil {
+		return err
+	}
+	_, err = c.writer.Write(data)
+	return err
+}
@@ -0,0 +1,103 @@
+package buflsp
+
+import (
+	"bytes"
+	"unicode/utf8"
+)
+
+// compilerTabWidth is the width of tab stops when the compiler counts columns.
+const compilerTabWidth = 8
+
+// lineMapper converts between the columns of the compiler and the characters of LSP positions.
+//
+// The compiler counts columns in runes and advances tabs to the next tab stop, while
+// LSP positions count UTF-16 code units. Lines are not changed.
+//
+// Positions on lines that are not within the content are not converted.
+type lineMapper struct {
+	lines [][]byte
+}
+
+func newLineMapper(data []byte) *lineMapper {
+	lines := bytes.Split(data, []byte("\n"))
+	for i, line := range lines {
+		lines[i] = bytes.TrimSuffix(line, []byte("\r"))
+	}
+	return &lineMapper{
+		lines: lines,
+	}
+}
+
+// toCharacters converts a range of zero-based compiler columns to a range of characters.
+func (l *lineMapper) toCharacters(columnRange *lspRange) *lspRange {
+	return &lspRange{
+		Start: l.toCharacter(columnRange.Start),
+		End:   l.toCharacter(columnRange.End),
+	}
+}
+
+// toCharacter converts a position of a zero-based compiler column to a position of a character.
+func (l *lineMapper) toCharacter(columnPosition *position) *position {
+	if columnPosition.Line < 0 || columnPosition.Line >= len(l.lines) {
+		return columnPosition
+	}
+	line := l.lines[columnPosition.Line]
+	column := 0
+	character := 0
+	for len(line) > 0 && column < columnPosition.Character {
+		r, size := utf8.DecodeRune(line)
+		line = line[size:]
+		column = advanceColumn(column, r)
+		character += runeLenUTF16(r)
+	}
+	if column < columnPosition.Character {
+		// past the end of the line
+		character += columnPosition.Character - column
+	}
+	return &position{
+		Line:      columnPosition.Line,
+		Character: character,
+	}
+}
+
+// toColumn converts a position of a character to a position of a zero-based compiler column.
+func (l *lineMapper) toColumn(characterPosition *position) *position {
+	if characterPosition.Line < 0 || characterPosition.Line >= len(l.lines) {
+		return characterPosition
+	}
+	line := l.lines[characterPosition.Line]
+	column := 0
+	character := 0
+	for len(line) > 0 && character < characterPosition.Character {
+		r, size := utf8.DecodeRune(line)
+		line = line[size:]
+		column = advanceColumn(column, r)
+		character += runeLenUTF16(r)
+	}
+	if character < characterPosition.Character {
+		// past the end of the line
+		column += characterPosition.Character - character
+	}
+	return &position{
+		Line:      characterPosition.Line,
+		Character: column,
+	}
+}
+
+func advanceColumn(column int, r rune) int {
+	if r == '\t' {
+		return column + compilerTabWidth - column%compilerTabWidth
+	}
+	return column + 1
+}
+
+// runeLenUTF16 returns the number of UTF-16 code units of the rune.
+//
+// Invalid UTF-8 is decoded as utf8.RuneError, which is a single code unit.
+func runeLenUTF16(r rune) int {
+	if r > 0xFFFF {
+		// surrogate pair
+		return 2
+	}
+	return 1
+}
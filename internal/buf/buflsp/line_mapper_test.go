@@ -0,0 +1,70 @@
+package buflsp
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLineMapper(t *testing.T) {
+	t.Parallel()
+	// é is two bytes and one code unit, 😀 is four bytes and two code units
+	lineMapper := newLineMapper([]byte("message Foo {\r\n  // é😀 Bar\n\tBar bar = 1;\n}\n"))
+	testCases := []struct {
+		description string
+		column      *position
+		character   *position
+	}{
+		{
+			description: "ascii",
+			column:      &position{Line: 0, Character: 8},
+			character:   &position{Line: 0, Character: 8},
+		},
+		{
+			description: "after multi-byte runes",
+			column:      &position{Line: 1, Character: 8},
+			character:   &position{Line: 1, Character: 9},
+		},
+		{
+			description: "after tab",
+			column:      &position{Line: 2, Character: 8},
+			character:   &position{Line: 2, Character: 1},
+		},
+		{
+			description: "past the end of the line",
+			column:      &position{Line: 0, Character: 15},
+			character:   &position{Line: 0, Character: 15},
+		},
+		{
+			description: "line not within the content",
+			column:      &position{Line: 10, Character: 3},
+			character:   &position{Line: 10, Character: 3},
+		},
+	}
+	for _, testCase := range testCases {
+		testCase := testCase
+		t.Run(testCase.description, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, testCase.character, lineMapper.toCharacter(testCase.column))
+			assert.Equal(t, testCase.column, lineMapper.toColumn(testCase.character))
+		})
+	}
+}
+
+func TestURIToPath(t *testing.T) {
+	t.Parallel()
+	path, err := uriToPath("file:///foo/bar.proto")
+	assert.NoError(t, err)
+	assert.Equal(t, "/foo/bar.proto", filepath.ToSlash(path))
+	path, err = uriToPath("file:///C:/foo/bar.proto")
+	assert.NoError(t, err)
+	assert.Equal(t, "C:/foo/bar.proto", filepath.ToSlash(path))
+	path, err = uriToPath("file:///c%3A/foo/bar.proto")
+	assert.NoError(t, err)
+	assert.Equal(t, "c:/foo/bar.proto", filepath.ToSlash(path))
+	_, err = uriToPath("http://foo/bar.proto")
+	assert.Error(t, err)
+	assert.Equal(t, "file:///foo/bar.proto", pathToURI(filepath.FromSlash("/foo/bar.proto")))
+	assert.Equal(t, "file:///C:/foo/bar.proto", pathToURI(filepath.FromSlash("C:/foo/bar.proto")))
+}
@@ -0,0 +1,197 @@
+package buflsp
+
+import "encoding/json"
+
+// This is the subset of the Language Server Protocol that is implemented.
+//
+// https://microsoft.github.io/language-server-protocol/specification
+
+const (
+	jsonrpcVersion = "2.0"
+
+	errorCodeParseError     = -32700
+	errorCodeInvalidRequest = -32600
+	errorCodeMethodNotFound = -32601
+	errorCodeInvalidParams  = -32602
+	errorCodeInternalError  = -32603
+
+	errorCodeServerNotInitialized = -32002
+
+	textDocumentSyncKindFull = 1
+
+	diagnosticSeverityError   = 1
+	diagnosticSeverityWarning = 2
+
+	symbolKindMethod     = 6
+	symbolKindField      = 8
+	symbolKindEnum       = 10
+	symbolKindInterface  = 11
+	symbolKindEnumMember = 22
+	symbolKindStruct     = 23
+
+	markupKindMarkdown = "markdown"
+
+	diagnosticSource = "buf"
+)
+
+// message is a request, a response, or a notification.
+type message struct {
+	JSONRPC string           `json:"jsonrpc"`
+	ID      *json.RawMessage `json:"id,omitempty"`
+	Method  string           `json:"method,omitempty"`
+	Params  json.RawMessage  `json:"params,omitempty"`
+}
+
+func (m *message) isNotification() bool {
+	return m.ID == nil
+}
+
+type response struct {
+	JSONRPC string           `json:"jsonrpc"`
+	ID      *json.RawMessage `json:"id"`
+	Result  interface{}      `json:"result"`
+}
+
+type errorResponse struct {
+	JSONRPC string           `json:"jsonrpc"`
+	ID      *json.RawMessage `json:"id"`
+	Error   *responseError   `json:"error"`
+}
+
+type responseError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (r *responseError) Error() string {
+	return r.Message
+}
+
+type notification struct {
+	JSONRPC string      `json:"jsonrpc"`
+	Method  string      `json:"method"`
+	Params  interface{} `json:"params"`
+}
+
+type initializeParams struct {
+	RootURI  string `json:"rootUri,omitempty"`
+	RootPath string `json:"rootPath,omitempty"`
+}
+
+type initializeResult struct {
+	Capabilities *serverCapabilities `json:"capabilities"`
+}
+
+type serverCapabilities struct {
+	TextDocumentSync       *textDocumentSyncOptions `json:"textDocumentSync,omitempty"`
+	DefinitionProvider     bool                     `json:"definitionProvider,omitempty"`
+	HoverProvider          bool                     `json:"hoverProvider,omitempty"`
+	DocumentSymbolProvider bool                     `json:"documentSymbolProvider,omitempty"`
+}
+
+type textDocumentSyncOptions struct {
+	OpenClose bool         `json:"openClose,omitempty"`
+	Change    int          `json:"change,omitempty"`
+	Save      *saveOptions `json:"save,omitempty"`
+}
+
+type saveOptions struct {
+	IncludeText bool `json:"includeText,omitempty"`
+}
+
+type textDocumentIdentifier struct {
+	URI string `json:"uri"`
+}
+
+type textDocumentItem struct {
+	URI  string `json:"uri"`
+	Text string `json:"text"`
+}
+
+type didOpenTextDocumentParams struct {
+	TextDocument *textDocumentItem `json:"textDocument"`
+}
+
+type didChangeTextDocumentParams struct {
+	TextDocument   *textDocumentIdentifier           `json:"textDocument"`
+	ContentChanges []*textDocumentContentChangeEvent `json:"contentChanges"`
+}
+
+type textDocumentContentChangeEvent struct {
+	Text string `json:"text"`
+}
+
+type didSaveTextDocumentParams struct {
+	TextDocument *textDocumentIdentifier `json:"textDocument"`
+	Text         *string                 `json:"text,omitempty"`
+}
+
+type didCloseTextDocumentParams struct {
+	TextDocument *textDocumentIdentifier `json:"textDocument"`
+}
+
+type textDocumentPositionParams struct {
+	TextDocument *textDocumentIdentifier `json:"textDocument"`
+	Position     *position               `json:"position"`
+}
+
+type documentSymbolParams struct {
+	TextDocument *textDocumentIdentifier `json:"textDocument"`
+}
+
+// position is zero-based.
+type position struct {
+	Line      int `json:"line"`
+	Character int `json:"character"`
+}
+
+// lessOrEqual returns true if p is before or at other.
+func (p *position) lessOrEqual(other *position) bool {
+	return p.Line < other.Line || (p.Line == other.Line && p.Character <= other.Character)
+}
+
+type lspRange struct {
+	Start *position `json:"start"`
+	End   *position `json:"end"`
+}
+
+func (r *lspRange) contains(position *position) bool {
+	return r.Start.lessOrEqual(position) && position.lessOrEqual(r.End)
+}
+
+type location struct {
+	URI   string    `json:"uri"`
+	Range *lspRange `json:"range"`
+}
+
+type diagnostic struct {
+	Range    *lspRange `json:"range"`
+	Severity int       `json:"severity,omitempty"`
+	Code     string    `json:"code,omitempty"`
+	Source   string    `json:"source,omitempty"`
+	Message  string    `json:"message"`
+}
+
+type publishDiagnosticsParams struct {
+	URI         string        `json:"uri"`
+	Diagnostics []*diagnostic `json:"diagnostics"`
+}
+
+type hover struct {
+	Contents *markupContent `json:"contents"`
+	Range    *lspRange      `json:"range,omitempty"`
+}
+
+type markupContent struct {
+	Kind  string `json:"kind"`
+	Value string `json:"value"`
+}
+
+type documentSymbol struct {
+	Name           string            `json:"name"`
+	Detail         string            `json:"detail,omitempty"`
+	Kind           int               `json:"kind"`
+	Range          *lspRange         `json:"range"`
+	SelectionRange *lspRange         `json:"selectionRange"`
+	Children       []*documentSymbol `json:"children,omitempty"`
+}
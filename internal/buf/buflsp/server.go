@@ -0,0 +1,778 @@
+package buflsp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/bufbuild/buf/internal/buf/bufbuild"
+	"github.com/bufbuild/buf/internal/buf/bufcheck/buflint"
+	"github.com/bufbuild/buf/internal/buf/bufconfig"
+	"github.com/bufbuild/buf/internal/buf/bufformat"
+	"github.com/bufbuild/buf/internal/buf/bufpb"
+	"github.com/bufbuild/buf/internal/pkg/analysis"
+	"github.com/bufbuild/buf/internal/pkg/errs"
+	"github.com/bufbuild/buf/internal/pkg/protodesc"
+	"github.com/bufbuild/buf/internal/pkg/storage/storageos"
+	"github.com/bufbuild/buf/internal/pkg/storage/storagepath"
+	"github.com/bufbuild/buf/internal/pkg/storage/storageutil"
+	"github.com/jhump/protoreflect/desc"
+	"go.uber.org/zap"
+)
+
+type server struct {
+	logger         *zap.Logger
+	buildHandler   bufbuild.Handler
+	lintHandler    buflint.Handler
+	configProvider bufconfig.Provider
+	formatter      bufformat.Formatter
+}
+
+func newServer(
+	logger *zap.Logger,
+	buildHandler bufbuild.Handler,
+	lintHandler buflint.Handler,
+	configProvider bufconfig.Provider,
+	formatter bufformat.Formatter,
+) *server {
+	return &server{
+		logger:         logger.Named("buflsp"),
+		buildHandler:   buildHandler,
+		lintHandler:    lintHandler,
+		configProvider: configProvider,
+		formatter:      formatter,
+	}
+}
+
+func (s *server) Serve(ctx context.Context, reader io.Reader, writer io.Writer) error {
+	return newSession(s, newConn(reader, writer)).run(ctx)
+}
+
+// session is the state of a single client.
+//
+// All methods are called from the goroutine of run.
+type session struct {
+	*server
+
+	conn *conn
+	// rootDirPath is the absolute path of the workspace, set on initialize.
+	rootDirPath string
+	// pathToData is the content of the open documents, keyed by the path
+	// relative to rootDirPath.
+	pathToData map[string][]byte
+	// workspace is the last successful build, or nil if there is none.
+	workspace *workspace
+	// dirty is true if the open documents changed since the last build.
+	dirty bool
+	// publishedPaths are the paths that diagnostics were last published for.
+	publishedPaths map[string]struct{}
+	shutdown       bool
+}
+
+func newSession(server *server, conn *conn) *session {
+	return &session{
+		server:         server,
+		conn:           conn,
+		pathToData:     make(map[string][]byte),
+		dirty:          true,
+		publishedPaths: make(map[string]struct{}),
+	}
+}
+
+func (s *session) run(ctx context.Context) error {
+	dataC := make(chan []byte)
+	errC := make(chan error, 1)
+	go func() {
+		for {
+			data, err := s.conn.read()
+			if err != nil {
+				errC <- err
+				return
+			}
+			select {
+			case dataC <- data:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case err := <-errC:
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		case data := <-dataC:
+			exit, err := s.handle(ctx, data)
+			if err != nil {
+				return err
+			}
+			if exit {
+				if !s.shutdown {
+					return errs.NewInvalidArgument("exit notification received before shutdown request")
+				}
+				return nil
+			}
+		}
+	}
+}
+
+// handle handles a single message.
+//
+// Returns true if the client requested to exit. Errors are only returned
+// if the connection failed.
+func (s *session) handle(ctx context.Context, data []byte) (bool, error) {
+	message := &message{}
+	if err := json.Unmarshal(data, message); err != nil {
+		return false, s.conn.write(
+			&errorResponse{
+				JSONRPC: jsonrpcVersion,
+				Error: &responseError{
+					Code:    errorCodeParseError,
+					Message: err.Error(),
+				},
+			},
+		)
+	}
+	s.logger.Debug("handle", zap.String("method", message.Method), zap.Bool("notification", message.isNotification()))
+	if message.Method == "exit" {
+		return true, nil
+	}
+	result, err := s.dispatch(ctx, message)
+	if message.isNotification() {
+		if err != nil {
+			s.logger.Warn("notification_failed", zap.String("method", message.Method), zap.Error(err))
+		}
+		return false, nil
+	}
+	if err != nil {
+		responseErr, ok := err.(*responseError)
+		if !ok {
+			responseErr = &responseError{
+				Code:    errorCodeInternalError,
+				Message: err.Error(),
+			}
+		}
+		return false, s.conn.write(
+			&errorResponse{
+				JSONRPC: jsonrpcVersion,
+				ID:      message.ID,
+				Error:   responseErr,
+			},
+		)
+	}
+	return false, s.conn.write(
+		&response{
+			JSONRPC: jsonrpcVersion,
+			ID:      message.ID,
+			Result:  result,
+		},
+	)
+}
+
+func (s *session) dispatch(ctx context.Context, message *message) (interface{}, error) {
+	// shutdown is allowed so that clients can exit without initializing
+	if s.rootDirPath == "" && !message.isNotification() && message.Method != "initialize" && message.Method != "shutdown" {
+		return nil, &responseError{
+			Code:    errorCodeServerNotInitialized,
+			Message: "not initialized",
+		}
+	}
+	switch message.Method {
+	case "initialize":
+		params := &initializeParams{}
+		if err := unmarshalParams(message, params); err != nil {
+			return nil, err
+		}
+		return s.initialize(params)
+	case "shutdown":
+		s.shutdown = true
+		return nil, nil
+	case "textDocument/didOpen":
+		params := &didOpenTextDocumentParams{}
+		if err := unmarshalParams(message, params); err != nil {
+			return nil, err
+		}
+		return nil, s.didOpen(ctx, params)
+	case "textDocument/didChange":
+		params := &didChangeTextDocumentParams{}
+		if err := unmarshalParams(message, params); err != nil {
+			return nil, err
+		}
+		return nil, s.didChange(params)
+	case "textDocument/didSave":
+		params := &didSaveTextDocumentParams{}
+		if err := unmarshalParams(message, params); err != nil {
+			return nil, err
+		}
+		return nil, s.didSave(ctx, params)
+	case "textDocument/didClose":
+		params := &didCloseTextDocumentParams{}
+		if err := unmarshalParams(message, params); err != nil {
+			return nil, err
+		}
+		return nil, s.didClose(params)
+	case "textDocument/definition":
+		params := &textDocumentPositionParams{}
+		if err := unmarshalParams(message, params); err != nil {
+			return nil, err
+		}
+		return s.definition(ctx, params)
+	case "textDocument/hover":
+		params := &textDocumentPositionParams{}
+		if err := unmarshalParams(message, params); err != nil {
+			return nil, err
+		}
+		return s.hover(ctx, params)
+	case "textDocument/documentSymbol":
+		params := &documentSymbolParams{}
+		if err := unmarshalParams(message, params); err != nil {
+			return nil, err
+		}
+		return s.documentSymbol(ctx, params)
+	default:
+		// notifications that are not handled, such as initialized, are ignored
+		if message.isNotification() {
+			return nil, nil
+		}
+		return nil, &responseError{
+			Code:    errorCodeMethodNotFound,
+			Message: fmt.Sprintf("method not found: %q", message.Method),
+		}
+	}
+}
+
+func (s *session) initialize(params *initializeParams) (interface{}, error) {
+	rootDirPath := params.RootPath
+	if params.RootURI != "" {
+		var err error
+		rootDirPath, err = uriToPath(params.RootURI)
+		if err != nil {
+			return nil, &responseError{
+				Code:    errorCodeInvalidParams,
+				Message: err.Error(),
+			}
+		}
+	}
+	if rootDirPath == "" {
+		return nil, &responseError{
+			Code:    errorCodeInvalidParams,
+			Message: "a root directory is required",
+		}
+	}
+	rootDirPath, err := filepath.Abs(rootDirPath)
+	if err != nil {
+		return nil, err
+	}
+	s.rootDirPath = rootDirPath
+	s.logger.Debug("initialize", zap.String("root_dir_path", rootDirPath))
+	return &initializeResult{
+		Capabilities: &serverCapabilities{
+			TextDocumentSync: &textDocumentSyncOptions{
+				OpenClose: true,
+				Change:    textDocumentSyncKindFull,
+				Save: &saveOptions{
+					IncludeText: true,
+				},
+			},
+			DefinitionProvider:     true,
+			HoverProvider:          true,
+			DocumentSymbolProvider: true,
+		},
+	}, nil
+}
+
+func (s *session) didOpen(ctx context.Context, params *didOpenTextDocumentParams) error {
+	path, ok, err := s.uriToBucketPath(params.TextDocument.URI)
+	if err != nil || !ok {
+		return err
+	}
+	s.pathToData[path] = []byte(params.TextDocument.Text)
+	s.dirty = true
+	return s.diagnose(ctx)
+}
+
+func (s *session) didChange(params *didChangeTextDocumentParams) error {
+	path, ok, err := s.uriToBucketPath(params.TextDocument.URI)
+	if err != nil || !ok {
+		return err
+	}
+	// we only support full syncs, so the last change is the whole document
+	if len(params.ContentChanges) > 0 {
+		s.pathToData[path] = []byte(params.ContentChanges[len(params.ContentChanges)-1].Text)
+		s.dirty = true
+	}
+	return nil
+}
+
+func (s *session) didSave(ctx context.Context, params *didSaveTextDocumentParams) error {
+	path, ok, err := s.uriToBucketPath(params.TextDocument.URI)
+	if err != nil || !ok {
+		return err
+	}
+	if params.Text != nil {
+		s.pathToData[path] = []byte(*params.Text)
+	}
+	s.dirty = true
+	return s.diagnose(ctx)
+}
+
+func (s *session) didClose(params *didCloseTextDocumentParams) error {
+	path, ok, err := s.uriToBucketPath(params.TextDocument.URI)
+	if err != nil || !ok {
+		return err
+	}
+	// the file system is the source of truth once the document is closed
+	delete(s.pathToData, path)
+	s.dirty = true
+	return nil
+}
+
+func (s *session) definition(ctx context.Context, params *textDocumentPositionParams) (interface{}, error) {
+	workspace, filePath, file, err := s.getWorkspaceFile(ctx, params.TextDocument.URI)
+	if err != nil || file == nil {
+		return nil, err
+	}
+	reference := findReference(file, s.getLineMapper(filePath).toColumn(params.Position))
+	if reference == nil {
+		return nil, nil
+	}
+	descriptor := workspace.findDescriptor(reference.name)
+	if descriptor == nil {
+		return nil, nil
+	}
+	sourceInfo := descriptor.GetSourceInfo()
+	if sourceInfo == nil {
+		return nil, nil
+	}
+	path, ok := workspace.rootToPath[descriptor.GetFile().GetName()]
+	if !ok {
+		// files that are not within the workspace, such as the well-known types
+		return nil, nil
+	}
+	return &location{
+		URI:   pathToURI(filepath.Join(s.rootDirPath, filepath.FromSlash(path))),
+		Range: s.getLineMapper(path).toCharacters(spanToRange(sourceInfo.GetSpan())),
+	}, nil
+}
+
+func (s *session) hover(ctx context.Context, params *textDocumentPositionParams) (interface{}, error) {
+	workspace, filePath, file, err := s.getWorkspaceFile(ctx, params.TextDocument.URI)
+	if err != nil || file == nil {
+		return nil, err
+	}
+	lineMapper := s.getLineMapper(filePath)
+	reference := findReference(file, lineMapper.toColumn(params.Position))
+	if reference == nil {
+		return nil, nil
+	}
+	descriptor := workspace.findDescriptor(reference.name)
+	if descriptor == nil {
+		return nil, nil
+	}
+	source, err := s.formatter.FormatDescriptor(descriptor)
+	if err != nil {
+		return nil, err
+	}
+	return &hover{
+		Contents: &markupContent{
+			Kind:  markupKindMarkdown,
+			Value: "```proto\n" + strings.TrimSuffix(source, "\n") + "\n```",
+		},
+		Range: lineMapper.toCharacters(locationToRange(reference.location)),
+	}, nil
+}
+
+func (s *session) documentSymbol(ctx context.Context, params *documentSymbolParams) (interface{}, error) {
+	_, filePath, file, err := s.getWorkspaceFile(ctx, params.TextDocument.URI)
+	if err != nil || file == nil {
+		return nil, err
+	}
+	documentSymbols := getDocumentSymbols(file)
+	if documentSymbols == nil {
+		// an empty result instead of null
+		documentSymbols = []*documentSymbol{}
+	}
+	documentSymbolsToCharacters(s.getLineMapper(filePath), documentSymbols)
+	return documentSymbols, nil
+}
+
+// diagnose builds and lints the workspace, and publishes the diagnostics.
+func (s *session) diagnose(ctx context.Context) error {
+	annotations, err := s.build(ctx)
+	if err != nil {
+		return err
+	}
+	severity := diagnosticSeverityError
+	if len(annotations) == 0 {
+		imageWithoutImports, err := s.workspace.image.WithoutImports()
+		if err != nil {
+			return err
+		}
+		annotations, err = s.lintHandler.LintCheck(ctx, s.workspace.config.Lint, imageWithoutImports)
+		if err != nil {
+			return err
+		}
+		if err := bufbuild.FixAnnotationFilenames(s.workspace.resolver, annotations); err != nil {
+			return err
+		}
+		severity = diagnosticSeverityWarning
+	}
+	return s.publishDiagnostics(annotations, severity)
+}
+
+// publishDiagnostics publishes the annotations as diagnostics.
+//
+// Diagnostics of paths that no longer have annotations are cleared.
+func (s *session) publishDiagnostics(annotations []*analysis.Annotation, severity int) error {
+	pathToDiagnostics := make(map[string][]*diagnostic)
+	for path := range s.publishedPaths {
+		pathToDiagnostics[path] = []*diagnostic{}
+	}
+	publishedPaths := make(map[string]struct{})
+	pathToLineMapper := make(map[string]*lineMapper)
+	for _, annotation := range annotations {
+		if annotation.Filename == "" {
+			s.logger.Warn("annotation_without_filename", zap.String("message", annotation.Message))
+			continue
+		}
+		lineMapper, ok := pathToLineMapper[annotation.Filename]
+		if !ok {
+			lineMapper = s.getLineMapper(annotation.Filename)
+			pathToLineMapper[annotation.Filename] = lineMapper
+		}
+		diagnostic := annotationToDiagnostic(annotation, severity)
+		diagnostic.Range = lineMapper.toCharacters(diagnostic.Range)
+		pathToDiagnostics[annotation.Filename] = append(
+			pathToDiagnostics[annotation.Filename],
+			diagnostic,
+		)
+		publishedPaths[annotation.Filename] = struct{}{}
+	}
+	paths := make([]string, 0, len(pathToDiagnostics))
+	for path := range pathToDiagnostics {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		if err := s.conn.write(
+			&notification{
+				JSONRPC: jsonrpcVersion,
+				Method:  "textDocument/publishDiagnostics",
+				Params: &publishDiagnosticsParams{
+					URI:         pathToURI(filepath.Join(s.rootDirPath, filepath.FromSlash(path))),
+					Diagnostics: pathToDiagnostics[path],
+				},
+			},
+		); err != nil {
+			return err
+		}
+	}
+	s.publishedPaths = publishedPaths
+	return nil
+}
+
+// build builds the workspace if the open documents changed since the last build.
+//
+// If the build succeeds, the workspace is updated. If there are compile errors, they
+// are returned, and the previous workspace is kept.
+func (s *session) build(ctx context.Context) (_ []*analysis.Annotation, retErr error) {
+	if !s.dirty && s.workspace != nil {
+		return nil, nil
+	}
+	delegate, err := storageos.NewReadBucket(s.rootDirPath)
+	if err != nil {
+		return nil, err
+	}
+	bucket := storageutil.NewOverlayReadBucket(delegate, s.pathToData)
+	defer func() {
+		retErr = errs.Append(retErr, bucket.Close())
+	}()
+	config, err := s.configProvider.GetConfigForBucket(ctx, bucket)
+	if err != nil {
+		return nil, err
+	}
+	image, resolver, annotations, err := s.buildHandler.BuildImage(
+		ctx,
+		bucket,
+		config.Build,
+		nil,   // we build all files
+		false, // this is ignored since we do not specify specific files
+		true,  // imports are needed to resolve definitions
+		true,  // source info is needed for locations
+	)
+	if err != nil {
+		return nil, err
+	}
+	s.dirty = false
+	if len(annotations) > 0 {
+		return annotations, nil
+	}
+	workspace, err := newWorkspace(image, resolver, config)
+	if err != nil {
+		return nil, err
+	}
+	s.workspace = workspace
+	return nil, nil
+}
+
+// getWorkspaceFile returns the current workspace, and the path relative to the
+// workspace and the File for the uri.
+//
+// If the open documents cannot be built, the last successful build is used.
+// Returns a nil File if there is no build or the uri is not within the workspace.
+func (s *session) getWorkspaceFile(ctx context.Context, uri string) (*workspace, string, protodesc.File, error) {
+	path, ok, err := s.uriToBucketPath(uri)
+	if err != nil || !ok {
+		return nil, "", nil, err
+	}
+	if _, err := s.build(ctx); err != nil {
+		return nil, "", nil, err
+	}
+	if s.workspace == nil {
+		return nil, "", nil, nil
+	}
+	file, ok := s.workspace.pathToFile[path]
+	if !ok {
+		return nil, "", nil, nil
+	}
+	return s.workspace, path, file, nil
+}
+
+// getLineMapper returns the lineMapper for the path relative to the workspace.
+//
+// The content of open documents is used if available. If the file cannot be read,
+// the returned lineMapper does not convert positions.
+func (s *session) getLineMapper(path string) *lineMapper {
+	data, ok := s.pathToData[path]
+	if !ok {
+		var err error
+		data, err = ioutil.ReadFile(filepath.Join(s.rootDirPath, filepath.FromSlash(path)))
+		if err != nil {
+			s.logger.Debug("line_mapper_read_failed", zap.String("path", path), zap.Error(err))
+			return newLineMapper(nil)
+		}
+	}
+	return newLineMapper(data)
+}
+
+// uriToBucketPath returns the path relative to the workspace of the uri.
+//
+// Returns false if the uri is not within the workspace.
+func (s *session) uriToBucketPath(uri string) (string, bool, error) {
+	path, err := uriToPath(uri)
+	if err != nil {
+		return "", false, &responseError{
+			Code:    errorCodeInvalidParams,
+			Message: err.Error(),
+		}
+	}
+	relPath, err := filepath.Rel(s.rootDirPath, path)
+	if err != nil {
+		return "", false, nil
+	}
+	bucketPath, err := storagepath.NormalizeAndValidate(relPath)
+	if err != nil {
+		// this is outside of the workspace
+		return "", false, nil
+	}
+	return bucketPath, true, nil
+}
+
+// workspace is a successful build of the workspace.
+type workspace struct {
+	image    bufpb.Image
+	resolver bufbuild.ProtoFilePathResolver
+	config   *bufconfig.Config
+	// pathToFile is keyed by the path relative to the workspace, and only
+	// contains files within the workspace.
+	pathToFile map[string]protodesc.File
+	// rootToPath maps the file names of the Image to paths relative to the workspace.
+	rootToPath map[string]string
+	// fileDescriptors are in Image order.
+	fileDescriptors []*desc.FileDescriptor
+}
+
+func newWorkspace(
+	image bufpb.Image,
+	resolver bufbuild.ProtoFilePathResolver,
+	config *bufconfig.Config,
+) (*workspace, error) {
+	descFileDescriptors, err := bufpb.ImageToDescFileDescriptors(image)
+	if err != nil {
+		return nil, err
+	}
+	workspace := &workspace{
+		image:      image,
+		resolver:   resolver,
+		config:     config,
+		pathToFile: make(map[string]protodesc.File),
+		rootToPath: make(map[string]string),
+	}
+	for _, fileDescriptor := range image.GetFile() {
+		descFileDescriptor, ok := descFileDescriptors[fileDescriptor.GetName()]
+		if !ok {
+			return nil, errs.NewInternalf("no FileDescriptor for %q", fileDescriptor.GetName())
+		}
+		workspace.fileDescriptors = append(workspace.fileDescriptors, descFileDescriptor)
+		path, err := resolver.GetFilePath(fileDescriptor.GetName())
+		if err != nil {
+			if err == bufbuild.ErrFilePathUnknown {
+				// imports that are not within the workspace
+				continue
+			}
+			return nil, err
+		}
+		file, err := protodesc.NewFile(fileDescriptor)
+		if err != nil {
+			return nil, err
+		}
+		workspace.pathToFile[path] = file
+		workspace.rootToPath[fileDescriptor.GetName()] = path
+	}
+	return workspace, nil
+}
+
+// findDescriptor returns the descriptor with the fully-qualified name, or nil.
+//
+// Map entries resolve to the type of their values, as they are not declared.
+func (w *workspace) findDescriptor(name string) desc.Descriptor {
+	for _, fileDescriptor := range w.fileDescriptors {
+		descriptor := fileDescriptor.FindSymbol(name)
+		if descriptor == nil {
+			continue
+		}
+		if messageDescriptor, ok := descriptor.(*desc.MessageDescriptor); ok && messageDescriptor.IsMapEntry() {
+			valueFieldDescriptor := messageDescriptor.FindFieldByNumber(2)
+			if valueMessageDescriptor := valueFieldDescriptor.GetMessageType(); valueMessageDescriptor != nil {
+				return valueMessageDescriptor
+			}
+			if valueEnumDescriptor := valueFieldDescriptor.GetEnumType(); valueEnumDescriptor != nil {
+				return valueEnumDescriptor
+			}
+			return nil
+		}
+		return descriptor
+	}
+	return nil
+}
+
+func annotationToDiagnostic(annotation *analysis.Annotation, severity int) *diagnostic {
+	start := &position{
+		Line:      nonNegative(annotation.StartLine - 1),
+		Character: nonNegative(annotation.StartColumn - 1),
+	}
+	end := &position{
+		Line:      nonNegative(annotation.EndLine - 1),
+		Character: nonNegative(annotation.EndColumn - 1),
+	}
+	if end.Line < start.Line || (end.Line == start.Line && end.Character < start.Character) {
+		end = start
+	}
+	return &diagnostic{
+		Range: &lspRange{
+			Start: start,
+			End:   end,
+		},
+		Severity: severity,
+		Code:     annotation.Type,
+		Source:   diagnosticSource,
+		Message:  annotation.Message,
+	}
+}
+
+// spanToRange converts the zero-based span of a SourceCodeInfo_Location to a range.
+//
+// The span is either [startLine, startColumn, endColumn] or
+// [startLine, startColumn, endLine, endColumn].
+func spanToRange(span []int32) *lspRange {
+	switch len(span) {
+	case 3:
+		return &lspRange{
+			Start: &position{Line: int(span[0]), Character: int(span[1])},
+			End:   &position{Line: int(span[0]), Character: int(span[2])},
+		}
+	case 4:
+		return &lspRange{
+			Start: &position{Line: int(span[0]), Character: int(span[1])},
+			End:   &position{Line: int(span[2]), Character: int(span[3])},
+		}
+	default:
+		return &lspRange{
+			Start: &position{},
+			End:   &position{},
+		}
+	}
+}
+
+// documentSymbolsToCharacters converts the ranges of the documentSymbols and
+// their children from compiler columns to characters.
+func documentSymbolsToCharacters(lineMapper *lineMapper, documentSymbols []*documentSymbol) {
+	for _, documentSymbol := range documentSymbols {
+		documentSymbol.Range = lineMapper.toCharacters(documentSymbol.Range)
+		documentSymbol.SelectionRange = lineMapper.toCharacters(documentSymbol.SelectionRange)
+		documentSymbolsToCharacters(lineMapper, documentSymbol.Children)
+	}
+}
+
+func unmarshalParams(message *message, params interface{}) error {
+	if err := json.Unmarshal(message.Params, params); err != nil {
+		return &responseError{
+			Code:    errorCodeInvalidParams,
+			Message: err.Error(),
+		}
+	}
+	return nil
+}
+
+func uriToPath(uri string) (string, error) {
+	parsedURI, err := url.Parse(uri)
+	if err != nil {
+		return "", err
+	}
+	if parsedURI.Scheme != "file" {
+		return "", fmt.Errorf("unsupported uri: %q", uri)
+	}
+	path := parsedURI.Path
+	// file:///C:/foo has the path /C:/foo, which is C:\foo on Windows
+	if hasDriveLetter(strings.TrimPrefix(path, "/")) {
+		path = strings.TrimPrefix(path, "/")
+	}
+	return filepath.FromSlash(path), nil
+}
+
+func pathToURI(path string) string {
+	path = filepath.ToSlash(path)
+	// C:\foo is file:///C:/foo, not file://C:/foo
+	if hasDriveLetter(path) {
+		path = "/" + path
+	}
+	return (&url.URL{
+		Scheme: "file",
+		Path:   path,
+	}).String()
+}
+
+// hasDriveLetter returns true if the slash-separated path starts with a Windows drive letter.
+func hasDriveLetter(path string) bool {
+	if len(path) < 2 || path[1] != ':' || (len(path) > 2 && path[2] != '/') {
+		return false
+	}
+	return ('a' <= path[0] && path[0] <= 'z') || ('A' <= path[0] && path[0] <= 'Z')
+}
+
+func nonNegative(i int) int {
+	if i < 0 {
+		return 0
+	}
+	return i
+}
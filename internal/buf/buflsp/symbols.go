@@ -0,0 +1,240 @@
+package buflsp
+
+import (
+	"strings"
+
+	"github.com/bufbuild/buf/internal/pkg/protodesc"
+)
+
+// reference is a reference to a named element at a position.
+type reference struct {
+	// name is the fully-qualified name of the referenced element.
+	name string
+	// location is the location of the reference itself.
+	location protodesc.Location
+}
+
+// findReference finds the reference at the position in the file.
+//
+// Names of elements are references to the elements themselves, and type names
+// of fields and methods are references to the types.
+//
+// Returns nil if there is no reference at the position.
+func findReference(file protodesc.File, position *position) *reference {
+	var found *reference
+	check := func(name string, location protodesc.Location) bool {
+		if found != nil || name == "" || location == nil {
+			return found != nil
+		}
+		if locationToRange(location).contains(position) {
+			found = &reference{
+				name:     strings.TrimPrefix(name, "."),
+				location: location,
+			}
+		}
+		return found != nil
+	}
+	checkField := func(field protodesc.Field) bool {
+		return check(field.FullName(), field.NameLocation()) ||
+			check(field.TypeName(), field.TypeNameLocation())
+	}
+	checkEnum := func(enum protodesc.Enum) bool {
+		if check(enum.FullName(), enum.NameLocation()) {
+			return true
+		}
+		for _, enumValue := range enum.Values() {
+			if check(enumValue.FullName(), enumValue.NameLocation()) {
+				return true
+			}
+		}
+		return false
+	}
+	var checkMessage func(protodesc.Message) bool
+	checkMessage = func(message protodesc.Message) bool {
+		if check(message.FullName(), message.NameLocation()) {
+			return true
+		}
+		for _, field := range message.Fields() {
+			if checkField(field) {
+				return true
+			}
+		}
+		for _, field := range message.Extensions() {
+			if checkField(field) {
+				return true
+			}
+		}
+		for _, enum := range message.Enums() {
+			if checkEnum(enum) {
+				return true
+			}
+		}
+		for _, nestedMessage := range message.Messages() {
+			if checkMessage(nestedMessage) {
+				return true
+			}
+		}
+		return false
+	}
+	for _, message := range file.Messages() {
+		if checkMessage(message) {
+			return found
+		}
+	}
+	for _, enum := range file.Enums() {
+		if checkEnum(enum) {
+			return found
+		}
+	}
+	for _, service := range file.Services() {
+		if check(service.FullName(), service.NameLocation()) {
+			return found
+		}
+		for _, method := range service.Methods() {
+			if check(method.FullName(), method.NameLocation()) ||
+				check(method.InputTypeName(), method.InputTypeLocation()) ||
+				check(method.OutputTypeName(), method.OutputTypeLocation()) {
+				return found
+			}
+		}
+	}
+	return nil
+}
+
+// getDocumentSymbols returns the symbols of the file.
+//
+// Elements without source code info are skipped.
+func getDocumentSymbols(file protodesc.File) []*documentSymbol {
+	var documentSymbols []*documentSymbol
+	for _, message := range file.Messages() {
+		documentSymbols = appendDocumentSymbol(documentSymbols, newMessageDocumentSymbol(message))
+	}
+	for _, enum := range file.Enums() {
+		documentSymbols = appendDocumentSymbol(documentSymbols, newEnumDocumentSymbol(enum))
+	}
+	for _, service := range file.Services() {
+		serviceDocumentSymbol := newDocumentSymbol(service, symbolKindInterface, "")
+		if serviceDocumentSymbol == nil {
+			continue
+		}
+		for _, method := range service.Methods() {
+			serviceDocumentSymbol.Children = appendDocumentSymbol(
+				serviceDocumentSymbol.Children,
+				newDocumentSymbol(method, symbolKindMethod, getMethodDetail(method)),
+			)
+		}
+		documentSymbols = append(documentSymbols, serviceDocumentSymbol)
+	}
+	return documentSymbols
+}
+
+func newMessageDocumentSymbol(message protodesc.Message) *documentSymbol {
+	messageDocumentSymbol := newDocumentSymbol(message, symbolKindStruct, "")
+	if messageDocumentSymbol == nil {
+		return nil
+	}
+	for _, field := range message.Fields() {
+		messageDocumentSymbol.Children = appendDocumentSymbol(
+			messageDocumentSymbol.Children,
+			newDocumentSymbol(field, symbolKindField, getFieldDetail(field)),
+		)
+	}
+	for _, field := range message.Extensions() {
+		messageDocumentSymbol.Children = appendDocumentSymbol(
+			messageDocumentSymbol.Children,
+			newDocumentSymbol(field, symbolKindField, getFieldDetail(field)),
+		)
+	}
+	for _, nestedMessage := range message.Messages() {
+		// map entries are generated by the compiler and are not declared
+		if nestedMessage.IsMapEntry() {
+			continue
+		}
+		messageDocumentSymbol.Children = appendDocumentSymbol(
+			messageDocumentSymbol.Children,
+			newMessageDocumentSymbol(nestedMessage),
+		)
+	}
+	for _, enum := range message.Enums() {
+		messageDocumentSymbol.Children = appendDocumentSymbol(
+			messageDocumentSymbol.Children,
+			newEnumDocumentSymbol(enum),
+		)
+	}
+	return messageDocumentSymbol
+}
+
+func newEnumDocumentSymbol(enum protodesc.Enum) *documentSymbol {
+	enumDocumentSymbol := newDocumentSymbol(enum, symbolKindEnum, "")
+	if enumDocumentSymbol == nil {
+		return nil
+	}
+	for _, enumValue := range enum.Values() {
+		enumDocumentSymbol.Children = appendDocumentSymbol(
+			enumDocumentSymbol.Children,
+			newDocumentSymbol(enumValue, symbolKindEnumMember, ""),
+		)
+	}
+	return enumDocumentSymbol
+}
+
+// newDocumentSymbol returns a new documentSymbol without children.
+//
+// Returns nil if the descriptor has no location.
+func newDocumentSymbol(namedDescriptor protodesc.NamedDescriptor, kind int, detail string) *documentSymbol {
+	location := namedDescriptor.Location()
+	if location == nil {
+		return nil
+	}
+	nameLocation := namedDescriptor.NameLocation()
+	if nameLocation == nil {
+		nameLocation = location
+	}
+	return &documentSymbol{
+		Name:           namedDescriptor.Name(),
+		Detail:         detail,
+		Kind:           kind,
+		Range:          locationToRange(location),
+		SelectionRange: locationToRange(nameLocation),
+	}
+}
+
+func appendDocumentSymbol(documentSymbols []*documentSymbol, documentSymbol *documentSymbol) []*documentSymbol {
+	if documentSymbol == nil {
+		return documentSymbols
+	}
+	return append(documentSymbols, documentSymbol)
+}
+
+func getFieldDetail(field protodesc.Field) string {
+	if typeName := field.TypeName(); typeName != "" {
+		return strings.TrimPrefix(typeName, ".")
+	}
+	return field.Type().String()
+}
+
+func getMethodDetail(method protodesc.Method) string {
+	inputTypeName := strings.TrimPrefix(method.InputTypeName(), ".")
+	if method.ClientStreaming() {
+		inputTypeName = "stream " + inputTypeName
+	}
+	outputTypeName := strings.TrimPrefix(method.OutputTypeName(), ".")
+	if method.ServerStreaming() {
+		outputTypeName = "stream " + outputTypeName
+	}
+	return "(" + inputTypeName + ") returns (" + outputTypeName + ")"
+}
+
+// locationToRange converts the one-based Location to a zero-based range.
+func locationToRange(location protodesc.Location) *lspRange {
+	return &lspRange{
+		Start: &position{
+			Line:      location.StartLine() - 1,
+			Character: location.StartColumn() - 1,
+		},
+		End: &position{
+			Line:      location.EndLine() - 1,
+			Character: location.EndColumn() - 1,
+		},
+	}
+}
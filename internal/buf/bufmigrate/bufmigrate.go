@@ -0,0 +1,52 @@
+// Package bufmigrate migrates configurations from other tools to buf.yaml.
+package bufmigrate
+
+import (
+	"context"
+
+	"github.com/bufbuild/buf/internal/buf/bufconfig"
+	"github.com/bufbuild/buf/internal/pkg/analysis"
+	"github.com/bufbuild/buf/internal/pkg/storage"
+	"go.uber.org/zap"
+)
+
+const (
+	// PrototoolConfigFilePath is the Prototool config file path within a bucket.
+	PrototoolConfigFilePath = "prototool.yaml"
+	// ProtolockFilePath is the Protolock lock file path within a bucket.
+	ProtolockFilePath = "proto.lock"
+)
+
+// Migrator migrates configurations.
+type Migrator interface {
+	// Migrate reads the Prototool config file and the Protolock lock file from the
+	// root of the bucket, and translates them into an ExternalConfig.
+	//
+	// Settings that cannot be translated are returned as annotations with the
+	// filename of the file that contained the setting. These are warnings, the
+	// returned ExternalConfig is still valid.
+	//
+	// Returns a user error if neither file exists.
+	Migrate(
+		ctx context.Context,
+		bucket storage.ReadBucket,
+	) (*bufconfig.ExternalConfig, []*analysis.Annotation, error)
+}
+
+// NewMigrator returns a new Migrator.
+//
+// The configProvider is used to validate the migrated configuration.
+func NewMigrator(
+	logger *zap.Logger,
+	configProvider bufconfig.Provider,
+) Migrator {
+	return newMigrator(
+		logger,
+		configProvider,
+	)
+}
+
+// GetConfigData returns the data for a buf.yaml for the ExternalConfig.
+func GetConfigData(externalConfig *bufconfig.ExternalConfig) ([]byte, error) {
+	return getConfigData(externalConfig)
+}
@@ -0,0 +1,184 @@
+package bufmigrate_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bufbuild/buf/internal/buf/bufconfig"
+	"github.com/bufbuild/buf/internal/buf/bufmigrate"
+	"github.com/bufbuild/buf/internal/pkg/analysis"
+	"github.com/bufbuild/buf/internal/pkg/storage/storageos"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestMigratePrototool(t *testing.T) {
+	t.Parallel()
+	externalConfig, annotations := testMigrate(t, "prototool")
+	assert.Equal(
+		t,
+		&bufconfig.ExternalConfig{
+			Build: bufconfig.ExternalBuildConfig{
+				Roots:    []string{"."},
+				Excludes: []string{"proto/foo/legacy"},
+			},
+			Breaking: bufconfig.ExternalBreakingConfig{
+				Use: []string{"PACKAGE"},
+			},
+			Lint: bufconfig.ExternalLintConfig{
+				Use:    []string{"DEFAULT", "SERVICE_SUFFIX"},
+				Except: []string{"ENUM_ZERO_VALUE_SUFFIX"},
+				IgnoreOnly: map[string][]string{
+					"RPC_PASCAL_CASE": []string{"proto/foo/v1/foo.proto"},
+				},
+				ServiceSuffix: "API",
+			},
+			Generate: bufconfig.ExternalGenerateConfig{
+				Plugins: []bufconfig.ExternalPluginConfig{
+					{
+						Name: "go",
+						Out:  "gen/go",
+						Opt:  "plugins=grpc",
+					},
+				},
+			},
+		},
+		externalConfig,
+	)
+	assert.Equal(
+		t,
+		[]string{
+			"protoc include proto is within the directory of prototool.yaml and is dropped as roots cannot be nested, imports relative to proto must be made relative to the directory instead",
+			"exclude ../other is not within the directory of prototool.yaml and is dropped",
+			"protoc.version is not needed as buf has its own compiler and is dropped",
+			"lint.group uber2 is approximated by [DEFAULT], the exact rules differ",
+			"lint.ignores rule FILE_OPTIONS_REQUIRE_GO_PACKAGE has no equivalent and is dropped",
+			"break.include_beta has no equivalent and is dropped, beta packages are always checked",
+			"generate.go_options has no equivalent and is dropped, set the M options in opt instead",
+			"generate.plugins go type has no equivalent and is dropped",
+		},
+		testGetAnnotationMessages(t, bufmigrate.PrototoolConfigFilePath, annotations),
+	)
+	data, err := bufmigrate.GetConfigData(externalConfig)
+	require.NoError(t, err)
+	assert.Equal(
+		t,
+		`build:
+  roots:
+  - .
+  excludes:
+  - proto/foo/legacy
+breaking:
+  use:
+  - PACKAGE
+lint:
+  use:
+  - DEFAULT
+  - SERVICE_SUFFIX
+  except:
+  - ENUM_ZERO_VALUE_SUFFIX
+  ignore_only:
+    RPC_PASCAL_CASE:
+    - proto/foo/v1/foo.proto
+  service_suffix: API
+generate:
+  plugins:
+  - name: go
+    out: gen/go
+    opt: plugins=grpc
+`,
+		string(data),
+	)
+}
+
+func TestMigratePrototoolDefault(t *testing.T) {
+	t.Parallel()
+	externalConfig, annotations := testMigrate(t, "prototool_default")
+	assert.Equal(
+		t,
+		&bufconfig.ExternalConfig{
+			Build: bufconfig.ExternalBuildConfig{
+				Roots:    []string{"."},
+				Excludes: []string{},
+			},
+			Breaking: bufconfig.ExternalBreakingConfig{
+				Use: []string{"PACKAGE"},
+			},
+			Lint: bufconfig.ExternalLintConfig{
+				Use:    []string{"DEFAULT"},
+				Except: []string{},
+				// the default Prototool group requires enum zero values to end in _INVALID
+				EnumZeroValueSuffix: "_INVALID",
+			},
+		},
+		externalConfig,
+	)
+	assert.Equal(
+		t,
+		[]string{
+			"protoc include vendor is within the directory of prototool.yaml and is dropped as roots cannot be nested, imports relative to vendor must be made relative to the directory instead",
+		},
+		testGetAnnotationMessages(t, bufmigrate.PrototoolConfigFilePath, annotations),
+	)
+}
+
+func TestMigrateProtolock(t *testing.T) {
+	t.Parallel()
+	externalConfig, annotations := testMigrate(t, "protolock")
+	assert.Equal(
+		t,
+		&bufconfig.ExternalConfig{
+			Build: bufconfig.ExternalBuildConfig{
+				Roots: []string{"."},
+			},
+			Breaking: bufconfig.ExternalBreakingConfig{
+				Use: []string{"FILE"},
+			},
+		},
+		externalConfig,
+	)
+	assert.Len(t, testGetAnnotationMessages(t, bufmigrate.ProtolockFilePath, annotations), 1)
+}
+
+func TestMigrateNoFiles(t *testing.T) {
+	t.Parallel()
+	bucket, err := storageos.NewReadBucket("testdata")
+	require.NoError(t, err)
+	defer func() {
+		assert.NoError(t, bucket.Close())
+	}()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	_, _, err = bufmigrate.NewMigrator(zap.NewNop(), bufconfig.NewProvider(zap.NewNop())).Migrate(ctx, bucket)
+	assert.Error(t, err)
+}
+
+func testMigrate(t *testing.T, dirPath string) (*bufconfig.ExternalConfig, []*analysis.Annotation) {
+	logger := zap.NewNop()
+	bucket, err := storageos.NewReadBucket(filepath.Join("testdata", dirPath))
+	require.NoError(t, err)
+	defer func() {
+		assert.NoError(t, bucket.Close())
+	}()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	externalConfig, annotations, err := bufmigrate.NewMigrator(
+		logger,
+		bufconfig.NewProvider(logger),
+	).Migrate(ctx, bucket)
+	require.NoError(t, err)
+	return externalConfig, annotations
+}
+
+func testGetAnnotationMessages(t *testing.T, expectedFilename string, annotations []*analysis.Annotation) []string {
+	messages := make([]string, len(annotations))
+	for i, annotation := range annotations {
+		assert.Equal(t, expectedFilename, annotation.Filename)
+		assert.Equal(t, "MIGRATE", annotation.Type)
+		messages[i] = annotation.Message
+	}
+	return messages
+}
@@ -0,0 +1,355 @@
+package bufmigrate
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/bufbuild/buf/internal/buf/bufconfig"
+	"github.com/bufbuild/buf/internal/pkg/analysis"
+	"github.com/bufbuild/buf/internal/pkg/encodingutil"
+	"github.com/bufbuild/buf/internal/pkg/errs"
+	"github.com/bufbuild/buf/internal/pkg/logutil"
+	"github.com/bufbuild/buf/internal/pkg/storage"
+	"github.com/bufbuild/buf/internal/pkg/storage/storagepath"
+	"github.com/bufbuild/buf/internal/pkg/storage/storageutil"
+	"github.com/bufbuild/buf/internal/pkg/stringutil"
+	"go.uber.org/zap"
+	"gopkg.in/yaml.v3"
+)
+
+const annotationType = "MIGRATE"
+
+type migrator struct {
+	logger         *zap.Logger
+	configProvider bufconfig.Provider
+}
+
+func newMigrator(
+	logger *zap.Logger,
+	configProvider bufconfig.Provider,
+) *migrator {
+	return &migrator{
+		logger:         logger.Named("bufmigrate"),
+		configProvider: configProvider,
+	}
+}
+
+func (m *migrator) Migrate(
+	ctx context.Context,
+	bucket storage.ReadBucket,
+) (_ *bufconfig.ExternalConfig, _ []*analysis.Annotation, retErr error) {
+	defer logutil.DeferWithError(m.logger, "migrate", &retErr)()
+
+	prototoolData, err := readPathIfExists(ctx, bucket, PrototoolConfigFilePath)
+	if err != nil {
+		return nil, nil, err
+	}
+	protolockData, err := readPathIfExists(ctx, bucket, ProtolockFilePath)
+	if err != nil {
+		return nil, nil, err
+	}
+	if prototoolData == nil && protolockData == nil {
+		return nil, nil, errs.NewInvalidArgumentf("neither %s nor %s found", PrototoolConfigFilePath, ProtolockFilePath)
+	}
+
+	externalConfig := &bufconfig.ExternalConfig{}
+	var annotations []*analysis.Annotation
+	if prototoolData != nil {
+		prototoolAnnotations, err := migratePrototool(prototoolData, externalConfig)
+		if err != nil {
+			return nil, nil, err
+		}
+		annotations = append(annotations, prototoolAnnotations...)
+	}
+	if protolockData != nil {
+		protolockAnnotations, err := migrateProtolock(protolockData, externalConfig)
+		if err != nil {
+			return nil, nil, err
+		}
+		annotations = append(annotations, protolockAnnotations...)
+	}
+
+	// make sure the result is a valid configuration
+	data, err := getConfigData(externalConfig)
+	if err != nil {
+		return nil, nil, err
+	}
+	if _, err := m.configProvider.GetConfigForData(data); err != nil {
+		return nil, nil, errs.NewInvalidArgumentf("migrated configuration is not valid: %v", err)
+	}
+	return externalConfig, annotations, nil
+}
+
+func migratePrototool(data []byte, externalConfig *bufconfig.ExternalConfig) ([]*analysis.Annotation, error) {
+	prototoolConfig := &externalPrototoolConfig{}
+	if err := yaml.Unmarshal(data, prototoolConfig); err != nil {
+		return nil, errs.NewInvalidArgumentf("%s: could not unmarshal as YAML: %v", PrototoolConfigFilePath, err)
+	}
+	var annotations []*analysis.Annotation
+	addWarningf := func(format string, args ...interface{}) {
+		annotations = append(annotations, newAnnotation(PrototoolConfigFilePath, fmt.Sprintf(format, args...)))
+	}
+
+	roots := migratePrototoolRoots(prototoolConfig.Protoc.Includes, addWarningf)
+	externalConfig.Build.Roots = roots
+	for _, exclude := range prototoolConfig.Excludes {
+		normalizedExclude, err := storagepath.NormalizeAndValidate(exclude)
+		if err != nil {
+			addWarningf("exclude %s is not within the directory of %s and is dropped", exclude, PrototoolConfigFilePath)
+			continue
+		}
+		root := getContainingRoot(roots, normalizedExclude)
+		if root == "" || root == normalizedExclude {
+			addWarningf("exclude %s is not within a root and is dropped", exclude)
+			continue
+		}
+		externalConfig.Build.Excludes = append(externalConfig.Build.Excludes, normalizedExclude)
+	}
+	externalConfig.Build.Excludes = stringutil.SliceToUniqueSortedSlice(externalConfig.Build.Excludes)
+	if prototoolConfig.Protoc.Version != "" {
+		addWarningf("protoc.version is not needed as buf has its own compiler and is dropped")
+	}
+	if prototoolConfig.Protoc.AllowUnusedImports {
+		addWarningf("protoc.allow_unused_imports has no equivalent and is dropped, unused imports are not errors")
+	}
+
+	migratePrototoolLint(prototoolConfig.Lint, roots, externalConfig, addWarningf)
+
+	// Prototool compared packages for breaking changes
+	externalConfig.Breaking.Use = []string{"PACKAGE"}
+	if prototoolConfig.Break.IncludeBeta {
+		addWarningf("break.include_beta has no equivalent and is dropped, beta packages are always checked")
+	}
+	if prototoolConfig.Break.AllowBetaDeps {
+		addWarningf("break.allow_beta_deps has no equivalent and is dropped")
+	}
+
+	migratePrototoolGenerate(prototoolConfig.Generate, externalConfig, addWarningf)
+	if len(prototoolConfig.Create) > 0 {
+		addWarningf("create has no equivalent and is dropped")
+	}
+	return annotations, nil
+}
+
+// migratePrototoolRoots migrates the protoc includes to roots.
+//
+// Prototool always includes the directory of the configuration file, so the
+// directory is always the root. Includes within the directory would be nested
+// within this root, which is not allowed, so they are dropped rather than
+// replacing the directory and dropping every file outside of them.
+func migratePrototoolRoots(includes []string, addWarningf func(string, ...interface{})) []string {
+	for _, include := range includes {
+		root, err := storagepath.NormalizeAndValidate(include)
+		if err != nil {
+			addWarningf("protoc include %s is outside the directory of %s, copy these files into a root instead", include, PrototoolConfigFilePath)
+			continue
+		}
+		if root == "." {
+			continue
+		}
+		addWarningf("protoc include %s is within the directory of %s and is dropped as roots cannot be nested, imports relative to %s must be made relative to the directory instead", include, PrototoolConfigFilePath, root)
+	}
+	return []string{"."}
+}
+
+func migratePrototoolLint(
+	lintConfig externalPrototoolLintConfig,
+	roots []string,
+	externalConfig *bufconfig.ExternalConfig,
+	addWarningf func(string, ...interface{}),
+) {
+	if !lintConfig.Rules.NoDefault {
+		categories, ok := prototoolLintGroupToCategories[lintConfig.Group]
+		if !ok {
+			addWarningf("lint.group %s is unknown, using DEFAULT", lintConfig.Group)
+			categories = []string{"DEFAULT"}
+		} else if lintConfig.Group != "" {
+			addWarningf("lint.group %s is approximated by %v, the exact rules differ", lintConfig.Group, categories)
+		}
+		externalConfig.Lint.Use = append(externalConfig.Lint.Use, categories...)
+	}
+	// the zero value checks of the Prototool groups require _INVALID rather than the default suffix
+	_, enumZeroValuesInvalid := prototoolLintGroupsWithEnumZeroValuesInvalid[lintConfig.Group]
+	enumZeroValuesInvalid = enumZeroValuesInvalid && !lintConfig.Rules.NoDefault
+	for _, rule := range lintConfig.Rules.Add {
+		ids := getLintIDs(rule, "lint.rules.add", addWarningf)
+		externalConfig.Lint.Use = append(externalConfig.Lint.Use, ids...)
+		if rule == "SERVICE_NAMES_API_SUFFIX" {
+			externalConfig.Lint.ServiceSuffix = "API"
+		}
+		if _, ok := prototoolEnumZeroValuesInvalidRules[strings.ToUpper(rule)]; ok {
+			enumZeroValuesInvalid = true
+		}
+	}
+	if lintConfig.Rules.NoDefault && len(externalConfig.Lint.Use) == 0 {
+		addWarningf("lint.rules.no_default is set but no rules could be migrated, using DEFAULT")
+		externalConfig.Lint.Use = []string{"DEFAULT"}
+	}
+	for _, rule := range lintConfig.Rules.Remove {
+		externalConfig.Lint.Except = append(externalConfig.Lint.Except, getLintIDs(rule, "lint.rules.remove", addWarningf)...)
+		if _, ok := prototoolEnumZeroValuesInvalidRules[strings.ToUpper(rule)]; ok {
+			enumZeroValuesInvalid = false
+		}
+	}
+	if enumZeroValuesInvalid {
+		externalConfig.Lint.EnumZeroValueSuffix = prototoolEnumZeroValueSuffix
+	}
+	for _, ignore := range lintConfig.Ignores {
+		ids := getLintIDs(ignore.ID, "lint.ignores", addWarningf)
+		if len(ids) == 0 {
+			continue
+		}
+		var rootPaths []string
+		for _, file := range ignore.Files {
+			rootPath, ok := getRootPath(roots, file)
+			if !ok {
+				addWarningf("lint.ignores file %s for %s is not within a root and is dropped", file, ignore.ID)
+				continue
+			}
+			rootPaths = append(rootPaths, rootPath)
+		}
+		if len(rootPaths) == 0 {
+			continue
+		}
+		if externalConfig.Lint.IgnoreOnly == nil {
+			externalConfig.Lint.IgnoreOnly = make(map[string][]string)
+		}
+		for _, id := range ids {
+			externalConfig.Lint.IgnoreOnly[id] = stringutil.SliceToUniqueSortedSlice(
+				append(externalConfig.Lint.IgnoreOnly[id], rootPaths...),
+			)
+		}
+	}
+	externalConfig.Lint.Use = stringutil.SliceToUniqueSortedSlice(externalConfig.Lint.Use)
+	externalConfig.Lint.Except = stringutil.SliceToUniqueSortedSlice(externalConfig.Lint.Except)
+	if len(lintConfig.FileHeader) > 0 {
+		addWarningf("lint.file_header has no equivalent and is dropped")
+	}
+	if lintConfig.JavaPackagePrefix != "" {
+		addWarningf("lint.java_package_prefix has no equivalent and is dropped")
+	}
+}
+
+func migratePrototoolGenerate(
+	generateConfig externalPrototoolGenerateConfig,
+	externalConfig *bufconfig.ExternalConfig,
+	addWarningf func(string, ...interface{}),
+) {
+	if len(generateConfig.GoOptions) > 0 {
+		addWarningf("generate.go_options has no equivalent and is dropped, set the M options in opt instead")
+	}
+	for _, plugin := range generateConfig.Plugins {
+		if plugin.Name == "" {
+			addWarningf("generate.plugins has a plugin with no name which is dropped")
+			continue
+		}
+		out, err := storagepath.NormalizeAndValidate(plugin.Output)
+		if err != nil {
+			addWarningf("generate.plugins %s output %q is not within the directory of %s and the plugin is dropped", plugin.Name, plugin.Output, PrototoolConfigFilePath)
+			continue
+		}
+		if plugin.Type != "" {
+			addWarningf("generate.plugins %s type has no equivalent and is dropped", plugin.Name)
+		}
+		if plugin.FileSuffix != "" || plugin.IncludeImports || plugin.IncludeSourceInfo {
+			addWarningf("generate.plugins %s file_suffix, include_imports, and include_source_info have no equivalent and are dropped", plugin.Name)
+		}
+		externalConfig.Generate.Plugins = append(
+			externalConfig.Generate.Plugins,
+			bufconfig.ExternalPluginConfig{
+				Name: plugin.Name,
+				Path: plugin.Path,
+				Out:  out,
+				Opt:  plugin.Flags,
+			},
+		)
+	}
+}
+
+func migrateProtolock(data []byte, externalConfig *bufconfig.ExternalConfig) ([]*analysis.Annotation, error) {
+	protolockLock := &externalProtolockLock{}
+	if err := json.Unmarshal(data, protolockLock); err != nil {
+		return nil, errs.NewInvalidArgumentf("%s: could not unmarshal as JSON: %v", ProtolockFilePath, err)
+	}
+	// Protolock checks are a subset of the FILE checks, which are stricter than PACKAGE
+	externalConfig.Breaking.Use = []string{"FILE"}
+	if len(externalConfig.Build.Roots) == 0 {
+		externalConfig.Build.Roots = []string{"."}
+	}
+	return []*analysis.Annotation{
+		newAnnotation(
+			ProtolockFilePath,
+			fmt.Sprintf(
+				"%s contains %d definitions which are not migrated, run buf check breaking with --against-input set to a previous version of your files instead, and then delete %s",
+				ProtolockFilePath,
+				len(protolockLock.Definitions),
+				ProtolockFilePath,
+			),
+		),
+	}, nil
+}
+
+func getLintIDs(rule string, fieldName string, addWarningf func(string, ...interface{})) []string {
+	ids, ok := prototoolLintRuleToIDs[strings.ToUpper(rule)]
+	if !ok {
+		addWarningf("%s rule %s has no equivalent and is dropped", fieldName, rule)
+		return nil
+	}
+	return ids
+}
+
+func getConfigData(externalConfig *bufconfig.ExternalConfig) ([]byte, error) {
+	return encodingutil.MarshalYAML(externalConfig)
+}
+
+func readPathIfExists(ctx context.Context, bucket storage.ReadBucket, path string) ([]byte, error) {
+	data, err := storageutil.ReadPath(ctx, bucket, path)
+	if err != nil {
+		if storage.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return data, nil
+}
+
+// getRootPath returns the path relative to the root that contains it.
+func getRootPath(roots []string, path string) (string, bool) {
+	normalizedPath, err := storagepath.NormalizeAndValidate(path)
+	if err != nil {
+		return "", false
+	}
+	root := getContainingRoot(roots, normalizedPath)
+	if root == "" {
+		return "", false
+	}
+	rootPath, err := storagepath.Rel(root, normalizedPath)
+	if err != nil {
+		return "", false
+	}
+	return rootPath, true
+}
+
+func getContainingRoot(roots []string, path string) string {
+	for _, root := range roots {
+		if pathContains(root, path) {
+			return root
+		}
+	}
+	return ""
+}
+
+// pathContains returns true if path is equal to or within dirPath.
+func pathContains(dirPath string, path string) bool {
+	return dirPath == "." || dirPath == path || strings.HasPrefix(path, dirPath+"/")
+}
+
+func newAnnotation(filename string, message string) *analysis.Annotation {
+	return &analysis.Annotation{
+		Filename: filename,
+		Type:     annotationType,
+		Message:  message,
+	}
+}
@@ -0,0 +1,148 @@
+package bufmigrate
+
+// externalPrototoolConfig is the subset of the Prototool configuration that is read.
+//
+// Unknown fields are ignored.
+type externalPrototoolConfig struct {
+	Excludes []string                        `yaml:"excludes,omitempty"`
+	Protoc   externalPrototoolProtocConfig   `yaml:"protoc,omitempty"`
+	Lint     externalPrototoolLintConfig     `yaml:"lint,omitempty"`
+	Break    externalPrototoolBreakConfig    `yaml:"break,omitempty"`
+	Generate externalPrototoolGenerateConfig `yaml:"generate,omitempty"`
+	Create   map[string]interface{}          `yaml:"create,omitempty"`
+}
+
+type externalPrototoolProtocConfig struct {
+	Version            string   `yaml:"version,omitempty"`
+	Includes           []string `yaml:"includes,omitempty"`
+	AllowUnusedImports bool     `yaml:"allow_unused_imports,omitempty"`
+}
+
+type externalPrototoolLintConfig struct {
+	Group             string                              `yaml:"group,omitempty"`
+	Ignores           []externalPrototoolLintIgnoreConfig `yaml:"ignores,omitempty"`
+	Rules             externalPrototoolLintRulesConfig    `yaml:"rules,omitempty"`
+	FileHeader        map[string]interface{}              `yaml:"file_header,omitempty"`
+	JavaPackagePrefix string                              `yaml:"java_package_prefix,omitempty"`
+}
+
+type externalPrototoolLintIgnoreConfig struct {
+	ID    string   `yaml:"id,omitempty"`
+	Files []string `yaml:"files,omitempty"`
+}
+
+type externalPrototoolLintRulesConfig struct {
+	NoDefault bool     `yaml:"no_default,omitempty"`
+	Add       []string `yaml:"add,omitempty"`
+	Remove    []string `yaml:"remove,omitempty"`
+}
+
+type externalPrototoolBreakConfig struct {
+	IncludeBeta   bool `yaml:"include_beta,omitempty"`
+	AllowBetaDeps bool `yaml:"allow_beta_deps,omitempty"`
+}
+
+type externalPrototoolGenerateConfig struct {
+	GoOptions map[string]interface{}                  `yaml:"go_options,omitempty"`
+	Plugins   []externalPrototoolGeneratePluginConfig `yaml:"plugins,omitempty"`
+}
+
+type externalPrototoolGeneratePluginConfig struct {
+	Name              string `yaml:"name,omitempty"`
+	Type              string `yaml:"type,omitempty"`
+	Flags             string `yaml:"flags,omitempty"`
+	Output            string `yaml:"output,omitempty"`
+	Path              string `yaml:"path,omitempty"`
+	FileSuffix        string `yaml:"file_suffix,omitempty"`
+	IncludeImports    bool   `yaml:"include_imports,omitempty"`
+	IncludeSourceInfo bool   `yaml:"include_source_info,omitempty"`
+}
+
+// externalProtolockLock is the subset of the Protolock lock file that is read.
+type externalProtolockLock struct {
+	Definitions []externalProtolockDefinition `json:"definitions,omitempty"`
+}
+
+type externalProtolockDefinition struct {
+	Protopath string `json:"protopath,omitempty"`
+}
+
+// prototoolEnumZeroValueSuffix is the enum zero value suffix that Prototool requires.
+const prototoolEnumZeroValueSuffix = "_INVALID"
+
+var (
+	// prototoolLintGroupToCategories maps Prototool lint groups to the closest lint categories.
+	//
+	// The empty group is the Prototool default of uber1.
+	prototoolLintGroupToCategories = map[string][]string{
+		"":       []string{"DEFAULT"},
+		"uber1":  []string{"DEFAULT"},
+		"uber2":  []string{"DEFAULT"},
+		"google": []string{"STYLE_BASIC"},
+	}
+
+	// prototoolLintGroupsWithEnumZeroValuesInvalid are the Prototool lint groups
+	// that include one of the prototoolEnumZeroValuesInvalidRules.
+	prototoolLintGroupsWithEnumZeroValuesInvalid = map[string]struct{}{
+		"":      struct{}{},
+		"uber1": struct{}{},
+		"uber2": struct{}{},
+	}
+
+	// prototoolEnumZeroValuesInvalidRules are the Prototool lint rules that
+	// require enum zero values to be suffixed with _INVALID.
+	//
+	// These map to ENUM_ZERO_VALUE_SUFFIX, which is only equivalent if
+	// enum_zero_value_suffix is set to prototoolEnumZeroValueSuffix.
+	prototoolEnumZeroValuesInvalidRules = map[string]struct{}{
+		"ENUM_ZERO_VALUES_INVALID":                struct{}{},
+		"ENUM_ZERO_VALUES_INVALID_EXCEPT_MESSAGE": struct{}{},
+	}
+
+	// prototoolLintRuleToIDs maps Prototool lint rules to the equivalent lint checker IDs.
+	//
+	// Rules that are not in this map have no equivalent.
+	prototoolLintRuleToIDs = map[string][]string{
+		"ENUM_FIELD_NAMES_UPPER_SNAKE_CASE":                             []string{"ENUM_VALUE_UPPER_SNAKE_CASE"},
+		"ENUM_FIELD_NAMES_UPPERCASE":                                    []string{"ENUM_VALUE_UPPER_SNAKE_CASE"},
+		"ENUM_FIELD_PREFIXES":                                           []string{"ENUM_VALUE_PREFIX"},
+		"ENUM_FIELD_PREFIXES_EXCEPT_MESSAGE":                            []string{"ENUM_VALUE_PREFIX"},
+		"ENUM_FIELDS_HAVE_COMMENTS":                                     []string{"COMMENT_ENUM_VALUE"},
+		"ENUM_FIELDS_HAVE_SENTENCE_COMMENTS":                            []string{"COMMENT_ENUM_VALUE"},
+		"ENUM_NAMES_CAMEL_CASE":                                         []string{"ENUM_PASCAL_CASE"},
+		"ENUM_NAMES_CAPITALIZED":                                        []string{"ENUM_PASCAL_CASE"},
+		"ENUM_ZERO_VALUES_INVALID":                                      []string{"ENUM_ZERO_VALUE_SUFFIX"},
+		"ENUM_ZERO_VALUES_INVALID_EXCEPT_MESSAGE":                       []string{"ENUM_ZERO_VALUE_SUFFIX"},
+		"ENUMS_HAVE_COMMENTS":                                           []string{"COMMENT_ENUM"},
+		"ENUMS_HAVE_SENTENCE_COMMENTS":                                  []string{"COMMENT_ENUM"},
+		"FILE_NAMES_LOWER_SNAKE_CASE":                                   []string{"FILE_LOWER_SNAKE_CASE"},
+		"IMPORTS_NOT_PUBLIC":                                            []string{"IMPORT_NO_PUBLIC"},
+		"IMPORTS_NOT_WEAK":                                              []string{"IMPORT_NO_WEAK"},
+		"MESSAGE_FIELD_NAMES_LOWER_SNAKE_CASE":                          []string{"FIELD_LOWER_SNAKE_CASE"},
+		"MESSAGE_FIELD_NAMES_LOWERCASE":                                 []string{"FIELD_LOWER_SNAKE_CASE"},
+		"MESSAGE_FIELDS_HAVE_COMMENTS":                                  []string{"COMMENT_FIELD"},
+		"MESSAGE_FIELDS_HAVE_SENTENCE_COMMENTS":                         []string{"COMMENT_FIELD"},
+		"MESSAGE_NAMES_CAMEL_CASE":                                      []string{"MESSAGE_PASCAL_CASE"},
+		"MESSAGE_NAMES_CAPITALIZED":                                     []string{"MESSAGE_PASCAL_CASE"},
+		"MESSAGES_HAVE_COMMENTS":                                        []string{"COMMENT_MESSAGE"},
+		"MESSAGES_HAVE_COMMENTS_EXCEPT_REQUEST_RESPONSE_TYPES":          []string{"COMMENT_MESSAGE"},
+		"MESSAGES_HAVE_SENTENCE_COMMENTS_EXCEPT_REQUEST_RESPONSE_TYPES": []string{"COMMENT_MESSAGE"},
+		"ONEOF_NAMES_LOWER_SNAKE_CASE":                                  []string{"ONEOF_LOWER_SNAKE_CASE"},
+		"PACKAGE_IS_DECLARED":                                           []string{"PACKAGE_DEFINED"},
+		"PACKAGE_LOWER_SNAKE_CASE":                                      []string{"PACKAGE_LOWER_SNAKE_CASE"},
+		"PACKAGE_MAJOR_BETA_VERSIONED":                                  []string{"PACKAGE_VERSION_SUFFIX"},
+		"PACKAGES_SAME_IN_DIR":                                          []string{"DIRECTORY_SAME_PACKAGE"},
+		"REQUEST_RESPONSE_NAMES_MATCH_RPC":                              []string{"RPC_REQUEST_STANDARD_NAME", "RPC_RESPONSE_STANDARD_NAME"},
+		"REQUEST_RESPONSE_TYPES_UNIQUE":                                 []string{"RPC_REQUEST_RESPONSE_UNIQUE"},
+		"RPC_NAMES_CAMEL_CASE":                                          []string{"RPC_PASCAL_CASE"},
+		"RPC_NAMES_CAPITALIZED":                                         []string{"RPC_PASCAL_CASE"},
+		"RPCS_HAVE_COMMENTS":                                            []string{"COMMENT_RPC"},
+		"RPCS_HAVE_SENTENCE_COMMENTS":                                   []string{"COMMENT_RPC"},
+		"RPCS_NO_STREAMING":                                             []string{"RPC_NO_CLIENT_STREAMING", "RPC_NO_SERVER_STREAMING"},
+		"SERVICE_NAMES_API_SUFFIX":                                      []string{"SERVICE_SUFFIX"},
+		"SERVICE_NAMES_CAMEL_CASE":                                      []string{"SERVICE_PASCAL_CASE"},
+		"SERVICE_NAMES_CAPITALIZED":                                     []string{"SERVICE_PASCAL_CASE"},
+		"SERVICES_HAVE_COMMENTS":                                        []string{"COMMENT_SERVICE"},
+		"SERVICES_HAVE_SENTENCE_COMMENTS":                               []string{"COMMENT_SERVICE"},
+	}
+)
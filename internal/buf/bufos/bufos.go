@@ -6,7 +6,6 @@ package bufos
 import (
 	"context"
 	"io"
-	"net/http"
 
 	"github.com/bufbuild/buf/internal/buf/bufbuild"
 	"github.com/bufbuild/buf/internal/buf/bufconfig"
@@ -14,6 +13,8 @@ import (
 	"github.com/bufbuild/buf/internal/buf/bufpb"
 	"github.com/bufbuild/buf/internal/pkg/analysis"
 	"github.com/bufbuild/buf/internal/pkg/bytepool"
+	"github.com/bufbuild/buf/internal/pkg/httpget"
+	"github.com/bufbuild/buf/internal/pkg/storage"
 	"go.uber.org/zap"
 )
 
@@ -28,6 +29,41 @@ type Env struct {
 	Config *bufconfig.Config
 }
 
+// BucketEnv is a bucket environment.
+//
+// This is used for operations that need access to the original source files
+// instead of a built Image.
+type BucketEnv struct {
+	// Bucket is the bucket that contains the source files.
+	//
+	// This must be closed when done.
+	Bucket storage.ReadBucket
+	// WriteBucket is the writable version of Bucket.
+	//
+	// This is only set if the source was a directory, and writes will go
+	// to the directory. Can be nil.
+	WriteBucket storage.Bucket
+	// Resolver is the resolver to apply to real file paths within the bucket
+	// before printing paths or annotations.
+	// Can be nil.
+	Resolver bufbuild.ProtoFilePathResolver
+	// Config is the config to use.
+	Config *bufconfig.Config
+	// SpecificRealFilePaths are the specific file paths given to ReadBucketEnv
+	// as real file paths within Bucket.
+	//
+	// Can be nil.
+	SpecificRealFilePaths []string
+}
+
+// WatchPaths are the local paths to watch for changes of a value.
+type WatchPaths struct {
+	// Paths are the file or directory paths to watch.
+	Paths []string
+	// ExcludePaths are the directory paths within Paths that should not be watched.
+	ExcludePaths []string
+}
+
 // EnvReader is an env reader.
 type EnvReader interface {
 	// ReadEnv reads an environment.
@@ -35,8 +71,9 @@ type EnvReader interface {
 	// If specificFilePaths is empty, this builds all the files under Buf control.
 	//
 	// Note that includeImports will only be respected for Images if the image was
-	// built with buf - if it was built with protoc, we have no way of detecting
-	// what is and isn't an import.
+	// built with buf, or if the value specifies the targets of the image with the
+	// target or infer_imports options - if it was built with protoc, there is
+	// otherwise no way of detecting what is and isn't an import.
 	//
 	// Note that includeSourceInfo will only be respected for Sources. We make
 	// no modifications for Images.
@@ -81,6 +118,21 @@ type EnvReader interface {
 		includeImports bool,
 	) (*Env, error)
 
+	// ReadBucketEnv reads a bucket environment.
+	//
+	// This disallows image values and does not build.
+	// If specificFilePaths is not empty, they are converted to real file paths
+	// within the bucket and set on SpecificRealFilePaths, for use with a later build.
+	// The returned Bucket must be closed by the caller.
+	// If stdin is nil and this tries to read from stdin, returns user error.
+	ReadBucketEnv(
+		ctx context.Context,
+		stdin io.Reader,
+		value string,
+		configOverride string,
+		specificFilePaths []string,
+	) (*BucketEnv, error)
+
 	// ListFiles lists the files.
 	ListFiles(
 		ctx context.Context,
@@ -89,6 +141,21 @@ type EnvReader interface {
 		configOverride string,
 	) ([]string, error)
 
+	// GetWatchPaths returns the local file or directory paths that the value
+	// is read from, for watching for changes.
+	//
+	// For directories, these are the roots and the configuration file, and the
+	// excludes of the configuration are returned as ExcludePaths. The roots and
+	// excludes are read once, so later changes to them are not reflected.
+	//
+	// Returns nil if the value is not read from local paths, ie for
+	// stdin, git repositories, and HTTP URLs.
+	GetWatchPaths(
+		ctx context.Context,
+		value string,
+		configOverride string,
+	) (*WatchPaths, error)
+
 	// GetConfig gets the config.
 	GetConfig(
 		ctx context.Context,
@@ -100,7 +167,7 @@ type EnvReader interface {
 func NewEnvReader(
 	logger *zap.Logger,
 	segList *bytepool.SegList,
-	httpClient *http.Client,
+	httpGetter httpget.Getter,
 	configProvider bufconfig.Provider,
 	buildHandler bufbuild.Handler,
 	valueFlagName string,
@@ -109,7 +176,7 @@ func NewEnvReader(
 	return newEnvReader(
 		logger,
 		segList,
-		httpClient,
+		httpGetter,
 		configProvider,
 		buildHandler,
 		valueFlagName,
@@ -157,3 +224,8 @@ func SourceFormatsToString() string {
 func ImageFormatsToString() string {
 	return internal.ImageFormatsToString()
 }
+
+// ImageFileFormatsToString returns image format strings that can be written to.
+func ImageFileFormatsToString() string {
+	return internal.ImageFileFormatsToString()
+}
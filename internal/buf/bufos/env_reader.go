@@ -4,9 +4,9 @@ import (
 	"bytes"
 	"compress/gzip"
 	"context"
+	"crypto/tls"
 	"io"
 	"io/ioutil"
-	"net/http"
 	"os"
 	"path/filepath"
 	"sort"
@@ -16,9 +16,11 @@ import (
 	"github.com/bufbuild/buf/internal/buf/bufconfig"
 	"github.com/bufbuild/buf/internal/buf/bufos/internal"
 	"github.com/bufbuild/buf/internal/buf/bufpb"
+	"github.com/bufbuild/buf/internal/buf/bufreflect"
 	"github.com/bufbuild/buf/internal/pkg/analysis"
 	"github.com/bufbuild/buf/internal/pkg/bytepool"
 	"github.com/bufbuild/buf/internal/pkg/errs"
+	"github.com/bufbuild/buf/internal/pkg/httpget"
 	"github.com/bufbuild/buf/internal/pkg/logutil"
 	"github.com/bufbuild/buf/internal/pkg/osutil"
 	"github.com/bufbuild/buf/internal/pkg/storage"
@@ -33,9 +35,10 @@ import (
 type envReader struct {
 	logger               *zap.Logger
 	segList              *bytepool.SegList
-	httpClient           *http.Client
+	httpGetter           httpget.Getter
 	configProvider       bufconfig.Provider
 	buildHandler         bufbuild.Handler
+	reflectReader        bufreflect.Reader
 	inputRefParser       internal.InputRefParser
 	configOverrideParser internal.ConfigOverrideParser
 }
@@ -43,7 +46,7 @@ type envReader struct {
 func newEnvReader(
 	logger *zap.Logger,
 	segList *bytepool.SegList,
-	httpClient *http.Client,
+	httpGetter httpget.Getter,
 	configProvider bufconfig.Provider,
 	buildHandler bufbuild.Handler,
 	valueFlagName string,
@@ -52,9 +55,10 @@ func newEnvReader(
 	return &envReader{
 		logger:         logger.Named("bufos"),
 		segList:        segList,
-		httpClient:     httpClient,
+		httpGetter:     httpGetter,
 		configProvider: configProvider,
 		buildHandler:   buildHandler,
+		reflectReader:  bufreflect.NewReader(logger),
 		inputRefParser: internal.NewInputRefParser(
 			valueFlagName,
 		),
@@ -144,6 +148,66 @@ func (e *envReader) ReadImageEnv(
 	return env, nil
 }
 
+func (e *envReader) ReadBucketEnv(
+	ctx context.Context,
+	stdin io.Reader,
+	value string,
+	configOverride string,
+	specificFilePaths []string,
+) (_ *BucketEnv, retErr error) {
+	inputRef, err := e.inputRefParser.ParseInputRef(value, true, false)
+	if err != nil {
+		return nil, err
+	}
+	e.logger.Debug("parse", zap.Any("input_ref", inputRef), zap.Stringer("format", inputRef.Format))
+
+	bucket, err := e.getBucket(ctx, stdin, inputRef)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if retErr != nil {
+			retErr = errs.Append(retErr, bucket.Close())
+		}
+	}()
+	var config *bufconfig.Config
+	if configOverride != "" {
+		config, err = e.configOverrideParser.ParseConfigOverride(configOverride)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		// if there is no config override, we read the config from the bucket
+		// if there was no file, this just returns default config
+		config, err = e.configProvider.GetConfigForBucket(ctx, bucket)
+		if err != nil {
+			return nil, err
+		}
+	}
+	specificRealFilePaths, err := getSpecificRealFilePaths(inputRef, specificFilePaths)
+	if err != nil {
+		return nil, err
+	}
+	bucketEnv := &BucketEnv{
+		Bucket:                bucket,
+		Config:                config,
+		SpecificRealFilePaths: specificRealFilePaths,
+	}
+	if inputRef.Format == internal.FormatDir {
+		// directory buckets are created with storageos.NewBucket, so this is always writable
+		writeBucket, ok := bucket.(storage.Bucket)
+		if !ok {
+			return nil, errs.NewInternalf("expected bucket for %s to be writable", inputRef.Path)
+		}
+		bucketEnv.WriteBucket = writeBucket
+		bucketEnv.Resolver, err = internal.NewRelProtoFilePathResolver(inputRef.Path, nil)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return bucketEnv, nil
+}
+
 func (e *envReader) ListFiles(
 	ctx context.Context,
 	stdin io.Reader,
@@ -219,6 +283,67 @@ func (e *envReader) ListFiles(
 	return filePaths, nil
 }
 
+func (e *envReader) GetWatchPaths(
+	ctx context.Context,
+	value string,
+	configOverride string,
+) (_ *WatchPaths, retErr error) {
+	inputRef, err := e.inputRefParser.ParseInputRef(value, false, false)
+	if err != nil {
+		return nil, err
+	}
+	path := inputRef.Path
+	switch {
+	case inputRef.Format == internal.FormatGit,
+		inputRef.Format == internal.FormatGRPC,
+		path == "-",
+		strings.HasPrefix(path, "http://"),
+		strings.HasPrefix(path, "https://"):
+		return nil, nil
+	case strings.HasPrefix(path, "file://"):
+		return &WatchPaths{
+			Paths: []string{strings.TrimPrefix(path, "file://")},
+		}, nil
+	case inputRef.Format == internal.FormatDir:
+		var config *bufconfig.Config
+		if configOverride != "" {
+			config, err = e.configOverrideParser.ParseConfigOverride(configOverride)
+			if err != nil {
+				return nil, err
+			}
+		} else {
+			bucket, err := e.getBucket(ctx, nil, inputRef)
+			if err != nil {
+				return nil, err
+			}
+			defer func() {
+				retErr = errs.Append(retErr, bucket.Close())
+			}()
+			// if there was no file, this just returns default config
+			config, err = e.configProvider.GetConfigForBucket(ctx, bucket)
+			if err != nil {
+				return nil, err
+			}
+		}
+		watchPaths := &WatchPaths{}
+		for _, root := range config.Build.Roots {
+			watchPaths.Paths = append(watchPaths.Paths, filepath.Join(path, filepath.FromSlash(root)))
+		}
+		if configOverride == "" {
+			// the config file is watched even if it does not exist so that its creation is seen
+			watchPaths.Paths = append(watchPaths.Paths, filepath.Join(path, bufconfig.ConfigFilePath))
+		}
+		for _, exclude := range config.Build.Excludes {
+			watchPaths.ExcludePaths = append(watchPaths.ExcludePaths, filepath.Join(path, filepath.FromSlash(exclude)))
+		}
+		return watchPaths, nil
+	default:
+		return &WatchPaths{
+			Paths: []string{path},
+		}, nil
+	}
+}
+
 func (e *envReader) GetConfig(
 	ctx context.Context,
 	configOverride string,
@@ -317,44 +442,12 @@ func (e *envReader) readEnvFromBucket(
 			return nil, nil, err
 		}
 	}
-	var specificRealFilePaths []string
-	if len(specificFilePaths) > 0 {
-		// since we are doing a build, we filter before doing the build
-		// via bufbuild.Provider
-		// this will include imports if necessary
-		specificRealFilePaths = make([]string, len(specificFilePaths))
-		if inputRef.Format == internal.FormatDir {
-			// if we had a directory input, then we need to make everything relative to that directory
-			absDirPath, err := filepath.Abs(inputRef.Path)
-			if err != nil {
-				return nil, nil, err
-			}
-			for i, specificFilePath := range specificFilePaths {
-				absSpecificFilePath, err := filepath.Abs(specificFilePath)
-				if err != nil {
-					return nil, nil, err
-				}
-				rel, err := filepath.Rel(absDirPath, absSpecificFilePath)
-				if err != nil {
-					return nil, nil, err
-				}
-				specificRealFilePath, err := storagepath.NormalizeAndValidate(rel)
-				if err != nil {
-					return nil, nil, err
-				}
-				specificRealFilePaths[i] = specificRealFilePath
-			}
-		} else {
-			// if we did not have a directory input, then we need to make sure all paths are normalized
-			// and relative
-			for i, specificFilePath := range specificFilePaths {
-				specificRealFilePath, err := storagepath.NormalizeAndValidate(specificFilePath)
-				if err != nil {
-					return nil, nil, err
-				}
-				specificRealFilePaths[i] = specificRealFilePath
-			}
-		}
+	// since we are doing a build, we filter before doing the build
+	// via bufbuild.Provider
+	// this will include imports if necessary
+	specificRealFilePaths, err := getSpecificRealFilePaths(inputRef, specificFilePaths)
+	if err != nil {
+		return nil, nil, err
 	}
 	// we now have everything we need, actually build the image
 	image, rootResolver, annotations, err := e.buildHandler.BuildImage(
@@ -431,8 +524,8 @@ func (e *envReader) getBucket(
 	switch inputRef.Format {
 	case internal.FormatDir:
 		return e.getBucketFromLocalDir(inputRef.Path)
-	case internal.FormatTar, internal.FormatTarGz:
-		return e.getBucketFromLocalTarball(
+	case internal.FormatTar, internal.FormatTarGz, internal.FormatZip:
+		return e.getBucketFromLocalArchive(
 			ctx,
 			stdin,
 			inputRef.Format,
@@ -440,10 +533,19 @@ func (e *envReader) getBucket(
 			inputRef.StripComponents,
 		)
 	case internal.FormatGit:
+		if !strings.Contains(inputRef.Path, "://") {
+			return e.getBucketFromLocalGitRepo(inputRef)
+		}
 		return e.getBucketFromGitRepo(
 			ctx,
 			inputRef.Path,
-			inputRef.GitBranch,
+			storagegit.CloneOptions{
+				Branch: inputRef.GitBranch,
+				Tag:    inputRef.GitTag,
+				Ref:    inputRef.GitRef,
+				SubDir: inputRef.GitSubDir,
+				Depth:  inputRef.GitDepth,
+			},
 		)
 	default:
 		return nil, errs.NewInternalf("unknown format outside of parse: %v", inputRef.Format)
@@ -457,7 +559,21 @@ func (e *envReader) getImage(
 ) (bufpb.Image, error) {
 	switch inputRef.Format {
 	case internal.FormatBin, internal.FormatBinGz, internal.FormatJSON, internal.FormatJSONGz:
-		return e.getImageFromLocalFile(ctx, stdin, inputRef.Format, inputRef.Path)
+		image, err := e.getImageFromLocalFile(ctx, stdin, inputRef.Format, inputRef.Path)
+		if err != nil {
+			return nil, err
+		}
+		// images not built with buf, such as those from protoc, do not record imports
+		switch {
+		case len(inputRef.ImageTargets) > 0:
+			return bufpb.ImageWithTargets(image, inputRef.ImageTargets...)
+		case inputRef.ImageInferImports:
+			return bufpb.ImageWithInferredTargets(image)
+		default:
+			return image, nil
+		}
+	case internal.FormatGRPC:
+		return e.getImageFromGRPC(ctx, inputRef.Path, inputRef.GRPCTLS)
 	default:
 		return nil, errs.NewInternalf("unknown format outside of parse: %v", inputRef.Format)
 	}
@@ -477,8 +593,8 @@ func (e *envReader) getBucketFromLocalDir(
 	return bucket, nil
 }
 
-// Can handle formats FormatTar, FormatTarGz
-func (e *envReader) getBucketFromLocalTarball(
+// Can handle formats FormatTar, FormatTarGz, FormatZip
+func (e *envReader) getBucketFromLocalArchive(
 	ctx context.Context,
 	stdin io.Reader,
 	format internal.Format,
@@ -500,42 +616,60 @@ func (e *envReader) getBucketFromLocalTarball(
 		)
 	}
 	bucket := storagemem.NewBucket(e.segList)
+	errPrefix := "untar"
 	switch format {
 	case internal.FormatTar:
 		err = storageutil.Untar(ctx, bytes.NewReader(data), bucket, transformerOptions...)
 	case internal.FormatTarGz:
 		err = storageutil.Untargz(ctx, bytes.NewReader(data), bucket, transformerOptions...)
+	case internal.FormatZip:
+		errPrefix = "unzip"
+		err = storageutil.Unzip(ctx, bytes.NewReader(data), int64(len(data)), bucket, transformerOptions...)
 	default:
-		return nil, errs.NewInternalf("got image format %v outside of parse", format)
+		return nil, errs.Append(errs.NewInternalf("got image format %v outside of parse", format), bucket.Close())
 	}
 	if err != nil {
 		// TODO: this isn't really an invalid argument
-		return nil, errs.Append(errs.NewInvalidArgumentf("untar error: %v", err), bucket.Close())
+		return nil, errs.Append(errs.NewInvalidArgumentf("%s error: %v", errPrefix, err), bucket.Close())
 	}
 	return bucket, nil
 }
 
-// For FormatGit
+// For FormatGit with a local path
+//
+// The repository is read in place instead of being cloned.
+func (e *envReader) getBucketFromLocalGitRepo(
+	inputRef *internal.InputRef,
+) (storage.ReadBucket, error) {
+	defer logutil.Defer(e.logger, "get_git_bucket_local")()
+
+	if inputRef.GitWorktree {
+		return storagegit.NewReadBucketForWorktree(inputRef.Path, inputRef.GitSubDir)
+	}
+	ref := inputRef.GitRef
+	switch {
+	case inputRef.GitBranch != "":
+		ref = "refs/heads/" + inputRef.GitBranch
+	case inputRef.GitTag != "":
+		ref = "refs/tags/" + inputRef.GitTag
+	}
+	return storagegit.NewReadBucketForRef(inputRef.Path, ref, inputRef.GitSubDir)
+}
+
+// For FormatGit with a URL
 func (e *envReader) getBucketFromGitRepo(
 	ctx context.Context,
 	gitRepo string,
-	gitBranch string,
+	cloneOptions storagegit.CloneOptions,
 ) (_ storage.ReadBucket, retErr error) {
 	defer logutil.Defer(e.logger, "get_git_bucket_memory")()
 
-	if !strings.Contains(gitRepo, "://") {
-		absGitRepo, err := filepath.Abs(gitRepo)
-		if err != nil {
-			return nil, err
-		}
-		gitRepo = "file://" + absGitRepo
-	}
 	bucket := storagemem.NewBucket(e.segList)
 	if err := storagegit.Clone(
 		ctx,
 		e.logger,
 		gitRepo,
-		gitBranch,
+		cloneOptions,
 		bucket,
 		storagepath.WithExt(".proto"),
 		storagepath.WithExactPath(bufconfig.ConfigFilePath),
@@ -563,44 +697,31 @@ func (e *envReader) getImageFromLocalFile(
 	return e.getImageFromData(format, data)
 }
 
+// Can handle formats FormatGRPC
+func (e *envReader) getImageFromGRPC(
+	ctx context.Context,
+	address string,
+	useTLS bool,
+) (bufpb.Image, error) {
+	var options []bufreflect.ReadOption
+	if useTLS {
+		// the zero value uses the system roots and the host of the address as the server name
+		options = append(options, bufreflect.ReadWithTLS(&tls.Config{}))
+	}
+	return e.reflectReader.ReadImage(ctx, address, options...)
+}
+
 func (e *envReader) getFileData(
 	ctx context.Context,
 	stdin io.Reader,
 	path string,
 ) ([]byte, error) {
 	if strings.HasPrefix(path, "http://") || strings.HasPrefix(path, "https://") {
-		return e.getFileDataFromHTTP(ctx, path)
+		return e.httpGetter.Get(ctx, path)
 	}
 	return e.getFileDataFromOS(stdin, path)
 }
 
-func (e *envReader) getFileDataFromHTTP(
-	ctx context.Context,
-	path string,
-) (_ []byte, retErr error) {
-	request, err := http.NewRequestWithContext(ctx, "GET", path, nil)
-	if err != nil {
-		return nil, err
-	}
-	response, err := e.httpClient.Do(request)
-	if err != nil {
-		return nil, err
-	}
-	defer func() {
-		retErr = errs.Append(retErr, response.Body.Close())
-	}()
-	if response.StatusCode != http.StatusOK {
-		// TODO: not really an invalid argument
-		return nil, errs.NewInvalidArgumentf("got HTTP status code %d for %s", response.StatusCode, path)
-	}
-	data, err := ioutil.ReadAll(response.Body)
-	if err != nil {
-		// TODO: not really an invalid argument
-		return nil, errs.NewInvalidArgumentf("could not read %s: %v", path, err)
-	}
-	return data, nil
-}
-
 func (e *envReader) getFileDataFromOS(
 	stdin io.Reader,
 	path string,
@@ -665,3 +786,47 @@ func (e *envReader) getImageFromData(
 	}
 	return image, nil
 }
+
+// getSpecificRealFilePaths converts the specific file paths to real file paths
+// within the bucket for the input.
+//
+// Returns nil if specificFilePaths is empty.
+func getSpecificRealFilePaths(inputRef *internal.InputRef, specificFilePaths []string) ([]string, error) {
+	if len(specificFilePaths) == 0 {
+		return nil, nil
+	}
+	specificRealFilePaths := make([]string, len(specificFilePaths))
+	if inputRef.Format == internal.FormatDir {
+		// if we had a directory input, then we need to make everything relative to that directory
+		absDirPath, err := filepath.Abs(inputRef.Path)
+		if err != nil {
+			return nil, err
+		}
+		for i, specificFilePath := range specificFilePaths {
+			absSpecificFilePath, err := filepath.Abs(specificFilePath)
+			if err != nil {
+				return nil, err
+			}
+			rel, err := filepath.Rel(absDirPath, absSpecificFilePath)
+			if err != nil {
+				return nil, err
+			}
+			specificRealFilePath, err := storagepath.NormalizeAndValidate(rel)
+			if err != nil {
+				return nil, err
+			}
+			specificRealFilePaths[i] = specificRealFilePath
+		}
+		return specificRealFilePaths, nil
+	}
+	// if we did not have a directory input, then we need to make sure all paths are normalized
+	// and relative
+	for i, specificFilePath := range specificFilePaths {
+		specificRealFilePath, err := storagepath.NormalizeAndValidate(specificFilePath)
+		if err != nil {
+			return nil, err
+		}
+		specificRealFilePaths[i] = specificRealFilePath
+	}
+	return specificRealFilePaths, nil
+}
@@ -50,6 +50,12 @@ func (i *imageWriter) WriteImage(
 		return err
 	}
 	i.logger.Debug("parse", zap.Any("input_ref", inputRef), zap.Stringer("format", inputRef.Format))
+	if !inputRef.Format.IsFile() {
+		return errs.NewInvalidArgumentf("cannot write an image to format %q", inputRef.Format.String())
+	}
+	if len(inputRef.ImageTargets) > 0 || inputRef.ImageInferImports {
+		return errs.NewInvalidArgumentf("%q: target and infer_imports are only valid when reading an image", value)
+	}
 	// we now know the format this is only one of FormatBin, FormatBinGz, FormatJSON, FormatJSONGz
 
 	var marshaler protodescpb.Marshaler = image
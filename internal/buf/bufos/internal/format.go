@@ -25,6 +25,10 @@ const (
 	FormatJSON Format = 7
 	// FormatJSONGz is a format.
 	FormatJSONGz Format = 8
+	// FormatZip is a format.
+	FormatZip Format = 9
+	// FormatGRPC is a format.
+	FormatGRPC Format = 10
 )
 
 var (
@@ -37,6 +41,8 @@ var (
 		FormatBinGz:  "bingz",
 		FormatJSON:   "json",
 		FormatJSONGz: "jsongz",
+		FormatZip:    "zip",
+		FormatGRPC:   "grpc",
 	}
 	stringToFormat = map[string]Format{
 		"dir":    FormatDir,
@@ -47,6 +53,8 @@ var (
 		"bingz":  FormatBinGz,
 		"json":   FormatJSON,
 		"jsongz": FormatJSONGz,
+		"zip":    FormatZip,
+		"grpc":   FormatGRPC,
 	}
 
 	formatToIsSource = map[Format]struct{}{
@@ -54,12 +62,14 @@ var (
 		FormatTar:   struct{}{},
 		FormatTarGz: struct{}{},
 		FormatGit:   struct{}{},
+		FormatZip:   struct{}{},
 	}
 	formatToIsImage = map[Format]struct{}{
 		FormatBin:    struct{}{},
 		FormatBinGz:  struct{}{},
 		FormatJSON:   struct{}{},
 		FormatJSONGz: struct{}{},
+		FormatGRPC:   struct{}{},
 	}
 	formatToIsFile = map[Format]struct{}{
 		FormatTar:    struct{}{},
@@ -68,6 +78,7 @@ var (
 		FormatBinGz:  struct{}{},
 		FormatJSON:   struct{}{},
 		FormatJSONGz: struct{}{},
+		FormatZip:    struct{}{},
 	}
 )
 
@@ -91,14 +102,14 @@ func (f Format) IsSource() bool {
 
 // IsImage returns true if f represents a image type.
 //
-// Images are alway files
+// Images are always files, except for FormatGRPC, which is read from a server.
 func (f Format) IsImage() bool {
 	_, ok := formatToIsImage[f]
 	return ok
 }
 
-// isFile returns true if f represents a file type.
-func (f Format) isFile() bool {
+// IsFile returns true if f represents a file type.
+func (f Format) IsFile() bool {
 	_, ok := formatToIsFile[f]
 	return ok
 }
@@ -118,6 +129,19 @@ func ImageFormatsToString() string {
 	return formatsToString(imageFormats())
 }
 
+// ImageFileFormatsToString returns image format strings that are also file formats.
+//
+// These are the formats that images can be written to.
+func ImageFileFormatsToString() string {
+	var formats []Format
+	for _, format := range imageFormats() {
+		if format.IsFile() {
+			formats = append(formats, format)
+		}
+	}
+	return formatsToString(formats)
+}
+
 // allFormats returns a new slice that contains all the formats.
 func allFormats() []Format {
 	formats := make([]Format, 0, len(formatToString))
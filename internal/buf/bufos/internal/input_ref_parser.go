@@ -7,6 +7,7 @@ import (
 
 	"github.com/bufbuild/buf/internal/pkg/errs"
 	"github.com/bufbuild/buf/internal/pkg/osutil"
+	"github.com/bufbuild/buf/internal/pkg/storage/storagepath"
 )
 
 type inputRefParser struct {
@@ -58,14 +59,29 @@ func (i *inputRefParser) ParseInputRef(value string, onlySources bool, onlyImage
 		}
 		inputRef.Format = format
 	}
+	if inputRef.Format == FormatGRPC {
+		inputRef.Path = strings.TrimPrefix(path, grpcPrefix)
+		if inputRef.Path == "" {
+			return nil, newPathEmptyGRPCAddressError(i.valueFlagName, value)
+		}
+	}
 
-	if inputRef.Format == FormatGit && inputRef.GitBranch == "" {
-		return nil, newMustSpecifyGitBranchError(i.valueFlagName, value)
+	if inputRef.Format == FormatGit && !hasGitRef(inputRef) {
+		return nil, newMustSpecifyGitRefError(i.valueFlagName, value)
+	}
+	if inputRef.GitWorktree && strings.Contains(path, "://") {
+		return nil, newOptionsWorktreeNotLocalError(i.valueFlagName, path)
+	}
+	if inputRef.Format != FormatGit && hasGitOptions(inputRef) {
+		return nil, newOptionsInvalidForFormatError(i.valueFlagName, inputRef.Format, options)
+	}
+	if inputRef.Format != FormatTar && inputRef.Format != FormatTarGz && inputRef.Format != FormatZip && inputRef.StripComponents > 0 {
+		return nil, newOptionsInvalidForFormatError(i.valueFlagName, inputRef.Format, options)
 	}
-	if inputRef.Format != FormatGit && inputRef.GitBranch != "" {
+	if inputRef.Format != FormatGRPC && inputRef.GRPCTLS {
 		return nil, newOptionsInvalidForFormatError(i.valueFlagName, inputRef.Format, options)
 	}
-	if inputRef.Format != FormatTar && inputRef.Format != FormatTarGz && inputRef.StripComponents > 0 {
+	if !(inputRef.Format.IsImage() && inputRef.Format.IsFile()) && hasImageOptions(inputRef) {
 		return nil, newOptionsInvalidForFormatError(i.valueFlagName, inputRef.Format, options)
 	}
 
@@ -75,7 +91,7 @@ func (i *inputRefParser) ParseInputRef(value string, onlySources bool, onlyImage
 	if onlyImages && !inputRef.Format.IsImage() {
 		return nil, newFormatMustBeImageError(inputRef.Format)
 	}
-	if path == "-" && !inputRef.Format.isFile() {
+	if path == "-" && !inputRef.Format.IsFile() {
 		return nil, newFormatNotFileForDashPathError(i.valueFlagName, inputRef.Format)
 	}
 
@@ -93,16 +109,23 @@ func (i *inputRefParser) parseFormatFromPath(path string) (Format, error) {
 	if path == "-" || path == devNull {
 		return FormatBin, nil
 	}
+	// this must be checked before extensions as addresses can look like they have them, ie 127.0.0.1:443
+	if strings.HasPrefix(path, grpcPrefix) {
+		return FormatGRPC, nil
+	}
 	switch filepath.Ext(path) {
 	case ".bin":
 		return FormatBin, nil
+	case ".pb", ".desc", ".protoset":
+		// FileDescriptorSets produced by protoc -o are valid binary images
+		return FormatBin, nil
 	case ".json":
 		return FormatJSON, nil
 	case ".tar":
 		return FormatTar, nil
 	case ".gz":
 		switch filepath.Ext(strings.TrimSuffix(path, filepath.Ext(path))) {
-		case ".bin":
+		case ".bin", ".pb", ".desc", ".protoset":
 			return FormatBinGz, nil
 		case ".json":
 			return FormatJSONGz, nil
@@ -113,6 +136,8 @@ func (i *inputRefParser) parseFormatFromPath(path string) (Format, error) {
 		}
 	case ".tgz":
 		return FormatTarGz, nil
+	case ".zip":
+		return FormatZip, nil
 	case ".git":
 		return FormatGit, nil
 	default:
@@ -149,8 +174,68 @@ func (i *inputRefParser) applyInputRefOptions(inputRef *InputRef, options string
 				return err
 			}
 			inputRef.Format = format
-		case "branch":
-			inputRef.GitBranch = value
+		case "branch", "tag", "commit", "ref":
+			if hasGitRef(inputRef) {
+				return newOptionsMultipleGitRefsError(i.valueFlagName, options)
+			}
+			switch key {
+			case "branch":
+				inputRef.GitBranch = value
+			case "tag":
+				inputRef.GitTag = value
+			case "commit", "ref":
+				inputRef.GitRef = value
+			}
+		case "worktree":
+			worktree, err := strconv.ParseBool(value)
+			if err != nil {
+				return newOptionsCouldNotParseWorktreeError(i.valueFlagName, value)
+			}
+			if !worktree {
+				continue
+			}
+			if hasGitRef(inputRef) {
+				return newOptionsMultipleGitRefsError(i.valueFlagName, options)
+			}
+			inputRef.GitWorktree = true
+		case "subdir":
+			subDir, err := storagepath.NormalizeAndValidate(value)
+			if err != nil {
+				return newOptionsInvalidSubDirError(i.valueFlagName, value)
+			}
+			if subDir != "." {
+				inputRef.GitSubDir = subDir
+			}
+		case "depth":
+			depth, err := strconv.ParseUint(value, 10, 32)
+			if err != nil || depth == 0 {
+				return newOptionsCouldNotParseDepthError(i.valueFlagName, value)
+			}
+			inputRef.GitDepth = uint32(depth)
+		case "tls":
+			tls, err := strconv.ParseBool(value)
+			if err != nil {
+				return newOptionsCouldNotParseTLSError(i.valueFlagName, value)
+			}
+			inputRef.GRPCTLS = tls
+		case "target":
+			if inputRef.ImageInferImports {
+				return newOptionsTargetAndInferImportsError(i.valueFlagName, options)
+			}
+			target, err := storagepath.NormalizeAndValidate(value)
+			if err != nil {
+				return newOptionsInvalidTargetError(i.valueFlagName, value)
+			}
+			inputRef.ImageTargets = append(inputRef.ImageTargets, target)
+		case "infer_imports":
+			inferImports, err := strconv.ParseBool(value)
+			if err != nil {
+				return newOptionsCouldNotParseInferImportsError(i.valueFlagName, value)
+			}
+			if inferImports && len(inputRef.ImageTargets) > 0 {
+				return newOptionsTargetAndInferImportsError(i.valueFlagName, options)
+			}
+			inputRef.ImageInferImports = inferImports
 		case "strip_components":
 			stripComponents, err := strconv.ParseUint(value, 10, 32)
 			if err != nil {
@@ -164,6 +249,24 @@ func (i *inputRefParser) applyInputRefOptions(inputRef *InputRef, options string
 	return nil
 }
 
+func hasGitRef(inputRef *InputRef) bool {
+	return inputRef.GitBranch != "" ||
+		inputRef.GitTag != "" ||
+		inputRef.GitRef != "" ||
+		inputRef.GitWorktree
+}
+
+func hasGitOptions(inputRef *InputRef) bool {
+	return hasGitRef(inputRef) ||
+		inputRef.GitSubDir != "" ||
+		inputRef.GitDepth > 0
+}
+
+func hasImageOptions(inputRef *InputRef) bool {
+	return len(inputRef.ImageTargets) > 0 ||
+		inputRef.ImageInferImports
+}
+
 func newValueEmptyError(valueFlagName string) error {
 	return errs.NewInvalidArgumentf("%s is required", valueFlagName)
 }
@@ -192,8 +295,12 @@ func newFormatMustBeImageError(format Format) error {
 	return errs.NewInvalidArgumentf("format was %q but must be a image format (allowed formats are %s)", format.String(), formatsToString(imageFormats()))
 }
 
-func newMustSpecifyGitBranchError(valueFlagName string, path string) error {
-	return errs.NewInvalidArgumentf(`%s: must specify git branch (example: "%s#branch=master")`, valueFlagName, path)
+func newMustSpecifyGitRefError(valueFlagName string, path string) error {
+	return errs.NewInvalidArgumentf(`%s: must specify git branch, tag, commit, ref, or worktree (example: "%s#branch=master")`, valueFlagName, path)
+}
+
+func newPathEmptyGRPCAddressError(valueFlagName string, value string) error {
+	return errs.NewInvalidArgumentf(`%s: %q has no gRPC server address (example: "grpc://localhost:8080")`, valueFlagName, value)
 }
 
 func newPathUnknownGzError(valueFlagName string, path string) error {
@@ -216,6 +323,42 @@ func newOptionsCouldNotParseStripComponentsError(valueFlagName string, s string)
 	return errs.NewInvalidArgumentf("%s: could not parse strip_components value %q", valueFlagName, s)
 }
 
+func newOptionsCouldNotParseTLSError(valueFlagName string, s string) error {
+	return errs.NewInvalidArgumentf("%s: could not parse tls value %q", valueFlagName, s)
+}
+
+func newOptionsInvalidTargetError(valueFlagName string, s string) error {
+	return errs.NewInvalidArgumentf("%s: target value %q must be a relative path within the image", valueFlagName, s)
+}
+
+func newOptionsCouldNotParseInferImportsError(valueFlagName string, s string) error {
+	return errs.NewInvalidArgumentf("%s: could not parse infer_imports value %q", valueFlagName, s)
+}
+
+func newOptionsTargetAndInferImportsError(valueFlagName string, s string) error {
+	return errs.NewInvalidArgumentf("%s: only one of target or infer_imports can be specified: %q", valueFlagName, s)
+}
+
+func newOptionsMultipleGitRefsError(valueFlagName string, s string) error {
+	return errs.NewInvalidArgumentf("%s: only one of branch, tag, commit, ref, or worktree can be specified: %q", valueFlagName, s)
+}
+
+func newOptionsCouldNotParseWorktreeError(valueFlagName string, s string) error {
+	return errs.NewInvalidArgumentf("%s: could not parse worktree value %q", valueFlagName, s)
+}
+
+func newOptionsWorktreeNotLocalError(valueFlagName string, path string) error {
+	return errs.NewInvalidArgumentf("%s: worktree is only valid for local git repositories but path was %q", valueFlagName, path)
+}
+
+func newOptionsInvalidSubDirError(valueFlagName string, s string) error {
+	return errs.NewInvalidArgumentf("%s: subdir value %q must be a relative path within the repository", valueFlagName, s)
+}
+
+func newOptionsCouldNotParseDepthError(valueFlagName string, s string) error {
+	return errs.NewInvalidArgumentf("%s: could not parse depth value %q, must be a positive integer", valueFlagName, s)
+}
+
 func newFormatOverrideNotAllowedForDevNullError(valueFlagName string, devNull string) error {
 	return errs.NewInvalidArgumentf("%s: not allowed if path is %s", valueFlagName, devNull)
 }
@@ -83,6 +83,97 @@ func TestParseInputRefSuccess(t *testing.T) {
 		},
 		"path/to/dir.git#branch=master",
 	)
+	testParseInputRefSuccess(
+		t,
+		&InputRef{
+			Format: FormatZip,
+			Path:   "path/to/file.zip",
+		},
+		"path/to/file.zip",
+	)
+	testParseInputRefSuccess(
+		t,
+		&InputRef{
+			Format:          FormatZip,
+			Path:            "path/to/file",
+			StripComponents: 1,
+		},
+		"path/to/file#format=zip,strip_components=1",
+	)
+	testParseInputRefSuccess(
+		t,
+		&InputRef{
+			Format: FormatZip,
+			Path:   "-",
+		},
+		"-#format=zip",
+	)
+	testParseInputRefSuccess(
+		t,
+		&InputRef{
+			Format: FormatGit,
+			Path:   "path/to/dir.git",
+			GitTag: "v1.2.0",
+		},
+		"path/to/dir.git#tag=v1.2.0",
+	)
+	testParseInputRefSuccess(
+		t,
+		&InputRef{
+			Format: FormatGit,
+			Path:   "path/to/dir.git",
+			GitRef: "0123456789abcdef0123456789abcdef01234567",
+		},
+		"path/to/dir.git#commit=0123456789abcdef0123456789abcdef01234567",
+	)
+	testParseInputRefSuccess(
+		t,
+		&InputRef{
+			Format:   FormatGit,
+			Path:     "path/to/dir.git",
+			GitRef:   "HEAD~1",
+			GitDepth: 10,
+		},
+		"path/to/dir.git#ref=HEAD~1,depth=10",
+	)
+	testParseInputRefSuccess(
+		t,
+		&InputRef{
+			Format:    FormatGit,
+			Path:      "path/to/dir.git",
+			GitBranch: "master",
+			GitSubDir: "proto/acme",
+		},
+		"path/to/dir.git#branch=master,subdir=proto/acme/",
+	)
+	testParseInputRefSuccess(
+		t,
+		&InputRef{
+			Format:    FormatGit,
+			Path:      "path/to/dir.git",
+			GitBranch: "master",
+		},
+		"path/to/dir.git#branch=master,subdir=.",
+	)
+	testParseInputRefSuccess(
+		t,
+		&InputRef{
+			Format:      FormatGit,
+			Path:        ".git",
+			GitWorktree: true,
+			GitSubDir:   "proto",
+		},
+		".git#worktree=true,subdir=proto",
+	)
+	testParseInputRefSuccess(
+		t,
+		&InputRef{
+			Format: FormatGit,
+			Path:   ".git",
+			GitRef: "HEAD",
+		},
+		".git#worktree=false,ref=HEAD",
+	)
 	testParseInputRefSuccess(
 		t,
 		&InputRef{
@@ -174,6 +265,73 @@ func TestParseInputRefSuccess(t *testing.T) {
 		},
 		"path/to/file#format=targz,strip_components=1",
 	)
+	testParseInputRefSuccess(
+		t,
+		&InputRef{
+			Format: FormatGRPC,
+			Path:   "127.0.0.1:8080",
+		},
+		"grpc://127.0.0.1:8080",
+	)
+	testParseInputRefSuccess(
+		t,
+		&InputRef{
+			Format:  FormatGRPC,
+			Path:    "api.example.com:443",
+			GRPCTLS: true,
+		},
+		"grpc://api.example.com:443#tls=true",
+	)
+	testParseInputRefSuccess(
+		t,
+		&InputRef{
+			Format: FormatGRPC,
+			Path:   "localhost:8080",
+		},
+		"localhost:8080#format=grpc,tls=false",
+	)
+	testParseInputRefSuccess(
+		t,
+		&InputRef{
+			Format: FormatBin,
+			Path:   "path/to/file.protoset",
+		},
+		"path/to/file.protoset",
+	)
+	testParseInputRefSuccess(
+		t,
+		&InputRef{
+			Format: FormatBin,
+			Path:   "path/to/file.pb",
+		},
+		"path/to/file.pb",
+	)
+	testParseInputRefSuccess(
+		t,
+		&InputRef{
+			Format: FormatBinGz,
+			Path:   "path/to/file.desc.gz",
+		},
+		"path/to/file.desc.gz",
+	)
+	testParseInputRefSuccess(
+		t,
+		&InputRef{
+			Format:       FormatBin,
+			Path:         "path/to/file.desc",
+			ImageTargets: []string{"a/a.proto", "b/b.proto"},
+		},
+		"path/to/file.desc#target=./a/a.proto,target=b/b.proto",
+	)
+	testParseInputRefSuccess(
+		t,
+		&InputRef{
+			Format:            FormatJSON,
+			Path:              "path/to/file.json",
+			ImageInferImports: true,
+		},
+		"path/to/file.json#infer_imports=true",
+	)
 }
 
 func TestParseInputRefError(t *testing.T) {
@@ -226,12 +384,12 @@ func TestParseInputRefError(t *testing.T) {
 	)
 	testParseInputRefErrorBasic(
 		t,
-		newMustSpecifyGitBranchError(testValueFlagName, "path/to/foo.git"),
+		newMustSpecifyGitRefError(testValueFlagName, "path/to/foo.git"),
 		"path/to/foo.git",
 	)
 	testParseInputRefErrorBasic(
 		t,
-		newMustSpecifyGitBranchError(testValueFlagName, "path/to/foo#format=git"),
+		newMustSpecifyGitRefError(testValueFlagName, "path/to/foo#format=git"),
 		"path/to/foo#format=git",
 	)
 	testParseInputRefErrorBasic(
@@ -294,6 +452,123 @@ func TestParseInputRefError(t *testing.T) {
 		newOptionsInvalidForFormatError(testValueFlagName, FormatDir, "strip_components=1"),
 		"path/to/foo#strip_components=1",
 	)
+	testParseInputRefErrorBasic(
+		t,
+		newOptionsMultipleGitRefsError(testValueFlagName, "branch=master,tag=v1.2.0"),
+		"path/to/foo.git#branch=master,tag=v1.2.0",
+	)
+	testParseInputRefErrorBasic(
+		t,
+		newOptionsMultipleGitRefsError(testValueFlagName, "commit=abc,ref=def"),
+		"path/to/foo.git#commit=abc,ref=def",
+	)
+	testParseInputRefErrorBasic(
+		t,
+		newOptionsMultipleGitRefsError(testValueFlagName, "ref=HEAD,worktree=true"),
+		"path/to/foo.git#ref=HEAD,worktree=true",
+	)
+	testParseInputRefErrorBasic(
+		t,
+		newOptionsCouldNotParseWorktreeError(testValueFlagName, "foo"),
+		"path/to/foo.git#worktree=foo",
+	)
+	testParseInputRefErrorBasic(
+		t,
+		newOptionsWorktreeNotLocalError(testValueFlagName, "https://example.com/foo.git"),
+		"https://example.com/foo.git#worktree=true",
+	)
+	testParseInputRefErrorBasic(
+		t,
+		newMustSpecifyGitRefError(testValueFlagName, "path/to/foo.git#subdir=proto"),
+		"path/to/foo.git#subdir=proto",
+	)
+	testParseInputRefErrorBasic(
+		t,
+		newOptionsInvalidSubDirError(testValueFlagName, "../proto"),
+		"path/to/foo.git#branch=master,subdir=../proto",
+	)
+	testParseInputRefErrorBasic(
+		t,
+		newOptionsInvalidSubDirError(testValueFlagName, "/proto"),
+		"path/to/foo.git#branch=master,subdir=/proto",
+	)
+	testParseInputRefErrorBasic(
+		t,
+		newOptionsCouldNotParseDepthError(testValueFlagName, "0"),
+		"path/to/foo.git#branch=master,depth=0",
+	)
+	testParseInputRefErrorBasic(
+		t,
+		newOptionsCouldNotParseDepthError(testValueFlagName, "foo"),
+		"path/to/foo.git#branch=master,depth=foo",
+	)
+	testParseInputRefErrorBasic(
+		t,
+		newOptionsInvalidForFormatError(testValueFlagName, FormatTarGz, "tag=v1.2.0"),
+		"path/to/foo.tar.gz#tag=v1.2.0",
+	)
+	testParseInputRefErrorBasic(
+		t,
+		newOptionsInvalidForFormatError(testValueFlagName, FormatDir, "subdir=proto"),
+		"path/to/foo#subdir=proto",
+	)
+	testParseInputRefErrorBasic(
+		t,
+		newOptionsInvalidForFormatError(testValueFlagName, FormatBin, "tls=true"),
+		"path/to/foo.bin#tls=true",
+	)
+	testParseInputRefErrorBasic(
+		t,
+		newOptionsCouldNotParseTLSError(testValueFlagName, "yes please"),
+		"grpc://localhost:8080#tls=yes please",
+	)
+	testParseInputRefErrorBasic(
+		t,
+		newPathEmptyGRPCAddressError(testValueFlagName, "grpc://"),
+		"grpc://",
+	)
+	testParseInputRefErrorBasic(
+		t,
+		newFormatNotFileForDashPathError(testValueFlagName, FormatGRPC),
+		"-#format=grpc",
+	)
+	testParseInputRefErrorBasic(
+		t,
+		newOptionsInvalidForFormatError(testValueFlagName, FormatDir, "target=a.proto"),
+		"path/to/dir#target=a.proto",
+	)
+	testParseInputRefErrorBasic(
+		t,
+		newOptionsInvalidForFormatError(testValueFlagName, FormatGRPC, "infer_imports=true"),
+		"grpc://localhost:8080#infer_imports=true",
+	)
+	testParseInputRefErrorBasic(
+		t,
+		newOptionsInvalidTargetError(testValueFlagName, "../a.proto"),
+		"path/to/file.pb#target=../a.proto",
+	)
+	testParseInputRefErrorBasic(
+		t,
+		newOptionsCouldNotParseInferImportsError(testValueFlagName, "maybe"),
+		"path/to/file.pb#infer_imports=maybe",
+	)
+	testParseInputRefErrorBasic(
+		t,
+		newOptionsTargetAndInferImportsError(testValueFlagName, "target=a.proto,infer_imports=true"),
+		"path/to/file.pb#target=a.proto,infer_imports=true",
+	)
+	testParseInputRefErrorBasic(
+		t,
+		newOptionsTargetAndInferImportsError(testValueFlagName, "infer_imports=true,target=a.proto"),
+		"path/to/file.pb#infer_imports=true,target=a.proto",
+	)
+	testParseInputRefError(
+		t,
+		newFormatMustBeSourceError(FormatGRPC),
+		"grpc://localhost:8080",
+		true,
+		false,
+	)
 }
 
 func testParseInputRefSuccess(
@@ -5,6 +5,8 @@ import (
 	"github.com/bufbuild/buf/internal/buf/bufconfig"
 )
 
+const grpcPrefix = "grpc://"
+
 // InputRef is a parsed input reference.
 type InputRef struct {
 	// Format is the format of the input.
@@ -12,17 +14,48 @@ type InputRef struct {
 	Format Format
 	// Path is the path of the input.
 	// The special value "-" indicates stdin or stdout.
-	// If this is "-", Format == FormatTar, FormatTarGz, FormatBin, FormatBinGz, FormatJSON, FormatJSONGz.
+	// If this is "-", Format == FormatTar, FormatTarGz, FormatZip, FormatBin, FormatBinGz, FormatJSON, FormatJSONGz.
+	// If Format == FormatGRPC, this is the address of the server, with the grpc:// prefix removed.
 	// Required.
 	Path string
 
 	// GitBranch is the branch of the git repository.
 	// This will only be set if Format == FormatGit.
-	// Optional regardless.
+	// If Format == FormatGit, exactly one of GitBranch, GitTag, GitRef, and GitWorktree is set.
 	GitBranch string
-	// StripComponents is the number of components to strip from a tarball.
-	// This will only be set if Format == FormatTar, FormatTarGz
+	// GitTag is the tag of the git repository.
+	// This will only be set if Format == FormatGit.
+	GitTag string
+	// GitRef is the ref of the git repository, for example a commit SHA.
+	// This is set by both the commit and ref options.
+	// This will only be set if Format == FormatGit.
+	GitRef string
+	// GitWorktree says to read the working tree of a local git repository.
+	// This will only be set if Format == FormatGit and Path is not a URL.
+	GitWorktree bool
+	// GitSubDir is the subdirectory of the git repository to use.
+	// This will only be set if Format == FormatGit.
+	// Normalized and validated if set.
+	GitSubDir string
+	// GitDepth is the number of commits to clone.
+	// This will only be set if Format == FormatGit.
+	// If 0, the default depth is used. This has no effect for local
+	// repositories as they are read in place.
+	GitDepth uint32
+	// StripComponents is the number of components to strip from a tarball or zip archive.
+	// This will only be set if Format == FormatTar, FormatTarGz, FormatZip
 	StripComponents uint32
+	// GRPCTLS says to connect to the gRPC server using TLS instead of plaintext.
+	// This will only be set if Format == FormatGRPC.
+	GRPCTLS bool
+	// ImageTargets are the names of the files in the image that are not imports.
+	// This is set by repeating the target option.
+	// This will only be set if Format == FormatBin, FormatBinGz, FormatJSON, FormatJSONGz.
+	// Normalized and validated if set. At most one of ImageTargets and ImageInferImports is set.
+	ImageTargets []string
+	// ImageInferImports says to mark the files in the image that other files depend on as imports.
+	// This will only be set if Format == FormatBin, FormatBinGz, FormatJSON, FormatJSONGz.
+	ImageInferImports bool
 }
 
 // InputRefParser parses InputRefs.
@@ -30,10 +63,10 @@ type InputRefParser interface {
 	// ParseInputRef parses the InputRef from the value.
 	//
 	// Value should always be non-empty - if you want this to be ".", specify it.
-	// If onlySources is true, the Format will only be FormatDir, FormatTar, FormatTarGz, FormatGit.
-	// If onlyImages is true, the Format will only be FormatBin, FormatBinGz, FormatJSON, FormatJSONGz.
+	// If onlySources is true, the Format will only be FormatDir, FormatTar, FormatTarGz, FormatZip, FormatGit.
+	// If onlyImages is true, the Format will only be FormatBin, FormatBinGz, FormatJSON, FormatJSONGz, FormatGRPC.
 	// If onlySources and onlyImages is true, this returns system error.
-	// Format will be valid and only one of these eight types.
+	// Format will be valid and only one of these ten types.
 	ParseInputRef(value string, onlySources bool, onlyImages bool) (*InputRef, error)
 }
 
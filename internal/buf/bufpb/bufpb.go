@@ -5,10 +5,14 @@ import (
 	"bytes"
 
 	imagev1beta1 "github.com/bufbuild/buf/internal/gen/proto/bufbuild/buf/image/v1beta1"
+	"github.com/bufbuild/buf/internal/pkg/errs"
 	"github.com/bufbuild/buf/internal/pkg/protodescpb"
+	"github.com/bufbuild/buf/internal/pkg/storage/storagepath"
 	"github.com/golang/protobuf/jsonpb"
 	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/protoc-gen-go/descriptor"
 	plugin_go "github.com/golang/protobuf/protoc-gen-go/plugin"
+	"github.com/jhump/protoreflect/desc"
 )
 
 // TODO: evaluate the normalization of names
@@ -103,3 +107,206 @@ func CodeGeneratorRequestToImage(request *plugin_go.CodeGeneratorRequest) (Image
 	}
 	return image.WithSpecificNames(false, request.FileToGenerate...)
 }
+
+// ImageToDescFileDescriptors converts the Files in the Image to desc.FileDescriptors.
+//
+// The Image must be self-contained, that is every dependency of every File must be
+// within the Image. This is the case for Images built with imports.
+//
+// Returns a map from file name to desc.FileDescriptor.
+func ImageToDescFileDescriptors(image Image) (map[string]*desc.FileDescriptor, error) {
+	fileDescriptors := image.GetFile()
+	fileDescriptorProtos := make([]*descriptor.FileDescriptorProto, len(fileDescriptors))
+	for i, fileDescriptor := range fileDescriptors {
+		fileDescriptorProto, ok := fileDescriptor.(*descriptor.FileDescriptorProto)
+		if !ok {
+			return nil, errs.NewInternalf("unexpected FileDescriptor type %T", fileDescriptor)
+		}
+		fileDescriptorProtos[i] = fileDescriptorProto
+	}
+	descFileDescriptors, err := desc.CreateFileDescriptors(fileDescriptorProtos)
+	if err != nil {
+		return nil, errs.NewInvalidArgumentf("could not resolve Image: %v", err)
+	}
+	return descFileDescriptors, nil
+}
+
+// ImageWithoutSourceCodeInfo returns a copy of the Image with all SourceCodeInfo removed.
+//
+// Backing FileDescriptorProtos are copied, the input Image is not modified.
+// Validates the output.
+func ImageWithoutSourceCodeInfo(image Image) (Image, error) {
+	fileDescriptors := image.GetFile()
+	fileDescriptorProtos := make([]*descriptor.FileDescriptorProto, len(fileDescriptors))
+	for i, fileDescriptor := range fileDescriptors {
+		fileDescriptorProto, ok := fileDescriptor.(*descriptor.FileDescriptorProto)
+		if !ok {
+			return nil, errs.NewInternalf("unexpected FileDescriptor type %T", fileDescriptor)
+		}
+		fileDescriptorProto = proto.Clone(fileDescriptorProto).(*descriptor.FileDescriptorProto)
+		fileDescriptorProto.SourceCodeInfo = nil
+		fileDescriptorProtos[i] = fileDescriptorProto
+	}
+	return NewImage(
+		&imagev1beta1.Image{
+			File:                   fileDescriptorProtos,
+			BufbuildImageExtension: image.GetBufbuildImageExtension(),
+		},
+	)
+}
+
+// ImageWithTargets returns a copy of the Image with every File that is not
+// one of the target names marked as an import.
+//
+// This is for Images that were not built with buf, such as FileDescriptorSets
+// produced by protoc, which do not record which Files are imports. Any
+// existing imports are replaced.
+//
+// Target names are normalized and validated, and must exist in the Image.
+// Backing FileDescriptorProtos are not copied, only the references are copied.
+// Validates the output.
+func ImageWithTargets(image Image, targetNames ...string) (Image, error) {
+	targetNamesMap, err := getTargetNamesMap(image, targetNames)
+	if err != nil {
+		return nil, err
+	}
+	return imageWithTargetNamesMap(image, targetNamesMap, nil)
+}
+
+// ImageWithTargetsAndDependencies returns a copy of the Image with only the
+// Files with the target names and their transitive dependencies.
+//
+// Dependencies that are not one of the target names are marked as imports,
+// so that the result is self-contained if the input Image was. Any existing
+// imports are replaced. Files keep the order of the input Image.
+//
+// Target names are normalized and validated, and must exist in the Image.
+// Backing FileDescriptorProtos are not copied, only the references are copied.
+// Validates the output.
+func ImageWithTargetsAndDependencies(image Image, targetNames ...string) (Image, error) {
+	targetNamesMap, err := getTargetNamesMap(image, targetNames)
+	if err != nil {
+		return nil, err
+	}
+	nameToFile := make(map[string]protodescpb.FileDescriptor, len(image.GetFile()))
+	for _, file := range image.GetFile() {
+		nameToFile[file.GetName()] = file
+	}
+	includeNamesMap := make(map[string]struct{})
+	for targetName := range targetNamesMap {
+		addDependencyNamesRec(nameToFile, includeNamesMap, targetName)
+	}
+	return imageWithTargetNamesMap(image, targetNamesMap, includeNamesMap)
+}
+
+// ImageWithInferredTargets returns a copy of the Image with every File that
+// another File in the Image depends on marked as an import.
+//
+// The Files that nothing else depends on are the targets. This is for Images
+// that were not built with buf, see ImageWithTargets. Note that if a target
+// depends on another target, the latter will be marked as an import, in which
+// case ImageWithTargets should be used instead.
+//
+// Backing FileDescriptorProtos are not copied, only the references are copied.
+// Validates the output.
+func ImageWithInferredTargets(image Image) (Image, error) {
+	dependencyNamesMap := make(map[string]struct{})
+	for _, file := range image.GetFile() {
+		for _, dependency := range file.GetDependency() {
+			dependencyNamesMap[dependency] = struct{}{}
+		}
+	}
+	targetNamesMap := make(map[string]struct{})
+	for _, file := range image.GetFile() {
+		if _, ok := dependencyNamesMap[file.GetName()]; !ok {
+			targetNamesMap[file.GetName()] = struct{}{}
+		}
+	}
+	return imageWithTargetNamesMap(image, targetNamesMap, nil)
+}
+
+// getTargetNamesMap normalizes and validates the target names, and checks
+// that they exist in the Image.
+func getTargetNamesMap(image Image, targetNames []string) (map[string]struct{}, error) {
+	if len(targetNames) == 0 {
+		return nil, errs.NewInvalidArgument("no target names given")
+	}
+	targetNamesMap := make(map[string]struct{}, len(targetNames))
+	for _, targetName := range targetNames {
+		normalizedName, err := storagepath.NormalizeAndValidate(targetName)
+		if err != nil {
+			return nil, err
+		}
+		targetNamesMap[normalizedName] = struct{}{}
+	}
+	allNamesMap := make(map[string]struct{}, len(image.GetFile()))
+	for _, file := range image.GetFile() {
+		allNamesMap[file.GetName()] = struct{}{}
+	}
+	for targetName := range targetNamesMap {
+		if _, ok := allNamesMap[targetName]; !ok {
+			return nil, errs.NewInvalidArgumentf("%s is not present in the Image", targetName)
+		}
+	}
+	return targetNamesMap, nil
+}
+
+// addDependencyNamesRec adds the name and the names of its transitive dependencies
+// to the map.
+//
+// Dependencies that are not in the Image are skipped, as the Image may not have
+// been built with imports.
+func addDependencyNamesRec(
+	nameToFile map[string]protodescpb.FileDescriptor,
+	namesMap map[string]struct{},
+	name string,
+) {
+	if _, ok := namesMap[name]; ok {
+		return
+	}
+	file, ok := nameToFile[name]
+	if !ok {
+		return
+	}
+	namesMap[name] = struct{}{}
+	for _, dependency := range file.GetDependency() {
+		addDependencyNamesRec(nameToFile, namesMap, dependency)
+	}
+}
+
+// imageWithTargetNamesMap returns a copy of the Image with every File that is not
+// a target marked as an import.
+//
+// If includeNamesMap is not nil, only the Files in includeNamesMap are kept.
+func imageWithTargetNamesMap(
+	image Image,
+	targetNamesMap map[string]struct{},
+	includeNamesMap map[string]struct{},
+) (Image, error) {
+	newBacking := &imagev1beta1.Image{
+		BufbuildImageExtension: &imagev1beta1.ImageExtension{
+			ImageImportRefs: make([]*imagev1beta1.ImageImportRef, 0),
+		},
+	}
+	for _, fileDescriptor := range image.GetFile() {
+		fileDescriptorProto, ok := fileDescriptor.(*descriptor.FileDescriptorProto)
+		if !ok {
+			return nil, errs.NewInternalf("unexpected FileDescriptor type %T", fileDescriptor)
+		}
+		if includeNamesMap != nil {
+			if _, include := includeNamesMap[fileDescriptorProto.GetName()]; !include {
+				continue
+			}
+		}
+		newBacking.File = append(newBacking.File, fileDescriptorProto)
+		if _, isTarget := targetNamesMap[fileDescriptorProto.GetName()]; !isTarget {
+			newBacking.BufbuildImageExtension.ImageImportRefs = append(
+				newBacking.BufbuildImageExtension.ImageImportRefs,
+				&imagev1beta1.ImageImportRef{
+					FileIndex: protodescpb.Uint32(uint32(len(newBacking.File) - 1)),
+				},
+			)
+		}
+	}
+	return NewImage(newBacking)
+}
@@ -0,0 +1,220 @@
+package bufpb_test
+
+import (
+	"testing"
+
+	"github.com/bufbuild/buf/internal/buf/bufpb"
+	imagev1beta1 "github.com/bufbuild/buf/internal/gen/proto/bufbuild/buf/image/v1beta1"
+	"github.com/bufbuild/buf/internal/pkg/errs"
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/protoc-gen-go/descriptor"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImageWithTargets(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		description         string
+		image               bufpb.Image
+		targetNames         []string
+		expectedFileNames   []string
+		expectedImportNames []string
+		expectedCode        errs.Code
+	}{
+		{
+			description:         "target",
+			image:               testNewImage(t),
+			targetNames:         []string{"c.proto"},
+			expectedFileNames:   []string{"a.proto", "b.proto", "c.proto"},
+			expectedImportNames: []string{"a.proto", "b.proto"},
+		},
+		{
+			description:         "target that is also a dependency",
+			image:               testNewImage(t),
+			targetNames:         []string{"b.proto", "c.proto"},
+			expectedFileNames:   []string{"a.proto", "b.proto", "c.proto"},
+			expectedImportNames: []string{"a.proto"},
+		},
+		{
+			description:         "unnormalized target",
+			image:               testNewImage(t),
+			targetNames:         []string{"./c.proto"},
+			expectedFileNames:   []string{"a.proto", "b.proto", "c.proto"},
+			expectedImportNames: []string{"a.proto", "b.proto"},
+		},
+		{
+			description:  "unknown target",
+			image:        testNewImage(t),
+			targetNames:  []string{"d.proto"},
+			expectedCode: errs.CodeInvalidArgument,
+		},
+		{
+			description:  "no targets",
+			image:        testNewImage(t),
+			expectedCode: errs.CodeInvalidArgument,
+		},
+		{
+			description:       "image without imports",
+			image:             testNewImage(t, "c.proto"),
+			targetNames:       []string{"c.proto"},
+			expectedFileNames: []string{"c.proto"},
+		},
+	}
+	for _, testCase := range testCases {
+		testCase := testCase
+		t.Run(testCase.description, func(t *testing.T) {
+			t.Parallel()
+			image, err := bufpb.ImageWithTargets(testCase.image, testCase.targetNames...)
+			testAssertImage(t, image, err, testCase.expectedFileNames, testCase.expectedImportNames, testCase.expectedCode)
+		})
+	}
+}
+
+func TestImageWithTargetsAndDependencies(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		description         string
+		image               bufpb.Image
+		targetNames         []string
+		expectedFileNames   []string
+		expectedImportNames []string
+		expectedCode        errs.Code
+	}{
+		{
+			description:         "target",
+			image:               testNewImage(t),
+			targetNames:         []string{"b.proto"},
+			expectedFileNames:   []string{"a.proto", "b.proto"},
+			expectedImportNames: []string{"a.proto"},
+		},
+		{
+			description:         "target that is also a dependency",
+			image:               testNewImage(t),
+			targetNames:         []string{"a.proto", "c.proto"},
+			expectedFileNames:   []string{"a.proto", "b.proto", "c.proto"},
+			expectedImportNames: []string{"b.proto"},
+		},
+		{
+			description:  "unknown target",
+			image:        testNewImage(t),
+			targetNames:  []string{"d.proto"},
+			expectedCode: errs.CodeInvalidArgument,
+		},
+		{
+			description:       "image without imports",
+			image:             testNewImage(t, "b.proto", "c.proto"),
+			targetNames:       []string{"b.proto"},
+			expectedFileNames: []string{"b.proto"},
+		},
+	}
+	for _, testCase := range testCases {
+		testCase := testCase
+		t.Run(testCase.description, func(t *testing.T) {
+			t.Parallel()
+			image, err := bufpb.ImageWithTargetsAndDependencies(testCase.image, testCase.targetNames...)
+			testAssertImage(t, image, err, testCase.expectedFileNames, testCase.expectedImportNames, testCase.expectedCode)
+		})
+	}
+}
+
+func TestImageWithInferredTargets(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		description         string
+		image               bufpb.Image
+		expectedFileNames   []string
+		expectedImportNames []string
+	}{
+		{
+			description:         "dependencies are imports",
+			image:               testNewImage(t),
+			expectedFileNames:   []string{"a.proto", "b.proto", "c.proto"},
+			expectedImportNames: []string{"a.proto", "b.proto"},
+		},
+		{
+			// b.proto is a dependency of c.proto, so it is not inferred as a target
+			description:         "target that is also a dependency",
+			image:               testNewImage(t, "b.proto", "c.proto"),
+			expectedFileNames:   []string{"b.proto", "c.proto"},
+			expectedImportNames: []string{"b.proto"},
+		},
+		{
+			description:       "image without imports",
+			image:             testNewImage(t, "c.proto"),
+			expectedFileNames: []string{"c.proto"},
+		},
+	}
+	for _, testCase := range testCases {
+		testCase := testCase
+		t.Run(testCase.description, func(t *testing.T) {
+			t.Parallel()
+			image, err := bufpb.ImageWithInferredTargets(testCase.image)
+			testAssertImage(t, image, err, testCase.expectedFileNames, testCase.expectedImportNames, errs.CodeOK)
+		})
+	}
+}
+
+// testNewImage returns a new Image where c.proto imports b.proto and b.proto
+// imports a.proto, without imports marked.
+//
+// If names are given, only the Files with these names are included.
+func testNewImage(t *testing.T, names ...string) bufpb.Image {
+	fileDescriptorProtos := []*descriptor.FileDescriptorProto{
+		testNewFileDescriptorProto("a.proto"),
+		testNewFileDescriptorProto("b.proto", "a.proto"),
+		testNewFileDescriptorProto("c.proto", "b.proto"),
+	}
+	if len(names) > 0 {
+		namesMap := make(map[string]struct{}, len(names))
+		for _, name := range names {
+			namesMap[name] = struct{}{}
+		}
+		var filteredFileDescriptorProtos []*descriptor.FileDescriptorProto
+		for _, fileDescriptorProto := range fileDescriptorProtos {
+			if _, ok := namesMap[fileDescriptorProto.GetName()]; ok {
+				filteredFileDescriptorProtos = append(filteredFileDescriptorProtos, fileDescriptorProto)
+			}
+		}
+		fileDescriptorProtos = filteredFileDescriptorProtos
+	}
+	image, err := bufpb.NewImage(
+		&imagev1beta1.Image{
+			File: fileDescriptorProtos,
+		},
+	)
+	require.NoError(t, err)
+	return image
+}
+
+func testNewFileDescriptorProto(name string, dependencies ...string) *descriptor.FileDescriptorProto {
+	return &descriptor.FileDescriptorProto{
+		Name:       proto.String(name),
+		Package:    proto.String("acme.v1"),
+		Dependency: dependencies,
+		Syntax:     proto.String("proto3"),
+	}
+}
+
+func testAssertImage(
+	t *testing.T,
+	image bufpb.Image,
+	err error,
+	expectedFileNames []string,
+	expectedImportNames []string,
+	expectedCode errs.Code,
+) {
+	if expectedCode != errs.CodeOK {
+		assert.Equal(t, expectedCode, errs.GetCode(err), err)
+		return
+	}
+	require.NoError(t, err)
+	fileNames := make([]string, len(image.GetFile()))
+	for i, file := range image.GetFile() {
+		fileNames[i] = file.GetName()
+	}
+	assert.Equal(t, expectedFileNames, fileNames)
+	importNames, err := image.ImportNames()
+	require.NoError(t, err)
+	assert.Equal(t, expectedImportNames, importNames)
+}
@@ -0,0 +1,161 @@
+// Package bufprotoc implements protoc compatibility.
+//
+// This handles parsing protoc's flag grammar, mapping protoc's include paths
+// onto a single bucket, and printing annotations in protoc's error format.
+package bufprotoc
+
+import (
+	"io"
+
+	"github.com/bufbuild/buf/internal/pkg/analysis"
+)
+
+const (
+	// ErrorFormatGCC is the gcc error format, which is protoc's default.
+	ErrorFormatGCC = "gcc"
+	// ErrorFormatMSVS is the Microsoft Visual Studio error format.
+	ErrorFormatMSVS = "msvs"
+)
+
+// Flags are the parsed protoc flags.
+type Flags struct {
+	// IncludeDirPaths are the -I/--proto_path values.
+	//
+	// These are the directory paths as given on the command line.
+	// If no include paths were given, this will be ["."], which matches protoc.
+	IncludeDirPaths []string
+	// FilePaths are the files to compile, as given on the command line.
+	FilePaths []string
+	// DescriptorSetOut is the -o/--descriptor_set_out value.
+	//
+	// Empty if not set.
+	DescriptorSetOut string
+	// IncludeImports is the --include_imports value.
+	IncludeImports bool
+	// IncludeSourceInfo is the --include_source_info value.
+	IncludeSourceInfo bool
+	// ErrorFormat is the --error_format value.
+	//
+	// This will be either ErrorFormatGCC or ErrorFormatMSVS.
+	ErrorFormat string
+	// PluginFlags are the plugins to run.
+	//
+	// These are in the order the --NAME_out flags were given.
+	PluginFlags []*PluginFlag
+	// PrintHelp is set if --help or -h was given.
+	PrintHelp bool
+}
+
+// PluginFlag is a plugin to run.
+type PluginFlag struct {
+	// Name is the name of the plugin, ie "go" for --go_out.
+	Name string
+	// Path is the path given by --plugin, if any.
+	//
+	// If empty, protoc-gen-Name should be searched for on the PATH.
+	Path string
+	// Out is the output directory given by --NAME_out.
+	Out string
+	// Opt is the parameter, combined from --NAME_out and all --NAME_opt values.
+	Opt string
+}
+
+// ParseFlags parses protoc flags.
+//
+// Arguments of the form @file are expanded with the contents of file,
+// one argument per line, which matches protoc.
+func ParseFlags(args []string) (*Flags, error) {
+	return newFlagsParser().ParseFlags(args)
+}
+
+// Layout is the mapping of include paths and files onto a single directory.
+//
+// protoc allows include paths anywhere on the filesystem, while builds require
+// all roots to be relative directories within a single bucket.
+//
+// Include paths may overlap, ie -I . -I third_party, which matches protoc.
+// Each file resolves against the first include path that contains it.
+type Layout struct {
+	// RootDirPath is the absolute directory that contains all include paths.
+	//
+	// A bucket should be created at this directory.
+	RootDirPath string
+	// Roots are the include paths relative to RootDirPath.
+	//
+	// These are in the order given, which is the order imports are searched in.
+	// These are normalized and validated, but may overlap.
+	Roots []string
+	// RealFilePaths are the files to compile relative to RootDirPath.
+	//
+	// These are normalized and validated.
+	RealFilePaths []string
+	// RootFilePaths are the files to compile relative to the include path
+	// they resolve against.
+	//
+	// These are in the same order as RealFilePaths, and are normalized and validated.
+	RootFilePaths []string
+
+	currentDirPath string
+}
+
+// NewLayout returns a new Layout for the include directory paths and file paths.
+//
+// File paths can either be paths on disk within an include path, or paths relative
+// to an include path, which matches protoc.
+func NewLayout(includeDirPaths []string, filePaths []string) (*Layout, error) {
+	return newLayout(includeDirPaths, filePaths)
+}
+
+// GetDisplayPath returns the path on disk relative to the current directory
+// for the real file path.
+//
+// If the path cannot be made relative to the current directory, the
+// absolute path is returned.
+func (l *Layout) GetDisplayPath(realFilePath string) (string, error) {
+	return l.getDisplayPath(realFilePath)
+}
+
+// PrintAnnotations prints the annotations to the writer in protoc's error format.
+//
+// Annotations with no filename, such as plugin errors, are printed as their message.
+func PrintAnnotations(writer io.Writer, annotations []*analysis.Annotation, errorFormat string) error {
+	return printAnnotations(writer, annotations, errorFormat)
+}
+
+// PrintHelp prints the supported protoc flags to the writer.
+func PrintHelp(writer io.Writer) error {
+	_, err := io.WriteString(writer, helpText)
+	return err
+}
+
+const helpText = `Usage: buf protoc [OPTION] PROTO_FILES
+Parse PROTO_FILES and generate output based on the options given:
+  -IPATH, --proto_path=PATH   Specify the directory in which to search for
+                              imports.  May be specified multiple times;
+                              directories will be searched in order.  If not
+                              given, the current working directory is used.
+  -oFILE,                     Writes a FileDescriptorSet (a protocol buffer,
+    --descriptor_set_out=FILE defined in descriptor.proto) containing all of
+                              the input files to FILE.
+  --include_imports           When using --descriptor_set_out, also include
+                              all dependencies of the input files in the
+                              set, so that the set is self-contained.
+  --include_source_info       When using --descriptor_set_out, do not strip
+                              SourceCodeInfo from the FileDescriptorProto.
+  --error_format=FORMAT       Set the format in which to print errors.
+                              FORMAT may be 'gcc' (the default) or 'msvs'
+                              (Microsoft Visual Studio format).
+  --plugin=EXECUTABLE         Specifies a plugin executable to use.
+                              Normally, protoc searches the PATH for
+                              plugins, but you may specify additional
+                              executables not in the path using this flag.
+                              Additionally, EXECUTABLE may be of the form
+                              NAME=PATH, in which case the given plugin name
+                              is mapped to the given executable even if
+                              the executable's own name differs.
+  --NAME_out=[OPT:]OUT_DIR    Generate code with the plugin protoc-gen-NAME
+                              and write it to OUT_DIR.
+  --NAME_opt=OPT              Pass an additional parameter to the plugin
+                              protoc-gen-NAME.
+  @<filename>                 Read options and filenames from file.
+`
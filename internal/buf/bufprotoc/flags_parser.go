@@ -0,0 +1,231 @@
+package bufprotoc
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/bufbuild/buf/internal/pkg/errs"
+)
+
+type flagsParser struct {
+	flags *Flags
+	// pluginNameToPluginFlag is used to match --NAME_opt and --plugin to --NAME_out
+	pluginNameToPluginFlag map[string]*PluginFlag
+	pluginNameToOpts       map[string][]string
+	pluginNameToPath       map[string]string
+}
+
+func newFlagsParser() *flagsParser {
+	return &flagsParser{
+		flags: &Flags{
+			ErrorFormat: ErrorFormatGCC,
+		},
+		pluginNameToPluginFlag: make(map[string]*PluginFlag),
+		pluginNameToOpts:       make(map[string][]string),
+		pluginNameToPath:       make(map[string]string),
+	}
+}
+
+func (p *flagsParser) ParseFlags(args []string) (*Flags, error) {
+	args, err := expandArgFiles(args)
+	if err != nil {
+		return nil, err
+	}
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "" {
+			return nil, errs.NewInvalidArgument("empty argument")
+		}
+		if !strings.HasPrefix(arg, "-") {
+			p.flags.FilePaths = append(p.flags.FilePaths, arg)
+			continue
+		}
+		name, value, hasValue := splitFlag(arg)
+		if flagRequiresValue(name) && !hasValue {
+			if i+1 >= len(args) {
+				return nil, errs.NewInvalidArgumentf("missing value for flag: %s", name)
+			}
+			i++
+			value = args[i]
+			hasValue = true
+		}
+		if err := p.parseFlag(name, value, hasValue); err != nil {
+			return nil, err
+		}
+	}
+	for _, pluginFlag := range p.flags.PluginFlags {
+		// the parameter from --NAME_out comes first, then all --NAME_opt values in order
+		opts := p.pluginNameToOpts[pluginFlag.Name]
+		if pluginFlag.Opt != "" {
+			opts = append([]string{pluginFlag.Opt}, opts...)
+		}
+		pluginFlag.Opt = strings.Join(opts, ",")
+		pluginFlag.Path = p.pluginNameToPath[pluginFlag.Name]
+	}
+	for name := range p.pluginNameToOpts {
+		if _, ok := p.pluginNameToPluginFlag[name]; !ok {
+			return nil, errs.NewInvalidArgumentf("--%s_opt given without --%s_out", name, name)
+		}
+	}
+	if len(p.flags.IncludeDirPaths) == 0 {
+		p.flags.IncludeDirPaths = []string{"."}
+	}
+	if !p.flags.PrintHelp && len(p.flags.FilePaths) == 0 {
+		return nil, errs.NewInvalidArgument("Missing input file.")
+	}
+	return p.flags, nil
+}
+
+func (p *flagsParser) parseFlag(name string, value string, hasValue bool) error {
+	switch name {
+	case "-h", "--help":
+		p.flags.PrintHelp = true
+	case "-I", "--proto_path":
+		for _, includeDirPath := range filepath.SplitList(value) {
+			if includeDirPath == "" {
+				continue
+			}
+			p.flags.IncludeDirPaths = append(p.flags.IncludeDirPaths, includeDirPath)
+		}
+	case "-o", "--descriptor_set_out":
+		if p.flags.DescriptorSetOut != "" {
+			return errs.NewInvalidArgumentf("%s may only be passed once.", name)
+		}
+		p.flags.DescriptorSetOut = value
+	case "--include_imports":
+		if hasValue {
+			return newFlagDoesNotTakeValueError(name)
+		}
+		p.flags.IncludeImports = true
+	case "--include_source_info":
+		if hasValue {
+			return newFlagDoesNotTakeValueError(name)
+		}
+		p.flags.IncludeSourceInfo = true
+	case "--error_format":
+		switch value {
+		case ErrorFormatGCC, ErrorFormatMSVS:
+			p.flags.ErrorFormat = value
+		default:
+			return errs.NewInvalidArgumentf("Unknown error format: %s", value)
+		}
+	case "--plugin":
+		pluginName, pluginPath := parsePluginValue(value)
+		if pluginName == "" {
+			return errs.NewInvalidArgumentf("invalid --plugin value: %s", value)
+		}
+		p.pluginNameToPath[pluginName] = pluginPath
+	default:
+		if strings.HasPrefix(name, "--") && strings.HasSuffix(name, "_out") {
+			return p.parsePluginOut(strings.TrimSuffix(strings.TrimPrefix(name, "--"), "_out"), value)
+		}
+		if strings.HasPrefix(name, "--") && strings.HasSuffix(name, "_opt") {
+			pluginName := strings.TrimSuffix(strings.TrimPrefix(name, "--"), "_opt")
+			p.pluginNameToOpts[pluginName] = append(p.pluginNameToOpts[pluginName], value)
+			return nil
+		}
+		return errs.NewInvalidArgumentf("Unknown flag: %s", name)
+	}
+	return nil
+}
+
+func (p *flagsParser) parsePluginOut(pluginName string, value string) error {
+	if pluginName == "" {
+		return errs.NewInvalidArgument("Unknown flag: --_out")
+	}
+	if _, ok := p.pluginNameToPluginFlag[pluginName]; ok {
+		return errs.NewInvalidArgumentf("--%s_out may only be passed once.", pluginName)
+	}
+	var opt string
+	out := value
+	// the output may be prefixed by opt:, but we need to allow windows drive letters
+	if colonIndex := strings.IndexByte(value, ':'); colonIndex >= 0 && !isWindowsDriveLetter(value, colonIndex) {
+		opt = value[:colonIndex]
+		out = value[colonIndex+1:]
+	}
+	if out == "" {
+		return errs.NewInvalidArgumentf("--%s_out has an empty output directory", pluginName)
+	}
+	pluginFlag := &PluginFlag{
+		Name: pluginName,
+		Out:  out,
+		Opt:  opt,
+	}
+	p.pluginNameToPluginFlag[pluginName] = pluginFlag
+	p.flags.PluginFlags = append(p.flags.PluginFlags, pluginFlag)
+	return nil
+}
+
+// splitFlag splits --name=value or -Xvalue.
+func splitFlag(arg string) (string, string, bool) {
+	if strings.HasPrefix(arg, "--") {
+		if equalIndex := strings.IndexByte(arg, '='); equalIndex >= 0 {
+			return arg[:equalIndex], arg[equalIndex+1:], true
+		}
+		return arg, "", false
+	}
+	// short flags are a single character and may have their value attached
+	if len(arg) > 2 {
+		return arg[:2], arg[2:], true
+	}
+	return arg, "", false
+}
+
+func flagRequiresValue(name string) bool {
+	switch name {
+	case "-I", "--proto_path", "-o", "--descriptor_set_out", "--error_format", "--plugin":
+		return true
+	default:
+		return strings.HasPrefix(name, "--") && (strings.HasSuffix(name, "_out") || strings.HasSuffix(name, "_opt"))
+	}
+}
+
+// parsePluginValue parses either protoc-gen-NAME=PATH or PATH, where the
+// base of PATH is protoc-gen-NAME.
+func parsePluginValue(value string) (string, string) {
+	if equalIndex := strings.IndexByte(value, '='); equalIndex >= 0 {
+		return strings.TrimPrefix(value[:equalIndex], "protoc-gen-"), value[equalIndex+1:]
+	}
+	base := strings.TrimSuffix(filepath.Base(value), ".exe")
+	if !strings.HasPrefix(base, "protoc-gen-") {
+		return "", ""
+	}
+	return strings.TrimPrefix(base, "protoc-gen-"), value
+}
+
+func isWindowsDriveLetter(value string, colonIndex int) bool {
+	return colonIndex == 1 &&
+		len(value) > 2 &&
+		(value[2] == '\\' || value[2] == '/') &&
+		((value[0] >= 'a' && value[0] <= 'z') || (value[0] >= 'A' && value[0] <= 'Z'))
+}
+
+// expandArgFiles expands @file arguments.
+func expandArgFiles(args []string) ([]string, error) {
+	expandedArgs := make([]string, 0, len(args))
+	for _, arg := range args {
+		if !strings.HasPrefix(arg, "@") {
+			expandedArgs = append(expandedArgs, arg)
+			continue
+		}
+		data, err := ioutil.ReadFile(arg[1:])
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil, errs.NewInvalidArgumentf("Failed to open argument file: %s", arg[1:])
+			}
+			return nil, err
+		}
+		for _, line := range strings.Split(string(data), "\n") {
+			if line = strings.TrimSpace(line); line != "" {
+				expandedArgs = append(expandedArgs, line)
+			}
+		}
+	}
+	return expandedArgs, nil
+}
+
+func newFlagDoesNotTakeValueError(name string) error {
+	return errs.NewInvalidArgumentf("%s does not take a parameter.", name)
+}
@@ -0,0 +1,115 @@
+package bufprotoc
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseFlags(t *testing.T) {
+	t.Parallel()
+	flags, err := ParseFlags(
+		[]string{
+			"-Iproto",
+			"--proto_path",
+			"vendor",
+			"-o",
+			"out.pb",
+			"--include_imports",
+			"--include_source_info",
+			"--error_format=msvs",
+			"--go_out=plugins=grpc:gen/go",
+			"--go_opt=paths=source_relative",
+			"--plugin=protoc-gen-foo=bin/foo",
+			"--foo_out",
+			"gen/foo",
+			"--plugin=bin/protoc-gen-bar",
+			"--bar_opt=a",
+			"--bar_out=gen/bar",
+			"--bar_opt=b",
+			"a.proto",
+			"b.proto",
+		},
+	)
+	require.NoError(t, err)
+	assert.Equal(
+		t,
+		&Flags{
+			IncludeDirPaths:   []string{"proto", "vendor"},
+			FilePaths:         []string{"a.proto", "b.proto"},
+			DescriptorSetOut:  "out.pb",
+			IncludeImports:    true,
+			IncludeSourceInfo: true,
+			ErrorFormat:       ErrorFormatMSVS,
+			PluginFlags: []*PluginFlag{
+				{
+					Name: "go",
+					Out:  "gen/go",
+					Opt:  "plugins=grpc,paths=source_relative",
+				},
+				{
+					Name: "foo",
+					Path: "bin/foo",
+					Out:  "gen/foo",
+				},
+				{
+					Name: "bar",
+					Path: "bin/protoc-gen-bar",
+					Out:  "gen/bar",
+					Opt:  "a,b",
+				},
+			},
+		},
+		flags,
+	)
+}
+
+func TestParseFlagsDefaults(t *testing.T) {
+	t.Parallel()
+	flags, err := ParseFlags([]string{"a.proto"})
+	require.NoError(t, err)
+	assert.Equal(
+		t,
+		&Flags{
+			IncludeDirPaths: []string{"."},
+			FilePaths:       []string{"a.proto"},
+			ErrorFormat:     ErrorFormatGCC,
+		},
+		flags,
+	)
+}
+
+func TestParseFlagsArgFile(t *testing.T) {
+	t.Parallel()
+	tmpDirPath, err := ioutil.TempDir("", "")
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, os.RemoveAll(tmpDirPath)) }()
+	argFilePath := filepath.Join(tmpDirPath, "args")
+	require.NoError(t, ioutil.WriteFile(argFilePath, []byte("-Iproto\n\na.proto\n"), 0644))
+	flags, err := ParseFlags([]string{"@" + argFilePath, "b.proto"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"proto"}, flags.IncludeDirPaths)
+	assert.Equal(t, []string{"a.proto", "b.proto"}, flags.FilePaths)
+}
+
+func TestParseFlagsErrors(t *testing.T) {
+	t.Parallel()
+	testParseFlagsError(t)
+	testParseFlagsError(t, "--foo", "a.proto")
+	testParseFlagsError(t, "a.proto", "-I")
+	testParseFlagsError(t, "--include_imports=true", "a.proto")
+	testParseFlagsError(t, "--error_format=foo", "a.proto")
+	testParseFlagsError(t, "--go_opt=foo", "a.proto")
+	testParseFlagsError(t, "--go_out=gen", "--go_out=gen2", "a.proto")
+	testParseFlagsError(t, "-oa.pb", "-ob.pb", "a.proto")
+	testParseFlagsError(t, "--plugin=foo", "a.proto")
+}
+
+func testParseFlagsError(t *testing.T, args ...string) {
+	_, err := ParseFlags(args)
+	assert.Error(t, err, args)
+}
@@ -0,0 +1,198 @@
+package bufprotoc
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/bufbuild/buf/internal/pkg/errs"
+	"github.com/bufbuild/buf/internal/pkg/storage/storagepath"
+)
+
+func newLayout(includeDirPaths []string, filePaths []string) (*Layout, error) {
+	if len(includeDirPaths) == 0 {
+		return nil, errs.NewInternal("no include paths")
+	}
+	currentDirPath, err := os.Getwd()
+	if err != nil {
+		return nil, err
+	}
+	absIncludeDirPaths := make([]string, 0, len(includeDirPaths))
+	seen := make(map[string]struct{}, len(includeDirPaths))
+	for _, includeDirPath := range includeDirPaths {
+		absIncludeDirPath, err := filepath.Abs(includeDirPath)
+		if err != nil {
+			return nil, err
+		}
+		fileInfo, err := os.Stat(absIncludeDirPath)
+		if err != nil {
+			if os.IsNotExist(err) {
+				// protoc only warns here, but there is nothing to build from a missing directory
+				return nil, errs.NewInvalidArgumentf("%s: warning: directory does not exist.", includeDirPath)
+			}
+			return nil, err
+		}
+		if !fileInfo.IsDir() {
+			return nil, errs.NewInvalidArgumentf("%s: not a directory", includeDirPath)
+		}
+		// duplicate include paths are allowed by protoc, but would be duplicate roots
+		if _, ok := seen[absIncludeDirPath]; ok {
+			continue
+		}
+		seen[absIncludeDirPath] = struct{}{}
+		absIncludeDirPaths = append(absIncludeDirPaths, absIncludeDirPath)
+	}
+	rootDirPath, err := getCommonDirPath(absIncludeDirPaths)
+	if err != nil {
+		return nil, err
+	}
+	roots := make([]string, len(absIncludeDirPaths))
+	for i, absIncludeDirPath := range absIncludeDirPaths {
+		roots[i], err = getRealPath(rootDirPath, absIncludeDirPath)
+		if err != nil {
+			return nil, err
+		}
+	}
+	realFilePaths := make([]string, len(filePaths))
+	rootFilePaths := make([]string, len(filePaths))
+	rootFilePathToFilePath := make(map[string]string, len(filePaths))
+	for i, filePath := range filePaths {
+		absFilePath, absIncludeDirPath, err := getAbsFilePath(absIncludeDirPaths, filePath)
+		if err != nil {
+			return nil, err
+		}
+		realFilePaths[i], err = getRealPath(rootDirPath, absFilePath)
+		if err != nil {
+			return nil, err
+		}
+		rootFilePaths[i], err = getRealPath(absIncludeDirPath, absFilePath)
+		if err != nil {
+			return nil, err
+		}
+		if otherFilePath, ok := rootFilePathToFilePath[rootFilePaths[i]]; ok {
+			return nil, errs.NewInvalidArgumentf("%s and %s both resolve to %s", otherFilePath, filePath, rootFilePaths[i])
+		}
+		rootFilePathToFilePath[rootFilePaths[i]] = filePath
+	}
+	return &Layout{
+		RootDirPath:    rootDirPath,
+		Roots:          roots,
+		RealFilePaths:  realFilePaths,
+		RootFilePaths:  rootFilePaths,
+		currentDirPath: currentDirPath,
+	}, nil
+}
+
+func (l *Layout) getDisplayPath(realFilePath string) (string, error) {
+	absFilePath := filepath.Join(l.RootDirPath, storagepath.Unnormalize(realFilePath))
+	relFilePath, err := filepath.Rel(l.currentDirPath, absFilePath)
+	if err != nil {
+		return absFilePath, nil
+	}
+	return relFilePath, nil
+}
+
+// getAbsFilePath gets the absolute path for the file path, and the absolute
+// include path it resolves against.
+//
+// If the file path exists on disk, it resolves against the first include path
+// that contains it. Otherwise, the file path is searched for within the include
+// paths in order. Both match protoc.
+//
+// Returns error if the file is shadowed by a file with the same path relative to
+// an earlier include path, which also matches protoc.
+func getAbsFilePath(absIncludeDirPaths []string, filePath string) (string, string, error) {
+	absFilePath, err := filepath.Abs(filePath)
+	if err != nil {
+		return "", "", err
+	}
+	if fileExists(absFilePath) {
+		for i, absIncludeDirPath := range absIncludeDirPaths {
+			if isWithin(absIncludeDirPath, absFilePath) {
+				if err := checkNotShadowed(absIncludeDirPaths[:i], absIncludeDirPath, absFilePath, filePath); err != nil {
+					return "", "", err
+				}
+				return absFilePath, absIncludeDirPath, nil
+			}
+		}
+		return "", "", errs.NewInvalidArgumentf("%s: File does not reside within any path specified using --proto_path (or -I).", filePath)
+	}
+	if !filepath.IsAbs(filePath) {
+		for _, absIncludeDirPath := range absIncludeDirPaths {
+			candidateFilePath := filepath.Join(absIncludeDirPath, filePath)
+			if fileExists(candidateFilePath) {
+				return candidateFilePath, absIncludeDirPath, nil
+			}
+		}
+	}
+	return "", "", errs.NewInvalidArgumentf("Could not make proto path relative: %s: No such file or directory", filePath)
+}
+
+// checkNotShadowed returns error if the file at absFilePath within absIncludeDirPath
+// also exists relative to any of the earlier include paths, as imports would
+// resolve to the earlier file.
+func checkNotShadowed(
+	earlierAbsIncludeDirPaths []string,
+	absIncludeDirPath string,
+	absFilePath string,
+	filePath string,
+) error {
+	relFilePath, err := filepath.Rel(absIncludeDirPath, absFilePath)
+	if err != nil {
+		return err
+	}
+	for _, earlierAbsIncludeDirPath := range earlierAbsIncludeDirPaths {
+		shadowingFilePath := filepath.Join(earlierAbsIncludeDirPath, relFilePath)
+		if fileExists(shadowingFilePath) {
+			return errs.NewInvalidArgumentf(
+				"%s: Input is shadowed in the --proto_path by %q.  Either use the latter file as your input or reorder the --proto_path so that the former file's location comes first.",
+				filePath,
+				shadowingFilePath,
+			)
+		}
+	}
+	return nil
+}
+
+// getCommonDirPath gets the closest directory that contains all the absolute
+// directory paths.
+//
+// Returns error if there is no such directory, ie the paths are on different
+// volumes on Windows.
+func getCommonDirPath(absDirPaths []string) (string, error) {
+	commonDirPath := absDirPaths[0]
+	for _, absDirPath := range absDirPaths[1:] {
+		for !isWithin(commonDirPath, absDirPath) {
+			parentDirPath := filepath.Dir(commonDirPath)
+			if parentDirPath == commonDirPath {
+				return "", errs.NewInvalidArgumentf("%s and %s do not share a common parent directory", absDirPaths[0], absDirPath)
+			}
+			commonDirPath = parentDirPath
+		}
+	}
+	return commonDirPath, nil
+}
+
+func getRealPath(rootDirPath string, absPath string) (string, error) {
+	relPath, err := filepath.Rel(rootDirPath, absPath)
+	if err != nil {
+		return "", err
+	}
+	return storagepath.NormalizeAndValidate(relPath)
+}
+
+// isWithin returns true if path is equal to or contained within dirPath.
+//
+// Both paths must be absolute and clean.
+func isWithin(dirPath string, path string) bool {
+	relPath, err := filepath.Rel(dirPath, path)
+	if err != nil {
+		return false
+	}
+	return relPath != ".." && !strings.HasPrefix(relPath, ".."+string(filepath.Separator))
+}
+
+func fileExists(path string) bool {
+	fileInfo, err := os.Stat(path)
+	return err == nil && fileInfo.Mode().IsRegular()
+}
@@ -0,0 +1,137 @@
+package bufprotoc
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewLayout(t *testing.T) {
+	t.Parallel()
+	layout, err := NewLayout(
+		[]string{
+			filepath.Join("testdata", "layout", "a"),
+			filepath.Join("testdata", "layout", "b"),
+			filepath.Join("testdata", "layout", "a"),
+		},
+		[]string{
+			filepath.Join("testdata", "layout", "a", "a.proto"),
+			"b.proto",
+		},
+	)
+	require.NoError(t, err)
+	absDirPath, err := filepath.Abs(filepath.Join("testdata", "layout"))
+	require.NoError(t, err)
+	assert.Equal(t, absDirPath, layout.RootDirPath)
+	assert.Equal(t, []string{"a", "b"}, layout.Roots)
+	assert.Equal(t, []string{"a/a.proto", "b/b.proto"}, layout.RealFilePaths)
+	assert.Equal(t, []string{"a.proto", "b.proto"}, layout.RootFilePaths)
+	displayPath, err := layout.GetDisplayPath("b/b.proto")
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join("testdata", "layout", "b", "b.proto"), displayPath)
+}
+
+func TestNewLayoutSingleRoot(t *testing.T) {
+	t.Parallel()
+	layout, err := NewLayout(
+		[]string{
+			filepath.Join("testdata", "layout", "a"),
+		},
+		[]string{
+			"a.proto",
+		},
+	)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"."}, layout.Roots)
+	assert.Equal(t, []string{"a.proto"}, layout.RealFilePaths)
+}
+
+func TestNewLayoutOverlapping(t *testing.T) {
+	t.Parallel()
+	layout, err := NewLayout(
+		[]string{
+			filepath.Join("testdata", "layout"),
+			filepath.Join("testdata", "layout", "b"),
+		},
+		[]string{
+			filepath.Join("testdata", "layout", "a", "a.proto"),
+			filepath.Join("testdata", "layout", "b", "b.proto"),
+		},
+	)
+	require.NoError(t, err)
+	absDirPath, err := filepath.Abs(filepath.Join("testdata", "layout"))
+	require.NoError(t, err)
+	assert.Equal(t, absDirPath, layout.RootDirPath)
+	assert.Equal(t, []string{".", "b"}, layout.Roots)
+	assert.Equal(t, []string{"a/a.proto", "b/b.proto"}, layout.RealFilePaths)
+	// files resolve against the first include path that contains them
+	assert.Equal(t, []string{"a/a.proto", "b/b.proto"}, layout.RootFilePaths)
+
+	layout, err = NewLayout(
+		[]string{
+			filepath.Join("testdata", "layout", "b"),
+			filepath.Join("testdata", "layout"),
+		},
+		[]string{
+			filepath.Join("testdata", "layout", "b", "b.proto"),
+		},
+	)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"b", "."}, layout.Roots)
+	assert.Equal(t, []string{"b/b.proto"}, layout.RealFilePaths)
+	assert.Equal(t, []string{"b.proto"}, layout.RootFilePaths)
+}
+
+func TestNewLayoutErrors(t *testing.T) {
+	t.Parallel()
+	_, err := NewLayout(
+		[]string{
+			filepath.Join("testdata", "layout", "a"),
+		},
+		[]string{
+			filepath.Join("testdata", "layout", "b", "b.proto"),
+		},
+	)
+	assert.Error(t, err)
+	_, err = NewLayout(
+		[]string{
+			filepath.Join("testdata", "layout", "a"),
+		},
+		[]string{
+			"c.proto",
+		},
+	)
+	assert.Error(t, err)
+	_, err = NewLayout(
+		[]string{
+			filepath.Join("testdata", "layout", "c"),
+		},
+		[]string{
+			"c.proto",
+		},
+	)
+	assert.Error(t, err)
+	// d/a.proto is shadowed by a/a.proto
+	_, err = NewLayout(
+		[]string{
+			filepath.Join("testdata", "layout", "a"),
+			filepath.Join("testdata", "layout", "d"),
+		},
+		[]string{
+			filepath.Join("testdata", "layout", "d", "a.proto"),
+		},
+	)
+	assert.Error(t, err)
+	_, err = NewLayout(
+		[]string{
+			filepath.Join("testdata", "layout", "a"),
+		},
+		[]string{
+			filepath.Join("testdata", "layout", "a", "a.proto"),
+			"a.proto",
+		},
+	)
+	assert.Error(t, err)
+}
@@ -0,0 +1,30 @@
+package bufprotoc
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/bufbuild/buf/internal/pkg/analysis"
+)
+
+func printAnnotations(writer io.Writer, annotations []*analysis.Annotation, errorFormat string) error {
+	for _, annotation := range annotations {
+		if _, err := fmt.Fprintln(writer, formatAnnotation(annotation, errorFormat)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func formatAnnotation(annotation *analysis.Annotation, errorFormat string) string {
+	if annotation.Filename == "" {
+		return annotation.Message
+	}
+	if annotation.StartLine == 0 {
+		return fmt.Sprintf("%s: %s", annotation.Filename, annotation.Message)
+	}
+	if errorFormat == ErrorFormatMSVS {
+		return fmt.Sprintf("%s(%d) : error in column=%d: %s", annotation.Filename, annotation.StartLine, annotation.StartColumn, annotation.Message)
+	}
+	return fmt.Sprintf("%s:%d:%d: %s", annotation.Filename, annotation.StartLine, annotation.StartColumn, annotation.Message)
+}
@@ -0,0 +1,44 @@
+// Package bufreflect reads Images from gRPC servers using server reflection.
+package bufreflect
+
+import (
+	"context"
+	"crypto/tls"
+
+	"github.com/bufbuild/buf/internal/buf/bufpb"
+	"go.uber.org/zap"
+)
+
+// Reader reads Images from gRPC servers.
+type Reader interface {
+	// ReadImage reads an Image from the gRPC server at the address.
+	//
+	// The address is of the form host:port. The Image contains the files of every
+	// service the server lists over grpc.reflection.v1alpha.ServerReflection, and
+	// their transitive dependencies as imports. The reflection services themselves
+	// are excluded.
+	//
+	// The Image does not contain SourceCodeInfo unless the server sends it.
+	ReadImage(
+		ctx context.Context,
+		address string,
+		options ...ReadOption,
+	) (bufpb.Image, error)
+}
+
+// ReadOption is an option for ReadImage.
+type ReadOption func(*readOptions)
+
+// ReadWithTLS returns a ReadOption that connects using TLS with the given config.
+//
+// The default is to connect using plaintext.
+func ReadWithTLS(tlsConfig *tls.Config) ReadOption {
+	return func(readOptions *readOptions) {
+		readOptions.tlsConfig = tlsConfig
+	}
+}
+
+// NewReader returns a new Reader.
+func NewReader(logger *zap.Logger) Reader {
+	return newReader(logger)
+}
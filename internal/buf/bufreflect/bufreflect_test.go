@@ -0,0 +1,143 @@
+package bufreflect_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/bufbuild/buf/internal/buf/bufpb"
+	"github.com/bufbuild/buf/internal/buf/bufreflect"
+	"github.com/bufbuild/buf/internal/buf/bufserve/bufservetesting"
+	"github.com/bufbuild/buf/internal/buf/buftesting"
+	"github.com/bufbuild/buf/internal/pkg/errs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+func TestReadImage(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	address, closeFunc := bufservetesting.StartReflectionServer(t, buftesting.BuildImage(t, "testdata", true, false))
+	defer closeFunc()
+
+	image, err := bufreflect.NewReader(zap.NewNop()).ReadImage(ctx, address)
+	require.NoError(t, err)
+	testAssertImage(t, image)
+}
+
+func TestReadImageTLS(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	certificate, rootCAs := testNewCertificate(t)
+	address, closeFunc := bufservetesting.StartReflectionServer(
+		t,
+		buftesting.BuildImage(t, "testdata", true, false),
+		grpc.Creds(credentials.NewServerTLSFromCert(&certificate)),
+	)
+	defer closeFunc()
+
+	image, err := bufreflect.NewReader(zap.NewNop()).ReadImage(
+		ctx,
+		address,
+		bufreflect.ReadWithTLS(
+			&tls.Config{
+				RootCAs:    rootCAs,
+				ServerName: "localhost",
+			},
+		),
+	)
+	require.NoError(t, err)
+	testAssertImage(t, image)
+
+	// plaintext cannot connect to a server that requires TLS
+	shortCtx, shortCancel := context.WithTimeout(ctx, time.Second)
+	defer shortCancel()
+	_, err = bufreflect.NewReader(zap.NewNop()).ReadImage(shortCtx, address)
+	assert.Error(t, err)
+}
+
+func TestReadImageReflectionNotRegistered(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	grpcServer := grpc.NewServer()
+	go func() {
+		_ = grpcServer.Serve(listener)
+	}()
+	defer grpcServer.Stop()
+
+	_, err = bufreflect.NewReader(zap.NewNop()).ReadImage(ctx, listener.Addr().String())
+	assert.Equal(t, errs.CodeInvalidArgument, errs.GetCode(err))
+}
+
+func testAssertImage(t *testing.T, image bufpb.Image) {
+	fileNames := make([]string, len(image.GetFile()))
+	for i, file := range image.GetFile() {
+		fileNames[i] = file.GetName()
+	}
+	assert.Equal(
+		t,
+		[]string{
+			"acme/type/v1/money.proto",
+			"google/protobuf/empty.proto",
+			"acme/v1/order.proto",
+			"acme/v1/user.proto",
+		},
+		fileNames,
+	)
+	importNames, err := image.ImportNames()
+	require.NoError(t, err)
+	assert.Equal(
+		t,
+		[]string{
+			"acme/type/v1/money.proto",
+			"google/protobuf/empty.proto",
+		},
+		importNames,
+	)
+	_, err = bufpb.ImageToDescFileDescriptors(image)
+	assert.NoError(t, err)
+}
+
+// testNewCertificate returns a new self-signed certificate for localhost,
+// and a pool containing it.
+func testNewCertificate(t *testing.T) (tls.Certificate, *x509.CertPool) {
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "localhost"},
+		DNSNames:              []string{"localhost"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	certificateData, err := x509.CreateCertificate(rand.Reader, template, template, &privateKey.PublicKey, privateKey)
+	require.NoError(t, err)
+	x509Certificate, err := x509.ParseCertificate(certificateData)
+	require.NoError(t, err)
+	rootCAs := x509.NewCertPool()
+	rootCAs.AddCert(x509Certificate)
+	return tls.Certificate{
+		Certificate: [][]byte{certificateData},
+		PrivateKey:  privateKey,
+	}, rootCAs
+}
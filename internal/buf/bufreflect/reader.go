@@ -0,0 +1,148 @@
+package bufreflect
+
+import (
+	"context"
+	"crypto/tls"
+	"sort"
+	"strings"
+
+	"github.com/bufbuild/buf/internal/buf/bufpb"
+	imagev1beta1 "github.com/bufbuild/buf/internal/gen/proto/bufbuild/buf/image/v1beta1"
+	"github.com/bufbuild/buf/internal/pkg/errs"
+	"github.com/bufbuild/buf/internal/pkg/protodescpb"
+	"github.com/jhump/protoreflect/desc"
+	"github.com/jhump/protoreflect/grpcreflect"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/reflection/grpc_reflection_v1alpha"
+	"google.golang.org/grpc/status"
+)
+
+// reflectionServicePrefix is the package prefix of the reflection services,
+// which are registered alongside the services of most servers.
+const reflectionServicePrefix = "grpc.reflection."
+
+type reader struct {
+	logger *zap.Logger
+}
+
+type readOptions struct {
+	tlsConfig *tls.Config
+}
+
+func newReader(logger *zap.Logger) *reader {
+	return &reader{
+		logger: logger.Named("bufreflect"),
+	}
+}
+
+func (r *reader) ReadImage(
+	ctx context.Context,
+	address string,
+	options ...ReadOption,
+) (bufpb.Image, error) {
+	readOptions := &readOptions{}
+	for _, option := range options {
+		option(readOptions)
+	}
+	dialOption := grpc.WithInsecure()
+	if readOptions.tlsConfig != nil {
+		dialOption = grpc.WithTransportCredentials(credentials.NewTLS(readOptions.tlsConfig))
+	}
+	clientConn, err := grpc.DialContext(ctx, address, dialOption)
+	if err != nil {
+		return nil, errs.NewUnavailablef("could not connect to %s: %v", address, err)
+	}
+	defer func() {
+		// the error from Close is only for a connection that is already closed
+		_ = clientConn.Close()
+	}()
+	client := grpcreflect.NewClient(ctx, grpc_reflection_v1alpha.NewServerReflectionClient(clientConn))
+	defer client.Reset()
+
+	serviceNames, err := client.ListServices()
+	if err != nil {
+		return nil, newReflectionError(address, err)
+	}
+	sort.Strings(serviceNames)
+	nameToFileDescriptor := make(map[string]*desc.FileDescriptor)
+	nonImportNames := make(map[string]struct{})
+	for _, serviceName := range serviceNames {
+		if strings.HasPrefix(serviceName, reflectionServicePrefix) {
+			continue
+		}
+		serviceDescriptor, err := client.ResolveService(serviceName)
+		if err != nil {
+			return nil, newReflectionError(address, err)
+		}
+		fileDescriptor := serviceDescriptor.GetFile()
+		nameToFileDescriptor[fileDescriptor.GetName()] = fileDescriptor
+		nonImportNames[fileDescriptor.GetName()] = struct{}{}
+	}
+	if len(nonImportNames) == 0 {
+		return nil, errs.NewInvalidArgumentf("%s did not list any services over reflection", address)
+	}
+	r.logger.Debug("read_services", zap.String("address", address), zap.Strings("service_names", serviceNames))
+
+	sortedNonImportNames := make([]string, 0, len(nonImportNames))
+	for nonImportName := range nonImportNames {
+		sortedNonImportNames = append(sortedNonImportNames, nonImportName)
+	}
+	sort.Strings(sortedNonImportNames)
+	image := &imagev1beta1.Image{
+		BufbuildImageExtension: &imagev1beta1.ImageExtension{
+			ImageImportRefs: make([]*imagev1beta1.ImageImportRef, 0),
+		},
+	}
+	alreadySeen := make(map[string]struct{})
+	for _, nonImportName := range sortedNonImportNames {
+		addFileRec(alreadySeen, nonImportNames, image, nameToFileDescriptor[nonImportName])
+	}
+	return bufpb.NewImage(image)
+}
+
+// addFileRec adds the file to the image after its dependencies, so that the
+// image is in topological order.
+//
+// Files not in nonImportNames are added as imports.
+func addFileRec(
+	alreadySeen map[string]struct{},
+	nonImportNames map[string]struct{},
+	image *imagev1beta1.Image,
+	fileDescriptor *desc.FileDescriptor,
+) {
+	if _, ok := alreadySeen[fileDescriptor.GetName()]; ok {
+		return
+	}
+	alreadySeen[fileDescriptor.GetName()] = struct{}{}
+	for _, dependency := range fileDescriptor.GetDependencies() {
+		addFileRec(alreadySeen, nonImportNames, image, dependency)
+	}
+	image.File = append(image.File, fileDescriptor.AsFileDescriptorProto())
+	if _, ok := nonImportNames[fileDescriptor.GetName()]; !ok {
+		image.BufbuildImageExtension.ImageImportRefs = append(
+			image.BufbuildImageExtension.ImageImportRefs,
+			&imagev1beta1.ImageImportRef{
+				FileIndex: protodescpb.Uint32(uint32(len(image.File) - 1)),
+			},
+		)
+	}
+}
+
+// newReflectionError returns an error for an error from the reflection client.
+//
+// Codes are taken from gRPC status errors, as errs codes mirror gRPC codes.
+func newReflectionError(address string, err error) error {
+	if grpcreflect.IsElementNotFoundError(err) {
+		return errs.NewNotFoundf("%s: %v", address, err)
+	}
+	if s, ok := status.FromError(err); ok {
+		if s.Code() == codes.Unimplemented {
+			return errs.NewInvalidArgumentf("%s does not support server reflection: %s", address, s.Message())
+		}
+		return errs.NewErrorf(errs.Code(s.Code()), "%s: %s", address, s.Message())
+	}
+	return errs.NewUnknownf("%s: %v", address, err)
+}
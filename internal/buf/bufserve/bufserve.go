@@ -0,0 +1,110 @@
+// Package bufserve serves Images over gRPC.
+package bufserve
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/bufbuild/buf/internal/buf/bufpb"
+	"github.com/bufbuild/buf/internal/pkg/storage"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/reflection/grpc_reflection_v1alpha"
+)
+
+// ReflectionServer is a grpc.reflection.v1alpha.ServerReflection server
+// that serves the FileDescriptorProtos of an Image.
+//
+// Register it with grpc_reflection_v1alpha.RegisterServerReflectionServer.
+type ReflectionServer interface {
+	grpc_reflection_v1alpha.ServerReflectionServer
+
+	// SetImage sets the Image to serve.
+	//
+	// The Image must include imports, as clients will request the dependencies
+	// of the files they are sent. Requests already in progress are answered with
+	// the previous Image. This is safe to call concurrently with requests.
+	//
+	// Returns a user error if the Image cannot be resolved, in which case the
+	// previous Image continues to be served.
+	SetImage(image bufpb.Image) error
+}
+
+// NewReflectionServer returns a new ReflectionServer for the Image.
+func NewReflectionServer(logger *zap.Logger, image bufpb.Image) (ReflectionServer, error) {
+	return newReflectionServer(logger, image)
+}
+
+// MockServer is a gRPC server that answers every method of the services of an
+// Image with canned or placeholder responses.
+//
+// Responses for a method are read from the fixtures bucket at the path of the
+// method full name with the extension .json, .yaml, or .yml, for example
+// "acme.v1.PingService.Ping.json". A fixture is either a single response, or a
+// list of responses, in the JSON mapping of the response type. Fixtures are read on
+// every call, so they can be changed while the server is running. If there is no
+// fixture for a method, a single placeholder response with every field set is used.
+//
+// Unary and client streaming methods send the first response. Server streaming
+// methods send all responses. Bidirectional streaming methods send all responses for
+// every request.
+type MockServer interface {
+	// Register registers a handler for every method of every service in the Image,
+	// excluding services of imports.
+	//
+	// This must be called before the gRPC server is started.
+	Register(grpcServer *grpc.Server)
+	// SetImage sets the Image to mock.
+	//
+	// Methods that are no longer in the Image return Unimplemented. Services that
+	// were not in the Image when Register was called are not served. This is safe
+	// to call concurrently with calls.
+	//
+	// Returns a user error if the Image cannot be resolved, in which case the
+	// previous Image continues to be mocked.
+	SetImage(image bufpb.Image) error
+}
+
+// MockServerOption is an option for a new MockServer.
+type MockServerOption func(*mockServer)
+
+// MockServerWithFixtures returns a new MockServerOption that reads responses
+// from the bucket.
+//
+// The default is to only use placeholder responses.
+func MockServerWithFixtures(bucket storage.ReadBucket) MockServerOption {
+	return func(mockServer *mockServer) {
+		mockServer.fixtures = bucket
+	}
+}
+
+// MockServerWithCallWriter returns a new MockServerOption that writes every
+// completed call as a JSON Call on a single line to the writer.
+//
+// The default is to not write calls.
+func MockServerWithCallWriter(writer io.Writer) MockServerOption {
+	return func(mockServer *mockServer) {
+		mockServer.callWriter = writer
+	}
+}
+
+// NewMockServer returns a new MockServer for the Image.
+//
+// The Image must include imports.
+func NewMockServer(logger *zap.Logger, image bufpb.Image, options ...MockServerOption) (MockServer, error) {
+	return newMockServer(logger, image, options...)
+}
+
+// Call is a completed call to a MockServer.
+type Call struct {
+	// Method is the full name of the method, ie "acme.v1.PingService.Ping".
+	Method string `json:"method,omitempty" yaml:"method,omitempty"`
+	// Requests are the received requests in the JSON mapping.
+	Requests []json.RawMessage `json:"requests,omitempty" yaml:"requests,omitempty"`
+	// Responses are the sent responses in the JSON mapping.
+	Responses []json.RawMessage `json:"responses,omitempty" yaml:"responses,omitempty"`
+	// Code is the gRPC status code of the call, ie "OK".
+	Code string `json:"code,omitempty" yaml:"code,omitempty"`
+	// Error is the error message if the code is not OK.
+	Error string `json:"error,omitempty" yaml:"error,omitempty"`
+}
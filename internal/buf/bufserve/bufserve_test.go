@@ -0,0 +1,227 @@
+package bufserve_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bufbuild/buf/internal/buf/bufpb"
+	"github.com/bufbuild/buf/internal/buf/bufserve"
+	"github.com/bufbuild/buf/internal/buf/buftesting"
+	"github.com/bufbuild/buf/internal/pkg/storage/storageos"
+	"github.com/jhump/protoreflect/dynamic"
+	"github.com/jhump/protoreflect/dynamic/grpcdynamic"
+	"github.com/jhump/protoreflect/grpcreflect"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/reflection/grpc_reflection_v1alpha"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func TestReflectionServer(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	image := buftesting.BuildImage(t, "testdata", true, false)
+	reflectionServer, err := bufserve.NewReflectionServer(zap.NewNop(), image)
+	require.NoError(t, err)
+	client, closeFunc := testNewReflectionClient(t, ctx, reflectionServer)
+	defer closeFunc()
+
+	serviceNames, err := client.ListServices()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"acme.v1.PingService"}, serviceNames)
+
+	serviceDescriptor, err := client.ResolveService("acme.v1.PingService")
+	require.NoError(t, err)
+	assert.Equal(t, "acme/v1/ping.proto", serviceDescriptor.GetFile().GetName())
+	require.Len(t, serviceDescriptor.GetMethods(), 4)
+	assert.Equal(t, "google.protobuf.Empty", serviceDescriptor.GetMethods()[0].GetOutputType().GetFullyQualifiedName())
+
+	fileDescriptor, err := client.FileContainingSymbol("acme.v1.PingRequest.value")
+	require.NoError(t, err)
+	assert.Equal(t, "acme/v1/ping.proto", fileDescriptor.GetName())
+
+	fileDescriptor, err = client.FileByFilename("google/protobuf/empty.proto")
+	require.NoError(t, err)
+	assert.Equal(t, "google/protobuf/empty.proto", fileDescriptor.GetName())
+
+	extensionNumbers, err := client.AllExtensionNumbersForType("acme.v1.Base")
+	require.NoError(t, err)
+	assert.Equal(t, []int32{100}, extensionNumbers)
+
+	fieldDescriptor, err := client.ResolveExtension("acme.v1.Base", 100)
+	require.NoError(t, err)
+	assert.Equal(t, "acme.v1.name", fieldDescriptor.GetFullyQualifiedName())
+
+	_, err = client.FileByFilename("acme/v1/nope.proto")
+	assert.True(t, grpcreflect.IsElementNotFoundError(err))
+	_, err = client.ResolveMessage("acme.v1.Nope")
+	assert.True(t, grpcreflect.IsElementNotFoundError(err))
+}
+
+func TestReflectionServerSetImage(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	image := buftesting.BuildImage(t, "testdata", true, false)
+	reflectionServer, err := bufserve.NewReflectionServer(zap.NewNop(), image)
+	require.NoError(t, err)
+	client, closeFunc := testNewReflectionClient(t, ctx, reflectionServer)
+	defer closeFunc()
+
+	// the image without imports cannot be resolved, so the previous image is kept
+	imageWithoutImports, err := image.WithoutImports()
+	require.NoError(t, err)
+	assert.Error(t, reflectionServer.SetImage(imageWithoutImports))
+	serviceNames, err := client.ListServices()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"acme.v1.PingService"}, serviceNames)
+
+	baseImage, err := image.WithSpecificNames(false, "acme/v1/base.proto")
+	require.NoError(t, err)
+	require.NoError(t, reflectionServer.SetImage(baseImage))
+	serviceNames, err = client.ListServices()
+	require.NoError(t, err)
+	assert.Empty(t, serviceNames)
+}
+
+func testNewReflectionClient(
+	t *testing.T,
+	ctx context.Context,
+	reflectionServer bufserve.ReflectionServer,
+) (*grpcreflect.Client, func()) {
+	listener := bufconn.Listen(1 << 20)
+	grpcServer := grpc.NewServer()
+	grpc_reflection_v1alpha.RegisterServerReflectionServer(grpcServer, reflectionServer)
+	go func() {
+		_ = grpcServer.Serve(listener)
+	}()
+	clientConn, err := grpc.DialContext(
+		ctx,
+		"bufconn",
+		grpc.WithInsecure(),
+		grpc.WithDialer(func(string, time.Duration) (net.Conn, error) { return listener.Dial() }),
+	)
+	require.NoError(t, err)
+	client := grpcreflect.NewClient(ctx, grpc_reflection_v1alpha.NewServerReflectionClient(clientConn))
+	return client, func() {
+		client.Reset()
+		_ = clientConn.Close()
+		grpcServer.Stop()
+	}
+}
+
+func TestMockServer(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	image := buftesting.BuildImage(t, "testdata", true, false)
+	fixtures, err := storageos.NewReadBucket("testdata/fixtures")
+	require.NoError(t, err)
+	callBuffer := bytes.NewBuffer(nil)
+	mockServer, err := bufserve.NewMockServer(
+		zap.NewNop(),
+		image,
+		bufserve.MockServerWithFixtures(fixtures),
+		bufserve.MockServerWithCallWriter(callBuffer),
+	)
+	require.NoError(t, err)
+	fileDescriptors, err := bufpb.ImageToDescFileDescriptors(image)
+	require.NoError(t, err)
+	serviceDescriptor := fileDescriptors["acme/v1/ping.proto"].FindService("acme.v1.PingService")
+	require.NotNil(t, serviceDescriptor)
+
+	listener := bufconn.Listen(1 << 20)
+	grpcServer := grpc.NewServer()
+	mockServer.Register(grpcServer)
+	go func() {
+		_ = grpcServer.Serve(listener)
+	}()
+	defer grpcServer.Stop()
+	clientConn, err := grpc.DialContext(
+		ctx,
+		"bufconn",
+		grpc.WithInsecure(),
+		grpc.WithDialer(func(string, time.Duration) (net.Conn, error) { return listener.Dial() }),
+	)
+	require.NoError(t, err)
+	defer func() {
+		_ = clientConn.Close()
+	}()
+	stub := grpcdynamic.NewStub(clientConn)
+
+	// placeholder
+	request := dynamic.NewMessage(serviceDescriptor.FindMethodByName("Echo").GetInputType())
+	request.SetFieldByName("value", "hello")
+	response, err := stub.InvokeRpc(ctx, serviceDescriptor.FindMethodByName("Echo"), request)
+	require.NoError(t, err)
+	data, err := response.(*dynamic.Message).MarshalJSON()
+	require.NoError(t, err)
+	assert.JSONEq(
+		t,
+		`{"value":"value","count":"1","kind":"KIND_ECHO","tags":["tags"],"sizes":{"key":1},"request":{"value":"value"}}`,
+		string(data),
+	)
+
+	// fixtures for bidirectional streaming are sent for every request
+	stream, err := stub.InvokeRpcBidiStream(ctx, serviceDescriptor.FindMethodByName("EchoStream"))
+	require.NoError(t, err)
+	require.NoError(t, stream.SendMsg(request))
+	require.NoError(t, stream.SendMsg(request))
+	require.NoError(t, stream.CloseSend())
+	var values []string
+	for {
+		response, err := stream.RecvMsg()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		values = append(values, response.(*dynamic.Message).GetFieldByName("value").(string))
+	}
+	assert.Equal(t, []string{"one", "two", "one", "two"}, values)
+
+	// client streaming responds with the first fixture once the client is done sending
+	clientStream, err := stub.InvokeRpcClientStream(ctx, serviceDescriptor.FindMethodByName("EchoCollect"))
+	require.NoError(t, err)
+	require.NoError(t, clientStream.SendMsg(request))
+	require.NoError(t, clientStream.SendMsg(request))
+	response, err = clientStream.CloseAndReceive()
+	require.NoError(t, err)
+	assert.Equal(t, "first", response.(*dynamic.Message).GetFieldByName("value"))
+
+	// invalid fixture
+	_, err = stub.InvokeRpc(ctx, serviceDescriptor.FindMethodByName("Ping"), request)
+	assert.Equal(t, codes.Internal, status.Code(err))
+
+	var calls []*bufserve.Call
+	for _, line := range strings.Split(strings.TrimSpace(callBuffer.String()), "\n") {
+		call := &bufserve.Call{}
+		require.NoError(t, json.Unmarshal([]byte(line), call))
+		calls = append(calls, call)
+	}
+	require.Len(t, calls, 4)
+	assert.Equal(t, "acme.v1.PingService.Echo", calls[0].Method)
+	assert.Equal(t, "OK", calls[0].Code)
+	require.Len(t, calls[0].Requests, 1)
+	assert.JSONEq(t, `{"value":"hello"}`, string(calls[0].Requests[0]))
+	assert.Equal(t, "acme.v1.PingService.EchoStream", calls[1].Method)
+	assert.Len(t, calls[1].Requests, 2)
+	assert.Len(t, calls[1].Responses, 4)
+	assert.Equal(t, "acme.v1.PingService.EchoCollect", calls[2].Method)
+	assert.Equal(t, "OK", calls[2].Code)
+	assert.Len(t, calls[2].Requests, 2)
+	assert.Len(t, calls[2].Responses, 1)
+	assert.Equal(t, "acme.v1.PingService.Ping", calls[3].Method)
+	assert.Equal(t, "Internal", calls[3].Code)
+	assert.NotEmpty(t, calls[3].Error)
+}
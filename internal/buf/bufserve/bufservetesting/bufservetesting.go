@@ -0,0 +1,35 @@
+// Package bufservetesting implements testing functionality for bufserve.
+package bufservetesting
+
+import (
+	"net"
+	"testing"
+
+	"github.com/bufbuild/buf/internal/buf/bufpb"
+	"github.com/bufbuild/buf/internal/buf/bufserve"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/reflection/grpc_reflection_v1alpha"
+)
+
+// StartReflectionServer starts a gRPC server reflection service for the Image
+// on a local port.
+//
+// Returns the address of the server and a function to stop the server.
+func StartReflectionServer(
+	t *testing.T,
+	image bufpb.Image,
+	serverOptions ...grpc.ServerOption,
+) (string, func()) {
+	reflectionServer, err := bufserve.NewReflectionServer(zap.NewNop(), image)
+	require.NoError(t, err)
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	grpcServer := grpc.NewServer(serverOptions...)
+	grpc_reflection_v1alpha.RegisterServerReflectionServer(grpcServer, reflectionServer)
+	go func() {
+		_ = grpcServer.Serve(listener)
+	}()
+	return listener.Addr().String(), grpcServer.Stop
+}
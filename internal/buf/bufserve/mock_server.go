@@ -0,0 +1,273 @@
+package bufserve
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"sync"
+
+	"github.com/bufbuild/buf/internal/buf/bufpb"
+	"github.com/bufbuild/buf/internal/pkg/errs"
+	"github.com/bufbuild/buf/internal/pkg/protodesc"
+	"github.com/bufbuild/buf/internal/pkg/storage"
+	"github.com/bufbuild/buf/internal/pkg/storage/storageutil"
+	"github.com/jhump/protoreflect/desc"
+	"github.com/jhump/protoreflect/dynamic"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gopkg.in/yaml.v3"
+)
+
+var fixtureExts = []string{".json", ".yaml", ".yml"}
+
+type mockServer struct {
+	logger     *zap.Logger
+	fixtures   storage.ReadBucket
+	callWriter io.Writer
+
+	lock     sync.RWMutex
+	services []protodesc.Service
+	// methodNameToDescriptor is keyed by the full name of the method
+	methodNameToDescriptor map[string]*desc.MethodDescriptor
+
+	callWriterLock sync.Mutex
+}
+
+func newMockServer(logger *zap.Logger, image bufpb.Image, options ...MockServerOption) (*mockServer, error) {
+	mockServer := &mockServer{
+		logger: logger.Named("bufserve"),
+	}
+	for _, option := range options {
+		option(mockServer)
+	}
+	if err := mockServer.SetImage(image); err != nil {
+		return nil, err
+	}
+	return mockServer, nil
+}
+
+func (m *mockServer) Register(grpcServer *grpc.Server) {
+	m.lock.RLock()
+	services := m.services
+	m.lock.RUnlock()
+	for _, service := range services {
+		serviceDesc := &grpc.ServiceDesc{
+			ServiceName: service.FullName(),
+			// every method is handled by the mockServer itself
+			HandlerType: (*interface{})(nil),
+			Metadata:    service.FilePath(),
+		}
+		for _, method := range service.Methods() {
+			methodFullName := method.FullName()
+			serviceDesc.Streams = append(
+				serviceDesc.Streams,
+				grpc.StreamDesc{
+					StreamName: method.Name(),
+					Handler: func(_ interface{}, stream grpc.ServerStream) error {
+						return m.handle(methodFullName, stream)
+					},
+					ServerStreams: method.ServerStreaming(),
+					ClientStreams: method.ClientStreaming(),
+				},
+			)
+		}
+		grpcServer.RegisterService(serviceDesc, m)
+	}
+}
+
+func (m *mockServer) SetImage(image bufpb.Image) error {
+	imageWithoutImports, err := image.WithoutImports()
+	if err != nil {
+		return err
+	}
+	files, err := protodesc.NewFiles(imageWithoutImports.GetFile()...)
+	if err != nil {
+		return err
+	}
+	fileNameToFileDescriptor, err := bufpb.ImageToDescFileDescriptors(image)
+	if err != nil {
+		return err
+	}
+	var services []protodesc.Service
+	methodNameToDescriptor := make(map[string]*desc.MethodDescriptor)
+	for _, file := range files {
+		fileDescriptor, ok := fileNameToFileDescriptor[file.FilePath()]
+		if !ok {
+			return errs.NewInternalf("no FileDescriptor for %q", file.FilePath())
+		}
+		for _, service := range file.Services() {
+			services = append(services, service)
+			for _, method := range service.Methods() {
+				methodDescriptor, ok := fileDescriptor.FindSymbol(method.FullName()).(*desc.MethodDescriptor)
+				if !ok {
+					return errs.NewInternalf("no MethodDescriptor for %q", method.FullName())
+				}
+				methodNameToDescriptor[method.FullName()] = methodDescriptor
+			}
+		}
+	}
+	m.lock.Lock()
+	m.services = services
+	m.methodNameToDescriptor = methodNameToDescriptor
+	m.lock.Unlock()
+	m.logger.Debug("set_image", zap.Int("num_services", len(services)), zap.Int("num_methods", len(methodNameToDescriptor)))
+	return nil
+}
+
+func (m *mockServer) handle(methodFullName string, stream grpc.ServerStream) (retErr error) {
+	call := &Call{
+		Method: methodFullName,
+	}
+	defer func() {
+		call.Code = status.Code(retErr).String()
+		if retErr != nil {
+			call.Error = status.Convert(retErr).Message()
+		}
+		m.writeCall(call)
+	}()
+
+	m.lock.RLock()
+	methodDescriptor, ok := m.methodNameToDescriptor[methodFullName]
+	m.lock.RUnlock()
+	if !ok {
+		return status.Errorf(codes.Unimplemented, "method %s is no longer in the Image", methodFullName)
+	}
+	responses, err := m.getResponses(stream.Context(), methodDescriptor)
+	if err != nil {
+		return err
+	}
+	recv := func() error {
+		request := dynamic.NewMessage(methodDescriptor.GetInputType())
+		if err := stream.RecvMsg(request); err != nil {
+			return err
+		}
+		data, err := request.MarshalJSON()
+		if err != nil {
+			return status.Errorf(codes.Internal, "could not marshal request as JSON: %v", err)
+		}
+		call.Requests = append(call.Requests, json.RawMessage(data))
+		return nil
+	}
+	send := func(responses []*dynamic.Message) error {
+		for _, response := range responses {
+			if err := stream.SendMsg(response); err != nil {
+				return err
+			}
+			data, err := response.MarshalJSON()
+			if err != nil {
+				return status.Errorf(codes.Internal, "could not marshal response as JSON: %v", err)
+			}
+			call.Responses = append(call.Responses, json.RawMessage(data))
+		}
+		return nil
+	}
+
+	switch {
+	case !methodDescriptor.IsClientStreaming():
+		if err := recv(); err != nil {
+			return err
+		}
+	default:
+		for {
+			err := recv()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return err
+			}
+			if methodDescriptor.IsServerStreaming() {
+				// bidirectional streaming responds to every request
+				if err := send(responses); err != nil {
+					return err
+				}
+			}
+		}
+		if methodDescriptor.IsServerStreaming() {
+			return nil
+		}
+		// client streaming responds once the client is done sending
+	}
+	if !methodDescriptor.IsServerStreaming() {
+		responses = responses[:1]
+	}
+	return send(responses)
+}
+
+// getResponses gets the responses from the fixtures, or a placeholder if there
+// is no fixture for the method.
+//
+// Always returns at least one response if there is no error.
+func (m *mockServer) getResponses(ctx context.Context, methodDescriptor *desc.MethodDescriptor) ([]*dynamic.Message, error) {
+	outputType := methodDescriptor.GetOutputType()
+	if m.fixtures != nil {
+		for _, fixtureExt := range fixtureExts {
+			fixturePath := methodDescriptor.GetFullyQualifiedName() + fixtureExt
+			data, err := storageutil.ReadPath(ctx, m.fixtures, fixturePath)
+			if err != nil {
+				if storage.IsNotExist(err) {
+					continue
+				}
+				return nil, status.Errorf(codes.Internal, "could not read fixture %s: %v", fixturePath, err)
+			}
+			responses, err := parseFixture(outputType, fixturePath, data)
+			if err != nil {
+				return nil, status.Errorf(codes.Internal, "invalid fixture %s: %v", fixturePath, err)
+			}
+			return responses, nil
+		}
+	}
+	return []*dynamic.Message{newPlaceholderMessage(outputType)}, nil
+}
+
+func (m *mockServer) writeCall(call *Call) {
+	if m.callWriter == nil {
+		return
+	}
+	data, err := json.Marshal(call)
+	if err != nil {
+		m.logger.Error("could not marshal call", zap.Error(err))
+		return
+	}
+	m.callWriterLock.Lock()
+	defer m.callWriterLock.Unlock()
+	if _, err := m.callWriter.Write(append(data, '\n')); err != nil {
+		m.logger.Error("could not write call", zap.Error(err))
+	}
+}
+
+// parseFixture parses the fixture data as a single response or a list of responses.
+func parseFixture(messageDescriptor *desc.MessageDescriptor, fixturePath string, data []byte) ([]*dynamic.Message, error) {
+	if path.Ext(fixturePath) != ".json" {
+		var value interface{}
+		if err := yaml.Unmarshal(data, &value); err != nil {
+			return nil, err
+		}
+		jsonData, err := json.Marshal(value)
+		if err != nil {
+			return nil, err
+		}
+		data = jsonData
+	}
+	var rawMessages []json.RawMessage
+	if err := json.Unmarshal(data, &rawMessages); err != nil {
+		// not a list, so a single response
+		rawMessages = []json.RawMessage{data}
+	}
+	if len(rawMessages) == 0 {
+		return nil, fmt.Errorf("no responses")
+	}
+	responses := make([]*dynamic.Message, len(rawMessages))
+	for i, rawMessage := range rawMessages {
+		response := dynamic.NewMessage(messageDescriptor)
+		if err := response.UnmarshalJSON(rawMessage); err != nil {
+			return nil, err
+		}
+		responses[i] = response
+	}
+	return responses, nil
+}
@@ -0,0 +1,108 @@
+package bufserve
+
+import (
+	"github.com/golang/protobuf/protoc-gen-go/descriptor"
+	"github.com/jhump/protoreflect/desc"
+	"github.com/jhump/protoreflect/dynamic"
+)
+
+// maxPlaceholderDepth is the maximum depth of nested placeholder messages,
+// so that recursive messages terminate.
+const maxPlaceholderDepth = 3
+
+// newPlaceholderMessage returns a new message with a placeholder value for every field.
+//
+// Strings and bytes are set to the field name, numbers to 1, bools to true, and
+// enums to their first non-zero value. Repeated fields and maps have a single
+// element, and only the first field of every oneof is set.
+func newPlaceholderMessage(messageDescriptor *desc.MessageDescriptor) *dynamic.Message {
+	return newPlaceholderMessageForDepth(messageDescriptor, 0)
+}
+
+func newPlaceholderMessageForDepth(messageDescriptor *desc.MessageDescriptor, depth int) *dynamic.Message {
+	message := dynamic.NewMessage(messageDescriptor)
+	if depth >= maxPlaceholderDepth {
+		return message
+	}
+	seenOneofs := make(map[string]struct{})
+	for _, fieldDescriptor := range messageDescriptor.GetFields() {
+		if oneofDescriptor := fieldDescriptor.GetOneOf(); oneofDescriptor != nil {
+			if _, ok := seenOneofs[oneofDescriptor.GetName()]; ok {
+				continue
+			}
+			seenOneofs[oneofDescriptor.GetName()] = struct{}{}
+		}
+		// errors are ignored, as the values are always of the correct type,
+		// and a placeholder is best-effort
+		switch {
+		case fieldDescriptor.IsMap():
+			key, ok := getPlaceholderValue(fieldDescriptor.GetMapKeyType(), depth)
+			if !ok {
+				continue
+			}
+			value, ok := getPlaceholderValue(fieldDescriptor.GetMapValueType(), depth)
+			if !ok {
+				continue
+			}
+			_ = message.TryPutMapField(fieldDescriptor, key, value)
+		case fieldDescriptor.IsRepeated():
+			if value, ok := getPlaceholderValue(fieldDescriptor, depth); ok {
+				_ = message.TryAddRepeatedField(fieldDescriptor, value)
+			}
+		default:
+			if value, ok := getPlaceholderValue(fieldDescriptor, depth); ok {
+				_ = message.TrySetField(fieldDescriptor, value)
+			}
+		}
+	}
+	return message
+}
+
+// getPlaceholderValue returns the placeholder value for a single element of the field.
+//
+// Returns false if the field should not be set.
+func getPlaceholderValue(fieldDescriptor *desc.FieldDescriptor, depth int) (interface{}, bool) {
+	switch fieldDescriptor.GetType() {
+	case descriptor.FieldDescriptorProto_TYPE_STRING:
+		return fieldDescriptor.GetName(), true
+	case descriptor.FieldDescriptorProto_TYPE_BYTES:
+		return []byte(fieldDescriptor.GetName()), true
+	case descriptor.FieldDescriptorProto_TYPE_BOOL:
+		return true, true
+	case descriptor.FieldDescriptorProto_TYPE_INT32,
+		descriptor.FieldDescriptorProto_TYPE_SINT32,
+		descriptor.FieldDescriptorProto_TYPE_SFIXED32:
+		return int32(1), true
+	case descriptor.FieldDescriptorProto_TYPE_INT64,
+		descriptor.FieldDescriptorProto_TYPE_SINT64,
+		descriptor.FieldDescriptorProto_TYPE_SFIXED64:
+		return int64(1), true
+	case descriptor.FieldDescriptorProto_TYPE_UINT32,
+		descriptor.FieldDescriptorProto_TYPE_FIXED32:
+		return uint32(1), true
+	case descriptor.FieldDescriptorProto_TYPE_UINT64,
+		descriptor.FieldDescriptorProto_TYPE_FIXED64:
+		return uint64(1), true
+	case descriptor.FieldDescriptorProto_TYPE_FLOAT:
+		return float32(1), true
+	case descriptor.FieldDescriptorProto_TYPE_DOUBLE:
+		return float64(1), true
+	case descriptor.FieldDescriptorProto_TYPE_ENUM:
+		enumValueDescriptors := fieldDescriptor.GetEnumType().GetValues()
+		for _, enumValueDescriptor := range enumValueDescriptors {
+			if enumValueDescriptor.GetNumber() != 0 {
+				return enumValueDescriptor.GetNumber(), true
+			}
+		}
+		return enumValueDescriptors[0].GetNumber(), true
+	case descriptor.FieldDescriptorProto_TYPE_MESSAGE,
+		descriptor.FieldDescriptorProto_TYPE_GROUP:
+		// an Any with a placeholder type URL cannot be marshaled as JSON
+		if fieldDescriptor.GetMessageType().GetFullyQualifiedName() == "google.protobuf.Any" {
+			return nil, false
+		}
+		return newPlaceholderMessageForDepth(fieldDescriptor.GetMessageType(), depth+1), true
+	default:
+		return nil, false
+	}
+}
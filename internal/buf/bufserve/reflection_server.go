@@ -0,0 +1,267 @@
+package bufserve
+
+import (
+	"io"
+	"sort"
+	"sync"
+
+	"github.com/bufbuild/buf/internal/buf/bufpb"
+	"github.com/bufbuild/buf/internal/pkg/errs"
+	"github.com/golang/protobuf/proto"
+	"github.com/jhump/protoreflect/desc"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/reflection/grpc_reflection_v1alpha"
+	"google.golang.org/grpc/status"
+)
+
+type reflectionServer struct {
+	logger *zap.Logger
+
+	lock  sync.RWMutex
+	index *reflectionIndex
+}
+
+func newReflectionServer(logger *zap.Logger, image bufpb.Image) (*reflectionServer, error) {
+	reflectionServer := &reflectionServer{
+		logger: logger.Named("bufserve"),
+	}
+	if err := reflectionServer.SetImage(image); err != nil {
+		return nil, err
+	}
+	return reflectionServer, nil
+}
+
+func (r *reflectionServer) SetImage(image bufpb.Image) error {
+	index, err := newReflectionIndex(image)
+	if err != nil {
+		return err
+	}
+	r.lock.Lock()
+	r.index = index
+	r.lock.Unlock()
+	r.logger.Debug("set_image", zap.Int("num_files", len(index.fileNameToData)), zap.Int("num_services", len(index.serviceNames)))
+	return nil
+}
+
+func (r *reflectionServer) ServerReflectionInfo(
+	stream grpc_reflection_v1alpha.ServerReflection_ServerReflectionInfoServer,
+) error {
+	for {
+		request, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		r.lock.RLock()
+		index := r.index
+		r.lock.RUnlock()
+		response := &grpc_reflection_v1alpha.ServerReflectionResponse{
+			ValidHost:       request.GetHost(),
+			OriginalRequest: request,
+		}
+		switch messageRequest := request.GetMessageRequest().(type) {
+		case *grpc_reflection_v1alpha.ServerReflectionRequest_FileByFilename:
+			index.setFileResponse(
+				response,
+				messageRequest.FileByFilename,
+				index.fileNameToFileDescriptor[messageRequest.FileByFilename],
+			)
+		case *grpc_reflection_v1alpha.ServerReflectionRequest_FileContainingSymbol:
+			index.setFileResponse(
+				response,
+				messageRequest.FileContainingSymbol,
+				index.getFileContainingSymbol(messageRequest.FileContainingSymbol),
+			)
+		case *grpc_reflection_v1alpha.ServerReflectionRequest_FileContainingExtension:
+			extensionRequest := messageRequest.FileContainingExtension
+			index.setFileResponse(
+				response,
+				extensionRequest.GetContainingType(),
+				index.typeNameToExtensionNumberToFileDescriptor[extensionRequest.GetContainingType()][extensionRequest.GetExtensionNumber()],
+			)
+		case *grpc_reflection_v1alpha.ServerReflectionRequest_AllExtensionNumbersOfType:
+			index.setAllExtensionNumbersResponse(response, messageRequest.AllExtensionNumbersOfType)
+		case *grpc_reflection_v1alpha.ServerReflectionRequest_ListServices:
+			index.setListServicesResponse(response)
+		default:
+			return status.Errorf(codes.InvalidArgument, "invalid MessageRequest: %v", request.GetMessageRequest())
+		}
+		if err := stream.Send(response); err != nil {
+			return err
+		}
+	}
+}
+
+// reflectionIndex is an index of an Image for answering reflection requests.
+//
+// It is immutable once created.
+type reflectionIndex struct {
+	// fileDescriptors are in image order, which makes symbol lookups deterministic
+	fileDescriptors                           []*desc.FileDescriptor
+	fileNameToFileDescriptor                  map[string]*desc.FileDescriptor
+	fileNameToData                            map[string][]byte
+	typeNameToExtensionNumberToFileDescriptor map[string]map[int32]*desc.FileDescriptor
+	serviceNames                              []string
+}
+
+func newReflectionIndex(image bufpb.Image) (*reflectionIndex, error) {
+	fileNameToFileDescriptor, err := bufpb.ImageToDescFileDescriptors(image)
+	if err != nil {
+		return nil, err
+	}
+	importNames, err := image.ImportNames()
+	if err != nil {
+		return nil, err
+	}
+	importNameMap := make(map[string]struct{}, len(importNames))
+	for _, importName := range importNames {
+		importNameMap[importName] = struct{}{}
+	}
+	index := &reflectionIndex{
+		fileNameToFileDescriptor:                  fileNameToFileDescriptor,
+		fileNameToData:                            make(map[string][]byte, len(fileNameToFileDescriptor)),
+		typeNameToExtensionNumberToFileDescriptor: make(map[string]map[int32]*desc.FileDescriptor),
+	}
+	for _, file := range image.GetFile() {
+		fileDescriptor, ok := fileNameToFileDescriptor[file.GetName()]
+		if !ok {
+			return nil, errs.NewInternalf("no FileDescriptor for %q", file.GetName())
+		}
+		index.fileDescriptors = append(index.fileDescriptors, fileDescriptor)
+		data, err := proto.Marshal(fileDescriptor.AsFileDescriptorProto())
+		if err != nil {
+			return nil, err
+		}
+		index.fileNameToData[file.GetName()] = data
+		extensionDescriptors := fileDescriptor.GetExtensions()
+		forEachMessage(fileDescriptor.GetMessageTypes(), func(messageDescriptor *desc.MessageDescriptor) {
+			extensionDescriptors = append(extensionDescriptors, messageDescriptor.GetNestedExtensions()...)
+		})
+		for _, extensionDescriptor := range extensionDescriptors {
+			typeName := extensionDescriptor.GetOwner().GetFullyQualifiedName()
+			extensionNumberToFileDescriptor, ok := index.typeNameToExtensionNumberToFileDescriptor[typeName]
+			if !ok {
+				extensionNumberToFileDescriptor = make(map[int32]*desc.FileDescriptor)
+				index.typeNameToExtensionNumberToFileDescriptor[typeName] = extensionNumberToFileDescriptor
+			}
+			extensionNumberToFileDescriptor[extensionDescriptor.GetNumber()] = fileDescriptor
+		}
+		// services in imports are not served, so they are not listed
+		if _, ok := importNameMap[file.GetName()]; ok {
+			continue
+		}
+		for _, serviceDescriptor := range fileDescriptor.GetServices() {
+			index.serviceNames = append(index.serviceNames, serviceDescriptor.GetFullyQualifiedName())
+		}
+	}
+	sort.Strings(index.serviceNames)
+	return index, nil
+}
+
+func (i *reflectionIndex) getFileContainingSymbol(symbol string) *desc.FileDescriptor {
+	for _, fileDescriptor := range i.fileDescriptors {
+		if descriptor := fileDescriptor.FindSymbol(symbol); descriptor != nil {
+			return fileDescriptor
+		}
+	}
+	return nil
+}
+
+// setFileResponse sets the response to the file and its transitive dependencies.
+//
+// If the file is nil, the response is set to a not found error for the name.
+func (i *reflectionIndex) setFileResponse(
+	response *grpc_reflection_v1alpha.ServerReflectionResponse,
+	name string,
+	fileDescriptor *desc.FileDescriptor,
+) {
+	if fileDescriptor == nil {
+		setNotFoundResponse(response, name)
+		return
+	}
+	var datas [][]byte
+	seen := make(map[string]struct{})
+	// the requested file is first, followed by its dependencies
+	var addFile func(*desc.FileDescriptor)
+	addFile = func(fileDescriptor *desc.FileDescriptor) {
+		if _, ok := seen[fileDescriptor.GetName()]; ok {
+			return
+		}
+		seen[fileDescriptor.GetName()] = struct{}{}
+		datas = append(datas, i.fileNameToData[fileDescriptor.GetName()])
+		for _, dependency := range fileDescriptor.GetDependencies() {
+			addFile(dependency)
+		}
+	}
+	addFile(fileDescriptor)
+	response.MessageResponse = &grpc_reflection_v1alpha.ServerReflectionResponse_FileDescriptorResponse{
+		FileDescriptorResponse: &grpc_reflection_v1alpha.FileDescriptorResponse{
+			FileDescriptorProto: datas,
+		},
+	}
+}
+
+func (i *reflectionIndex) setAllExtensionNumbersResponse(
+	response *grpc_reflection_v1alpha.ServerReflectionResponse,
+	typeName string,
+) {
+	fileDescriptor := i.getFileContainingSymbol(typeName)
+	if fileDescriptor == nil {
+		setNotFoundResponse(response, typeName)
+		return
+	}
+	if _, ok := fileDescriptor.FindSymbol(typeName).(*desc.MessageDescriptor); !ok {
+		setNotFoundResponse(response, typeName)
+		return
+	}
+	extensionNumbers := make([]int32, 0, len(i.typeNameToExtensionNumberToFileDescriptor[typeName]))
+	for extensionNumber := range i.typeNameToExtensionNumberToFileDescriptor[typeName] {
+		extensionNumbers = append(extensionNumbers, extensionNumber)
+	}
+	sort.Slice(
+		extensionNumbers,
+		func(i int, j int) bool {
+			return extensionNumbers[i] < extensionNumbers[j]
+		},
+	)
+	response.MessageResponse = &grpc_reflection_v1alpha.ServerReflectionResponse_AllExtensionNumbersResponse{
+		AllExtensionNumbersResponse: &grpc_reflection_v1alpha.ExtensionNumberResponse{
+			BaseTypeName:    typeName,
+			ExtensionNumber: extensionNumbers,
+		},
+	}
+}
+
+func (i *reflectionIndex) setListServicesResponse(response *grpc_reflection_v1alpha.ServerReflectionResponse) {
+	serviceResponses := make([]*grpc_reflection_v1alpha.ServiceResponse, len(i.serviceNames))
+	for j, serviceName := range i.serviceNames {
+		serviceResponses[j] = &grpc_reflection_v1alpha.ServiceResponse{
+			Name: serviceName,
+		}
+	}
+	response.MessageResponse = &grpc_reflection_v1alpha.ServerReflectionResponse_ListServicesResponse{
+		ListServicesResponse: &grpc_reflection_v1alpha.ListServiceResponse{
+			Service: serviceResponses,
+		},
+	}
+}
+
+func setNotFoundResponse(response *grpc_reflection_v1alpha.ServerReflectionResponse, name string) {
+	response.MessageResponse = &grpc_reflection_v1alpha.ServerReflectionResponse_ErrorResponse{
+		ErrorResponse: &grpc_reflection_v1alpha.ErrorResponse{
+			ErrorCode:    int32(codes.NotFound),
+			ErrorMessage: name + " not found",
+		},
+	}
+}
+
+// forEachMessage calls f for every message and nested message.
+func forEachMessage(messageDescriptors []*desc.MessageDescriptor, f func(*desc.MessageDescriptor)) {
+	for _, messageDescriptor := range messageDescriptors {
+		f(messageDescriptor)
+		forEachMessage(messageDescriptor.GetNestedMessageTypes(), f)
+	}
+}
@@ -0,0 +1,139 @@
+// Package bufstats computes size statistics of Images.
+package bufstats
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/bufbuild/buf/internal/buf/bufpb"
+	"github.com/bufbuild/buf/internal/pkg/errs"
+	"go.uber.org/zap"
+)
+
+const (
+	// GroupByPackage groups statistics by Protobuf package.
+	GroupByPackage = "package"
+	// GroupByDirectory groups statistics by the directory of the files.
+	GroupByDirectory = "directory"
+)
+
+var (
+	// AllGroupBys are all groupings.
+	AllGroupBys = []string{
+		GroupByPackage,
+		GroupByDirectory,
+	}
+)
+
+// Stats are the statistics of a group of files.
+type Stats struct {
+	// Name is the package or directory of the group.
+	//
+	// Files without a package are grouped under the empty package.
+	Name string `json:"name" yaml:"name"`
+	// Files is the number of files.
+	Files int `json:"files" yaml:"files"`
+	// Messages is the number of messages, including nested messages.
+	//
+	// Map entries are not counted, as they are generated by the compiler.
+	Messages int `json:"messages" yaml:"messages"`
+	// Fields is the number of fields of messages, including extensions
+	// declared within messages.
+	Fields int `json:"fields" yaml:"fields"`
+	// Enums is the number of enums, including nested enums.
+	Enums int `json:"enums" yaml:"enums"`
+	// Services is the number of services.
+	Services int `json:"services" yaml:"services"`
+	// Methods is the number of methods, ie RPCs.
+	Methods int `json:"methods" yaml:"methods"`
+	// StreamingMethods is the number of methods with client streaming,
+	// server streaming, or both.
+	StreamingMethods int `json:"streaming_methods" yaml:"streaming_methods"`
+	// Deprecated is the number of files, messages, fields, enums, enum values,
+	// services, and methods with the deprecated option set to true.
+	Deprecated int `json:"deprecated" yaml:"deprecated"`
+	// MaxMessageDepth is the maximum nesting depth of messages, where
+	// top-level messages have depth 1. This is 0 if there are no messages.
+	MaxMessageDepth int `json:"max_message_depth" yaml:"max_message_depth"`
+}
+
+// Handler handles statistics.
+type Handler interface {
+	// Stats returns the statistics of the Image grouped by groupBy.
+	//
+	// Imports are included in the statistics if they are in the Image.
+	// Directories are relative to the roots.
+	//
+	// Stats are sorted by name.
+	Stats(
+		ctx context.Context,
+		image bufpb.Image,
+		groupBy string,
+	) ([]*Stats, error)
+}
+
+// NewHandler returns a new Handler.
+func NewHandler(logger *zap.Logger) Handler {
+	return newHandler(logger)
+}
+
+// ValidateGroupBy returns a user error if the groupBy is not in AllGroupBys.
+func ValidateGroupBy(groupBy string) error {
+	switch groupBy {
+	case GroupByPackage, GroupByDirectory:
+		return nil
+	default:
+		return errs.NewInvalidArgumentf("unknown group by: %q", groupBy)
+	}
+}
+
+// PrintStats prints the stats to the writer.
+//
+// The text format is a table with a header row.
+// The JSON format is one Stats per line.
+func PrintStats(writer io.Writer, stats []*Stats, asJSON bool) (retErr error) {
+	if len(stats) == 0 {
+		return nil
+	}
+	if asJSON {
+		for _, groupStats := range stats {
+			data, err := json.Marshal(groupStats)
+			if err != nil {
+				return err
+			}
+			if _, err := fmt.Fprintln(writer, string(data)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	tabWriter := tabwriter.NewWriter(writer, 0, 0, 2, ' ', 0)
+	defer func() {
+		retErr = errs.Append(retErr, tabWriter.Flush())
+	}()
+	if _, err := fmt.Fprintln(tabWriter, "NAME\tFILES\tMESSAGES\tFIELDS\tENUMS\tSERVICES\tMETHODS\tSTREAMING METHODS\tDEPRECATED\tMAX MESSAGE DEPTH"); err != nil {
+		return err
+	}
+	for _, groupStats := range stats {
+		if _, err := fmt.Fprintf(
+			tabWriter,
+			"%s\t%d\t%d\t%d\t%d\t%d\t%d\t%d\t%d\t%d\n",
+			groupStats.Name,
+			groupStats.Files,
+			groupStats.Messages,
+			groupStats.Fields,
+			groupStats.Enums,
+			groupStats.Services,
+			groupStats.Methods,
+			groupStats.StreamingMethods,
+			groupStats.Deprecated,
+			groupStats.MaxMessageDepth,
+		); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package bufstats_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/bufbuild/buf/internal/buf/bufstats"
+	"github.com/bufbuild/buf/internal/buf/buftesting"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestStatsPackage(t *testing.T) {
+	t.Parallel()
+	stats := testStats(t, bufstats.GroupByPackage)
+	assert.Equal(
+		t,
+		[]*bufstats.Stats{
+			{
+				Name:             "a.v1",
+				Files:            2,
+				Messages:         3,
+				Fields:           5,
+				Enums:            2,
+				Services:         1,
+				Methods:          3,
+				StreamingMethods: 2,
+				Deprecated:       4,
+				MaxMessageDepth:  3,
+			},
+			{
+				Name:            "b.v1",
+				Files:           1,
+				Messages:        1,
+				Fields:          2,
+				MaxMessageDepth: 1,
+			},
+		},
+		stats,
+	)
+	buffer := bytes.NewBuffer(nil)
+	require.NoError(t, bufstats.PrintStats(buffer, stats, false))
+	assert.Equal(
+		t,
+		`NAME  FILES  MESSAGES  FIELDS  ENUMS  SERVICES  METHODS  STREAMING METHODS  DEPRECATED  MAX MESSAGE DEPTH
+a.v1  2      3         5       2      1         3        2                  4           3
+b.v1  1      1         2       0      0         0        0                  0           1
+`,
+		buffer.String(),
+	)
+	buffer.Reset()
+	require.NoError(t, bufstats.PrintStats(buffer, stats[1:], true))
+	assert.Equal(
+		t,
+		`{"name":"b.v1","files":1,"messages":1,"fields":2,"enums":0,"services":0,"methods":0,"streaming_methods":0,"deprecated":0,"max_message_depth":1}
+`,
+		buffer.String(),
+	)
+}
+
+func TestStatsDirectory(t *testing.T) {
+	t.Parallel()
+	stats := testStats(t, bufstats.GroupByDirectory)
+	require.Len(t, stats, 2)
+	assert.Equal(t, "a/v1", stats[0].Name)
+	assert.Equal(t, 2, stats[0].Files)
+	assert.Equal(t, "b/v1", stats[1].Name)
+}
+
+func TestStatsUnknownGroupBy(t *testing.T) {
+	t.Parallel()
+	_, err := bufstats.NewHandler(zap.NewNop()).Stats(context.Background(), buftesting.BuildImage(t, "testdata", false, false), "foo")
+	assert.Error(t, err)
+}
+
+func testStats(t *testing.T, groupBy string) []*bufstats.Stats {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	stats, err := bufstats.NewHandler(zap.NewNop()).Stats(ctx, buftesting.BuildImage(t, "testdata", false, false), groupBy)
+	require.NoError(t, err)
+	return stats
+}
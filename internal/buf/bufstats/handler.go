@@ -0,0 +1,137 @@
+package bufstats
+
+import (
+	"context"
+	"sort"
+
+	"github.com/bufbuild/buf/internal/buf/bufpb"
+	"github.com/bufbuild/buf/internal/pkg/logutil"
+	"github.com/bufbuild/buf/internal/pkg/protodesc"
+	"go.uber.org/zap"
+)
+
+type handler struct {
+	logger *zap.Logger
+}
+
+func newHandler(logger *zap.Logger) *handler {
+	return &handler{
+		logger: logger.Named("bufstats"),
+	}
+}
+
+func (h *handler) Stats(
+	ctx context.Context,
+	image bufpb.Image,
+	groupBy string,
+) (_ []*Stats, retErr error) {
+	defer logutil.DeferWithError(h.logger, "stats", &retErr)()
+
+	if err := ValidateGroupBy(groupBy); err != nil {
+		return nil, err
+	}
+	files, err := protodesc.NewFiles(image.GetFile()...)
+	if err != nil {
+		return nil, err
+	}
+	var nameToFiles map[string][]protodesc.File
+	switch groupBy {
+	case GroupByPackage:
+		nameToFiles, err = protodesc.PackageToFiles(files...)
+	case GroupByDirectory:
+		nameToFiles, err = protodesc.DirPathToFiles(files...)
+	}
+	if err != nil {
+		return nil, err
+	}
+	stats := make([]*Stats, 0, len(nameToFiles))
+	for name, groupFiles := range nameToFiles {
+		groupStats := &Stats{
+			Name: name,
+		}
+		for _, file := range groupFiles {
+			if err := addFileStats(groupStats, file); err != nil {
+				return nil, err
+			}
+		}
+		stats = append(stats, groupStats)
+	}
+	sort.Slice(
+		stats,
+		func(i int, j int) bool {
+			return stats[i].Name < stats[j].Name
+		},
+	)
+	return stats, nil
+}
+
+func addFileStats(stats *Stats, file protodesc.File) error {
+	stats.Files++
+	addDeprecated(stats, file)
+	if err := protodesc.ForEachMessage(
+		func(message protodesc.Message) error {
+			// map entries are generated by the compiler and are not declared
+			if message.IsMapEntry() {
+				return nil
+			}
+			stats.Messages++
+			addDeprecated(stats, message)
+			for _, field := range message.Fields() {
+				stats.Fields++
+				addDeprecated(stats, field)
+			}
+			for _, field := range message.Extensions() {
+				stats.Fields++
+				addDeprecated(stats, field)
+			}
+			if depth := getMessageDepth(message); depth > stats.MaxMessageDepth {
+				stats.MaxMessageDepth = depth
+			}
+			return nil
+		},
+		file,
+	); err != nil {
+		return err
+	}
+	if err := protodesc.ForEachEnum(
+		func(enum protodesc.Enum) error {
+			stats.Enums++
+			addDeprecated(stats, enum)
+			for _, enumValue := range enum.Values() {
+				addDeprecated(stats, enumValue)
+			}
+			return nil
+		},
+		file,
+	); err != nil {
+		return err
+	}
+	for _, service := range file.Services() {
+		stats.Services++
+		addDeprecated(stats, service)
+		for _, method := range service.Methods() {
+			stats.Methods++
+			addDeprecated(stats, method)
+			if method.ClientStreaming() || method.ServerStreaming() {
+				stats.StreamingMethods++
+			}
+		}
+	}
+	return nil
+}
+
+func addDeprecated(stats *Stats, deprecatedDescriptor protodesc.DeprecatedDescriptor) {
+	if deprecatedDescriptor.Deprecated() {
+		stats.Deprecated++
+	}
+}
+
+// getMessageDepth returns the nesting depth of the message, where top-level
+// messages have depth 1.
+func getMessageDepth(message protodesc.Message) int {
+	depth := 0
+	for ; message != nil; message = message.Parent() {
+		depth++
+	}
+	return depth
+}
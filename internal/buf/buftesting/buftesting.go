@@ -4,6 +4,7 @@ package buftesting
 import (
 	"context"
 	"net/http"
+	"testing"
 	"time"
 
 	"github.com/bufbuild/buf/internal/buf/bufpb"
@@ -37,6 +38,23 @@ func GetProtocImage(
 	)
 }
 
+// BuildImage builds the Image for the directory with the default build configuration.
+//
+// The test fails if the directory does not build.
+func BuildImage(
+	t *testing.T,
+	dirPath string,
+	includeImports bool,
+	includeSourceInfo bool,
+) bufpb.Image {
+	return buildImage(
+		t,
+		dirPath,
+		includeImports,
+		includeSourceInfo,
+	)
+}
+
 // GetGithubArchive gets the GitHub archive and untars it to the output directory path.
 //
 // The root directory within the tarball is stripped.
@@ -0,0 +1,54 @@
+package buftesting
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/bufbuild/buf/internal/buf/bufbuild"
+	"github.com/bufbuild/buf/internal/buf/bufpb"
+	"github.com/bufbuild/buf/internal/pkg/bytepool"
+	"github.com/bufbuild/buf/internal/pkg/bytepool/bytepooltesting"
+	"github.com/bufbuild/buf/internal/pkg/storage/storageos"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func buildImage(
+	t *testing.T,
+	dirPath string,
+	includeImports bool,
+	includeSourceInfo bool,
+) bufpb.Image {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	logger := zap.NewNop()
+	segList := bytepool.NewSegList()
+	defer bytepooltesting.AssertAllRecycled(t, segList)
+
+	bucket, err := storageos.NewReadBucket(dirPath)
+	require.NoError(t, err)
+	defer func() {
+		assert.NoError(t, bucket.Close())
+	}()
+	config, err := bufbuild.ConfigBuilder{}.NewConfig()
+	require.NoError(t, err)
+	image, _, annotations, err := bufbuild.NewHandler(
+		logger,
+		segList,
+		bufbuild.NewProvider(logger),
+		bufbuild.NewRunner(logger),
+	).BuildImage(
+		ctx,
+		bucket,
+		config,
+		nil,
+		false,
+		includeImports,
+		includeSourceInfo,
+	)
+	require.NoError(t, err)
+	require.Empty(t, annotations)
+	return image
+}
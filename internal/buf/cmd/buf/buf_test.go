@@ -1,17 +1,26 @@
 package buf
 
 import (
+	"archive/zip"
 	"bytes"
+	"context"
 	"fmt"
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
+	"github.com/bufbuild/buf/internal/buf/bufpb"
+	"github.com/bufbuild/buf/internal/buf/bufserve/bufservetesting"
 	"github.com/bufbuild/buf/internal/pkg/cli"
 	"github.com/bufbuild/buf/internal/pkg/cli/clicobra"
 	"github.com/bufbuild/buf/internal/pkg/osutil"
+	"github.com/bufbuild/buf/internal/pkg/storage/storageos"
+	"github.com/bufbuild/buf/internal/pkg/storage/storageutil"
 	"github.com/bufbuild/buf/internal/pkg/stringutil"
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/protoc-gen-go/descriptor"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -69,6 +78,20 @@ func TestFail1(t *testing.T) {
 	)
 }
 
+func TestFail1WatchNotLocal(t *testing.T) {
+	devNull, err := osutil.DevNull()
+	require.NoError(t, err)
+	testRun(
+		t,
+		1,
+		``,
+		"image", "build", "-o", devNull,
+		"--source",
+		"https://example.com/buf.tar.gz",
+		"--watch",
+	)
+}
+
 func TestFail2(t *testing.T) {
 	devNull, err := osutil.DevNull()
 	require.NoError(t, err)
@@ -125,6 +148,23 @@ func TestFail5(t *testing.T) {
 	)
 }
 
+func TestFail5Watch(t *testing.T) {
+	// the annotations are printed once, and then the command runs until the explicitly set timeout
+	testRun(
+		t,
+		0,
+		`testdata/fail/buf/buf.proto:3:1:Files with package "other" must be within a directory "other" relative to root but were in directory "buf".
+        testdata/fail/buf/buf.proto:6:9:Field name "oneTwo" should be lower_snake_case, such as "one_two".`,
+		"check",
+		"lint",
+		"--input",
+		filepath.Join("testdata", "fail"),
+		"--watch",
+		"--timeout",
+		"100ms",
+	)
+}
+
 func TestFail6(t *testing.T) {
 	testRun(
 		t,
@@ -233,6 +273,171 @@ func TestFailCheckBreaking1(t *testing.T) {
 	)
 }
 
+func TestCheckChanges(t *testing.T) {
+	testRun(
+		t,
+		0,
+		`
+		modified method a.Four.Five: server streaming changed from false to true (testdata/changes/previous/a.proto:21:26 -> testdata/changes/current/a.proto:21:33)
+		added method a.Four.Six (testdata/changes/current/a.proto:22:7)
+		modified field a.One.bar: type changed from "int32" to "int64" (testdata/changes/previous/a.proto:9:3 -> testdata/changes/current/a.proto:9:3)
+		modified field a.One.bat: field 3 renamed from "baz" to "bat" (testdata/changes/previous/a.proto:10:9 -> testdata/changes/current/a.proto:10:9)
+		modified field a.One.bat: json_name changed from "baz" to "bat" (testdata/changes/previous/a.proto:10:9 -> testdata/changes/current/a.proto:10:9)
+		modified field a.One.foo: deprecated (testdata/changes/previous/a.proto:8:10 -> testdata/changes/current/a.proto:8:10)
+		added field a.One.qux (testdata/changes/current/a.proto:11:8)
+		modified enum value a.Three.THREE_ONE: number changed from 1 to 2 (testdata/changes/previous/a.proto:17:15 -> testdata/changes/current/a.proto:16:15)
+		added enum value a.Three.THREE_TWO (testdata/changes/current/a.proto:17:3)
+		removed message a.Two (testdata/changes/previous/a.proto:13:9)
+		modified file a.proto: option go_package changed from "apb" to "apbv2" (testdata/changes/previous/a.proto:5:1 -> testdata/changes/current/a.proto:5:1)
+		`,
+		"check",
+		"changes",
+		"--input",
+		filepath.Join("testdata", "changes", "current"),
+		"--against-input",
+		filepath.Join("testdata", "changes", "previous"),
+	)
+}
+
+func TestCheckChangesNoChanges(t *testing.T) {
+	testRun(
+		t,
+		0,
+		``,
+		"check",
+		"changes",
+		"--input",
+		filepath.Join("testdata", "changes", "current"),
+		"--against-input",
+		filepath.Join("testdata", "changes", "current"),
+		"--format",
+		"markdown",
+	)
+}
+
+func TestCheckChangesFail(t *testing.T) {
+	testRun(
+		t,
+		1,
+		``,
+		"check",
+		"changes",
+		"--input",
+		filepath.Join("testdata", "changes", "current"),
+		"--against-input",
+		filepath.Join("testdata", "changes", "previous"),
+		"--format",
+		"yaml",
+	)
+}
+
+func TestImageConvert(t *testing.T) {
+	t.Parallel()
+	tmpDirPath, err := ioutil.TempDir("", "")
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, os.RemoveAll(tmpDirPath)) }()
+	jsonFilePath := filepath.Join(tmpDirPath, "image.json")
+	binFilePath := filepath.Join(tmpDirPath, "image.bin")
+	convertedBinFilePath := filepath.Join(tmpDirPath, "converted.bin")
+	testRunCmdNoParallel(t, newRootCommand("test", false), 0, ``, "image", "build", "-o", jsonFilePath, "--source", filepath.Join("testdata", "success"))
+	testRunCmdNoParallel(t, newRootCommand("test", false), 0, ``, "image", "build", "-o", binFilePath, "--exclude-imports", "--exclude-source-info", "--source", filepath.Join("testdata", "success"))
+	testRunCmdNoParallel(t, newRootCommand("test", false), 0, ``, "image", "convert", "--input", jsonFilePath, "-o", convertedBinFilePath, "--exclude-imports", "--exclude-source-info")
+	expectedData, err := ioutil.ReadFile(binFilePath)
+	require.NoError(t, err)
+	data, err := ioutil.ReadFile(convertedBinFilePath)
+	require.NoError(t, err)
+	assert.Equal(t, expectedData, data)
+}
+
+func TestImageConvertProtoset(t *testing.T) {
+	t.Parallel()
+	tmpDirPath, err := ioutil.TempDir("", "")
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, os.RemoveAll(tmpDirPath)) }()
+	protosetFilePath := filepath.Join(tmpDirPath, "image.protoset")
+	inferredBinFilePath := filepath.Join(tmpDirPath, "inferred.bin")
+	targetBinFilePath := filepath.Join(tmpDirPath, "target.bin")
+	testRunCmdNoParallel(t, newRootCommand("test", false), 0, ``, "image", "build", "-o", protosetFilePath, "--as-file-descriptor-set", "--source", filepath.Join("testdata", "graph"))
+	// without import information, --exclude-imports has no effect
+	testRunCmdNoParallel(
+		t,
+		newRootCommand("test", false),
+		0,
+		`
+		a/a.proto
+		b/b.proto
+		`,
+		"ls-files",
+		"--input",
+		protosetFilePath,
+	)
+	testRunCmdNoParallel(t, newRootCommand("test", false), 0, ``, "image", "convert", "--input", protosetFilePath+"#infer_imports=true", "-o", inferredBinFilePath, "--exclude-imports")
+	testRunCmdNoParallel(
+		t,
+		newRootCommand("test", false),
+		0,
+		`
+		a/a.proto
+		`,
+		"ls-files",
+		"--input",
+		inferredBinFilePath,
+	)
+	testRunCmdNoParallel(t, newRootCommand("test", false), 0, ``, "image", "convert", "--input", protosetFilePath+"#target=b/b.proto", "-o", targetBinFilePath, "--exclude-imports")
+	testRunCmdNoParallel(
+		t,
+		newRootCommand("test", false),
+		0,
+		`
+		b/b.proto
+		`,
+		"ls-files",
+		"--input",
+		targetBinFilePath,
+	)
+	testRunCmdNoParallel(t, newRootCommand("test", false), 1, ``, "image", "convert", "--input", protosetFilePath+"#target=c/c.proto", "-o", targetBinFilePath)
+}
+
+func TestImageConvertFiles(t *testing.T) {
+	t.Parallel()
+	tmpDirPath, err := ioutil.TempDir("", "")
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, os.RemoveAll(tmpDirPath)) }()
+	imageFilePath := filepath.Join(tmpDirPath, "image.bin")
+	convertedFilePath := filepath.Join(tmpDirPath, "converted.bin")
+	testRunCmdNoParallel(t, newRootCommand("test", false), 0, ``, "image", "build", "-o", imageFilePath, "--source", filepath.Join("testdata", "graph"))
+
+	// a/a.proto imports b/b.proto, which must be kept as an import
+	testRunCmdNoParallel(t, newRootCommand("test", false), 0, ``, "image", "convert", "--input", imageFilePath, "-o", convertedFilePath, "--file", "a/a.proto")
+	image := testReadImage(t, convertedFilePath)
+	assert.Equal(t, []string{"b/b.proto", "a/a.proto"}, testGetImageFileNames(image))
+	importNames, err := image.ImportNames()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"b/b.proto"}, importNames)
+	_, err = bufpb.ImageToDescFileDescriptors(image)
+	assert.NoError(t, err)
+
+	testRunCmdNoParallel(t, newRootCommand("test", false), 0, ``, "image", "convert", "--input", imageFilePath, "-o", convertedFilePath, "--file", "a/a.proto", "--exclude-imports")
+	assert.Equal(t, []string{"a/a.proto"}, testGetImageFileNames(testReadImage(t, convertedFilePath)))
+
+	testRunCmdNoParallel(t, newRootCommand("test", false), 0, ``, "image", "convert", "--input", imageFilePath, "-o", convertedFilePath, "--file", "b/b.proto")
+	assert.Equal(t, []string{"b/b.proto"}, testGetImageFileNames(testReadImage(t, convertedFilePath)))
+
+	testRunCmdNoParallel(t, newRootCommand("test", false), 1, ``, "image", "convert", "--input", imageFilePath, "-o", convertedFilePath, "--file", "c/c.proto")
+}
+
+func TestImageConvertFail1(t *testing.T) {
+	devNull, err := osutil.DevNull()
+	require.NoError(t, err)
+	testRun(t, 1, ``, "image", "convert", "-o", devNull)
+}
+
+func TestImageConvertFail2(t *testing.T) {
+	devNull, err := osutil.DevNull()
+	require.NoError(t, err)
+	testRun(t, 1, ``, "image", "convert", "--input", filepath.Join("testdata", "success"), "-o", devNull)
+}
+
 func TestCheckLsLintCheckers1(t *testing.T) {
 	testRun(
 		t,
@@ -328,51 +533,903 @@ func TestLsFiles(t *testing.T) {
 	)
 }
 
-func testRun(t *testing.T, expectedExitCode int, expectedStdout string, args ...string) {
-	t.Parallel()
-	t.Run("buf", func(t *testing.T) {
-		testRunCmd(
-			t,
-			newRootCommand("test", false),
-			expectedExitCode,
-			expectedStdout,
-			args...,
-		)
-	})
-	t.Run("bufdev", func(t *testing.T) {
-		testRunCmd(
-			t,
-			newRootCommand("test", true),
-			expectedExitCode,
-			expectedStdout,
-			args...,
-		)
-	})
+func TestLsFilesGitRef(t *testing.T) {
+	testRun(
+		t,
+		0,
+		`
+		buf/buf.proto
+		`,
+		"ls-files",
+		"--input",
+		filepath.Join("..", "..", "..", "..", ".git")+"#ref=HEAD,subdir=internal/buf/cmd/buf/testdata/success",
+	)
 }
 
-func testRunProfile(t *testing.T, expectedExitCode int, expectedStdout string, args ...string) {
-	t.Run("bufdev-profile", func(t *testing.T) {
-		profileDirPath, err := ioutil.TempDir("", "")
-		require.NoError(t, err)
-		defer func() { assert.NoError(t, os.RemoveAll(profileDirPath)) }()
-		testRunCmd(
-			t,
-			newRootCommand("test", true),
-			0,
-			``,
-			append(
-				args,
-				"--profile",
-				fmt.Sprintf("--profile-path=%s", profileDirPath),
-				"--profile-loops=1",
-				"--profile-type=cpu",
-			)...,
-		)
-	})
+func TestLsFilesZipStdin(t *testing.T) {
+	bucket, err := storageos.NewBucket("testdata")
+	require.NoError(t, err)
+	buffer := bytes.NewBuffer(nil)
+	require.NoError(t, storageutil.Zip(context.Background(), buffer, bucket, "success"))
+	require.NoError(t, bucket.Close())
+	testRunStdin(
+		t,
+		0,
+		`
+		buf/buf.proto
+		`,
+		buffer.String(),
+		"ls-files",
+		"--input",
+		"-#format=zip,strip_components=1",
+	)
 }
 
-func testRunCmd(t *testing.T, cmd *clicobra.Command, expectedExitCode int, expectedStdout string, args ...string) {
+func TestLsFilesAndCheckBreakingGRPC(t *testing.T) {
 	t.Parallel()
+	tmpDirPath, err := ioutil.TempDir("", "")
+	require.NoError(t, err)
+	defer func() {
+		assert.NoError(t, os.RemoveAll(tmpDirPath))
+	}()
+	imageFilePath := filepath.Join(tmpDirPath, "image.bin")
+	testRunCmdNoParallel(
+		t,
+		newRootCommand("test", false),
+		0,
+		``,
+		"image",
+		"build",
+		"--source",
+		filepath.Join("testdata", "grpc"),
+		"-o",
+		imageFilePath,
+	)
+	address, closeFunc := bufservetesting.StartReflectionServer(t, testReadImage(t, imageFilePath))
+	defer closeFunc()
+	grpcInput := "grpc://" + address
+
+	testRunCmdNoParallel(
+		t,
+		newRootCommand("test", false),
+		0,
+		`
+		acme/v1/ping.proto
+		`,
+		"ls-files",
+		"--input",
+		grpcInput,
+	)
+	testRunCmdNoParallel(
+		t,
+		newRootCommand("test", false),
+		0,
+		``,
+		"check",
+		"breaking",
+		"--input",
+		filepath.Join("testdata", "grpc"),
+		"--against-input",
+		grpcInput,
+	)
+	testRunCmdNoParallel(
+		t,
+		newRootCommand("test", false),
+		1,
+		``,
+		"image",
+		"build",
+		"--source",
+		filepath.Join("testdata", "grpc"),
+		"-o",
+		grpcInput,
+	)
+}
+
+func TestFormat(t *testing.T) {
+	data, err := ioutil.ReadFile(filepath.Join("testdata", "format", "formatted", "a", "a.proto"))
+	require.NoError(t, err)
+	testRun(
+		t,
+		0,
+		string(data),
+		"format",
+		"--input",
+		filepath.Join("testdata", "format", "unformatted"),
+	)
+}
+
+func TestFormatExitCode1(t *testing.T) {
+	testRun(
+		t,
+		0,
+		``,
+		"format",
+		"--input",
+		filepath.Join("testdata", "format", "formatted"),
+		"--exit-code",
+	)
+}
+
+func TestFormatExitCode2(t *testing.T) {
+	testRun(
+		t,
+		1,
+		``,
+		"format",
+		"--input",
+		filepath.Join("testdata", "format", "unformatted"),
+		"--exit-code",
+	)
+}
+
+func TestFormatWrite(t *testing.T) {
+	expectedData, err := ioutil.ReadFile(filepath.Join("testdata", "format", "formatted", "a", "a.proto"))
+	require.NoError(t, err)
+	unformattedData, err := ioutil.ReadFile(filepath.Join("testdata", "format", "unformatted", "a", "a.proto"))
+	require.NoError(t, err)
+	tmpDirPath, err := ioutil.TempDir("", "")
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, os.RemoveAll(tmpDirPath)) }()
+	require.NoError(t, os.MkdirAll(filepath.Join(tmpDirPath, "a"), 0755))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(tmpDirPath, "a", "a.proto"), unformattedData, 0644))
+	testRunCmd(
+		t,
+		newRootCommand("test", false),
+		0,
+		``,
+		"format",
+		"--input",
+		tmpDirPath,
+		"--write",
+	)
+	data, err := ioutil.ReadFile(filepath.Join(tmpDirPath, "a", "a.proto"))
+	require.NoError(t, err)
+	assert.Equal(t, string(expectedData), string(data))
+}
+
+func TestLsPackages(t *testing.T) {
+	testRun(
+		t,
+		0,
+		`
+		package  buf  testdata/success/buf/buf.proto:3
+		`,
+		"ls-packages",
+		"--input",
+		filepath.Join("testdata", "success"),
+	)
+}
+
+func TestLsTypes(t *testing.T) {
+	testRun(
+		t,
+		0,
+		`
+		message  buf.Foo  testdata/success/buf/buf.proto:5
+		`,
+		"ls-types",
+		"--input",
+		filepath.Join("testdata", "success"),
+	)
+}
+
+func TestDescribe(t *testing.T) {
+	testRun(
+		t,
+		0,
+		`
+		message buf.Foo (testdata/success/buf/buf.proto:5:1)
+
+		message Foo {
+		  int64 one = 1;
+		}
+		`,
+		"describe",
+		"--input",
+		filepath.Join("testdata", "success"),
+		"buf.Foo",
+	)
+}
+
+func TestDescribeJSON(t *testing.T) {
+	testRun(
+		t,
+		0,
+		`
+		{"kind":"field","name":"buf.Foo.one","filename":"testdata/success/buf/buf.proto","line":6,"column":3,"source":"int64 one = 1;\n"}
+		`,
+		"describe",
+		"--input",
+		filepath.Join("testdata", "success"),
+		"--format",
+		"json",
+		".buf.Foo.one",
+	)
+}
+
+func TestDescribeNotFound(t *testing.T) {
+	testRun(
+		t,
+		1,
+		``,
+		"describe",
+		"--input",
+		filepath.Join("testdata", "success"),
+		"buf.Bar",
+	)
+}
+
+func TestGraph(t *testing.T) {
+	testRun(
+		t,
+		0,
+		`
+		digraph {
+		"buf";
+		}
+		`,
+		"graph",
+		"--input",
+		filepath.Join("testdata", "success"),
+	)
+}
+
+func TestGraphFileMermaid(t *testing.T) {
+	testRun(
+		t,
+		0,
+		`
+		graph LR
+		n0["buf/buf.proto"]
+		`,
+		"graph",
+		"--input",
+		filepath.Join("testdata", "success"),
+		"--view",
+		"file",
+		"--format",
+		"mermaid",
+	)
+}
+
+func TestGraphNotAllowed(t *testing.T) {
+	testRun(
+		t,
+		1,
+		`
+		{"nodes":["a","b"],"edges":[{"from":"a","to":"b","not_allowed":true}]}
+		`,
+		"graph",
+		"--input",
+		filepath.Join("testdata", "graph"),
+		"--format",
+		"json",
+	)
+}
+
+func TestStats(t *testing.T) {
+	testRun(
+		t,
+		0,
+		`
+		NAME  FILES  MESSAGES  FIELDS  ENUMS  SERVICES  METHODS  STREAMING METHODS  DEPRECATED  MAX MESSAGE DEPTH
+		buf   1      1         1       0      0         0        0                  0           1
+		`,
+		"stats",
+		"--input",
+		filepath.Join("testdata", "success"),
+	)
+}
+
+func TestStatsDirectoryJSON(t *testing.T) {
+	testRun(
+		t,
+		0,
+		`{"name":"buf","files":1,"messages":1,"fields":1,"enums":0,"services":0,"methods":0,"streaming_methods":0,"deprecated":0,"max_message_depth":1}`,
+		"stats",
+		"--input",
+		filepath.Join("testdata", "success"),
+		"--group-by",
+		"directory",
+		"--format",
+		"json",
+	)
+}
+
+func TestStatsUnknownGroupBy(t *testing.T) {
+	testRun(
+		t,
+		1,
+		``,
+		"stats",
+		"--input",
+		filepath.Join("testdata", "success"),
+		"--group-by",
+		"foo",
+	)
+}
+
+func TestDocs(t *testing.T) {
+	t.Parallel()
+	outputDirPath, err := ioutil.TempDir("", "")
+	require.NoError(t, err)
+	defer func() {
+		assert.NoError(t, os.RemoveAll(outputDirPath))
+	}()
+	testRunCmdNoParallel(
+		t,
+		newRootCommand("test", false),
+		0,
+		``,
+		"docs",
+		"--input",
+		filepath.Join("testdata", "success"),
+		"--output",
+		outputDirPath,
+		"--format",
+		"json",
+	)
+	data, err := ioutil.ReadFile(filepath.Join(outputDirPath, "buf.json"))
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"name": "buf.Foo"`)
+}
+
+func TestDocsOutputRequired(t *testing.T) {
+	testRun(
+		t,
+		1,
+		``,
+		"docs",
+		"--input",
+		filepath.Join("testdata", "success"),
+	)
+}
+
+func TestExportFileIncludesImports(t *testing.T) {
+	t.Parallel()
+	outputDirPath, err := ioutil.TempDir("", "")
+	require.NoError(t, err)
+	defer func() {
+		assert.NoError(t, os.RemoveAll(outputDirPath))
+	}()
+	testRunCmdNoParallel(
+		t,
+		newRootCommand("test", false),
+		0,
+		``,
+		"export",
+		"--input",
+		filepath.Join("testdata", "graph"),
+		"--file",
+		filepath.Join("testdata", "graph", "a", "a.proto"),
+		"--output",
+		outputDirPath,
+		"--include-config",
+	)
+	data, err := ioutil.ReadFile(filepath.Join(outputDirPath, "b", "b.proto"))
+	require.NoError(t, err)
+	expectedData, err := ioutil.ReadFile(filepath.Join("testdata", "graph", "b", "b.proto"))
+	require.NoError(t, err)
+	assert.Equal(t, string(expectedData), string(data))
+	_, err = os.Stat(filepath.Join(outputDirPath, "a", "a.proto"))
+	assert.NoError(t, err)
+	data, err = ioutil.ReadFile(filepath.Join(outputDirPath, "buf.yaml"))
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "roots:\n    - .\n")
+}
+
+func TestExportZip(t *testing.T) {
+	t.Parallel()
+	outputDirPath, err := ioutil.TempDir("", "")
+	require.NoError(t, err)
+	defer func() {
+		assert.NoError(t, os.RemoveAll(outputDirPath))
+	}()
+	outputFilePath := filepath.Join(outputDirPath, "out.zip")
+	testRunCmdNoParallel(
+		t,
+		newRootCommand("test", false),
+		0,
+		``,
+		"export",
+		"--input",
+		filepath.Join("testdata", "graph"),
+		"--file",
+		filepath.Join("testdata", "graph", "b", "b.proto"),
+		"--output",
+		outputFilePath,
+	)
+	zipReader, err := zip.OpenReader(outputFilePath)
+	require.NoError(t, err)
+	defer func() {
+		assert.NoError(t, zipReader.Close())
+	}()
+	require.Len(t, zipReader.File, 1)
+	assert.Equal(t, "b/b.proto", zipReader.File[0].Name)
+}
+
+func TestExportOutputRequired(t *testing.T) {
+	testRun(
+		t,
+		1,
+		``,
+		"export",
+		"--input",
+		filepath.Join("testdata", "success"),
+	)
+}
+
+func TestConvert(t *testing.T) {
+	testRunStdin(
+		t,
+		0,
+		`{"one":"2"}`,
+		"\x08\x02",
+		"convert",
+		"--input",
+		filepath.Join("testdata", "success"),
+		"--type",
+		"buf.Foo",
+	)
+}
+
+func TestConvertText(t *testing.T) {
+	testRunStdin(
+		t,
+		0,
+		`one: 2`,
+		`{"one":"2"}`,
+		"convert",
+		"--input",
+		filepath.Join("testdata", "success"),
+		"--type",
+		"buf.Foo",
+		"--from",
+		"json",
+		"--to",
+		"text",
+	)
+}
+
+func TestConvertTypeNotFound(t *testing.T) {
+	testRunStdin(
+		t,
+		1,
+		``,
+		"",
+		"convert",
+		"--input",
+		filepath.Join("testdata", "success"),
+		"--type",
+		"buf.Bar",
+	)
+}
+
+func TestServeReflection(t *testing.T) {
+	// the server runs until the explicitly set timeout
+	testRun(
+		t,
+		0,
+		``,
+		"serve",
+		"reflection",
+		"--input",
+		filepath.Join("testdata", "success"),
+		"--address",
+		"127.0.0.1:0",
+		"--timeout",
+		"100ms",
+	)
+}
+
+func TestServeReflectionFail(t *testing.T) {
+	testRun(
+		t,
+		1,
+		``,
+		"serve",
+		"reflection",
+		"--input",
+		filepath.Join("testdata", "success"),
+		"--address",
+		"127.0.0.1:-1",
+	)
+}
+
+func TestServeMock(t *testing.T) {
+	testRun(
+		t,
+		0,
+		``,
+		"serve",
+		"mock",
+		"--input",
+		filepath.Join("testdata", "success"),
+		"--address",
+		"127.0.0.1:0",
+		"--timeout",
+		"100ms",
+	)
+}
+
+func TestServeMockFixturesNotExist(t *testing.T) {
+	testRun(
+		t,
+		1,
+		``,
+		"serve",
+		"mock",
+		"--input",
+		filepath.Join("testdata", "success"),
+		"--fixtures",
+		filepath.Join("testdata", "nope"),
+	)
+}
+
+func TestLspShutdown(t *testing.T) {
+	testRunStdin(
+		t,
+		0,
+		"Content-Length: 38\r\n\r\n"+`{"jsonrpc":"2.0","id":1,"result":null}`,
+		"Content-Length: 44\r\n\r\n"+`{"jsonrpc":"2.0","id":1,"method":"shutdown"}`+
+			"Content-Length: 33\r\n\r\n"+`{"jsonrpc":"2.0","method":"exit"}`,
+		"lsp",
+	)
+}
+
+func TestLsTypesJSON(t *testing.T) {
+	testRun(
+		t,
+		0,
+		`
+		{"kind":"package","name":"buf","filename":"testdata/success/buf/buf.proto","line":3}
+		{"kind":"message","name":"buf.Foo","filename":"testdata/success/buf/buf.proto","line":5}
+		`,
+		"ls-types",
+		"--input",
+		filepath.Join("testdata", "success"),
+		"--kind",
+		"package,message",
+		"--format",
+		"json",
+	)
+}
+
+func TestLsTypesPackage(t *testing.T) {
+	testRun(
+		t,
+		0,
+		``,
+		"ls-types",
+		"--input",
+		filepath.Join("testdata", "success"),
+		"--package",
+		"bu",
+	)
+}
+
+func TestLsTypesFail(t *testing.T) {
+	testRun(
+		t,
+		1,
+		``,
+		"ls-types",
+		"--input",
+		filepath.Join("testdata", "success"),
+		"--kind",
+		"foo",
+	)
+}
+
+func TestLsServices(t *testing.T) {
+	testRun(
+		t,
+		0,
+		``,
+		"ls-services",
+		"--input",
+		filepath.Join("testdata", "success"),
+	)
+}
+
+func TestCheckLintFix(t *testing.T) {
+	t.Parallel()
+	tmpDirPath, err := ioutil.TempDir("", "")
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, os.RemoveAll(tmpDirPath)) }()
+	require.NoError(t, ioutil.WriteFile(
+		filepath.Join(tmpDirPath, "a.proto"),
+		[]byte(`syntax = "proto3";
+
+package buf;
+
+message Foo {
+  int64 oneTwo = 1;
+}
+`),
+		0644,
+	))
+	config := `{"lint":{"use":["FIELD_LOWER_SNAKE_CASE","PACKAGE_VERSION_SUFFIX"]}}`
+	expectedStdout := filepath.Join(tmpDirPath, "a.proto") + `:3:1:Package name "buf" should be suffixed with a correctly formed version, such as "buf.v1".`
+	testRunCmdNoParallel(t, newRootCommand("test", false), 1, ``, "check", "lint", "--input", tmpDirPath, "--input-config", config, "--fix", "--fix-dry-run")
+	testRunCmdNoParallel(t, newRootCommand("test", false), 1, ``, "check", "lint", "--input", tmpDirPath, "--input-config", config, "--fix", "--file", filepath.Join(tmpDirPath, "a.proto"))
+	// the package cannot be fixed, so --fix-dry-run fails without a diff
+	testRunCmdNoParallel(t, newRootCommand("test", false), 1, ``, "check", "lint", "--input", tmpDirPath, "--input-config", `{"lint":{"use":["PACKAGE_VERSION_SUFFIX"]}}`, "--fix-dry-run")
+	testRunCmdNoParallel(t, newRootCommand("test", false), 1, expectedStdout, "check", "lint", "--input", tmpDirPath, "--input-config", config, "--fix")
+	data, err := ioutil.ReadFile(filepath.Join(tmpDirPath, "a.proto"))
+	require.NoError(t, err)
+	assert.Equal(
+		t,
+		`syntax = "proto3";
+
+package buf;
+
+message Foo {
+  int64 one_two = 1;
+}
+`,
+		string(data),
+	)
+	testRunCmdNoParallel(t, newRootCommand("test", false), 1, expectedStdout, "check", "lint", "--input", tmpDirPath, "--input-config", config, "--fix")
+	testRunCmdNoParallel(t, newRootCommand("test", false), 0, ``, "check", "lint", "--input", tmpDirPath, "--input-config", `{"lint":{"use":["FIELD_LOWER_SNAKE_CASE"]}}`, "--fix-dry-run")
+}
+
+func TestCheckLintFixDoesNotCompile(t *testing.T) {
+	t.Parallel()
+	tmpDirPath, err := ioutil.TempDir("", "")
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, os.RemoveAll(tmpDirPath)) }()
+	original := `syntax = "proto3";
+
+package buf;
+
+service Hello {}
+`
+	require.NoError(t, ioutil.WriteFile(filepath.Join(tmpDirPath, "a.proto"), []byte(original), 0644))
+	require.NoError(t, ioutil.WriteFile(
+		filepath.Join(tmpDirPath, "b.proto"),
+		[]byte(`syntax = "proto3";
+
+package buf;
+
+message HelloService {}
+`),
+		0644,
+	))
+	// renaming Hello to HelloService conflicts with the message in b.proto, so nothing is written
+	testRunCmdNoParallel(
+		t,
+		newRootCommand("test", false),
+		1,
+		filepath.Join(tmpDirPath, "a.proto")+`:5:9:Service name "Hello" should be suffixed with "Service".`,
+		"check",
+		"lint",
+		"--input",
+		tmpDirPath,
+		"--input-config",
+		`{"lint":{"use":["SERVICE_SUFFIX"]}}`,
+		"--fix",
+	)
+	data, err := ioutil.ReadFile(filepath.Join(tmpDirPath, "a.proto"))
+	require.NoError(t, err)
+	assert.Equal(t, original, string(data))
+}
+
+func TestInit(t *testing.T) {
+	t.Parallel()
+	data, err := ioutil.ReadFile(filepath.Join("testdata", "success", "buf", "buf.proto"))
+	require.NoError(t, err)
+	tmpDirPath, err := ioutil.TempDir("", "")
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, os.RemoveAll(tmpDirPath)) }()
+	require.NoError(t, os.MkdirAll(filepath.Join(tmpDirPath, "proto", "buf"), 0755))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(tmpDirPath, "proto", "buf", "buf.proto"), data, 0644))
+	require.NoError(t, ioutil.WriteFile(
+		filepath.Join(tmpDirPath, "proto", "buf", "importer.proto"),
+		[]byte(`syntax = "proto3";
+
+package buf;
+
+import "buf/buf.proto";
+`),
+		0644,
+	))
+	testRunCmdNoParallel(t, newRootCommand("test", false), 0, ``, "init", "--input", tmpDirPath)
+	data, err = ioutil.ReadFile(filepath.Join(tmpDirPath, "buf.yaml"))
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "  roots:\n    - proto\n")
+	testRunCmdNoParallel(t, newRootCommand("test", false), 1, ``, "init", "--input", tmpDirPath)
+	testRunCmdNoParallel(t, newRootCommand("test", false), 0, ``, "init", "--input", tmpDirPath, "--force")
+	testRunCmdNoParallel(
+		t,
+		newRootCommand("test", false),
+		0,
+		filepath.Join(tmpDirPath, "proto", "buf", "buf.proto")+"\n"+filepath.Join(tmpDirPath, "proto", "buf", "importer.proto"),
+		"ls-files",
+		"--input",
+		tmpDirPath,
+	)
+}
+
+func TestConfigMigrate(t *testing.T) {
+	t.Parallel()
+	data, err := ioutil.ReadFile(filepath.Join("..", "..", "bufmigrate", "testdata", "prototool", "prototool.yaml"))
+	require.NoError(t, err)
+	tmpDirPath, err := ioutil.TempDir("", "")
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, os.RemoveAll(tmpDirPath)) }()
+	testRunCmdNoParallel(t, newRootCommand("test", false), 1, ``, "config", "migrate", "--input", tmpDirPath)
+	require.NoError(t, ioutil.WriteFile(filepath.Join(tmpDirPath, "prototool.yaml"), data, 0644))
+	testRunCmdNoParallel(t, newRootCommand("test", false), 0, ``, "config", "migrate", "--input", tmpDirPath)
+	data, err = ioutil.ReadFile(filepath.Join(tmpDirPath, "buf.yaml"))
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "build:\n  roots:\n  - .\n")
+	testRunCmdNoParallel(t, newRootCommand("test", false), 1, ``, "config", "migrate", "--input", tmpDirPath)
+	testRunCmdNoParallel(t, newRootCommand("test", false), 0, ``, "config", "migrate", "--input", tmpDirPath, "--force")
+}
+
+func TestProtoc1(t *testing.T) {
+	devNull, err := osutil.DevNull()
+	require.NoError(t, err)
+	testRun(
+		t,
+		0,
+		``,
+		"protoc",
+		"-I",
+		filepath.Join("testdata", "success"),
+		"-o",
+		devNull,
+		filepath.Join("testdata", "success", "buf", "buf.proto"),
+	)
+}
+
+func TestProtoc2(t *testing.T) {
+	devNull, err := osutil.DevNull()
+	require.NoError(t, err)
+	testRun(
+		t,
+		0,
+		``,
+		"protoc",
+		"--proto_path="+filepath.Join("testdata", "success"),
+		"--descriptor_set_out="+devNull,
+		"--include_imports",
+		"buf/buf.proto",
+	)
+}
+
+func TestProtocOverlappingIncludePaths(t *testing.T) {
+	t.Parallel()
+	tmpDirPath, err := ioutil.TempDir("", "")
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, os.RemoveAll(tmpDirPath)) }()
+	// the output path is used as-is, even if it contains characters that are
+	// meaningful in inputs
+	descriptorSetOut := filepath.Join(tmpDirPath, "image#format=json.bin")
+	testRunCmdNoParallel(
+		t,
+		newRootCommand("test", false),
+		0,
+		``,
+		"protoc",
+		"-I",
+		filepath.Join("testdata", "success"),
+		"-I",
+		filepath.Join("testdata", "success", "buf"),
+		"-o",
+		descriptorSetOut,
+		"buf.proto",
+	)
+	data, err := ioutil.ReadFile(descriptorSetOut)
+	require.NoError(t, err)
+	fileDescriptorSet := &descriptor.FileDescriptorSet{}
+	require.NoError(t, proto.Unmarshal(data, fileDescriptorSet))
+	require.Len(t, fileDescriptorSet.GetFile(), 1)
+	assert.Equal(t, "buf.proto", fileDescriptorSet.GetFile()[0].GetName())
+}
+
+func TestProtocFail1(t *testing.T) {
+	testRun(
+		t,
+		1,
+		``,
+		"protoc",
+		"-I",
+		filepath.Join("testdata", "success"),
+		"buf/nope.proto",
+	)
+}
+
+func testReadImage(t *testing.T, imageFilePath string) bufpb.Image {
+	data, err := ioutil.ReadFile(imageFilePath)
+	require.NoError(t, err)
+	image, err := bufpb.UnmarshalWireDataImage(data)
+	require.NoError(t, err)
+	return image
+}
+
+func testGetImageFileNames(image bufpb.Image) []string {
+	fileNames := make([]string, len(image.GetFile()))
+	for i, file := range image.GetFile() {
+		fileNames[i] = file.GetName()
+	}
+	return fileNames
+}
+
+func testRun(t *testing.T, expectedExitCode int, expectedStdout string, args ...string) {
+	t.Parallel()
+	t.Run("buf", func(t *testing.T) {
+		testRunCmd(
+			t,
+			newRootCommand("test", false),
+			expectedExitCode,
+			expectedStdout,
+			args...,
+		)
+	})
+	t.Run("bufdev", func(t *testing.T) {
+		testRunCmd(
+			t,
+			newRootCommand("test", true),
+			expectedExitCode,
+			expectedStdout,
+			args...,
+		)
+	})
+}
+
+func testRunStdin(t *testing.T, expectedExitCode int, expectedStdout string, stdin string, args ...string) {
+	t.Parallel()
+	for _, devel := range []bool{false, true} {
+		stdout := bytes.NewBuffer(nil)
+		stderr := bytes.NewBuffer(nil)
+		exitCode := clicobra.Run(
+			newRootCommand("test", devel),
+			"test",
+			&cli.RunEnv{
+				Args:   args,
+				Stdin:  strings.NewReader(stdin),
+				Stdout: stdout,
+				Stderr: stderr,
+			},
+		)
+		assert.Equal(t, expectedExitCode, exitCode, stringutil.TrimLines(stderr.String()))
+		if exitCode == expectedExitCode {
+			assert.Equal(t, stringutil.TrimLines(expectedStdout), stringutil.TrimLines(stdout.String()), stringutil.TrimLines(stderr.String()))
+		}
+	}
+}
+
+func testRunProfile(t *testing.T, expectedExitCode int, expectedStdout string, args ...string) {
+	t.Run("bufdev-profile", func(t *testing.T) {
+		profileDirPath, err := ioutil.TempDir("", "")
+		require.NoError(t, err)
+		defer func() { assert.NoError(t, os.RemoveAll(profileDirPath)) }()
+		testRunCmd(
+			t,
+			newRootCommand("test", true),
+			0,
+			``,
+			append(
+				args,
+				"--profile",
+				fmt.Sprintf("--profile-path=%s", profileDirPath),
+				"--profile-loops=1",
+				"--profile-type=cpu",
+			)...,
+		)
+	})
+}
+
+func testRunCmd(t *testing.T, cmd *clicobra.Command, expectedExitCode int, expectedStdout string, args ...string) {
+	t.Parallel()
+	testRunCmdNoParallel(t, cmd, expectedExitCode, expectedStdout, args...)
+}
+
+// testRunCmdNoParallel is used when multiple commands need to be run in order within a single test.
+func testRunCmdNoParallel(t *testing.T, cmd *clicobra.Command, expectedExitCode int, expectedStdout string, args ...string) {
 	stdout := bytes.NewBuffer(nil)
 	stderr := bytes.NewBuffer(nil)
 	exitCode := clicobra.Run(
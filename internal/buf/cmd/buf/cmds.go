@@ -6,25 +6,94 @@ import (
 	"github.com/spf13/pflag"
 )
 
+// httpInputsLong documents the environment used to get inputs over HTTP.
+const httpInputsLong = `Images can be read from http:// and https:// URLs. Requests are authenticated
+with the netrc file at $NETRC or $HOME/.netrc, the per-host bearer tokens in
+$BUF_HTTP_TOKENS (token@host, separated by commas), and the per-host headers in
+$BUF_HTTP_HEADERS (host=Name:Value, separated by semicolons).
+
+Responses are only cached if $BUF_CACHE_DIR is set, in which case they are
+stored in $BUF_CACHE_DIR/http and revalidated on each request. The cache can be
+cleared by removing this directory.`
+
 func newRootCommand(use string, devel bool) *clicobra.Command {
 	flags := newFlags(devel)
 	return &clicobra.Command{
 		Use: use,
 		SubCommands: []*clicobra.Command{
+			newInitCmd(flags),
+			newConfigCmd(flags),
 			newImageCmd(flags),
 			newCheckCmd(flags),
+			newFormatCmd(flags),
+			newGenerateCmd(flags),
+			newDocsCmd(flags),
+			newExportCmd(flags),
+			newProtocCmd(flags),
 			newLsFilesCmd(flags),
+			newLsPackagesCmd(flags),
+			newLsTypesCmd(flags),
+			newLsServicesCmd(flags),
+			newDescribeCmd(flags),
+			newGraphCmd(flags),
+			newStatsCmd(flags),
+			newConvertCmd(flags),
+			newServeCmd(flags),
+			newLspCmd(flags),
 		},
 		BindFlags: flags.bindRootCommandFlags,
 	}
 }
 
+func newInitCmd(flags *Flags) *clicobra.Command {
+	return &clicobra.Command{
+		Use:   "init",
+		Short: "Create a buf.yaml with roots and excludes inferred from the existing .proto files.",
+		Long: `Imports are parsed to infer the smallest set of roots that the imports are relative to.
+Directories that fail to compile with these roots are excluded.`,
+		Args: cobra.NoArgs,
+		Run:  flags.newRunFunc(initConfig),
+		BindFlags: func(flagSet *pflag.FlagSet) {
+			flags.bindInitInput(flagSet)
+			flags.bindInitForce(flagSet)
+			flags.bindInitErrorFormat(flagSet)
+		},
+	}
+}
+
+func newConfigCmd(flags *Flags) *clicobra.Command {
+	return &clicobra.Command{
+		Use:   "config",
+		Short: "Work with buf.yaml configuration files.",
+		SubCommands: []*clicobra.Command{
+			newConfigMigrateCmd(flags),
+		},
+	}
+}
+
+func newConfigMigrateCmd(flags *Flags) *clicobra.Command {
+	return &clicobra.Command{
+		Use:   "migrate",
+		Short: "Create a buf.yaml from an existing prototool.yaml and proto.lock.",
+		Long: `Settings that cannot be migrated are printed as warnings.
+The buf.yaml is still written if there are warnings.`,
+		Args: cobra.NoArgs,
+		Run:  flags.newRunFunc(configMigrate),
+		BindFlags: func(flagSet *pflag.FlagSet) {
+			flags.bindConfigMigrateInput(flagSet)
+			flags.bindConfigMigrateForce(flagSet)
+			flags.bindConfigMigrateErrorFormat(flagSet)
+		},
+	}
+}
+
 func newImageCmd(flags *Flags) *clicobra.Command {
 	return &clicobra.Command{
 		Use:   "image",
 		Short: "Work with Images and FileDescriptorSets.",
 		SubCommands: []*clicobra.Command{
 			newImageBuildCmd(flags),
+			newImageConvertCmd(flags),
 		},
 	}
 }
@@ -34,7 +103,7 @@ func newImageBuildCmd(flags *Flags) *clicobra.Command {
 		Use:   "build",
 		Short: "Build all files from the input location  and output an Image or FileDescriptorSet.",
 		Args:  cobra.NoArgs,
-		Run:   flags.newRunFunc(imageBuild),
+		Run:   flags.newWatchRunFunc(imageBuild, imageBuildInputFlagName, imageBuildConfigFlagName),
 		BindFlags: func(flagSet *pflag.FlagSet) {
 			flags.bindImageBuildInput(flagSet)
 			flags.bindImageBuildConfig(flagSet)
@@ -42,18 +111,37 @@ func newImageBuildCmd(flags *Flags) *clicobra.Command {
 			flags.bindImageBuildAsFileDescriptorSet(flagSet)
 			flags.bindImageBuildExcludeImports(flagSet)
 			flags.bindImageBuildExcludeSourceInfo(flagSet)
+			flags.bindWatch(flagSet)
 			flags.bindImageBuildErrorFormat(flagSet)
 		},
 	}
 }
 
+func newImageConvertCmd(flags *Flags) *clicobra.Command {
+	return &clicobra.Command{
+		Use:   "convert",
+		Short: "Convert an Image or FileDescriptorSet to another format, optionally filtering it.",
+		Args:  cobra.NoArgs,
+		Run:   flags.newRunFunc(imageConvert),
+		BindFlags: func(flagSet *pflag.FlagSet) {
+			flags.bindImageConvertInput(flagSet)
+			flags.bindImageConvertOutput(flagSet)
+			flags.bindImageConvertFiles(flagSet)
+			flags.bindImageBuildAsFileDescriptorSet(flagSet)
+			flags.bindImageBuildExcludeImports(flagSet)
+			flags.bindImageBuildExcludeSourceInfo(flagSet)
+		},
+	}
+}
+
 func newCheckCmd(flags *Flags) *clicobra.Command {
 	return &clicobra.Command{
 		Use:   "check",
-		Short: "Run lint or breaking change checks.",
+		Short: "Run lint or breaking change checks, or list changes.",
 		SubCommands: []*clicobra.Command{
 			newCheckLintCmd(flags),
 			newCheckBreakingCmd(flags),
+			newCheckChangesCmd(flags),
 			newCheckLsLintCheckersCmd(flags),
 			newCheckLsBreakingCheckersCmd(flags),
 		},
@@ -65,11 +153,14 @@ func newCheckLintCmd(flags *Flags) *clicobra.Command {
 		Use:   "lint",
 		Short: "Check that the input location passes lint checks.",
 		Args:  cobra.NoArgs,
-		Run:   flags.newRunFunc(checkLint),
+		Run:   flags.newWatchRunFunc(checkLint, checkLintInputFlagName, checkLintConfigFlagName),
 		BindFlags: func(flagSet *pflag.FlagSet) {
 			flags.bindCheckLintInput(flagSet)
 			flags.bindCheckLintConfig(flagSet)
+			flags.bindCheckLintFix(flagSet)
+			flags.bindCheckLintFixDryRun(flagSet)
 			flags.bindCheckFiles(flagSet)
+			flags.bindWatch(flagSet)
 			flags.bindCheckErrorFormat(flagSet)
 		},
 	}
@@ -79,8 +170,9 @@ func newCheckBreakingCmd(flags *Flags) *clicobra.Command {
 	return &clicobra.Command{
 		Use:   "breaking",
 		Short: "Check that the input location has no breaking changes compared to the against location.",
+		Long:  httpInputsLong,
 		Args:  cobra.NoArgs,
-		Run:   flags.newRunFunc(checkBreaking),
+		Run:   flags.newWatchRunFunc(checkBreaking, checkBreakingInputFlagName, checkBreakingConfigFlagName),
 		BindFlags: func(flagSet *pflag.FlagSet) {
 			flags.bindCheckBreakingInput(flagSet)
 			flags.bindCheckBreakingConfig(flagSet)
@@ -89,6 +181,27 @@ func newCheckBreakingCmd(flags *Flags) *clicobra.Command {
 			flags.bindCheckBreakingLimitToInputFiles(flagSet)
 			flags.bindCheckBreakingExcludeImports(flagSet)
 			flags.bindCheckFiles(flagSet)
+			flags.bindWatch(flagSet)
+			flags.bindCheckErrorFormat(flagSet)
+		},
+	}
+}
+
+func newCheckChangesCmd(flags *Flags) *clicobra.Command {
+	return &clicobra.Command{
+		Use:   "changes",
+		Short: "List all changes in the input location compared to the against location.",
+		Long:  httpInputsLong,
+		Args:  cobra.NoArgs,
+		Run:   flags.newRunFunc(checkChanges),
+		BindFlags: func(flagSet *pflag.FlagSet) {
+			flags.bindCheckChangesInput(flagSet)
+			flags.bindCheckChangesConfig(flagSet)
+			flags.bindCheckChangesAgainstInput(flagSet)
+			flags.bindCheckChangesAgainstConfig(flagSet)
+			flags.bindCheckChangesExcludeImports(flagSet)
+			flags.bindCheckFiles(flagSet)
+			flags.bindCheckChangesFormat(flagSet)
 			flags.bindCheckErrorFormat(flagSet)
 		},
 	}
@@ -136,3 +249,321 @@ func newLsFilesCmd(flags *Flags) *clicobra.Command {
 		},
 	}
 }
+
+func newLsPackagesCmd(flags *Flags) *clicobra.Command {
+	return &clicobra.Command{
+		Use:   "ls-packages",
+		Short: "List all packages for the input location.",
+		Args:  cobra.NoArgs,
+		Run:   flags.newRunFunc(lsPackages),
+		BindFlags: func(flagSet *pflag.FlagSet) {
+			flags.bindLsTypesInput(flagSet)
+			flags.bindLsTypesConfig(flagSet)
+			flags.bindLsTypesPackage(flagSet)
+			flags.bindLsTypesFormat(flagSet)
+		},
+	}
+}
+
+func newLsTypesCmd(flags *Flags) *clicobra.Command {
+	return &clicobra.Command{
+		Use:   "ls-types",
+		Short: "List all messages and enums for the input location.",
+		Long:  "Use --kind to also list packages, services, or methods.",
+		Args:  cobra.NoArgs,
+		Run:   flags.newRunFunc(lsTypes),
+		BindFlags: func(flagSet *pflag.FlagSet) {
+			flags.bindLsTypesInput(flagSet)
+			flags.bindLsTypesConfig(flagSet)
+			flags.bindLsTypesPackage(flagSet)
+			flags.bindLsTypesKind(flagSet)
+			flags.bindLsTypesFormat(flagSet)
+		},
+	}
+}
+
+func newLsServicesCmd(flags *Flags) *clicobra.Command {
+	return &clicobra.Command{
+		Use:   "ls-services",
+		Short: "List all services and their methods for the input location.",
+		Args:  cobra.NoArgs,
+		Run:   flags.newRunFunc(lsServices),
+		BindFlags: func(flagSet *pflag.FlagSet) {
+			flags.bindLsTypesInput(flagSet)
+			flags.bindLsTypesConfig(flagSet)
+			flags.bindLsTypesPackage(flagSet)
+			flags.bindLsTypesFormat(flagSet)
+		},
+	}
+}
+
+func newDescribeCmd(flags *Flags) *clicobra.Command {
+	return &clicobra.Command{
+		Use:   "describe <name>",
+		Short: "Describe a message, field, enum, enum value, service, or method by its fully-qualified name.",
+		Long: `The element is printed as Protobuf source with its leading comments, followed by
+the messages and enums it depends on, and the fields and methods that depend on it.`,
+		Args: cobra.ExactArgs(1),
+		Run:  flags.newRunFunc(describe),
+		BindFlags: func(flagSet *pflag.FlagSet) {
+			flags.bindDescribeInput(flagSet)
+			flags.bindDescribeConfig(flagSet)
+			flags.bindDescribeFormat(flagSet)
+		},
+	}
+}
+
+func newGraphCmd(flags *Flags) *clicobra.Command {
+	return &clicobra.Command{
+		Use:   "graph",
+		Short: "Print the file or package import graph for the input location.",
+		Long: `Package import cycles, and package dependencies not allowed by the
+graph.allowed_dependencies section of your configuration, are highlighted in the
+graph and printed as errors. For example:
+
+graph:
+  allowed_dependencies:
+    acme.api.v1:
+      - acme.type
+      - google.protobuf
+
+This allows files in the package acme.api.v1 to only import files in the same package,
+in the package acme.type and its sub-packages, and in the package google.protobuf.`,
+		Args: cobra.NoArgs,
+		Run:  flags.newRunFunc(graph),
+		BindFlags: func(flagSet *pflag.FlagSet) {
+			flags.bindGraphInput(flagSet)
+			flags.bindGraphConfig(flagSet)
+			flags.bindGraphView(flagSet)
+			flags.bindGraphFormat(flagSet)
+			flags.bindGraphErrorFormat(flagSet)
+		},
+	}
+}
+
+func newStatsCmd(flags *Flags) *clicobra.Command {
+	return &clicobra.Command{
+		Use:   "stats",
+		Short: "Print size statistics of the input location by package or directory.",
+		Long: `The statistics are the number of files, messages, fields, enums, services,
+methods, streaming methods, and deprecated elements, and the maximum message
+nesting depth. Imports are not included.
+
+Directories are relative to the roots. The JSON format prints one group per line.`,
+		Args: cobra.NoArgs,
+		Run:  flags.newRunFunc(stats),
+		BindFlags: func(flagSet *pflag.FlagSet) {
+			flags.bindStatsInput(flagSet)
+			flags.bindStatsConfig(flagSet)
+			flags.bindStatsGroupBy(flagSet)
+			flags.bindStatsFormat(flagSet)
+		},
+	}
+}
+
+func newConvertCmd(flags *Flags) *clicobra.Command {
+	return &clicobra.Command{
+		Use:   "convert",
+		Short: "Convert messages between the binary, JSON, and text formats using the types of the input location.",
+		Long: `Messages are read from stdin and written to stdout, for example:
+
+  buf convert --type acme.v1.Event --from bin --to json < event.bin
+
+google.protobuf.Any values are resolved against all messages of the input in the JSON format.
+The text format writes google.protobuf.Any values with their type URL and binary value.`,
+		Args: cobra.NoArgs,
+		Run:  flags.newRunFunc(convert),
+		BindFlags: func(flagSet *pflag.FlagSet) {
+			flags.bindConvertInput(flagSet)
+			flags.bindConvertConfig(flagSet)
+			flags.bindConvertType(flagSet)
+			flags.bindConvertFrom(flagSet)
+			flags.bindConvertTo(flagSet)
+			flags.bindConvertDelimited(flagSet)
+			flags.bindConvertErrorFormat(flagSet)
+		},
+	}
+}
+
+func newServeCmd(flags *Flags) *clicobra.Command {
+	return &clicobra.Command{
+		Use:   "serve",
+		Short: "Serve Images over gRPC.",
+		SubCommands: []*clicobra.Command{
+			newServeReflectionCmd(flags),
+			newServeMockCmd(flags),
+		},
+	}
+}
+
+func newServeReflectionCmd(flags *Flags) *clicobra.Command {
+	return &clicobra.Command{
+		Use:   "reflection",
+		Short: "Start a gRPC server reflection service for the input location.",
+		Long: `This implements grpc.reflection.v1alpha.ServerReflection using the files of the
+built Image, for use with tools such as grpcurl.
+
+The server runs until interrupted. If the input is a local directory or file, the
+Image is rebuilt when .proto files or the configuration change. If the rebuild fails,
+the errors are printed and the previous Image continues to be served.`,
+		Args: cobra.NoArgs,
+		Run:  flags.newInterruptRunFunc(serveReflection),
+		BindFlags: func(flagSet *pflag.FlagSet) {
+			flags.bindServeInput(flagSet)
+			flags.bindServeConfig(flagSet)
+			flags.bindServeAddress(flagSet)
+			flags.bindServeErrorFormat(flagSet)
+		},
+	}
+}
+
+func newServeMockCmd(flags *Flags) *clicobra.Command {
+	return &clicobra.Command{
+		Use:   "mock",
+		Short: "Start a mock gRPC server for the services of the input location.",
+		Long: `Every method of every service of the built Image is served, excluding services
+of imports. Unary and client streaming methods respond with the first response,
+server streaming methods respond with all responses, and bidirectional streaming
+methods respond with all responses for every request. Server reflection is also served.
+
+Every completed call is printed to stdout as JSON on a single line, including the
+method, the requests, the responses, and the status code.
+
+The server runs until interrupted. If the input is a local directory or file, the
+Image is rebuilt when .proto files or the configuration change. If the rebuild fails,
+the errors are printed and the previous Image continues to be served. Services that
+are added after the server starts are not served until it is restarted.`,
+		Args: cobra.NoArgs,
+		Run:  flags.newInterruptRunFunc(serveMock),
+		BindFlags: func(flagSet *pflag.FlagSet) {
+			flags.bindServeInput(flagSet)
+			flags.bindServeConfig(flagSet)
+			flags.bindServeAddress(flagSet)
+			flags.bindServeMockFixtures(flagSet)
+			flags.bindServeErrorFormat(flagSet)
+		},
+	}
+}
+
+func newLspCmd(flags *Flags) *clicobra.Command {
+	return &clicobra.Command{
+		Use:   "lsp",
+		Short: "Start a Language Server Protocol server over stdin and stdout.",
+		Long: `The workspace is the root directory sent by the client on initialize, which is
+built with the buf.yaml in the workspace, if any.
+
+Compile errors are published as diagnostics when a file is opened or saved. If the
+workspace compiles, lint failures are published as warnings instead. Unsaved changes
+of open files are used for builds.
+
+Go to definition, hover, and document symbols are also supported.
+
+The server runs until the client sends exit, or until interrupted.`,
+		Args: cobra.NoArgs,
+		Run:  flags.newInterruptRunFunc(lsp),
+	}
+}
+
+func newFormatCmd(flags *Flags) *clicobra.Command {
+	return &clicobra.Command{
+		Use:   "format",
+		Short: "Format all files from the input location in a canonical layout.",
+		Long: `By default, the formatted files are printed to stdout.
+
+All comments are kept. Declarations are printed in the order they are defined, but
+spacing, indentation, and the placement of options and comments are normalized.`,
+		Args: cobra.NoArgs,
+		Run:  flags.newRunFunc(format),
+		BindFlags: func(flagSet *pflag.FlagSet) {
+			flags.bindFormatInput(flagSet)
+			flags.bindFormatConfig(flagSet)
+			flags.bindFormatDiff(flagSet)
+			flags.bindFormatWrite(flagSet)
+			flags.bindFormatExitCode(flagSet)
+			flags.bindFormatErrorFormat(flagSet)
+		},
+	}
+}
+
+func newGenerateCmd(flags *Flags) *clicobra.Command {
+	return &clicobra.Command{
+		Use:   "generate",
+		Short: "Generate code with the protoc plugins in the generate section of your configuration.",
+		Long: `The input is built once, and all plugins are run in parallel against the resulting Image.
+Plugins are invoked using the CodeGeneratorRequest/CodeGeneratorResponse protocol, the same as protoc.`,
+		Args: cobra.NoArgs,
+		Run:  flags.newRunFunc(generate),
+		BindFlags: func(flagSet *pflag.FlagSet) {
+			flags.bindGenerateInput(flagSet)
+			flags.bindGenerateConfig(flagSet)
+			flags.bindGenerateOutput(flagSet)
+			flags.bindGenerateErrorFormat(flagSet)
+		},
+	}
+}
+
+func newDocsCmd(flags *Flags) *clicobra.Command {
+	return &clicobra.Command{
+		Use:   "docs",
+		Short: "Generate documentation from the comments of the input location.",
+		Long: `One page is written per package, named after the package, for example acme.v1.md.
+Files without a package are written to default.md.
+
+Pages have sections for every service, message, and enum, including nested
+messages and enums, with the leading and trailing comments of each element.
+Methods include their signature and any google.api.http mappings, and messages
+include a table of their fields. Deprecated elements are marked, and references
+to documented types are linked. Imports are not documented.`,
+		Args: cobra.NoArgs,
+		Run:  flags.newRunFunc(docs),
+		BindFlags: func(flagSet *pflag.FlagSet) {
+			flags.bindDocsInput(flagSet)
+			flags.bindDocsConfig(flagSet)
+			flags.bindDocsOutput(flagSet)
+			flags.bindDocsFormat(flagSet)
+			flags.bindDocsErrorFormat(flagSet)
+		},
+	}
+}
+
+func newExportCmd(flags *Flags) *clicobra.Command {
+	return &clicobra.Command{
+		Use:   "export",
+		Short: "Export the source files of the input location and all the files they import.",
+		Long: `Files are written relative to the roots, so that the output can be
+used with protoc with a single include path, for example protoc -I out.
+The original contents of each file are written, and the Well-Known Types
+are not written as they are included with protoc.
+
+If --file is set, only the specified files and the files they transitively
+import are written.`,
+		Args: cobra.NoArgs,
+		Run:  flags.newRunFunc(export),
+		BindFlags: func(flagSet *pflag.FlagSet) {
+			flags.bindExportInput(flagSet)
+			flags.bindExportConfig(flagSet)
+			flags.bindExportOutput(flagSet)
+			flags.bindExportIncludeConfig(flagSet)
+			flags.bindExportFiles(flagSet)
+			flags.bindExportErrorFormat(flagSet)
+		},
+	}
+}
+
+func newProtocCmd(flags *Flags) *clicobra.Command {
+	return &clicobra.Command{
+		Use:   "protoc",
+		Short: "High-performance protoc replacement.",
+		Long: `This accepts protoc's flags and compiles with the internal compiler instead of protoc.
+
+Supported flags are -I/--proto_path, -o/--descriptor_set_out, --include_imports,
+--include_source_info, --error_format, --plugin, --NAME_out, and --NAME_opt.
+Run "buf protoc --help" for details.
+
+As with protoc, a file is resolved against the first include path that contains it.
+It is an error if a file is shadowed by a file with the same path relative to an
+earlier include path.`,
+		Run:                flags.newRunFunc(protoc),
+		DisableFlagParsing: true,
+	}
+}
@@ -3,9 +3,15 @@ package buf
 import (
 	"context"
 	"fmt"
+	"strings"
 	"time"
 
+	"github.com/bufbuild/buf/internal/buf/bufconvert"
+	"github.com/bufbuild/buf/internal/buf/bufdocs"
+	"github.com/bufbuild/buf/internal/buf/bufgraph"
+	"github.com/bufbuild/buf/internal/buf/bufinventory"
 	"github.com/bufbuild/buf/internal/buf/bufos"
+	"github.com/bufbuild/buf/internal/buf/bufstats"
 	"github.com/bufbuild/buf/internal/pkg/bytepool"
 	"github.com/bufbuild/buf/internal/pkg/cli"
 	"github.com/bufbuild/buf/internal/pkg/cli/clicobra"
@@ -18,19 +24,86 @@ const (
 	imageBuildConfigFlagName = "source-config"
 	imageBuildOutputFlagName = "output"
 
-	checkLintInputFlagName  = "input"
-	checkLintConfigFlagName = "input-config"
+	imageConvertInputFlagName  = "input"
+	imageConvertOutputFlagName = "output"
+
+	checkLintInputFlagName     = "input"
+	checkLintConfigFlagName    = "input-config"
+	checkLintFixFlagName       = "fix"
+	checkLintFixDryRunFlagName = "fix-dry-run"
 
 	checkBreakingInputFlagName         = "input"
 	checkBreakingConfigFlagName        = "input-config"
 	checkBreakingAgainstInputFlagName  = "against-input"
 	checkBreakingAgainstConfigFlagName = "against-input-config"
 
+	checkChangesInputFlagName         = "input"
+	checkChangesConfigFlagName        = "input-config"
+	checkChangesAgainstInputFlagName  = "against-input"
+	checkChangesAgainstConfigFlagName = "against-input-config"
+	checkChangesFormatFlagName        = "format"
+
 	lsFilesInputFlagName  = "input"
 	lsFilesConfigFlagName = "input-config"
 
+	lsTypesInputFlagName   = "input"
+	lsTypesConfigFlagName  = "input-config"
+	lsTypesPackageFlagName = "package"
+	lsTypesKindFlagName    = "kind"
+	lsTypesFormatFlagName  = "format"
+
+	describeInputFlagName  = "input"
+	describeConfigFlagName = "input-config"
+	describeFormatFlagName = "format"
+
+	graphInputFlagName  = "input"
+	graphConfigFlagName = "input-config"
+	graphViewFlagName   = "view"
+	graphFormatFlagName = "format"
+
+	statsInputFlagName   = "input"
+	statsConfigFlagName  = "input-config"
+	statsGroupByFlagName = "group-by"
+	statsFormatFlagName  = "format"
+
+	convertInputFlagName     = "input"
+	convertConfigFlagName    = "input-config"
+	convertTypeFlagName      = "type"
+	convertFromFlagName      = "from"
+	convertToFlagName        = "to"
+	convertDelimitedFlagName = "delimited"
+
+	serveInputFlagName   = "input"
+	serveConfigFlagName  = "input-config"
+	serveAddressFlagName = "address"
+
+	serveMockFixturesFlagName = "fixtures"
+
+	formatInputFlagName  = "input"
+	formatConfigFlagName = "input-config"
+	formatWriteFlagName  = "write"
+
+	generateInputFlagName  = "input"
+	generateConfigFlagName = "input-config"
+
+	docsInputFlagName  = "input"
+	docsConfigFlagName = "input-config"
+	docsOutputFlagName = "output"
+	docsFormatFlagName = "format"
+
+	exportInputFlagName         = "input"
+	exportConfigFlagName        = "input-config"
+	exportOutputFlagName        = "output"
+	exportIncludeConfigFlagName = "include-config"
+
+	initInputFlagName = "input"
+
+	configMigrateInputFlagName = "input"
+
 	checkLsCheckersConfigFlagName = "config"
 
+	watchFlagName = "watch"
+
 	errorFormatFlagName           = "error-format"
 	checkLsCheckersFormatFlagName = "format"
 )
@@ -57,6 +130,40 @@ type Flags struct {
 	CheckerAll        bool
 	CheckerCategories []string
 
+	Diff     bool
+	Write    bool
+	ExitCode bool
+
+	Force bool
+
+	IncludeConfig bool
+
+	Fix       bool
+	FixDryRun bool
+
+	Watch bool
+
+	Package string
+	Kinds   []string
+
+	View string
+	// GraphFormat is separate from Format as it has a different default,
+	// and all commands bind to the same Flags.
+	GraphFormat string
+
+	GroupBy string
+	// DocsFormat is separate from Format as it has a different default,
+	// and all commands bind to the same Flags.
+	DocsFormat string
+
+	TypeName  string
+	From      string
+	To        string
+	Delimited bool
+
+	Address  string
+	Fixtures string
+
 	ErrorFormat string
 	Format      string
 }
@@ -90,6 +197,62 @@ func (f *Flags) newRunFunc(
 	)
 }
 
+// newInterruptRunFunc creates a new run function for commands that run until interrupted.
+func (f *Flags) newInterruptRunFunc(
+	fn func(
+		context.Context,
+		*cli.ExecEnv,
+		*Flags,
+		*zap.Logger,
+		*bytepool.SegList,
+	) error,
+) func(*cli.ExecEnv) error {
+	return f.baseFlags.NewInterruptRunFunc(
+		func(
+			ctx context.Context,
+			execEnv *cli.ExecEnv,
+			logger *zap.Logger,
+			segList *bytepool.SegList,
+		) error {
+			return fn(ctx, execEnv, f, logger, segList)
+		},
+	)
+}
+
+// newWatchRunFunc creates a new run function for commands that support --watch.
+//
+// If --watch is set, the command runs until interrupted, see watch.
+func (f *Flags) newWatchRunFunc(
+	fn func(
+		context.Context,
+		*cli.ExecEnv,
+		*Flags,
+		*zap.Logger,
+		*bytepool.SegList,
+	) error,
+	inputFlagName string,
+	configFlagName string,
+) func(*cli.ExecEnv) error {
+	runFunc := f.newRunFunc(fn)
+	watchRunFunc := f.newInterruptRunFunc(
+		func(
+			ctx context.Context,
+			execEnv *cli.ExecEnv,
+			flags *Flags,
+			logger *zap.Logger,
+			segList *bytepool.SegList,
+		) error {
+			return watch(ctx, execEnv, flags, logger, segList, inputFlagName, configFlagName, fn)
+		},
+	)
+	return func(execEnv *cli.ExecEnv) error {
+		if f.Watch {
+			return watchRunFunc(execEnv)
+		}
+		return runFunc(execEnv)
+	}
+}
+
 func (f *Flags) bindRootCommandFlags(flagSet *pflag.FlagSet) {
 	f.baseFlags.BindRootCommandFlags(flagSet, 10*time.Second)
 }
@@ -103,7 +266,7 @@ func (f *Flags) bindImageBuildConfig(flagSet *pflag.FlagSet) {
 }
 
 func (f *Flags) bindImageBuildOutput(flagSet *pflag.FlagSet) {
-	flagSet.StringVarP(&f.Output, imageBuildOutputFlagName, "o", "", fmt.Sprintf(`Required. The location to write the image. Must be one of format %s.`, bufos.ImageFormatsToString()))
+	flagSet.StringVarP(&f.Output, imageBuildOutputFlagName, "o", "", fmt.Sprintf(`Required. The location to write the image. Must be one of format %s.`, bufos.ImageFileFormatsToString()))
 }
 
 func (f *Flags) bindImageBuildAsFileDescriptorSet(flagSet *pflag.FlagSet) {
@@ -125,6 +288,19 @@ func (f *Flags) bindImageBuildErrorFormat(flagSet *pflag.FlagSet) {
 	flagSet.StringVar(&f.ErrorFormat, errorFormatFlagName, "text", "The format for build errors, printed to stderr. Must be one of [text,json].")
 }
 
+func (f *Flags) bindImageConvertInput(flagSet *pflag.FlagSet) {
+	flagSet.StringVar(&f.Input, imageConvertInputFlagName, "", fmt.Sprintf(`Required. The image to convert. Must be one of format %s.`, bufos.ImageFormatsToString()))
+}
+
+func (f *Flags) bindImageConvertOutput(flagSet *pflag.FlagSet) {
+	flagSet.StringVarP(&f.Output, imageConvertOutputFlagName, "o", "", fmt.Sprintf(`Required. The location to write the image. Must be one of format %s.`, bufos.ImageFileFormatsToString()))
+}
+
+func (f *Flags) bindImageConvertFiles(flagSet *pflag.FlagSet) {
+	flagSet.StringSliceVar(&f.Files, "file", nil, `Limit to specific files within the image.
+The transitive dependencies of these files are kept as imports unless --exclude-imports is also set.`)
+}
+
 func (f *Flags) bindCheckLintInput(flagSet *pflag.FlagSet) {
 	flagSet.StringVar(&f.Input, checkLintInputFlagName, ".", fmt.Sprintf(`The source or image to lint. Must be one of format %s.`, bufos.AllFormatsToString()))
 }
@@ -133,6 +309,19 @@ func (f *Flags) bindCheckLintConfig(flagSet *pflag.FlagSet) {
 	flagSet.StringVar(&f.Config, checkLintConfigFlagName, "", `The config file or data to use.`)
 }
 
+func (f *Flags) bindCheckLintFix(flagSet *pflag.FlagSet) {
+	flagSet.BoolVar(&f.Fix, checkLintFixFlagName, false, `Apply the suggested fixes for lint failures to the source files.
+References to renamed packages, types, and enum values are also rewritten.
+If the fixed files do not compile, no files are written.
+Lint failures that could not be fixed are printed.
+Only valid for directory inputs.`)
+}
+
+func (f *Flags) bindCheckLintFixDryRun(flagSet *pflag.FlagSet) {
+	flagSet.BoolVar(&f.FixDryRun, checkLintFixDryRunFlagName, false, `Display diffs of the suggested fixes for lint failures instead of rewriting files.
+Lint failures that could not be fixed are printed to stderr.`)
+}
+
 func (f *Flags) bindCheckBreakingInput(flagSet *pflag.FlagSet) {
 	flagSet.StringVar(&f.Input, checkBreakingInputFlagName, ".", fmt.Sprintf(`The source or image to check for breaking changes. Must be one of format %s.`, bufos.AllFormatsToString()))
 }
@@ -167,6 +356,30 @@ func (f *Flags) bindCheckErrorFormat(flagSet *pflag.FlagSet) {
 	flagSet.StringVar(&f.ErrorFormat, errorFormatFlagName, "text", "The format for build errors or check violations, printed to stdout. Must be one of [text,json].")
 }
 
+func (f *Flags) bindCheckChangesInput(flagSet *pflag.FlagSet) {
+	flagSet.StringVar(&f.Input, checkChangesInputFlagName, ".", fmt.Sprintf(`The source or image to list changes for. Must be one of format %s.`, bufos.AllFormatsToString()))
+}
+
+func (f *Flags) bindCheckChangesConfig(flagSet *pflag.FlagSet) {
+	flagSet.StringVar(&f.Config, checkChangesConfigFlagName, "", `The config file or data to use.`)
+}
+
+func (f *Flags) bindCheckChangesAgainstInput(flagSet *pflag.FlagSet) {
+	flagSet.StringVar(&f.AgainstInput, checkChangesAgainstInputFlagName, "", fmt.Sprintf(`Required. The source or image to compare against. Must be one of format %s.`, bufos.AllFormatsToString()))
+}
+
+func (f *Flags) bindCheckChangesAgainstConfig(flagSet *pflag.FlagSet) {
+	flagSet.StringVar(&f.AgainstConfig, checkChangesAgainstConfigFlagName, "", `The config file or data to use for the against source or image.`)
+}
+
+func (f *Flags) bindCheckChangesExcludeImports(flagSet *pflag.FlagSet) {
+	flagSet.BoolVar(&f.ExcludeImports, "exclude-imports", false, "Exclude imports from the changelog.")
+}
+
+func (f *Flags) bindCheckChangesFormat(flagSet *pflag.FlagSet) {
+	flagSet.StringVar(&f.Format, checkChangesFormatFlagName, "text", "The format to print changes as. Must be one of [text,json,markdown].")
+}
+
 func (f *Flags) bindLsFilesInput(flagSet *pflag.FlagSet) {
 	flagSet.StringVar(&f.Input, lsFilesInputFlagName, ".", fmt.Sprintf(`The source or image to list the files from. Must be one of format %s.`, bufos.AllFormatsToString()))
 }
@@ -190,3 +403,242 @@ func (f *Flags) bindCheckLsCheckersCategories(flagSet *pflag.FlagSet) {
 func (f *Flags) bindCheckLsCheckersFormat(flagSet *pflag.FlagSet) {
 	flagSet.StringVar(&f.Format, checkLsCheckersFormatFlagName, "text", "The format to print checkers as. Must be one of [text,json].")
 }
+
+func (f *Flags) bindLsTypesInput(flagSet *pflag.FlagSet) {
+	flagSet.StringVar(&f.Input, lsTypesInputFlagName, ".", fmt.Sprintf(`The source or image to list from. Must be one of format %s.`, bufos.AllFormatsToString()))
+}
+
+func (f *Flags) bindLsTypesConfig(flagSet *pflag.FlagSet) {
+	flagSet.StringVar(&f.Config, lsTypesConfigFlagName, "", `The config file or data to use.`)
+}
+
+func (f *Flags) bindLsTypesPackage(flagSet *pflag.FlagSet) {
+	flagSet.StringVar(&f.Package, lsTypesPackageFlagName, "", `Only list within this package and its sub-packages.
+For example, foo matches foo and foo.bar, but not foobar.`)
+}
+
+func (f *Flags) bindLsTypesKind(flagSet *pflag.FlagSet) {
+	flagSet.StringSliceVar(&f.Kinds, lsTypesKindFlagName, []string{bufinventory.KindMessage, bufinventory.KindEnum}, fmt.Sprintf(`The kinds to list. Must be one of [%s].`, strings.Join(bufinventory.AllKinds, ",")))
+}
+
+func (f *Flags) bindLsTypesFormat(flagSet *pflag.FlagSet) {
+	flagSet.StringVar(&f.Format, lsTypesFormatFlagName, "text", "The format to print as. Must be one of [text,json].")
+}
+
+func (f *Flags) bindDescribeInput(flagSet *pflag.FlagSet) {
+	flagSet.StringVar(&f.Input, describeInputFlagName, ".", fmt.Sprintf(`The source or image to describe from. Must be one of format %s.`, bufos.AllFormatsToString()))
+}
+
+func (f *Flags) bindDescribeConfig(flagSet *pflag.FlagSet) {
+	flagSet.StringVar(&f.Config, describeConfigFlagName, "", `The config file or data to use.`)
+}
+
+func (f *Flags) bindDescribeFormat(flagSet *pflag.FlagSet) {
+	flagSet.StringVar(&f.Format, describeFormatFlagName, "text", "The format to print as. Must be one of [text,json].")
+}
+
+func (f *Flags) bindGraphInput(flagSet *pflag.FlagSet) {
+	flagSet.StringVar(&f.Input, graphInputFlagName, ".", fmt.Sprintf(`The source or image to graph. Must be one of format %s.`, bufos.AllFormatsToString()))
+}
+
+func (f *Flags) bindGraphConfig(flagSet *pflag.FlagSet) {
+	flagSet.StringVar(&f.Config, graphConfigFlagName, "", `The config file or data to use.`)
+}
+
+func (f *Flags) bindGraphView(flagSet *pflag.FlagSet) {
+	flagSet.StringVar(&f.View, graphViewFlagName, bufgraph.ViewPackage, fmt.Sprintf(`The nodes of the graph. Must be one of [%s].`, strings.Join(bufgraph.AllViews, ",")))
+}
+
+func (f *Flags) bindGraphFormat(flagSet *pflag.FlagSet) {
+	flagSet.StringVar(&f.GraphFormat, graphFormatFlagName, bufgraph.FormatDOT, fmt.Sprintf(`The format to print the graph as. Must be one of [%s].`, strings.Join(bufgraph.AllFormats, ",")))
+}
+
+func (f *Flags) bindWatch(flagSet *pflag.FlagSet) {
+	flagSet.BoolVar(&f.Watch, watchFlagName, false, `Run again every time the .proto files or the configuration of the input change, until interrupted.
+The input must be a local directory or file.`)
+}
+
+func (f *Flags) bindGraphErrorFormat(flagSet *pflag.FlagSet) {
+	flagSet.StringVar(&f.ErrorFormat, errorFormatFlagName, "text", "The format for build errors or graph violations, printed to stderr. Must be one of [text,json].")
+}
+
+func (f *Flags) bindStatsInput(flagSet *pflag.FlagSet) {
+	flagSet.StringVar(&f.Input, statsInputFlagName, ".", fmt.Sprintf(`The source or image to compute statistics for. Must be one of format %s.`, bufos.AllFormatsToString()))
+}
+
+func (f *Flags) bindStatsConfig(flagSet *pflag.FlagSet) {
+	flagSet.StringVar(&f.Config, statsConfigFlagName, "", `The config file or data to use.`)
+}
+
+func (f *Flags) bindStatsGroupBy(flagSet *pflag.FlagSet) {
+	flagSet.StringVar(&f.GroupBy, statsGroupByFlagName, bufstats.GroupByPackage, fmt.Sprintf(`The grouping of the statistics. Must be one of [%s].`, strings.Join(bufstats.AllGroupBys, ",")))
+}
+
+func (f *Flags) bindStatsFormat(flagSet *pflag.FlagSet) {
+	flagSet.StringVar(&f.Format, statsFormatFlagName, "text", "The format to print as. Must be one of [text,json].")
+}
+
+func (f *Flags) bindConvertInput(flagSet *pflag.FlagSet) {
+	flagSet.StringVar(&f.Input, convertInputFlagName, ".", fmt.Sprintf(`The source or image to resolve the type from. Must be one of format %s.
+This cannot be stdin, as the messages to convert are read from stdin.`, bufos.AllFormatsToString()))
+}
+
+func (f *Flags) bindConvertConfig(flagSet *pflag.FlagSet) {
+	flagSet.StringVar(&f.Config, convertConfigFlagName, "", `The config file or data to use.`)
+}
+
+func (f *Flags) bindConvertType(flagSet *pflag.FlagSet) {
+	flagSet.StringVar(&f.TypeName, convertTypeFlagName, "", `Required. The fully-qualified name of the message type, for example acme.v1.Event.`)
+}
+
+func (f *Flags) bindConvertFrom(flagSet *pflag.FlagSet) {
+	flagSet.StringVar(&f.From, convertFromFlagName, bufconvert.FormatBin, fmt.Sprintf("The format to read from stdin. Must be one of [%s].", strings.Join(bufconvert.AllFormats, ",")))
+}
+
+func (f *Flags) bindConvertTo(flagSet *pflag.FlagSet) {
+	flagSet.StringVar(&f.To, convertToFlagName, bufconvert.FormatJSON, fmt.Sprintf("The format to write to stdout. Must be one of [%s].", strings.Join(bufconvert.AllFormats, ",")))
+}
+
+func (f *Flags) bindConvertDelimited(flagSet *pflag.FlagSet) {
+	flagSet.BoolVar(&f.Delimited, convertDelimitedFlagName, false, `Read and write a stream of messages instead of a single message.
+Binary messages are prefixed with their length as a varint, JSON and text messages are one per line.`)
+}
+
+func (f *Flags) bindConvertErrorFormat(flagSet *pflag.FlagSet) {
+	flagSet.StringVar(&f.ErrorFormat, errorFormatFlagName, "text", "The format for build errors, printed to stderr. Must be one of [text,json].")
+}
+
+func (f *Flags) bindServeInput(flagSet *pflag.FlagSet) {
+	flagSet.StringVar(&f.Input, serveInputFlagName, ".", fmt.Sprintf(`The source or image to serve. Must be one of format %s.
+If the input is a local directory or file, it is reloaded when it changes.`, bufos.AllFormatsToString()))
+}
+
+func (f *Flags) bindServeConfig(flagSet *pflag.FlagSet) {
+	flagSet.StringVar(&f.Config, serveConfigFlagName, "", `The config file or data to use.`)
+}
+
+func (f *Flags) bindServeAddress(flagSet *pflag.FlagSet) {
+	flagSet.StringVar(&f.Address, serveAddressFlagName, "localhost:50051", `The TCP address to listen on.`)
+}
+
+func (f *Flags) bindServeMockFixtures(flagSet *pflag.FlagSet) {
+	flagSet.StringVar(&f.Fixtures, serveMockFixturesFlagName, "", `The directory of response fixtures.
+A fixture is a file named by the fully-qualified name of the method with the extension .json, .yaml, or .yml,
+for example acme.v1.PingService.Ping.json, that contains a response or a list of responses in the JSON mapping.
+Methods without a fixture respond with placeholder values.`)
+}
+
+func (f *Flags) bindServeErrorFormat(flagSet *pflag.FlagSet) {
+	flagSet.StringVar(&f.ErrorFormat, errorFormatFlagName, "text", "The format for build errors, printed to stderr. Must be one of [text,json].")
+}
+
+func (f *Flags) bindFormatInput(flagSet *pflag.FlagSet) {
+	flagSet.StringVar(&f.Input, formatInputFlagName, ".", fmt.Sprintf(`The source to format. Must be one of format %s.`, bufos.SourceFormatsToString()))
+}
+
+func (f *Flags) bindFormatConfig(flagSet *pflag.FlagSet) {
+	flagSet.StringVar(&f.Config, formatConfigFlagName, "", `The config file or data to use.`)
+}
+
+func (f *Flags) bindFormatDiff(flagSet *pflag.FlagSet) {
+	flagSet.BoolVarP(&f.Diff, "diff", "d", false, "Display diffs instead of the formatted output.")
+}
+
+func (f *Flags) bindFormatWrite(flagSet *pflag.FlagSet) {
+	flagSet.BoolVarP(&f.Write, formatWriteFlagName, "w", false, `Rewrite files in place instead of printing them to stdout.
+Only valid if the input is a directory.`)
+}
+
+func (f *Flags) bindFormatExitCode(flagSet *pflag.FlagSet) {
+	flagSet.BoolVar(&f.ExitCode, "exit-code", false, "Exit with a non-zero exit code if any files were not already formatted.")
+}
+
+func (f *Flags) bindFormatErrorFormat(flagSet *pflag.FlagSet) {
+	flagSet.StringVar(&f.ErrorFormat, errorFormatFlagName, "text", "The format for build errors, printed to stderr. Must be one of [text,json].")
+}
+
+func (f *Flags) bindGenerateInput(flagSet *pflag.FlagSet) {
+	flagSet.StringVar(&f.Input, generateInputFlagName, ".", fmt.Sprintf(`The source or image to generate from. Must be one of format %s.`, bufos.AllFormatsToString()))
+}
+
+func (f *Flags) bindGenerateConfig(flagSet *pflag.FlagSet) {
+	flagSet.StringVar(&f.Config, generateConfigFlagName, "", `The config file or data to use.`)
+}
+
+func (f *Flags) bindGenerateOutput(flagSet *pflag.FlagSet) {
+	flagSet.StringVarP(&f.Output, "output", "o", ".", `The base directory to write generated files to.
+The out directories of all plugins are relative to this directory.`)
+}
+
+func (f *Flags) bindGenerateErrorFormat(flagSet *pflag.FlagSet) {
+	flagSet.StringVar(&f.ErrorFormat, errorFormatFlagName, "text", "The format for build errors or plugin errors, printed to stdout. Must be one of [text,json].")
+}
+
+func (f *Flags) bindDocsInput(flagSet *pflag.FlagSet) {
+	flagSet.StringVar(&f.Input, docsInputFlagName, ".", fmt.Sprintf(`The source or image to document. Must be one of format %s.`, bufos.AllFormatsToString()))
+}
+
+func (f *Flags) bindDocsConfig(flagSet *pflag.FlagSet) {
+	flagSet.StringVar(&f.Config, docsConfigFlagName, "", `The config file or data to use.`)
+}
+
+func (f *Flags) bindDocsOutput(flagSet *pflag.FlagSet) {
+	flagSet.StringVarP(&f.Output, docsOutputFlagName, "o", "", `Required. The directory to write the pages to.`)
+}
+
+func (f *Flags) bindDocsFormat(flagSet *pflag.FlagSet) {
+	flagSet.StringVar(&f.DocsFormat, docsFormatFlagName, bufdocs.FormatMarkdown, fmt.Sprintf(`The format of the pages. Must be one of [%s].`, strings.Join(bufdocs.AllFormats, ",")))
+}
+
+func (f *Flags) bindDocsErrorFormat(flagSet *pflag.FlagSet) {
+	flagSet.StringVar(&f.ErrorFormat, errorFormatFlagName, "text", "The format for build errors, printed to stderr. Must be one of [text,json].")
+}
+
+func (f *Flags) bindExportInput(flagSet *pflag.FlagSet) {
+	flagSet.StringVar(&f.Input, exportInputFlagName, ".", fmt.Sprintf(`The source to export. Must be one of format %s.`, bufos.SourceFormatsToString()))
+}
+
+func (f *Flags) bindExportConfig(flagSet *pflag.FlagSet) {
+	flagSet.StringVar(&f.Config, exportConfigFlagName, "", `The config file or data to use.`)
+}
+
+func (f *Flags) bindExportOutput(flagSet *pflag.FlagSet) {
+	flagSet.StringVarP(&f.Output, exportOutputFlagName, "o", "", `Required. The directory or archive to write the files to.
+Archives are written if the path ends in .tar.gz, .tgz, or .zip, otherwise a directory is written.`)
+}
+
+func (f *Flags) bindExportIncludeConfig(flagSet *pflag.FlagSet) {
+	flagSet.BoolVar(&f.IncludeConfig, exportIncludeConfigFlagName, false, `Include a generated buf.yaml with a single root.`)
+}
+
+func (f *Flags) bindExportFiles(flagSet *pflag.FlagSet) {
+	flagSet.StringSliceVar(&f.Files, "file", nil, `Limit to specific files and the files they import.`)
+}
+
+func (f *Flags) bindExportErrorFormat(flagSet *pflag.FlagSet) {
+	flagSet.StringVar(&f.ErrorFormat, errorFormatFlagName, "text", "The format for build errors, printed to stderr. Must be one of [text,json].")
+}
+
+func (f *Flags) bindInitInput(flagSet *pflag.FlagSet) {
+	flagSet.StringVar(&f.Input, initInputFlagName, ".", `The directory containing the .proto files to initialize. The buf.yaml is written to this directory.`)
+}
+
+func (f *Flags) bindInitForce(flagSet *pflag.FlagSet) {
+	flagSet.BoolVar(&f.Force, "force", false, "Overwrite an existing buf.yaml.")
+}
+
+func (f *Flags) bindInitErrorFormat(flagSet *pflag.FlagSet) {
+	flagSet.StringVar(&f.ErrorFormat, errorFormatFlagName, "text", "The format for build errors that could not be excluded, printed to stderr. Must be one of [text,json].")
+}
+
+func (f *Flags) bindConfigMigrateInput(flagSet *pflag.FlagSet) {
+	flagSet.StringVar(&f.Input, configMigrateInputFlagName, ".", `The directory containing the prototool.yaml or proto.lock to migrate. The buf.yaml is written to this directory.`)
+}
+
+func (f *Flags) bindConfigMigrateForce(flagSet *pflag.FlagSet) {
+	flagSet.BoolVar(&f.Force, "force", false, "Overwrite an existing buf.yaml.")
+}
+
+func (f *Flags) bindConfigMigrateErrorFormat(flagSet *pflag.FlagSet) {
+	flagSet.StringVar(&f.ErrorFormat, errorFormatFlagName, "text", "The format for settings that could not be migrated, printed to stderr. Must be one of [text,json].")
+}
@@ -1,21 +1,57 @@
 package buf
 
 import (
+	"bytes"
 	"context"
 	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
 
 	"github.com/bufbuild/buf/internal/buf/bufbuild"
+	"github.com/bufbuild/buf/internal/buf/bufchangelog"
 	"github.com/bufbuild/buf/internal/buf/bufcheck"
 	"github.com/bufbuild/buf/internal/buf/bufcheck/bufbreaking"
 	"github.com/bufbuild/buf/internal/buf/bufcheck/buflint"
+	"github.com/bufbuild/buf/internal/buf/bufconfig"
+	"github.com/bufbuild/buf/internal/buf/bufconvert"
+	"github.com/bufbuild/buf/internal/buf/bufdescribe"
+	"github.com/bufbuild/buf/internal/buf/bufdocs"
+	"github.com/bufbuild/buf/internal/buf/bufgen"
+	"github.com/bufbuild/buf/internal/buf/bufgraph"
+	"github.com/bufbuild/buf/internal/buf/bufinit"
+	"github.com/bufbuild/buf/internal/buf/bufinventory"
+	"github.com/bufbuild/buf/internal/buf/bufmigrate"
+	"github.com/bufbuild/buf/internal/buf/bufos"
+	"github.com/bufbuild/buf/internal/buf/bufpb"
+	"github.com/bufbuild/buf/internal/buf/bufprotoc"
+	"github.com/bufbuild/buf/internal/buf/bufserve"
+	"github.com/bufbuild/buf/internal/buf/bufstats"
 	"github.com/bufbuild/buf/internal/buf/cmd/internal"
 	"github.com/bufbuild/buf/internal/pkg/analysis"
 	"github.com/bufbuild/buf/internal/pkg/bytepool"
 	"github.com/bufbuild/buf/internal/pkg/cli"
+	"github.com/bufbuild/buf/internal/pkg/diff"
 	"github.com/bufbuild/buf/internal/pkg/errs"
+	"github.com/bufbuild/buf/internal/pkg/fswatch"
+	"github.com/bufbuild/buf/internal/pkg/osutil"
+	"github.com/bufbuild/buf/internal/pkg/storage"
+	"github.com/bufbuild/buf/internal/pkg/storage/storagemem"
+	"github.com/bufbuild/buf/internal/pkg/storage/storageos"
+	"github.com/bufbuild/buf/internal/pkg/storage/storagepath"
+	"github.com/bufbuild/buf/internal/pkg/storage/storageutil"
 	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/reflection/grpc_reflection_v1alpha"
 )
 
+// clearScreen is the ANSI escape sequence to clear the terminal and move the
+// cursor to the top left.
+const clearScreen = "\033[H\033[2J"
+
 func imageBuild(
 	ctx context.Context,
 	execEnv *cli.ExecEnv,
@@ -33,6 +69,7 @@ func imageBuild(
 	env, annotations, err := internal.NewBufosEnvReader(
 		logger,
 		segList,
+		execEnv.Env,
 		imageBuildInputFlagName,
 		imageBuildConfigFlagName,
 		// must be source only
@@ -68,6 +105,141 @@ func imageBuild(
 	)
 }
 
+func imageConvert(
+	ctx context.Context,
+	execEnv *cli.ExecEnv,
+	flags *Flags,
+	logger *zap.Logger,
+	segList *bytepool.SegList,
+) (retErr error) {
+	if flags.Input == "" {
+		return errs.NewInvalidArgumentf("--%s is required", imageConvertInputFlagName)
+	}
+	if flags.Output == "" {
+		return errs.NewInvalidArgumentf("--%s is required", imageConvertOutputFlagName)
+	}
+	env, err := internal.NewBufosEnvReader(
+		logger,
+		segList,
+		execEnv.Env,
+		imageConvertInputFlagName,
+		"",
+	).ReadImageEnv(
+		ctx,
+		execEnv.Stdin,
+		flags.Input,
+		"",
+		nil,   // files are selected below along with their dependencies
+		false, // this is ignored since we do not specify specific files
+		!flags.ExcludeImports || len(flags.Files) > 0,
+	)
+	if err != nil {
+		return err
+	}
+	image := env.Image
+	if len(flags.Files) > 0 {
+		// the dependencies of the files are kept as imports so that the
+		// image stays self-contained
+		image, err = bufpb.ImageWithTargetsAndDependencies(image, flags.Files...)
+		if err != nil {
+			return err
+		}
+		if flags.ExcludeImports {
+			image, err = image.WithoutImports()
+			if err != nil {
+				return err
+			}
+		}
+	}
+	if flags.ExcludeSourceInfo {
+		image, err = bufpb.ImageWithoutSourceCodeInfo(image)
+		if err != nil {
+			return err
+		}
+	}
+	return internal.NewBufosImageWriter(
+		logger,
+		imageConvertOutputFlagName,
+	).WriteImage(
+		ctx,
+		execEnv.Stdout,
+		flags.Output,
+		flags.AsFileDescriptorSet,
+		image,
+	)
+}
+
+func initConfig(
+	ctx context.Context,
+	execEnv *cli.ExecEnv,
+	flags *Flags,
+	logger *zap.Logger,
+	segList *bytepool.SegList,
+) (retErr error) {
+	asJSON, err := internal.IsFormatJSON(errorFormatFlagName, flags.ErrorFormat)
+	if err != nil {
+		return err
+	}
+	bucket, err := storageos.NewBucket(flags.Input)
+	if err != nil {
+		return errs.NewInvalidArgumentf("--%s: %v", initInputFlagName, err)
+	}
+	defer func() {
+		retErr = errs.Append(retErr, bucket.Close())
+	}()
+	if err := checkConfigNotExists(ctx, bucket, flags.Input, flags.Force); err != nil {
+		return err
+	}
+	configBuilder, annotations, err := internal.NewBufinitHandler(logger, segList).InferBuildConfig(ctx, bucket)
+	if err != nil {
+		return err
+	}
+	if len(annotations) > 0 {
+		// the config is still written, these files need to be fixed by hand
+		if err := analysis.PrintAnnotations(execEnv.Stderr, annotations, asJSON); err != nil {
+			return err
+		}
+	}
+	return storageutil.WritePath(ctx, bucket, bufconfig.ConfigFilePath, bufinit.GetConfigData(configBuilder))
+}
+
+func configMigrate(
+	ctx context.Context,
+	execEnv *cli.ExecEnv,
+	flags *Flags,
+	logger *zap.Logger,
+	segList *bytepool.SegList,
+) (retErr error) {
+	asJSON, err := internal.IsFormatJSON(errorFormatFlagName, flags.ErrorFormat)
+	if err != nil {
+		return err
+	}
+	bucket, err := storageos.NewBucket(flags.Input)
+	if err != nil {
+		return errs.NewInvalidArgumentf("--%s: %v", configMigrateInputFlagName, err)
+	}
+	defer func() {
+		retErr = errs.Append(retErr, bucket.Close())
+	}()
+	if err := checkConfigNotExists(ctx, bucket, flags.Input, flags.Force); err != nil {
+		return err
+	}
+	externalConfig, annotations, err := internal.NewBufmigrateMigrator(logger).Migrate(ctx, bucket)
+	if err != nil {
+		return err
+	}
+	if len(annotations) > 0 {
+		if err := analysis.PrintAnnotations(execEnv.Stderr, annotations, asJSON); err != nil {
+			return err
+		}
+	}
+	data, err := bufmigrate.GetConfigData(externalConfig)
+	if err != nil {
+		return err
+	}
+	return storageutil.WritePath(ctx, bucket, bufconfig.ConfigFilePath, data)
+}
+
 func checkLint(
 	ctx context.Context,
 	execEnv *cli.ExecEnv,
@@ -79,9 +251,13 @@ func checkLint(
 	if err != nil {
 		return err
 	}
+	if flags.Fix || flags.FixDryRun {
+		return checkLintFix(ctx, execEnv, flags, logger, segList, asJSON)
+	}
 	env, annotations, err := internal.NewBufosEnvReader(
 		logger,
 		segList,
+		execEnv.Env,
 		checkLintInputFlagName,
 		checkLintConfigFlagName,
 	).ReadEnv(
@@ -123,6 +299,158 @@ func checkLint(
 	return nil
 }
 
+// checkLintFix is split out from checkLint as fixes need access to the source bucket.
+func checkLintFix(
+	ctx context.Context,
+	execEnv *cli.ExecEnv,
+	flags *Flags,
+	logger *zap.Logger,
+	segList *bytepool.SegList,
+	asJSON bool,
+) (retErr error) {
+	if flags.Fix && flags.FixDryRun {
+		return errs.NewInvalidArgumentf("cannot set both --%s and --%s", checkLintFixFlagName, checkLintFixDryRunFlagName)
+	}
+	if len(flags.Files) > 0 {
+		return errs.NewInvalidArgumentf("--file cannot be used with --%s or --%s", checkLintFixFlagName, checkLintFixDryRunFlagName)
+	}
+	bucketEnv, err := internal.NewBufosEnvReader(
+		logger,
+		segList,
+		execEnv.Env,
+		checkLintInputFlagName,
+		checkLintConfigFlagName,
+	).ReadBucketEnv(
+		ctx,
+		execEnv.Stdin,
+		flags.Input,
+		flags.Config,
+		nil,
+	)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		retErr = errs.Append(retErr, bucketEnv.Bucket.Close())
+	}()
+	if flags.Fix && bucketEnv.WriteBucket == nil {
+		return errs.NewInvalidArgumentf("--%s is only valid for directory inputs", checkLintFixFlagName)
+	}
+	buildHandler := internal.NewBufbuildHandler(logger, segList)
+	lintHandler := internal.NewBuflintHandler(logger)
+	image, rootResolver, annotations, err := buildHandler.BuildImage(
+		ctx,
+		bucketEnv.Bucket,
+		bucketEnv.Config.Build,
+		nil,
+		false,
+		false, // we only fix the files under buf control
+		true,  // we must include source info for linting and fixing
+	)
+	if err != nil {
+		return err
+	}
+	if len(annotations) > 0 {
+		return printBucketAnnotations(execEnv.Stdout, bucketEnv.Resolver, annotations, asJSON)
+	}
+	realFilePathToData, annotations, err := lintHandler.LintFix(
+		ctx,
+		bucketEnv.Config.Lint,
+		image,
+		bucketEnv.Bucket,
+		rootResolver,
+	)
+	if err != nil {
+		return err
+	}
+	realFilePaths := make([]string, 0, len(realFilePathToData))
+	for realFilePath := range realFilePathToData {
+		realFilePaths = append(realFilePaths, realFilePath)
+	}
+	sort.Strings(realFilePaths)
+	if flags.FixDryRun {
+		for _, realFilePath := range realFilePaths {
+			displayFilePath := realFilePath
+			if bucketEnv.Resolver != nil {
+				displayFilePath, err = bucketEnv.Resolver.GetFilePath(realFilePath)
+				if err != nil {
+					return err
+				}
+			}
+			original, err := storageutil.ReadPath(ctx, bucketEnv.Bucket, realFilePath)
+			if err != nil {
+				return err
+			}
+			diffData, err := diff.Do(original, realFilePathToData[realFilePath], displayFilePath)
+			if err != nil {
+				return err
+			}
+			if _, err := execEnv.Stdout.Write(diffData); err != nil {
+				return err
+			}
+		}
+		if len(annotations) > 0 {
+			if err := bufbuild.FixAnnotationFilenames(rootResolver, annotations); err != nil {
+				return err
+			}
+			// stderr as the diffs are written to stdout
+			return printBucketAnnotations(execEnv.Stderr, bucketEnv.Resolver, annotations, asJSON)
+		}
+		return nil
+	}
+	if len(realFilePaths) > 0 {
+		// rebuild from the fixed files before writing them to make sure they
+		// still compile, and to report the lint failures that actually remain
+		fixedImage, fixedRootResolver, buildAnnotations, err := buildHandler.BuildImage(
+			ctx,
+			storageutil.NewOverlayReadBucket(bucketEnv.Bucket, realFilePathToData),
+			bucketEnv.Config.Build,
+			nil,
+			false,
+			false,
+			true,
+		)
+		if err != nil {
+			return err
+		}
+		if len(buildAnnotations) > 0 {
+			// the fixed files do not compile, so nothing is written and
+			// all lint failures are reported as unfixed
+			logger.Warn("fixed files do not compile, not writing fixes", zap.Int("num_build_errors", len(buildAnnotations)))
+			annotations, err = lintHandler.LintCheck(
+				ctx,
+				bucketEnv.Config.Lint,
+				image,
+			)
+			if err != nil {
+				return err
+			}
+		} else {
+			for _, realFilePath := range realFilePaths {
+				if err := storageutil.WritePath(ctx, bucketEnv.WriteBucket, realFilePath, realFilePathToData[realFilePath]); err != nil {
+					return err
+				}
+			}
+			rootResolver = fixedRootResolver
+			annotations, err = lintHandler.LintCheck(
+				ctx,
+				bucketEnv.Config.Lint,
+				fixedImage,
+			)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	if len(annotations) > 0 {
+		if err := bufbuild.FixAnnotationFilenames(rootResolver, annotations); err != nil {
+			return err
+		}
+		return printBucketAnnotations(execEnv.Stdout, bucketEnv.Resolver, annotations, asJSON)
+	}
+	return nil
+}
+
 func checkBreaking(
 	ctx context.Context,
 	execEnv *cli.ExecEnv,
@@ -140,6 +468,7 @@ func checkBreaking(
 	env, annotations, err := internal.NewBufosEnvReader(
 		logger,
 		segList,
+		execEnv.Env,
 		checkBreakingInputFlagName,
 		checkBreakingConfigFlagName,
 	).ReadEnv(
@@ -176,6 +505,7 @@ func checkBreaking(
 	againstEnv, annotations, err := internal.NewBufosEnvReader(
 		logger,
 		segList,
+		execEnv.Env,
 		checkBreakingAgainstInputFlagName,
 		checkBreakingAgainstConfigFlagName,
 	).ReadEnv(
@@ -224,6 +554,93 @@ func checkBreaking(
 	return nil
 }
 
+func checkChanges(
+	ctx context.Context,
+	execEnv *cli.ExecEnv,
+	flags *Flags,
+	logger *zap.Logger,
+	segList *bytepool.SegList,
+) (retErr error) {
+	if flags.AgainstInput == "" {
+		return errs.NewInvalidArgumentf("--%s is required", checkChangesAgainstInputFlagName)
+	}
+	asJSON, err := internal.IsFormatJSON(errorFormatFlagName, flags.ErrorFormat)
+	if err != nil {
+		return err
+	}
+	format, err := internal.ParseBufchangelogFormat(checkChangesFormatFlagName, flags.Format)
+	if err != nil {
+		return err
+	}
+	env, annotations, err := internal.NewBufosEnvReader(
+		logger,
+		segList,
+		execEnv.Env,
+		checkChangesInputFlagName,
+		checkChangesConfigFlagName,
+	).ReadEnv(
+		ctx,
+		execEnv.Stdin,
+		flags.Input,
+		flags.Config,
+		flags.Files,
+		false, // files specified must exist on the main input
+		!flags.ExcludeImports,
+		true, // we must include source info for locations
+	)
+	if err != nil {
+		return err
+	}
+	if len(annotations) > 0 {
+		if err := analysis.PrintAnnotations(execEnv.Stdout, annotations, asJSON); err != nil {
+			return err
+		}
+		return errs.NewInternal("")
+	}
+	againstEnv, annotations, err := internal.NewBufosEnvReader(
+		logger,
+		segList,
+		execEnv.Env,
+		checkChangesAgainstInputFlagName,
+		checkChangesAgainstConfigFlagName,
+	).ReadEnv(
+		ctx,
+		execEnv.Stdin,
+		flags.AgainstInput,
+		flags.AgainstConfig,
+		flags.Files,
+		true, // files are allowed to not exist on the against input
+		!flags.ExcludeImports,
+		true, // we must include source info for previous locations
+	)
+	if err != nil {
+		return err
+	}
+	if len(annotations) > 0 {
+		for _, annotation := range annotations {
+			if annotation.Filename != "" {
+				annotation.Filename = annotation.Filename + "@against"
+			}
+		}
+		if err := analysis.PrintAnnotations(execEnv.Stdout, annotations, asJSON); err != nil {
+			return err
+		}
+		return errs.NewInternal("")
+	}
+	changes, err := internal.NewBufchangelogHandler(logger).Changelog(
+		ctx,
+		againstEnv.Image,
+		env.Image,
+	)
+	if err != nil {
+		return err
+	}
+	if err := bufchangelog.FixChangeFilenames(againstEnv.Resolver, env.Resolver, changes); err != nil {
+		return err
+	}
+	return bufchangelog.PrintChanges(execEnv.Stdout, changes, format)
+}
+
 func checkLsLintCheckers(
 	ctx context.Context,
 	execEnv *cli.ExecEnv,
@@ -245,6 +662,7 @@ func checkLsLintCheckers(
 		config, err := internal.NewBufosEnvReader(
 			logger,
 			segList,
+			execEnv.Env,
 			"",
 			checkLsCheckersConfigFlagName,
 		).GetConfig(
@@ -283,6 +701,7 @@ func checkLsBreakingCheckers(
 		config, err := internal.NewBufosEnvReader(
 			logger,
 			segList,
+			execEnv.Env,
 			"",
 			checkLsCheckersConfigFlagName,
 		).GetConfig(
@@ -310,6 +729,7 @@ func lsFiles(
 	filePaths, err := internal.NewBufosEnvReader(
 		logger,
 		segList,
+		execEnv.Env,
 		lsFilesInputFlagName,
 		lsFilesConfigFlagName,
 	).ListFiles(
@@ -328,3 +748,1161 @@ func lsFiles(
 	}
 	return nil
 }
+
+func lsPackages(
+	ctx context.Context,
+	execEnv *cli.ExecEnv,
+	flags *Flags,
+	logger *zap.Logger,
+	segList *bytepool.SegList,
+) error {
+	return lsInventory(ctx, execEnv, flags, logger, segList, bufinventory.KindPackage)
+}
+
+func lsTypes(
+	ctx context.Context,
+	execEnv *cli.ExecEnv,
+	flags *Flags,
+	logger *zap.Logger,
+	segList *bytepool.SegList,
+) error {
+	if err := bufinventory.ValidateKinds(flags.Kinds...); err != nil {
+		return errs.NewInvalidArgumentf("--%s: %v", lsTypesKindFlagName, err)
+	}
+	return lsInventory(ctx, execEnv, flags, logger, segList, flags.Kinds...)
+}
+
+func lsServices(
+	ctx context.Context,
+	execEnv *cli.ExecEnv,
+	flags *Flags,
+	logger *zap.Logger,
+	segList *bytepool.SegList,
+) error {
+	return lsInventory(ctx, execEnv, flags, logger, segList, bufinventory.KindService, bufinventory.KindMethod)
+}
+
+func lsInventory(
+	ctx context.Context,
+	execEnv *cli.ExecEnv,
+	flags *Flags,
+	logger *zap.Logger,
+	segList *bytepool.SegList,
+	kinds ...string,
+) error {
+	asJSON, err := internal.IsFormatJSON(lsTypesFormatFlagName, flags.Format)
+	if err != nil {
+		return err
+	}
+	env, annotations, err := internal.NewBufosEnvReader(
+		logger,
+		segList,
+		execEnv.Env,
+		lsTypesInputFlagName,
+		lsTypesConfigFlagName,
+	).ReadEnv(
+		ctx,
+		execEnv.Stdin,
+		flags.Input,
+		flags.Config,
+		nil,   // we list for all files
+		false, // this is ignored since we do not specify specific files
+		false, // we do not list imports
+		true,  // we need source info for the locations
+	)
+	if err != nil {
+		return err
+	}
+	if len(annotations) > 0 {
+		if err := analysis.PrintAnnotations(execEnv.Stderr, annotations, false); err != nil {
+			return err
+		}
+		return errs.NewInternal("")
+	}
+	items, err := internal.NewBufinventoryHandler(logger).Inventory(
+		ctx,
+		env.Image,
+		flags.Package,
+		kinds...,
+	)
+	if err != nil {
+		return err
+	}
+	if err := bufinventory.FixItemFilenames(env.Resolver, items); err != nil {
+		return err
+	}
+	return bufinventory.PrintItems(execEnv.Stdout, items, asJSON)
+}
+
+func describe(
+	ctx context.Context,
+	execEnv *cli.ExecEnv,
+	flags *Flags,
+	logger *zap.Logger,
+	segList *bytepool.SegList,
+) error {
+	asJSON, err := internal.IsFormatJSON(describeFormatFlagName, flags.Format)
+	if err != nil {
+		return err
+	}
+	env, annotations, err := internal.NewBufosEnvReader(
+		logger,
+		segList,
+		execEnv.Env,
+		describeInputFlagName,
+		describeConfigFlagName,
+	).ReadEnv(
+		ctx,
+		execEnv.Stdin,
+		flags.Input,
+		flags.Config,
+		nil,   // we describe from all files
+		false, // this is ignored since we do not specify specific files
+		true,  // we need imports to resolve dependencies
+		true,  // we need source info for the comments and locations
+	)
+	if err != nil {
+		return err
+	}
+	if len(annotations) > 0 {
+		if err := analysis.PrintAnnotations(execEnv.Stderr, annotations, false); err != nil {
+			return err
+		}
+		return errs.NewInternal("")
+	}
+	description, err := internal.NewBufdescribeHandler(logger).Describe(
+		ctx,
+		env.Image,
+		execEnv.Args[0],
+	)
+	if err != nil {
+		return err
+	}
+	if err := bufdescribe.FixDescriptionFilenames(env.Resolver, description); err != nil {
+		return err
+	}
+	return bufdescribe.PrintDescription(execEnv.Stdout, description, asJSON)
+}
+
+func graph(
+	ctx context.Context,
+	execEnv *cli.ExecEnv,
+	flags *Flags,
+	logger *zap.Logger,
+	segList *bytepool.SegList,
+) error {
+	asJSON, err := internal.IsFormatJSON(errorFormatFlagName, flags.ErrorFormat)
+	if err != nil {
+		return err
+	}
+	if err := bufgraph.ValidateView(flags.View); err != nil {
+		return errs.NewInvalidArgumentf("--%s: %v", graphViewFlagName, err)
+	}
+	if err := bufgraph.ValidateFormat(flags.GraphFormat); err != nil {
+		return errs.NewInvalidArgumentf("--%s: %v", graphFormatFlagName, err)
+	}
+	env, annotations, err := internal.NewBufosEnvReader(
+		logger,
+		segList,
+		execEnv.Env,
+		graphInputFlagName,
+		graphConfigFlagName,
+	).ReadEnv(
+		ctx,
+		execEnv.Stdin,
+		flags.Input,
+		flags.Config,
+		nil,   // we graph all files
+		false, // this is ignored since we do not specify specific files
+		true,  // we need imports for the packages of imported files
+		true,  // we need source info for the locations of violations
+	)
+	if err != nil {
+		return err
+	}
+	if len(annotations) > 0 {
+		if err := analysis.PrintAnnotations(execEnv.Stderr, annotations, asJSON); err != nil {
+			return err
+		}
+		return errs.NewInternal("")
+	}
+	importGraph, annotations, err := internal.NewBufgraphHandler(logger).Graph(
+		ctx,
+		env.Config.Graph,
+		env.Image,
+		flags.View,
+	)
+	if err != nil {
+		return err
+	}
+	if err := bufgraph.PrintGraph(execEnv.Stdout, importGraph, flags.GraphFormat); err != nil {
+		return err
+	}
+	if len(annotations) > 0 {
+		if err := bufbuild.FixAnnotationFilenames(env.Resolver, annotations); err != nil {
+			return err
+		}
+		if err := analysis.PrintAnnotations(execEnv.Stderr, annotations, asJSON); err != nil {
+			return err
+		}
+		return errs.NewInternal("")
+	}
+	return nil
+}
+
+func stats(
+	ctx context.Context,
+	execEnv *cli.ExecEnv,
+	flags *Flags,
+	logger *zap.Logger,
+	segList *bytepool.SegList,
+) error {
+	asJSON, err := internal.IsFormatJSON(statsFormatFlagName, flags.Format)
+	if err != nil {
+		return err
+	}
+	if err := bufstats.ValidateGroupBy(flags.GroupBy); err != nil {
+		return errs.NewInvalidArgumentf("--%s: %v", statsGroupByFlagName, err)
+	}
+	env, annotations, err := internal.NewBufosEnvReader(
+		logger,
+		segList,
+		execEnv.Env,
+		statsInputFlagName,
+		statsConfigFlagName,
+	).ReadEnv(
+		ctx,
+		execEnv.Stdin,
+		flags.Input,
+		flags.Config,
+		nil,   // we compute statistics for all files
+		false, // this is ignored since we do not specify specific files
+		false, // we do not compute statistics for imports
+		false, // we do not need source info
+	)
+	if err != nil {
+		return err
+	}
+	if len(annotations) > 0 {
+		if err := analysis.PrintAnnotations(execEnv.Stderr, annotations, false); err != nil {
+			return err
+		}
+		return errs.NewInternal("")
+	}
+	groupStats, err := internal.NewBufstatsHandler(logger).Stats(
+		ctx,
+		env.Image,
+		flags.GroupBy,
+	)
+	if err != nil {
+		return err
+	}
+	return bufstats.PrintStats(execEnv.Stdout, groupStats, asJSON)
+}
+
+func convert(
+	ctx context.Context,
+	execEnv *cli.ExecEnv,
+	flags *Flags,
+	logger *zap.Logger,
+	segList *bytepool.SegList,
+) error {
+	if flags.TypeName == "" {
+		return errs.NewInvalidArgumentf("--%s is required", convertTypeFlagName)
+	}
+	asJSON, err := internal.IsFormatJSON(errorFormatFlagName, flags.ErrorFormat)
+	if err != nil {
+		return err
+	}
+	if err := bufconvert.ValidateFormat(flags.From); err != nil {
+		return errs.NewInvalidArgumentf("--%s: %v", convertFromFlagName, err)
+	}
+	if err := bufconvert.ValidateFormat(flags.To); err != nil {
+		return errs.NewInvalidArgumentf("--%s: %v", convertToFlagName, err)
+	}
+	env, annotations, err := internal.NewBufosEnvReader(
+		logger,
+		segList,
+		execEnv.Env,
+		convertInputFlagName,
+		convertConfigFlagName,
+	).ReadEnv(
+		ctx,
+		nil, // stdin is the messages to convert
+		flags.Input,
+		flags.Config,
+		nil,   // we resolve types from all files
+		false, // this is ignored since we do not specify specific files
+		true,  // we need imports to resolve types
+		false, // source info is not needed for conversion
+	)
+	if err != nil {
+		return err
+	}
+	if len(annotations) > 0 {
+		if err := analysis.PrintAnnotations(execEnv.Stderr, annotations, asJSON); err != nil {
+			return err
+		}
+		return errs.NewInternal("")
+	}
+	return internal.NewBufconvertHandler(logger).Convert(
+		ctx,
+		env.Image,
+		flags.TypeName,
+		execEnv.Stdout,
+		execEnv.Stdin,
+		flags.From,
+		flags.To,
+		flags.Delimited,
+	)
+}
+
+func serveReflection(
+	ctx context.Context,
+	execEnv *cli.ExecEnv,
+	flags *Flags,
+	logger *zap.Logger,
+	segList *bytepool.SegList,
+) error {
+	asJSON, err := internal.IsFormatJSON(errorFormatFlagName, flags.ErrorFormat)
+	if err != nil {
+		return err
+	}
+	envReader := internal.NewBufosEnvReader(
+		logger,
+		segList,
+		execEnv.Env,
+		serveInputFlagName,
+		serveConfigFlagName,
+	)
+	image, annotations, err := readServeImage(ctx, execEnv, flags, envReader)
+	if err != nil {
+		return err
+	}
+	if len(annotations) > 0 {
+		if err := analysis.PrintAnnotations(execEnv.Stderr, annotations, asJSON); err != nil {
+			return err
+		}
+		return errs.NewInternal("")
+	}
+	reflectionServer, err := bufserve.NewReflectionServer(logger, image)
+	if err != nil {
+		return err
+	}
+	grpcServer := grpc.NewServer()
+	grpc_reflection_v1alpha.RegisterServerReflectionServer(grpcServer, reflectionServer)
+	return serveGRPC(
+		ctx,
+		execEnv,
+		flags,
+		logger,
+		envReader,
+		grpcServer,
+		asJSON,
+		func(image bufpb.Image) error {
+			return reflectionServer.SetImage(image)
+		},
+	)
+}
+
+func serveMock(
+	ctx context.Context,
+	execEnv *cli.ExecEnv,
+	flags *Flags,
+	logger *zap.Logger,
+	segList *bytepool.SegList,
+) (retErr error) {
+	asJSON, err := internal.IsFormatJSON(errorFormatFlagName, flags.ErrorFormat)
+	if err != nil {
+		return err
+	}
+	mockServerOptions := []bufserve.MockServerOption{
+		bufserve.MockServerWithCallWriter(execEnv.Stdout),
+	}
+	if flags.Fixtures != "" {
+		fixtures, err := storageos.NewReadBucket(flags.Fixtures)
+		if err != nil {
+			return errs.NewInvalidArgumentf("--%s: %v", serveMockFixturesFlagName, err)
+		}
+		defer func() {
+			retErr = errs.Append(retErr, fixtures.Close())
+		}()
+		mockServerOptions = append(mockServerOptions, bufserve.MockServerWithFixtures(fixtures))
+	}
+	envReader := internal.NewBufosEnvReader(
+		logger,
+		segList,
+		execEnv.Env,
+		serveInputFlagName,
+		serveConfigFlagName,
+	)
+	image, annotations, err := readServeImage(ctx, execEnv, flags, envReader)
+	if err != nil {
+		return err
+	}
+	if len(annotations) > 0 {
+		if err := analysis.PrintAnnotations(execEnv.Stderr, annotations, asJSON); err != nil {
+			return err
+		}
+		return errs.NewInternal("")
+	}
+	mockServer, err := bufserve.NewMockServer(logger, image, mockServerOptions...)
+	if err != nil {
+		return err
+	}
+	reflectionServer, err := bufserve.NewReflectionServer(logger, image)
+	if err != nil {
+		return err
+	}
+	grpcServer := grpc.NewServer()
+	mockServer.Register(grpcServer)
+	grpc_reflection_v1alpha.RegisterServerReflectionServer(grpcServer, reflectionServer)
+	return serveGRPC(
+		ctx,
+		execEnv,
+		flags,
+		logger,
+		envReader,
+		grpcServer,
+		asJSON,
+		func(image bufpb.Image) error {
+			if err := mockServer.SetImage(image); err != nil {
+				return err
+			}
+			return reflectionServer.SetImage(image)
+		},
+	)
+}
+
+// readServeImage reads the Image to serve.
+func readServeImage(
+	ctx context.Context,
+	execEnv *cli.ExecEnv,
+	flags *Flags,
+	envReader bufos.EnvReader,
+) (bufpb.Image, []*analysis.Annotation, error) {
+	env, annotations, err := envReader.ReadEnv(
+		ctx,
+		execEnv.Stdin,
+		flags.Input,
+		flags.Config,
+		nil,   // we serve all files
+		false, // this is ignored since we do not specify specific files
+		true,  // clients request the dependencies of files
+		true,  // source info allows clients to show comments
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(annotations) > 0 {
+		return nil, annotations, nil
+	}
+	return env.Image, nil, nil
+}
+
+// serveGRPC serves the gRPC server on the address until the context is done.
+//
+// If the input can be watched, the Image is re-read on changes and passed
+// to setImage. Errors while reloading are printed and do not stop the server.
+func serveGRPC(
+	ctx context.Context,
+	execEnv *cli.ExecEnv,
+	flags *Flags,
+	logger *zap.Logger,
+	envReader bufos.EnvReader,
+	grpcServer *grpc.Server,
+	asJSON bool,
+	setImage func(bufpb.Image) error,
+) error {
+	watchPaths, err := envReader.GetWatchPaths(ctx, flags.Input, flags.Config)
+	if err != nil {
+		return err
+	}
+	listener, err := net.Listen("tcp", flags.Address)
+	if err != nil {
+		return errs.NewInvalidArgumentf("--%s: %v", serveAddressFlagName, err)
+	}
+	logger.Info("serving", zap.String("address", listener.Addr().String()))
+	if watchPaths != nil {
+		watcher := newInputWatcher(logger, watchPaths)
+		go func() {
+			_ = watcher.Watch(
+				ctx,
+				func(ctx context.Context) {
+					image, annotations, err := readServeImage(ctx, execEnv, flags, envReader)
+					if err == nil && len(annotations) == 0 {
+						err = setImage(image)
+					}
+					if err != nil {
+						logger.Warn("reload_failed", zap.Error(err))
+						return
+					}
+					if len(annotations) > 0 {
+						if err := analysis.PrintAnnotations(execEnv.Stderr, annotations, asJSON); err != nil {
+							logger.Warn("reload_failed", zap.Error(err))
+						}
+						return
+					}
+					logger.Info("reloaded")
+				},
+			)
+		}()
+	}
+	serveErrC := make(chan error, 1)
+	go func() {
+		serveErrC <- grpcServer.Serve(listener)
+	}()
+	select {
+	case <-ctx.Done():
+		grpcServer.GracefulStop()
+		<-serveErrC
+		return nil
+	case err := <-serveErrC:
+		return err
+	}
+}
+
+// watch runs fn, and then runs fn again every time the .proto files or the
+// configuration of the input change, until the context is done.
+//
+// Every run starts with clearing the screen. If a change is seen while fn is
+// running, the context passed to fn is cancelled. Errors from fn are printed
+// and do not stop watching.
+func watch(
+	ctx context.Context,
+	execEnv *cli.ExecEnv,
+	flags *Flags,
+	logger *zap.Logger,
+	segList *bytepool.SegList,
+	inputFlagName string,
+	configFlagName string,
+	fn func(context.Context, *cli.ExecEnv, *Flags, *zap.Logger, *bytepool.SegList) error,
+) error {
+	watchPaths, err := internal.NewBufosEnvReader(
+		logger,
+		segList,
+		execEnv.Env,
+		inputFlagName,
+		configFlagName,
+	).GetWatchPaths(ctx, flags.Input, flags.Config)
+	if err != nil {
+		return err
+	}
+	if watchPaths == nil {
+		return errs.NewInvalidArgumentf("--%s can only be used with local inputs", watchFlagName)
+	}
+	run := func(ctx context.Context) {
+		_, _ = execEnv.Stderr.Write([]byte(clearScreen))
+		if err := fn(ctx, execEnv, flags, logger, segList); err != nil && ctx.Err() == nil {
+			if errString := err.Error(); errString != "" {
+				_, _ = fmt.Fprintln(execEnv.Stderr, errString)
+			}
+		}
+	}
+	run(ctx)
+	logger.Info("watching", zap.Strings("paths", watchPaths.Paths))
+	return newInputWatcher(logger, watchPaths).Watch(ctx, run)
+}
+
+// newInputWatcher returns a new Watcher for the .proto files and configuration of an input.
+func newInputWatcher(logger *zap.Logger, watchPaths *bufos.WatchPaths) fswatch.Watcher {
+	return fswatch.NewWatcher(
+		logger,
+		watchPaths.Paths,
+		fswatch.WatcherWithFilter(isProtoOrConfigFilePath),
+		fswatch.WatcherWithExcludes(watchPaths.ExcludePaths...),
+	)
+}
+
+// isProtoOrConfigFilePath returns true if the path is a .proto file or a config file.
+func isProtoOrConfigFilePath(path string) bool {
+	return filepath.Ext(path) == ".proto" || filepath.Base(path) == bufconfig.ConfigFilePath
+}
+
+func lsp(
+	ctx context.Context,
+	execEnv *cli.ExecEnv,
+	flags *Flags,
+	logger *zap.Logger,
+	segList *bytepool.SegList,
+) error {
+	return internal.NewBuflspServer(logger, segList).Serve(ctx, execEnv.Stdin, execEnv.Stdout)
+}
+
+func format(
+	ctx context.Context,
+	execEnv *cli.ExecEnv,
+	flags *Flags,
+	logger *zap.Logger,
+	segList *bytepool.SegList,
+) (retErr error) {
+	asJSON, err := internal.IsFormatJSON(errorFormatFlagName, flags.ErrorFormat)
+	if err != nil {
+		return err
+	}
+	bucketEnv, err := internal.NewBufosEnvReader(
+		logger,
+		segList,
+		execEnv.Env,
+		formatInputFlagName,
+		formatConfigFlagName,
+	).ReadBucketEnv(
+		ctx,
+		execEnv.Stdin,
+		flags.Input,
+		flags.Config,
+		nil,
+	)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		retErr = errs.Append(retErr, bucketEnv.Bucket.Close())
+	}()
+	if flags.Write && bucketEnv.WriteBucket == nil {
+		return errs.NewInvalidArgumentf("--%s is only valid for directory inputs", formatWriteFlagName)
+	}
+	image, rootResolver, annotations, err := internal.NewBufbuildHandler(
+		logger,
+		segList,
+	).BuildImage(
+		ctx,
+		bucketEnv.Bucket,
+		bucketEnv.Config.Build,
+		nil,
+		false,
+		true, // imports are needed to resolve types
+		true, // source info is needed for comments
+	)
+	if err != nil {
+		return err
+	}
+	if len(annotations) > 0 {
+		if bucketEnv.Resolver != nil {
+			if err := bufbuild.FixAnnotationFilenames(bucketEnv.Resolver, annotations); err != nil {
+				return err
+			}
+		}
+		if err := analysis.PrintAnnotations(execEnv.Stderr, annotations, asJSON); err != nil {
+			return err
+		}
+		return errs.NewInternal("")
+	}
+	nameToData, err := internal.NewBufformatFormatter(logger).FormatImage(ctx, image)
+	if err != nil {
+		return err
+	}
+	names := make([]string, 0, len(nameToData))
+	for name := range nameToData {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	changed := false
+	for _, name := range names {
+		formatted := nameToData[name]
+		realFilePath, err := rootResolver.GetFilePath(name)
+		if err != nil {
+			return err
+		}
+		displayFilePath := realFilePath
+		if bucketEnv.Resolver != nil {
+			displayFilePath, err = bucketEnv.Resolver.GetFilePath(realFilePath)
+			if err != nil {
+				return err
+			}
+		}
+		if !flags.Diff && !flags.Write && !flags.ExitCode {
+			if _, err := execEnv.Stdout.Write(formatted); err != nil {
+				return err
+			}
+			continue
+		}
+		original, err := storageutil.ReadPath(ctx, bucketEnv.Bucket, realFilePath)
+		if err != nil {
+			return err
+		}
+		if bytes.Equal(original, formatted) {
+			continue
+		}
+		changed = true
+		if flags.Diff {
+			diffData, err := diff.Do(original, formatted, displayFilePath)
+			if err != nil {
+				return err
+			}
+			if _, err := execEnv.Stdout.Write(diffData); err != nil {
+				return err
+			}
+		}
+		if flags.Write {
+			if err := storageutil.WritePath(ctx, bucketEnv.WriteBucket, realFilePath, formatted); err != nil {
+				return err
+			}
+		}
+	}
+	if changed && flags.ExitCode {
+		return errs.NewInternal("")
+	}
+	return nil
+}
+
+func generate(
+	ctx context.Context,
+	execEnv *cli.ExecEnv,
+	flags *Flags,
+	logger *zap.Logger,
+	segList *bytepool.SegList,
+) (retErr error) {
+	asJSON, err := internal.IsFormatJSON(errorFormatFlagName, flags.ErrorFormat)
+	if err != nil {
+		return err
+	}
+	env, annotations, err := internal.NewBufosEnvReader(
+		logger,
+		segList,
+		execEnv.Env,
+		generateInputFlagName,
+		generateConfigFlagName,
+	).ReadEnv(
+		ctx,
+		execEnv.Stdin,
+		flags.Input,
+		flags.Config,
+		nil,   // we generate for all files
+		false, // this is ignored since we do not specify specific files
+		true,  // plugins need all imports
+		true,  // plugins may use source info for comments
+	)
+	if err != nil {
+		return err
+	}
+	if len(annotations) > 0 {
+		if err := analysis.PrintAnnotations(execEnv.Stdout, annotations, asJSON); err != nil {
+			return err
+		}
+		return errs.NewInternal("")
+	}
+	if err := os.MkdirAll(flags.Output, 0755); err != nil {
+		return err
+	}
+	bucket, err := storageos.NewBucket(flags.Output)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		retErr = errs.Append(retErr, bucket.Close())
+	}()
+	annotations, err = internal.NewBufgenHandler(logger).Generate(
+		ctx,
+		bucket,
+		env.Image,
+		env.Config.Generate,
+	)
+	if err != nil {
+		return err
+	}
+	if len(annotations) > 0 {
+		if err := analysis.PrintAnnotations(execEnv.Stdout, annotations, asJSON); err != nil {
+			return err
+		}
+		return errs.NewInternal("")
+	}
+	return nil
+}
+
+func docs(
+	ctx context.Context,
+	execEnv *cli.ExecEnv,
+	flags *Flags,
+	logger *zap.Logger,
+	segList *bytepool.SegList,
+) (retErr error) {
+	if flags.Output == "" {
+		return errs.NewInvalidArgumentf("--%s is required", docsOutputFlagName)
+	}
+	asJSON, err := internal.IsFormatJSON(errorFormatFlagName, flags.ErrorFormat)
+	if err != nil {
+		return err
+	}
+	if err := bufdocs.ValidateFormat(flags.DocsFormat); err != nil {
+		return errs.NewInvalidArgumentf("--%s: %v", docsFormatFlagName, err)
+	}
+	env, annotations, err := internal.NewBufosEnvReader(
+		logger,
+		segList,
+		execEnv.Env,
+		docsInputFlagName,
+		docsConfigFlagName,
+	).ReadEnv(
+		ctx,
+		execEnv.Stdin,
+		flags.Input,
+		flags.Config,
+		nil,   // we document all files
+		false, // this is ignored since we do not specify specific files
+		false, // we do not document imports
+		true,  // we need source info for the comments
+	)
+	if err != nil {
+		return err
+	}
+	if len(annotations) > 0 {
+		if err := analysis.PrintAnnotations(execEnv.Stderr, annotations, asJSON); err != nil {
+			return err
+		}
+		return errs.NewInternal("")
+	}
+	if err := os.MkdirAll(flags.Output, 0755); err != nil {
+		return err
+	}
+	bucket, err := storageos.NewBucket(flags.Output)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		retErr = errs.Append(retErr, bucket.Close())
+	}()
+	return internal.NewBufdocsHandler(logger).Generate(
+		ctx,
+		bucket,
+		env.Image,
+		flags.DocsFormat,
+	)
+}
+
+func export(
+	ctx context.Context,
+	execEnv *cli.ExecEnv,
+	flags *Flags,
+	logger *zap.Logger,
+	segList *bytepool.SegList,
+) (retErr error) {
+	if flags.Output == "" {
+		return errs.NewInvalidArgumentf("--%s is required", exportOutputFlagName)
+	}
+	asJSON, err := internal.IsFormatJSON(errorFormatFlagName, flags.ErrorFormat)
+	if err != nil {
+		return err
+	}
+	bucketEnv, err := internal.NewBufosEnvReader(
+		logger,
+		segList,
+		execEnv.Env,
+		exportInputFlagName,
+		exportConfigFlagName,
+	).ReadBucketEnv(
+		ctx,
+		execEnv.Stdin,
+		flags.Input,
+		flags.Config,
+		flags.Files,
+	)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		retErr = errs.Append(retErr, bucketEnv.Bucket.Close())
+	}()
+	image, _, annotations, err := internal.NewBufbuildHandler(
+		logger,
+		segList,
+	).BuildImage(
+		ctx,
+		bucketEnv.Bucket,
+		bucketEnv.Config.Build,
+		bucketEnv.SpecificRealFilePaths,
+		false,
+		true,  // imports are exported so that the output is self-contained
+		false, // we copy the original files so source info is not needed
+	)
+	if err != nil {
+		return err
+	}
+	if len(annotations) > 0 {
+		return printBucketAnnotations(execEnv.Stderr, bucketEnv.Resolver, annotations, asJSON)
+	}
+	var archive func(context.Context, io.Writer, storage.Bucket, string, ...storagepath.TransformerOption) error
+	switch {
+	case strings.HasSuffix(flags.Output, ".tar.gz"), strings.HasSuffix(flags.Output, ".tgz"):
+		archive = storageutil.Targz
+	case strings.HasSuffix(flags.Output, ".zip"):
+		archive = storageutil.Zip
+	}
+	var bucket storage.Bucket
+	if archive != nil {
+		bucket = storagemem.NewBucket(segList)
+	} else {
+		if err := os.MkdirAll(flags.Output, 0755); err != nil {
+			return err
+		}
+		bucket, err = storageos.NewBucket(flags.Output)
+		if err != nil {
+			return err
+		}
+	}
+	defer func() {
+		retErr = errs.Append(retErr, bucket.Close())
+	}()
+	if _, err := internal.NewBufexportHandler(logger).Export(
+		ctx,
+		bucketEnv.Bucket,
+		bucketEnv.Config.Build,
+		image,
+		bucket,
+	); err != nil {
+		return err
+	}
+	if flags.IncludeConfig {
+		// all files are exported relative to the roots
+		configData := bufinit.GetConfigData(&bufbuild.ConfigBuilder{Roots: []string{"."}})
+		if err := storageutil.WritePath(ctx, bucket, bufconfig.ConfigFilePath, configData); err != nil {
+			return err
+		}
+	}
+	if archive == nil {
+		return nil
+	}
+	file, err := os.Create(flags.Output)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		retErr = errs.Append(retErr, file.Close())
+	}()
+	return archive(ctx, file, bucket, "")
+}
+
+func protoc(
+	ctx context.Context,
+	execEnv *cli.ExecEnv,
+	flags *Flags,
+	logger *zap.Logger,
+	segList *bytepool.SegList,
+) (retErr error) {
+	protocFlags, err := bufprotoc.ParseFlags(execEnv.Args)
+	if err != nil {
+		return err
+	}
+	if protocFlags.PrintHelp {
+		return bufprotoc.PrintHelp(execEnv.Stdout)
+	}
+	layout, err := bufprotoc.NewLayout(protocFlags.IncludeDirPaths, protocFlags.FilePaths)
+	if err != nil {
+		return err
+	}
+	rootFilePathToRealFilePath := make(map[string]string, len(layout.RootFilePaths))
+	for i, rootFilePath := range layout.RootFilePaths {
+		rootFilePathToRealFilePath[rootFilePath] = layout.RealFilePaths[i]
+	}
+	// the include paths may overlap, so the file set is built from the layout
+	// rather than from a build config, which requires roots to be disjoint
+	protoFileSet, err := bufbuild.NewProtoFileSet(layout.Roots, rootFilePathToRealFilePath)
+	if err != nil {
+		return err
+	}
+	bucket, err := storageos.NewReadBucket(layout.RootDirPath)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		retErr = errs.Append(retErr, bucket.Close())
+	}()
+	// plugins always get imports and source info, which matches protoc
+	hasPlugins := len(protocFlags.PluginFlags) > 0
+	var runOptions []bufbuild.RunOption
+	if protocFlags.IncludeImports || hasPlugins {
+		runOptions = append(runOptions, bufbuild.RunWithIncludeImports())
+	}
+	if protocFlags.IncludeSourceInfo || hasPlugins {
+		runOptions = append(runOptions, bufbuild.RunWithIncludeSourceInfo())
+	}
+	image, annotations, err := bufbuild.NewRunner(logger).Run(
+		ctx,
+		bucket,
+		protoFileSet,
+		runOptions...,
+	)
+	if err != nil {
+		return err
+	}
+	if len(annotations) > 0 {
+		if err := bufbuild.FixAnnotationFilenames(protoFileSet, annotations); err != nil {
+			return err
+		}
+		for _, annotation := range annotations {
+			if annotation.Filename != "" {
+				annotation.Filename, err = layout.GetDisplayPath(annotation.Filename)
+				if err != nil {
+					return err
+				}
+			}
+		}
+		if err := bufprotoc.PrintAnnotations(execEnv.Stderr, annotations, protocFlags.ErrorFormat); err != nil {
+			return err
+		}
+		return errs.NewInternal("")
+	}
+	if err := protocGenerate(ctx, execEnv, logger, protocFlags, image); err != nil {
+		return err
+	}
+	if protocFlags.DescriptorSetOut == "" {
+		return nil
+	}
+	if !protocFlags.IncludeImports {
+		image, err = image.WithoutImports()
+		if err != nil {
+			return err
+		}
+	}
+	if !protocFlags.IncludeSourceInfo && hasPlugins {
+		image, err = bufpb.ImageWithoutSourceCodeInfo(image)
+		if err != nil {
+			return err
+		}
+	}
+	return protocWriteDescriptorSet(execEnv, protocFlags.DescriptorSetOut, image)
+}
+
+// protocWriteDescriptorSet writes the image as a binary FileDescriptorSet
+// to the file path.
+//
+// protoc always writes binary FileDescriptorSets regardless of extension, so
+// the file path is used as-is rather than parsed as an input.
+func protocWriteDescriptorSet(
+	execEnv *cli.ExecEnv,
+	filePath string,
+	image bufpb.Image,
+) (retErr error) {
+	fileDescriptorSet, err := image.ToFileDescriptorSet()
+	if err != nil {
+		return err
+	}
+	data, err := fileDescriptorSet.MarshalWire()
+	if err != nil {
+		return err
+	}
+	writeCloser, err := osutil.WriteCloserForFilePath(execEnv.Stdout, filePath)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		retErr = errs.Append(retErr, writeCloser.Close())
+	}()
+	_, err = writeCloser.Write(data)
+	return err
+}
+
+// protocGenerate runs the plugins for buf protoc.
+//
+// Plugins that share an output directory are run together so that
+// insertion points work across plugins, which matches protoc.
+func protocGenerate(
+	ctx context.Context,
+	execEnv *cli.ExecEnv,
+	logger *zap.Logger,
+	protocFlags *bufprotoc.Flags,
+	image bufpb.Image,
+) error {
+	var outs []string
+	outToPluginConfigBuilders := make(map[string][]bufgen.PluginConfigBuilder)
+	for _, pluginFlag := range protocFlags.PluginFlags {
+		if _, ok := outToPluginConfigBuilders[pluginFlag.Out]; !ok {
+			outs = append(outs, pluginFlag.Out)
+		}
+		outToPluginConfigBuilders[pluginFlag.Out] = append(
+			outToPluginConfigBuilders[pluginFlag.Out],
+			bufgen.PluginConfigBuilder{
+				Name: pluginFlag.Name,
+				Path: pluginFlag.Path,
+				Out:  ".",
+				Opt:  pluginFlag.Opt,
+			},
+		)
+	}
+	for _, out := range outs {
+		if err := protocGenerateOut(ctx, execEnv, logger, protocFlags, image, out, outToPluginConfigBuilders[out]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func protocGenerateOut(
+	ctx context.Context,
+	execEnv *cli.ExecEnv,
+	logger *zap.Logger,
+	protocFlags *bufprotoc.Flags,
+	image bufpb.Image,
+	out string,
+	pluginConfigBuilders []bufgen.PluginConfigBuilder,
+) (retErr error) {
+	generateConfig, err := bufgen.ConfigBuilder{
+		Plugins: pluginConfigBuilders,
+	}.NewConfig()
+	if err != nil {
+		return err
+	}
+	bucket, err := storageos.NewBucket(out)
+	if err != nil {
+		if storage.IsNotExist(err) {
+			return errs.NewInvalidArgumentf("%s: No such file or directory", out)
+		}
+		return err
+	}
+	defer func() {
+		retErr = errs.Append(retErr, bucket.Close())
+	}()
+	annotations, err := internal.NewBufgenHandler(logger).Generate(
+		ctx,
+		bucket,
+		image,
+		generateConfig,
+	)
+	if err != nil {
+		return err
+	}
+	if len(annotations) > 0 {
+		if err := bufprotoc.PrintAnnotations(execEnv.Stderr, annotations, protocFlags.ErrorFormat); err != nil {
+			return err
+		}
+		return errs.NewInternal("")
+	}
+	return nil
+}
+
+// checkConfigNotExists returns a user error if a buf.yaml already exists in the
+// bucket and force is not set.
+func checkConfigNotExists(ctx context.Context, bucket storage.ReadBucket, dirPath string, force bool) error {
+	if force {
+		return nil
+	}
+	_, err := bucket.Stat(ctx, bufconfig.ConfigFilePath)
+	if err == nil {
+		return errs.NewInvalidArgumentf("%s already exists, use --force to overwrite", filepath.Join(dirPath, bufconfig.ConfigFilePath))
+	}
+	if storage.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+// printBucketAnnotations prints the annotations with real file paths relative to
+// the bucket and returns an error to signal failure.
+//
+// The resolver can be nil.
+func printBucketAnnotations(
+	writer io.Writer,
+	resolver bufbuild.ProtoFilePathResolver,
+	annotations []*analysis.Annotation,
+	asJSON bool,
+) error {
+	if resolver != nil {
+		if err := bufbuild.FixAnnotationFilenames(resolver, annotations); err != nil {
+			return err
+		}
+	}
+	if err := analysis.PrintAnnotations(writer, annotations, asJSON); err != nil {
+		return err
+	}
+	return errs.NewInternal("")
+}
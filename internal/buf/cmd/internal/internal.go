@@ -2,46 +2,124 @@ package internal
 
 import (
 	"net/http"
+	"path/filepath"
 	"strings"
 	"time"
 
 	"github.com/bufbuild/buf/internal/buf/bufbuild"
+	"github.com/bufbuild/buf/internal/buf/bufchangelog"
 	"github.com/bufbuild/buf/internal/buf/bufcheck/bufbreaking"
 	"github.com/bufbuild/buf/internal/buf/bufcheck/buflint"
 	"github.com/bufbuild/buf/internal/buf/bufconfig"
+	"github.com/bufbuild/buf/internal/buf/bufconvert"
+	"github.com/bufbuild/buf/internal/buf/bufdescribe"
+	"github.com/bufbuild/buf/internal/buf/bufdocs"
+	"github.com/bufbuild/buf/internal/buf/bufexport"
+	"github.com/bufbuild/buf/internal/buf/bufformat"
+	"github.com/bufbuild/buf/internal/buf/bufgen"
+	"github.com/bufbuild/buf/internal/buf/bufgraph"
+	"github.com/bufbuild/buf/internal/buf/bufinit"
+	"github.com/bufbuild/buf/internal/buf/bufinventory"
+	"github.com/bufbuild/buf/internal/buf/buflsp"
+	"github.com/bufbuild/buf/internal/buf/bufmigrate"
 	"github.com/bufbuild/buf/internal/buf/bufos"
+	"github.com/bufbuild/buf/internal/buf/bufstats"
 	"github.com/bufbuild/buf/internal/pkg/bytepool"
 	"github.com/bufbuild/buf/internal/pkg/errs"
+	"github.com/bufbuild/buf/internal/pkg/httpget"
 	"go.uber.org/zap"
 )
 
+// cacheDirEnvKey is the environment variable that specifies the buf cache directory.
+//
+// HTTP responses are only cached if this is set. The cache can be cleared
+// by removing the directory.
+const cacheDirEnvKey = "BUF_CACHE_DIR"
+
 var defaultHTTPClient = &http.Client{
 	Timeout: 5 * time.Second,
 }
 
 // NewBufosEnvReader returns a new bufos.EnvReader.
+//
+// The env is used to read HTTP credentials and the cache directory.
+// If env is nil, HTTP requests are not authenticated or cached.
 func NewBufosEnvReader(
 	logger *zap.Logger,
 	segList *bytepool.SegList,
+	env map[string]string,
 	inputFlagName string,
 	configOverrideFlagName string,
 ) bufos.EnvReader {
 	return bufos.NewEnvReader(
 		logger,
 		segList,
-		defaultHTTPClient,
+		NewHTTPGetter(logger, env),
 		bufconfig.NewProvider(logger),
-		bufbuild.NewHandler(
-			logger,
-			segList,
-			bufbuild.NewProvider(logger),
-			bufbuild.NewRunner(logger),
-		),
+		NewBufbuildHandler(logger, segList),
 		inputFlagName,
 		configOverrideFlagName,
 	)
 }
 
+// NewHTTPGetter returns a new httpget.Getter.
+//
+// The env is used to read HTTP credentials and the cache directory.
+// HTTP responses are cached in $BUF_CACHE_DIR/http if BUF_CACHE_DIR is set.
+// If env is nil, HTTP requests are not authenticated or cached.
+func NewHTTPGetter(
+	logger *zap.Logger,
+	env map[string]string,
+) httpget.Getter {
+	if env == nil {
+		return httpget.NewGetter(logger, defaultHTTPClient)
+	}
+	options := []httpget.GetterOption{
+		httpget.GetterWithAuthenticator(httpget.NewEnvAuthenticator(env)),
+	}
+	if cacheDirPath := env[cacheDirEnvKey]; cacheDirPath != "" {
+		options = append(
+			options,
+			httpget.GetterWithCacheDirPath(filepath.Join(cacheDirPath, "http")),
+		)
+	}
+	return httpget.NewGetter(logger, defaultHTTPClient, options...)
+}
+
+// NewBufbuildHandler returns a new bufbuild.Handler.
+func NewBufbuildHandler(
+	logger *zap.Logger,
+	segList *bytepool.SegList,
+) bufbuild.Handler {
+	return bufbuild.NewHandler(
+		logger,
+		segList,
+		bufbuild.NewProvider(logger),
+		bufbuild.NewRunner(logger),
+	)
+}
+
+// NewBufinitHandler returns a new bufinit.Handler.
+func NewBufinitHandler(
+	logger *zap.Logger,
+	segList *bytepool.SegList,
+) bufinit.Handler {
+	return bufinit.NewHandler(
+		logger,
+		NewBufbuildHandler(logger, segList),
+	)
+}
+
+// NewBufmigrateMigrator returns a new bufmigrate.Migrator.
+func NewBufmigrateMigrator(
+	logger *zap.Logger,
+) bufmigrate.Migrator {
+	return bufmigrate.NewMigrator(
+		logger,
+		bufconfig.NewProvider(logger),
+	)
+}
+
 // NewBufosImageWriter returns a new bufos.ImageWriter.
 func NewBufosImageWriter(
 	logger *zap.Logger,
@@ -73,6 +151,90 @@ func NewBufbreakingHandler(
 	)
 }
 
+// NewBufchangelogHandler returns a new bufchangelog.Handler.
+func NewBufchangelogHandler(
+	logger *zap.Logger,
+) bufchangelog.Handler {
+	return bufchangelog.NewHandler(logger)
+}
+
+// NewBufinventoryHandler returns a new bufinventory.Handler.
+func NewBufinventoryHandler(
+	logger *zap.Logger,
+) bufinventory.Handler {
+	return bufinventory.NewHandler(logger)
+}
+
+// NewBufdescribeHandler returns a new bufdescribe.Handler.
+func NewBufdescribeHandler(
+	logger *zap.Logger,
+) bufdescribe.Handler {
+	return bufdescribe.NewHandler(logger, NewBufformatFormatter(logger))
+}
+
+// NewBufdocsHandler returns a new bufdocs.Handler.
+func NewBufdocsHandler(
+	logger *zap.Logger,
+) bufdocs.Handler {
+	return bufdocs.NewHandler(logger)
+}
+
+// NewBufexportHandler returns a new bufexport.Handler.
+func NewBufexportHandler(
+	logger *zap.Logger,
+) bufexport.Handler {
+	return bufexport.NewHandler(logger, bufbuild.NewProvider(logger))
+}
+
+// NewBufformatFormatter returns a new bufformat.Formatter.
+func NewBufformatFormatter(
+	logger *zap.Logger,
+) bufformat.Formatter {
+	return bufformat.NewFormatter(logger)
+}
+
+// NewBufgenHandler returns a new bufgen.Handler.
+func NewBufgenHandler(
+	logger *zap.Logger,
+) bufgen.Handler {
+	return bufgen.NewHandler(logger)
+}
+
+// NewBufgraphHandler returns a new bufgraph.Handler.
+func NewBufgraphHandler(
+	logger *zap.Logger,
+) bufgraph.Handler {
+	return bufgraph.NewHandler(logger)
+}
+
+// NewBufstatsHandler returns a new bufstats.Handler.
+func NewBufstatsHandler(
+	logger *zap.Logger,
+) bufstats.Handler {
+	return bufstats.NewHandler(logger)
+}
+
+// NewBufconvertHandler returns a new bufconvert.Handler.
+func NewBufconvertHandler(
+	logger *zap.Logger,
+) bufconvert.Handler {
+	return bufconvert.NewHandler(logger)
+}
+
+// NewBuflspServer returns a new buflsp.Server.
+func NewBuflspServer(
+	logger *zap.Logger,
+	segList *bytepool.SegList,
+) buflsp.Server {
+	return buflsp.NewServer(
+		logger,
+		NewBufbuildHandler(logger, segList),
+		NewBuflintHandler(logger),
+		bufconfig.NewProvider(logger),
+		NewBufformatFormatter(logger),
+	)
+}
+
 // IsFormatJSON returns true if the format is JSON.
 //
 // This will probably eventually need to be split between the image/check flags
@@ -87,3 +249,12 @@ func IsFormatJSON(flagName string, format string) (bool, error) {
 		return false, errs.NewInvalidArgumentf("--%s: unknown format: %q", flagName, s)
 	}
 }
+
+// ParseBufchangelogFormat parses the bufchangelog.Format.
+func ParseBufchangelogFormat(flagName string, format string) (bufchangelog.Format, error) {
+	changelogFormat, ok := bufchangelog.ParseFormat(format)
+	if !ok {
+		return 0, errs.NewInvalidArgumentf("--%s: unknown format: %q", flagName, format)
+	}
+	return changelogFormat, nil
+}
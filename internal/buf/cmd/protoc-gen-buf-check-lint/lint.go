@@ -29,6 +29,7 @@ func Main() {
 // Public so this can be used in the cmdtesting package.
 func Handle(
 	stderr io.Writer,
+	env map[string]string,
 	responseWriter cliplugin.ResponseWriter,
 	request *plugin_go.CodeGeneratorRequest,
 ) {
@@ -51,7 +52,7 @@ func Handle(
 		responseWriter.WriteError(err.Error())
 		return
 	}
-	envReader := internal.NewBufosEnvReader(logger, bytepool.NewNoPoolSegList(), "", "input_config")
+	envReader := internal.NewBufosEnvReader(logger, bytepool.NewNoPoolSegList(), env, "", "input_config")
 	config, err := envReader.GetConfig(ctx, encodingutil.GetJSONStringOrStringValue(externalConfig.InputConfig))
 	if err != nil {
 		responseWriter.WriteError(err.Error())
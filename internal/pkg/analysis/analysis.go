@@ -35,6 +35,30 @@ type Annotation struct {
 	Type string `json:"type,omitempty" yaml:"type,omitempty"`
 	// Message is the message of the annotation. This is required.
 	Message string `json:"message,omitempty" yaml:"message,omitempty"`
+	// Fix is the suggested fix for the annotation. If there is no
+	// suggested fix, this will be nil.
+	//
+	// Fix is not part of the printed output of annotations.
+	Fix *Fix `json:"-" yaml:"-"`
+}
+
+// Fix is a suggested text edit for an Annotation.
+//
+// The edit replaces the first occurrence of OldText within the location
+// of the Annotation with NewText.
+type Fix struct {
+	// OldText is the text to replace. This is required.
+	OldText string `json:"old_text,omitempty" yaml:"old_text,omitempty"`
+	// NewText is the replacement text. This is required.
+	NewText string `json:"new_text,omitempty" yaml:"new_text,omitempty"`
+	// OldName is the fully-qualified name of the symbol that this Fix
+	// renames. If the Fix is not a rename, this will be empty.
+	//
+	// References to OldName should be rewritten to NewName when applying the Fix.
+	OldName string `json:"old_name,omitempty" yaml:"old_name,omitempty"`
+	// NewName is the fully-qualified name of the renamed symbol. If the Fix
+	// is not a rename, this will be empty.
+	NewName string `json:"new_name,omitempty" yaml:"new_name,omitempty"`
 }
 
 // String returns a basic string representation of a.
@@ -7,7 +7,9 @@ import (
 	"io"
 	"io/ioutil"
 	"os"
+	"os/signal"
 	"strings"
+	"syscall"
 	"time"
 
 	"github.com/bufbuild/buf/internal/pkg/bytepool"
@@ -76,8 +78,9 @@ func (f *Flags) Devel() bool {
 
 // TimeoutFlags are base flags with a root timeout.
 type TimeoutFlags struct {
-	flags   *Flags
-	timeout time.Duration
+	flags       *Flags
+	timeout     time.Duration
+	timeoutFlag *pflag.Flag
 }
 
 // NewTimeoutFlags returns a new TimeoutFlags.
@@ -93,6 +96,7 @@ func NewTimeoutFlags(devel bool) *TimeoutFlags {
 func (t *TimeoutFlags) BindRootCommandFlags(flagSet *pflag.FlagSet, defaultTimeout time.Duration) {
 	t.flags.BindRootCommandFlags(flagSet)
 	flagSet.DurationVar(&t.timeout, "timeout", defaultTimeout, `The duration until timing out.`)
+	t.timeoutFlag = flagSet.Lookup("timeout")
 }
 
 // NewRunFunc creates a new run function.
@@ -125,6 +129,50 @@ func (t *TimeoutFlags) NewRunFunc(
 	}
 }
 
+// NewInterruptRunFunc creates a new run function for commands that run until
+// interrupted, such as servers.
+//
+// The context is cancelled on SIGINT or SIGTERM. The timeout is only applied
+// if it was explicitly set.
+func (t *TimeoutFlags) NewInterruptRunFunc(
+	fn func(
+		context.Context,
+		*cli.ExecEnv,
+		*zap.Logger,
+		*bytepool.SegList,
+	) error,
+) func(*cli.ExecEnv) error {
+	return func(execEnv *cli.ExecEnv) error {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		if !t.flags.profile && t.timeout != 0 && t.timeoutFlag != nil && t.timeoutFlag.Changed {
+			ctx, cancel = context.WithTimeout(ctx, t.timeout)
+			defer cancel()
+		}
+		signalC := make(chan os.Signal, 1)
+		signal.Notify(signalC, os.Interrupt, syscall.SIGTERM)
+		defer signal.Stop(signalC)
+		go func() {
+			select {
+			case <-signalC:
+				cancel()
+			case <-ctx.Done():
+			}
+		}()
+		return doRun(
+			execEnv,
+			t.flags,
+			func(
+				execEnv *cli.ExecEnv,
+				logger *zap.Logger,
+				segList *bytepool.SegList,
+			) error {
+				return fn(ctx, execEnv, logger, segList)
+			},
+		)
+	}
+}
+
 // Devel returns true if devel was set.
 func (t *TimeoutFlags) Devel() bool {
 	return t.flags.devel
@@ -166,6 +214,11 @@ type Command struct {
 	BindFlags func(*pflag.FlagSet)
 	// SubCommands are the sub-commands. Optional.
 	SubCommands []*Command
+	// DisableFlagParsing disables flag parsing, all flags are passed as arguments
+	// to Run. BindFlags should not be set if this is set. Optional.
+	//
+	// This is useful for commands that need to emulate another tool's flag grammar.
+	DisableFlagParsing bool
 }
 
 func (c *Command) toCobra(start time.Time, runEnv *cli.RunEnv, exitCodeAddr *int) *cobra.Command {
@@ -176,6 +229,7 @@ func (c *Command) toCobra(start time.Time, runEnv *cli.RunEnv, exitCodeAddr *int
 		cmd.Long = fmt.Sprintf("%s\n%s", cmd.Short, strings.TrimSpace(c.Long))
 	}
 	cmd.Args = c.Args
+	cmd.DisableFlagParsing = c.DisableFlagParsing
 	if c.Run != nil {
 		cmd.Run = func(_ *cobra.Command, args []string) {
 			execEnv, err := internal.NewExecEnv(
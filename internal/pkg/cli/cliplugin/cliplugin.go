@@ -34,9 +34,13 @@ type ResponseWriter interface {
 type Handler interface {
 	// Handle handles the request.
 	//
+	// The env is the environment of the plugin. For values set as KEY=,
+	// there will be an empty string associated as the value.
+	//
 	// Only system errors should be returned.
 	Handle(
 		stderr io.Writer,
+		env map[string]string,
 		responseWriter ResponseWriter,
 		request *plugin_go.CodeGeneratorRequest,
 	)
@@ -45,6 +49,7 @@ type Handler interface {
 // HandlerFunc is a function that implements Handler.
 type HandlerFunc func(
 	io.Writer,
+	map[string]string,
 	ResponseWriter,
 	*plugin_go.CodeGeneratorRequest,
 )
@@ -52,10 +57,11 @@ type HandlerFunc func(
 // Handle implements Handler.
 func (h HandlerFunc) Handle(
 	stderr io.Writer,
+	env map[string]string,
 	responseWriter ResponseWriter,
 	request *plugin_go.CodeGeneratorRequest,
 ) {
-	h(stderr, responseWriter, request)
+	h(stderr, env, responseWriter, request)
 }
 
 // Main runs the application using the OS runtime and calling os.Exit on the return value of Run.
@@ -88,8 +94,12 @@ func runHandler(handler Handler, start time.Time, runEnv *cli.RunEnv) error {
 	if err := proto.Unmarshal(input, request); err != nil {
 		return err
 	}
+	env, err := internal.EnvironToEnv(runEnv.Environ)
+	if err != nil {
+		return err
+	}
 	responseWriter := newResponseWriter()
-	handler.Handle(runEnv.Stderr, responseWriter, request)
+	handler.Handle(runEnv.Stderr, env, responseWriter, request)
 	response := responseWriter.ToCodeGeneratorResponse()
 	data, err := proto.Marshal(response)
 	if err != nil {
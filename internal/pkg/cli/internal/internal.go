@@ -53,7 +53,7 @@ func NewExecEnv(
 	environ []string,
 	start time.Time,
 ) (*cli.ExecEnv, error) {
-	env, err := environToEnv(environ)
+	env, err := EnvironToEnv(environ)
 	if err != nil {
 		return nil, err
 	}
@@ -67,7 +67,10 @@ func NewExecEnv(
 	}, nil
 }
 
-func environToEnv(environ []string) (map[string]string, error) {
+// EnvironToEnv converts the KEY=VALUE environment to a map.
+//
+// For values set as KEY=, there will be an empty string associated as the value.
+func EnvironToEnv(environ []string) (map[string]string, error) {
 	env := make(map[string]string, len(environ))
 	for _, elem := range environ {
 		if !strings.ContainsRune(elem, '=') {
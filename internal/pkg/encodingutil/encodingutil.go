@@ -41,6 +41,20 @@ func UnmarshalYAMLStrict(data []byte, v interface{}) error {
 	return nil
 }
 
+// MarshalYAML marshals the value as YAML with an indent of two spaces.
+func MarshalYAML(v interface{}) ([]byte, error) {
+	buffer := bytes.NewBuffer(nil)
+	yamlEncoder := yaml.NewEncoder(buffer)
+	yamlEncoder.SetIndent(2)
+	if err := yamlEncoder.Encode(v); err != nil {
+		return nil, err
+	}
+	if err := yamlEncoder.Close(); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
 // UnmarshalJSONOrYAMLStrict unmarshals the data as JSON or YAML in order, returning
 // a user error with both errors on failure.
 //
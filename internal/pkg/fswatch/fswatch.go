@@ -0,0 +1,72 @@
+// Package fswatch watches local file paths for changes.
+//
+// Changes are detected by polling the sizes and modification times of files,
+// which works the same on all operating systems.
+package fswatch
+
+import (
+	"context"
+	"path/filepath"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// DefaultInterval is the default polling interval.
+const DefaultInterval = 500 * time.Millisecond
+
+// Watcher watches file paths.
+type Watcher interface {
+	// Watch calls f every time a change is detected, until the context is done.
+	//
+	// A change is only acted on once the files have not changed for a full interval,
+	// so that a burst of changes such as a save of many files results in a single call.
+	// If a change is detected while f is running, the context passed to f is
+	// cancelled, and f is called again once it returns.
+	//
+	// Returns nil once the context is done and f has returned.
+	Watch(ctx context.Context, f func(context.Context)) error
+}
+
+// WatcherOption is an option for a new Watcher.
+type WatcherOption func(*watcher)
+
+// WatcherWithInterval returns a new WatcherOption that sets the polling interval.
+//
+// The default is DefaultInterval.
+func WatcherWithInterval(interval time.Duration) WatcherOption {
+	return func(watcher *watcher) {
+		watcher.interval = interval
+	}
+}
+
+// WatcherWithFilter returns a new WatcherOption that only watches the files
+// for which filter returns true.
+//
+// The filter is called with the file path relative to the watched directory it is
+// under, using forward slashes. Directories are always traversed, and watched paths
+// that are files are always watched.
+func WatcherWithFilter(filter func(string) bool) WatcherOption {
+	return func(watcher *watcher) {
+		watcher.filter = filter
+	}
+}
+
+// WatcherWithExcludes returns a new WatcherOption that does not traverse the
+// directory paths.
+//
+// The directory paths should be within the watched directories.
+func WatcherWithExcludes(dirPaths ...string) WatcherOption {
+	return func(watcher *watcher) {
+		for _, dirPath := range dirPaths {
+			watcher.excludes[filepath.Clean(dirPath)] = struct{}{}
+		}
+	}
+}
+
+// NewWatcher returns a new Watcher for the file or directory paths.
+//
+// Paths that do not exist are watched for creation.
+func NewWatcher(logger *zap.Logger, paths []string, options ...WatcherOption) Watcher {
+	return newWatcher(logger, paths, options...)
+}
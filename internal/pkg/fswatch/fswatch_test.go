@@ -0,0 +1,57 @@
+package fswatch_test
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bufbuild/buf/internal/pkg/fswatch"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestWatch(t *testing.T) {
+	t.Parallel()
+	dirPath, err := ioutil.TempDir("", "")
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, os.RemoveAll(dirPath)) }()
+	excludeDirPath := filepath.Join(dirPath, "exclude")
+	require.NoError(t, os.Mkdir(excludeDirPath, 0755))
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	called := make(chan struct{}, 16)
+	watcher := fswatch.NewWatcher(
+		zap.NewNop(),
+		[]string{dirPath},
+		fswatch.WatcherWithInterval(10*time.Millisecond),
+		fswatch.WatcherWithFilter(func(path string) bool { return strings.HasSuffix(path, ".proto") }),
+		fswatch.WatcherWithExcludes(excludeDirPath),
+	)
+	watchDone := make(chan error)
+	go func() {
+		watchDone <- watcher.Watch(ctx, func(context.Context) { called <- struct{}{} })
+	}()
+
+	// give the watcher time to take the initial snapshot, and make sure
+	// filtered files and excluded directories are ignored
+	time.Sleep(50 * time.Millisecond)
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dirPath, "a.txt"), []byte("a"), 0644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(excludeDirPath, "b.proto"), []byte("b"), 0644))
+	time.Sleep(50 * time.Millisecond)
+	assert.Len(t, called, 0)
+
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dirPath, "a.proto"), []byte("a"), 0644))
+	select {
+	case <-called:
+	case <-ctx.Done():
+		t.Fatal("change not detected")
+	}
+	cancel()
+	assert.NoError(t, <-watchDone)
+}
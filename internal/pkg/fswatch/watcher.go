@@ -0,0 +1,147 @@
+package fswatch
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+type watcher struct {
+	logger   *zap.Logger
+	paths    []string
+	interval time.Duration
+	filter   func(string) bool
+	excludes map[string]struct{}
+}
+
+func newWatcher(logger *zap.Logger, paths []string, options ...WatcherOption) *watcher {
+	watcher := &watcher{
+		logger:   logger.Named("fswatch"),
+		paths:    paths,
+		interval: DefaultInterval,
+		excludes: make(map[string]struct{}),
+	}
+	for _, option := range options {
+		option(watcher)
+	}
+	return watcher
+}
+
+func (w *watcher) Watch(ctx context.Context, f func(context.Context)) error {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	previous := w.snapshot()
+	// changed is true if a change was seen, but the files have not been stable for an interval yet
+	changed := false
+	// pending is true if f should be called again once the current call returns
+	pending := false
+	var cancelRun context.CancelFunc
+	var runDone chan struct{}
+	startRun := func() {
+		var runCtx context.Context
+		runCtx, cancelRun = context.WithCancel(ctx)
+		runDone = make(chan struct{})
+		go func(runCtx context.Context, runDone chan struct{}) {
+			defer close(runDone)
+			f(runCtx)
+		}(runCtx, runDone)
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			if runDone != nil {
+				cancelRun()
+				<-runDone
+			}
+			return nil
+		case <-runDone:
+			cancelRun()
+			runDone = nil
+			if pending {
+				pending = false
+				startRun()
+			}
+		case <-ticker.C:
+			current := w.snapshot()
+			if !current.equal(previous) {
+				previous = current
+				changed = true
+				continue
+			}
+			if !changed {
+				continue
+			}
+			changed = false
+			w.logger.Debug("change_detected")
+			if runDone != nil {
+				cancelRun()
+				pending = true
+				continue
+			}
+			startRun()
+		}
+	}
+}
+
+func (w *watcher) snapshot() snapshot {
+	s := make(snapshot)
+	for _, path := range w.paths {
+		// errors are treated as the file not existing, and will show up as a change
+		// if the file later exists
+		_ = filepath.Walk(
+			path,
+			func(filePath string, fileInfo os.FileInfo, err error) error {
+				if err != nil {
+					return nil
+				}
+				if fileInfo.IsDir() {
+					if _, ok := w.excludes[filepath.Clean(filePath)]; ok {
+						return filepath.SkipDir
+					}
+					return nil
+				}
+				// the filter does not apply to watched paths that are files
+				if w.filter != nil && filePath != path {
+					relPath, err := filepath.Rel(path, filePath)
+					if err != nil {
+						return nil
+					}
+					if !w.filter(filepath.ToSlash(relPath)) {
+						return nil
+					}
+				}
+				s[filePath] = fileState{
+					size:    fileInfo.Size(),
+					modTime: fileInfo.ModTime(),
+				}
+				return nil
+			},
+		)
+	}
+	return s
+}
+
+type fileState struct {
+	size    int64
+	modTime time.Time
+}
+
+// snapshot is a map from file path to state.
+type snapshot map[string]fileState
+
+func (s snapshot) equal(other snapshot) bool {
+	if len(s) != len(other) {
+		return false
+	}
+	for filePath, state := range s {
+		otherState, ok := other[filePath]
+		if !ok || state.size != otherState.size || !state.modTime.Equal(otherState.modTime) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,177 @@
+package httpget
+
+import (
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/bufbuild/buf/internal/pkg/errs"
+)
+
+type envAuthenticator struct {
+	env map[string]string
+
+	once    sync.Once
+	config  *authConfig
+	loadErr error
+}
+
+func newEnvAuthenticator(env map[string]string) *envAuthenticator {
+	return &envAuthenticator{
+		env: env,
+	}
+}
+
+func (e *envAuthenticator) Authenticate(request *http.Request) error {
+	e.once.Do(e.load)
+	if e.loadErr != nil {
+		return e.loadErr
+	}
+	e.config.authenticate(request)
+	return nil
+}
+
+func (e *envAuthenticator) load() {
+	e.config, e.loadErr = newAuthConfigForEnv(e.env)
+}
+
+type authConfig struct {
+	// hostToHeaders is from host to header name to value.
+	hostToHeaders map[string]map[string]string
+	hostToToken   map[string]string
+	netrc         *netrc
+}
+
+func newAuthConfigForEnv(env map[string]string) (*authConfig, error) {
+	hostToHeaders, err := parseHeaders(env[HeadersEnvKey])
+	if err != nil {
+		return nil, err
+	}
+	hostToToken, err := parseTokens(env[TokensEnvKey])
+	if err != nil {
+		return nil, err
+	}
+	netrc, err := readNetrcForEnv(env)
+	if err != nil {
+		return nil, err
+	}
+	return &authConfig{
+		hostToHeaders: hostToHeaders,
+		hostToToken:   hostToToken,
+		netrc:         netrc,
+	}, nil
+}
+
+func (a *authConfig) authenticate(request *http.Request) {
+	host := request.URL.Host
+	hostname := request.URL.Hostname()
+	if token, ok := getForHost(a.hostToToken, host, hostname); ok {
+		request.Header.Set("Authorization", "Bearer "+token)
+	} else if a.netrc != nil {
+		if machine := a.netrc.getMachine(hostname); machine != nil {
+			request.SetBasicAuth(machine.login, machine.password)
+		}
+	}
+	if headers, ok := a.hostToHeaders[host]; ok {
+		setHeaders(request, headers)
+	} else if headers, ok := a.hostToHeaders[hostname]; ok {
+		setHeaders(request, headers)
+	}
+}
+
+// parseHeaders parses the value of HeadersEnvKey.
+func parseHeaders(value string) (map[string]map[string]string, error) {
+	hostToHeaders := make(map[string]map[string]string)
+	for _, entry := range strings.Split(value, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		split := strings.SplitN(entry, "=", 2)
+		if len(split) != 2 {
+			return nil, newInvalidEnvEntryError(HeadersEnvKey, entry, "host=Name:Value")
+		}
+		host := strings.TrimSpace(split[0])
+		headerSplit := strings.SplitN(split[1], ":", 2)
+		if len(headerSplit) != 2 {
+			return nil, newInvalidEnvEntryError(HeadersEnvKey, entry, "host=Name:Value")
+		}
+		name := strings.TrimSpace(headerSplit[0])
+		if host == "" || name == "" {
+			return nil, newInvalidEnvEntryError(HeadersEnvKey, entry, "host=Name:Value")
+		}
+		headers, ok := hostToHeaders[host]
+		if !ok {
+			headers = make(map[string]string)
+			hostToHeaders[host] = headers
+		}
+		headers[name] = strings.TrimSpace(headerSplit[1])
+	}
+	return hostToHeaders, nil
+}
+
+// parseTokens parses the value of TokensEnvKey.
+func parseTokens(value string) (map[string]string, error) {
+	hostToToken := make(map[string]string)
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		// hosts cannot contain @, so split on the last one in case the token does
+		index := strings.LastIndex(entry, "@")
+		if index <= 0 || index == len(entry)-1 {
+			return nil, newInvalidEnvEntryError(TokensEnvKey, entry, "token@host")
+		}
+		hostToToken[entry[index+1:]] = entry[:index]
+	}
+	return hostToToken, nil
+}
+
+// readNetrcForEnv reads the netrc file specified by NetrcEnvKey, or $HOME/.netrc.
+//
+// Returns nil if NetrcEnvKey is not set and $HOME/.netrc does not exist.
+func readNetrcForEnv(env map[string]string) (*netrc, error) {
+	filePath := env[NetrcEnvKey]
+	explicit := filePath != ""
+	if !explicit {
+		home := env["HOME"]
+		if home == "" {
+			return nil, nil
+		}
+		filePath = filepath.Join(home, ".netrc")
+	}
+	data, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		if os.IsNotExist(err) && !explicit {
+			return nil, nil
+		}
+		return nil, errs.NewInvalidArgumentf("could not read netrc file %s: %v", filePath, err)
+	}
+	netrc, err := parseNetrc(data)
+	if err != nil {
+		return nil, errs.NewInvalidArgumentf("could not parse netrc file %s: %v", filePath, err)
+	}
+	return netrc, nil
+}
+
+func getForHost(m map[string]string, host string, hostname string) (string, bool) {
+	if value, ok := m[host]; ok {
+		return value, true
+	}
+	value, ok := m[hostname]
+	return value, ok
+}
+
+func setHeaders(request *http.Request, headers map[string]string) {
+	for name, value := range headers {
+		request.Header.Set(name, value)
+	}
+}
+
+func newInvalidEnvEntryError(envKey string, entry string, expected string) error {
+	return errs.NewInvalidArgumentf("invalid %s entry %q: expected %s", envKey, entry, expected)
+}
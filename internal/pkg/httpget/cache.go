@@ -0,0 +1,126 @@
+package httpget
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"go.uber.org/zap"
+)
+
+// cache is a disk cache of responses keyed by URL.
+//
+// Errors writing to the cache are logged and otherwise ignored, as the
+// cache is only an optimization.
+type cache struct {
+	logger  *zap.Logger
+	dirPath string
+}
+
+// cacheEntry is the metadata stored for a cached response.
+type cacheEntry struct {
+	URL          string `json:"url,omitempty"`
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"last_modified,omitempty"`
+}
+
+func newCache(logger *zap.Logger, dirPath string) *cache {
+	return &cache{
+		logger:  logger,
+		dirPath: dirPath,
+	}
+}
+
+// getEntry gets the entry for the URL.
+//
+// Returns nil if there is no valid entry.
+func (c *cache) getEntry(url string) *cacheEntry {
+	data, err := ioutil.ReadFile(c.getEntryFilePath(url))
+	if err != nil {
+		return nil
+	}
+	entry := &cacheEntry{}
+	if err := json.Unmarshal(data, entry); err != nil {
+		c.logger.Debug("cache_entry_invalid", zap.String("url", url), zap.Error(err))
+		return nil
+	}
+	// guard against hash collisions and entries that cannot be revalidated
+	if entry.URL != url || (entry.ETag == "" && entry.LastModified == "") {
+		return nil
+	}
+	return entry
+}
+
+// getData gets the cached data for the URL.
+func (c *cache) getData(url string) ([]byte, error) {
+	return ioutil.ReadFile(c.getDataFilePath(url))
+}
+
+// put puts the entry and data for the URL.
+//
+// If the entry has no ETag or Last-Modified value, the response cannot
+// be revalidated, so any existing entry is removed instead.
+func (c *cache) put(url string, entry *cacheEntry, data []byte) {
+	entryFilePath := c.getEntryFilePath(url)
+	if entry.ETag == "" && entry.LastModified == "" {
+		_ = os.Remove(entryFilePath)
+		_ = os.Remove(c.getDataFilePath(url))
+		return
+	}
+	entry.URL = url
+	entryData, err := json.Marshal(entry)
+	if err != nil {
+		c.logger.Warn("cache_write_failed", zap.String("url", url), zap.Error(err))
+		return
+	}
+	if err := os.MkdirAll(c.dirPath, 0755); err != nil {
+		c.logger.Warn("cache_write_failed", zap.String("url", url), zap.Error(err))
+		return
+	}
+	// write the data before the entry so that an entry never points to missing data
+	if err := writeFileAtomic(c.getDataFilePath(url), data); err != nil {
+		c.logger.Warn("cache_write_failed", zap.String("url", url), zap.Error(err))
+		return
+	}
+	if err := writeFileAtomic(entryFilePath, entryData); err != nil {
+		c.logger.Warn("cache_write_failed", zap.String("url", url), zap.Error(err))
+	}
+}
+
+func (c *cache) getEntryFilePath(url string) string {
+	return filepath.Join(c.dirPath, getCacheKey(url)+".json")
+}
+
+func (c *cache) getDataFilePath(url string) string {
+	return filepath.Join(c.dirPath, getCacheKey(url)+".data")
+}
+
+func getCacheKey(url string) string {
+	sum := sha256.Sum256([]byte(url))
+	return hex.EncodeToString(sum[:])
+}
+
+// writeFileAtomic writes to a temporary file in the same directory and then
+// renames it, so that concurrent readers never see a partial file.
+func writeFileAtomic(filePath string, data []byte) (retErr error) {
+	file, err := ioutil.TempFile(filepath.Dir(filePath), filepath.Base(filePath)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if retErr != nil {
+			_ = os.Remove(file.Name())
+		}
+	}()
+	if _, err := file.Write(data); err != nil {
+		_ = file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	return os.Rename(file.Name(), filePath)
+}
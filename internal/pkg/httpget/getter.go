@@ -0,0 +1,237 @@
+package httpget
+
+import (
+	"context"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/bufbuild/buf/internal/pkg/errs"
+	"go.uber.org/zap"
+)
+
+type getter struct {
+	logger         *zap.Logger
+	httpClient     *http.Client
+	authenticator  Authenticator
+	cacheDirPath   string
+	maxRetries     int
+	initialBackoff time.Duration
+	// delegateCheckRedirect is the CheckRedirect of the given http.Client.
+	delegateCheckRedirect func(*http.Request, []*http.Request) error
+}
+
+func newGetter(
+	logger *zap.Logger,
+	httpClient *http.Client,
+	options ...GetterOption,
+) *getter {
+	getter := &getter{
+		logger:         logger.Named("httpget"),
+		httpClient:     httpClient,
+		maxRetries:     defaultMaxRetries,
+		initialBackoff: defaultInitialBackoff,
+	}
+	for _, option := range options {
+		option(getter)
+	}
+	if getter.authenticator != nil {
+		// copy so that the given http.Client is not modified
+		redirectHTTPClient := *httpClient
+		redirectHTTPClient.CheckRedirect = getter.checkRedirect
+		getter.httpClient = &redirectHTTPClient
+		getter.delegateCheckRedirect = httpClient.CheckRedirect
+	}
+	return getter
+}
+
+func (g *getter) Get(ctx context.Context, url string) ([]byte, error) {
+	var cache *cache
+	if g.cacheDirPath != "" {
+		cache = newCache(g.logger, g.cacheDirPath)
+	}
+	var entry *cacheEntry
+	if cache != nil {
+		entry = cache.getEntry(url)
+	}
+	backoff := g.initialBackoff
+	for attempt := 0; ; attempt++ {
+		data, err := g.get(ctx, url, cache, entry)
+		if err == nil {
+			return data, nil
+		}
+		if attempt >= g.maxRetries || ctx.Err() != nil || !isRetryable(err) {
+			return nil, err
+		}
+		g.logger.Debug(
+			"retry",
+			zap.String("url", url),
+			zap.Int("attempt", attempt+1),
+			zap.Duration("backoff", backoff),
+			zap.Error(err),
+		)
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, newContextError(ctx, url)
+		case <-timer.C:
+		}
+		backoff *= 2
+		if backoff > maxBackoff {
+			backoff = maxBackoff
+		}
+	}
+}
+
+// get does a single request.
+//
+// If entry is not nil, the request is conditional on the entry,
+// and the cached data is returned if the server returns HTTP 304.
+func (g *getter) get(
+	ctx context.Context,
+	url string,
+	cache *cache,
+	entry *cacheEntry,
+) (_ []byte, retErr error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, errs.NewInvalidArgumentf("invalid URL %s: %v", url, err)
+	}
+	if g.authenticator != nil {
+		if err := g.authenticator.Authenticate(request); err != nil {
+			return nil, err
+		}
+	}
+	if entry != nil {
+		if entry.ETag != "" {
+			request.Header.Set("If-None-Match", entry.ETag)
+		}
+		if entry.LastModified != "" {
+			request.Header.Set("If-Modified-Since", entry.LastModified)
+		}
+	}
+	response, err := g.httpClient.Do(request)
+	if err != nil {
+		if checkRedirectErr := getCheckRedirectError(err); checkRedirectErr != nil {
+			return nil, checkRedirectErr
+		}
+		if ctx.Err() != nil {
+			return nil, newContextError(ctx, url)
+		}
+		if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
+			return nil, errs.NewDeadlineExceededf("timed out getting %s: %v", url, err)
+		}
+		return nil, errs.NewUnavailablef("could not get %s: %v", url, err)
+	}
+	defer func() {
+		retErr = errs.Append(retErr, response.Body.Close())
+	}()
+	if entry != nil && response.StatusCode == http.StatusNotModified {
+		data, err := cache.getData(url)
+		if err == nil {
+			g.logger.Debug("cache_hit", zap.String("url", url))
+			return data, nil
+		}
+		// the cached data is gone, so drop the entry and retry unconditionally
+		g.logger.Debug("cache_data_missing", zap.String("url", url), zap.Error(err))
+		return g.get(ctx, url, cache, nil)
+	}
+	if response.StatusCode != http.StatusOK {
+		return nil, newStatusCodeError(url, response.StatusCode)
+	}
+	data, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, newContextError(ctx, url)
+		}
+		return nil, errs.NewUnavailablef("could not read %s: %v", url, err)
+	}
+	if cache != nil {
+		cache.put(
+			url,
+			&cacheEntry{
+				ETag:         response.Header.Get("ETag"),
+				LastModified: response.Header.Get("Last-Modified"),
+			},
+			data,
+		)
+	}
+	return data, nil
+}
+
+// checkRedirect replaces the authentication of the redirected request with
+// the authentication for the host of the redirect.
+//
+// The http.Client copies the headers of the initial request to redirects, and
+// only removes the Authorization header for redirects to other domains, so
+// custom headers would otherwise be sent to any host that we are redirected to.
+func (g *getter) checkRedirect(request *http.Request, via []*http.Request) error {
+	if g.delegateCheckRedirect != nil {
+		if err := g.delegateCheckRedirect(request, via); err != nil {
+			return err
+		}
+	} else if len(via) >= maxRedirects {
+		return errs.NewInvalidArgumentf("stopped after %d redirects getting %s", maxRedirects, via[0].URL.String())
+	}
+	for _, viaRequest := range via {
+		// the headers that authentication sets for the host of viaRequest
+		authRequest := &http.Request{
+			URL:    viaRequest.URL,
+			Header: make(http.Header),
+		}
+		if err := g.authenticator.Authenticate(authRequest); err != nil {
+			return err
+		}
+		for name := range authRequest.Header {
+			request.Header.Del(name)
+		}
+	}
+	return g.authenticator.Authenticate(request)
+}
+
+func newStatusCodeError(url string, statusCode int) error {
+	switch {
+	case statusCode == http.StatusUnauthorized:
+		return errs.NewUnauthenticatedf("got HTTP status code %d for %s", statusCode, url)
+	case statusCode == http.StatusForbidden:
+		return errs.NewPermissionDeniedf("got HTTP status code %d for %s", statusCode, url)
+	case statusCode == http.StatusNotFound, statusCode == http.StatusGone:
+		return errs.NewNotFoundf("got HTTP status code %d for %s", statusCode, url)
+	case statusCode == http.StatusTooManyRequests:
+		return errs.NewResourceExhaustedf("got HTTP status code %d for %s", statusCode, url)
+	case statusCode == http.StatusGatewayTimeout:
+		return errs.NewDeadlineExceededf("got HTTP status code %d for %s", statusCode, url)
+	case statusCode >= 500:
+		return errs.NewUnavailablef("got HTTP status code %d for %s", statusCode, url)
+	default:
+		return errs.NewInvalidArgumentf("got HTTP status code %d for %s", statusCode, url)
+	}
+}
+
+func newContextError(ctx context.Context, url string) error {
+	if ctx.Err() == context.DeadlineExceeded {
+		return errs.NewDeadlineExceededf("timed out getting %s", url)
+	}
+	return errs.NewCanceledf("canceled getting %s", url)
+}
+
+// getCheckRedirectError returns the error of checkRedirect that failed the
+// request, or nil if the request failed for another reason.
+func getCheckRedirectError(err error) error {
+	if urlErr, ok := err.(*url.Error); ok && errs.IsError(urlErr.Err) {
+		return urlErr.Err
+	}
+	return nil
+}
+
+func isRetryable(err error) bool {
+	switch errs.GetCode(err) {
+	case errs.CodeUnavailable, errs.CodeResourceExhausted, errs.CodeDeadlineExceeded:
+		return true
+	default:
+		return false
+	}
+}
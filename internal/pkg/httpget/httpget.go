@@ -0,0 +1,120 @@
+// Package httpget provides HTTP GET requests with authentication,
+// caching, and retries.
+package httpget
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// NetrcEnvKey is the environment variable that specifies the netrc file path.
+	//
+	// If not set, $HOME/.netrc is used.
+	NetrcEnvKey = "NETRC"
+	// HeadersEnvKey is the environment variable that specifies per-host headers.
+	//
+	// Entries are separated by semicolons, and are of the form host=Name:Value,
+	// for example "example.com=X-Api-Key:foo;other.com:8080=X-Other:bar".
+	HeadersEnvKey = "BUF_HTTP_HEADERS"
+	// TokensEnvKey is the environment variable that specifies per-host bearer tokens.
+	//
+	// Entries are separated by commas, and are of the form token@host,
+	// for example "foo@example.com,bar@other.com:8080".
+	TokensEnvKey = "BUF_HTTP_TOKENS"
+
+	defaultMaxRetries     = 3
+	defaultInitialBackoff = 100 * time.Millisecond
+	maxBackoff            = 5 * time.Second
+	// maxRedirects is the default limit of the http.Client.
+	maxRedirects = 10
+)
+
+// Getter gets data over HTTP.
+type Getter interface {
+	// Get gets the data at the URL.
+	//
+	// Returns an error with a code mapped from the HTTP status code
+	// if the request does not succeed.
+	Get(ctx context.Context, url string) ([]byte, error)
+}
+
+// GetterOption is an option for a new Getter.
+type GetterOption func(*getter)
+
+// GetterWithAuthenticator returns a GetterOption that authenticates
+// each request with the given Authenticator.
+//
+// Redirects are authenticated for the host that is redirected to, and the
+// authentication for the hosts that were redirected from is removed.
+//
+// The default is no authentication.
+func GetterWithAuthenticator(authenticator Authenticator) GetterOption {
+	return func(getter *getter) {
+		getter.authenticator = authenticator
+	}
+}
+
+// GetterWithCacheDirPath returns a GetterOption that caches responses
+// in the given directory.
+//
+// Cached responses are revalidated using the ETag and Last-Modified headers
+// returned by the server. Responses without either header are not cached.
+//
+// The default is no caching.
+func GetterWithCacheDirPath(cacheDirPath string) GetterOption {
+	return func(getter *getter) {
+		getter.cacheDirPath = cacheDirPath
+	}
+}
+
+// GetterWithMaxRetries returns a GetterOption that retries failed requests
+// up to the given number of times.
+//
+// Network errors, HTTP 429, and HTTP 5xx responses are retried.
+//
+// The default is 3.
+func GetterWithMaxRetries(maxRetries int) GetterOption {
+	return func(getter *getter) {
+		getter.maxRetries = maxRetries
+	}
+}
+
+// GetterWithInitialBackoff returns a GetterOption that waits the given
+// duration before the first retry. The backoff is doubled on each
+// subsequent retry.
+//
+// The default is 100ms.
+func GetterWithInitialBackoff(initialBackoff time.Duration) GetterOption {
+	return func(getter *getter) {
+		getter.initialBackoff = initialBackoff
+	}
+}
+
+// NewGetter returns a new Getter.
+func NewGetter(
+	logger *zap.Logger,
+	httpClient *http.Client,
+	options ...GetterOption,
+) Getter {
+	return newGetter(logger, httpClient, options...)
+}
+
+// Authenticator adds authentication to requests.
+type Authenticator interface {
+	// Authenticate adds authentication to the request for the request's host, if any.
+	Authenticate(request *http.Request) error
+}
+
+// NewEnvAuthenticator returns a new Authenticator that reads credentials
+// using the given environment.
+//
+// Credentials are read from the netrc file, HeadersEnvKey, and TokensEnvKey.
+// Tokens take precedence over netrc entries, and headers take precedence
+// over both. Credentials are lazily read on the first call to Authenticate.
+func NewEnvAuthenticator(env map[string]string) Authenticator {
+	return newEnvAuthenticator(env)
+}
@@ -0,0 +1,342 @@
+package httpget_test
+
+import (
+	"context"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/bufbuild/buf/internal/pkg/errs"
+	"github.com/bufbuild/buf/internal/pkg/httpget"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestGetAuthenticated(t *testing.T) {
+	t.Parallel()
+	server := httptest.NewServer(
+		http.HandlerFunc(
+			func(responseWriter http.ResponseWriter, request *http.Request) {
+				switch request.URL.Path {
+				case "/token":
+					if request.Header.Get("Authorization") != "Bearer foo" {
+						responseWriter.WriteHeader(http.StatusUnauthorized)
+						return
+					}
+				case "/netrc":
+					username, password, ok := request.BasicAuth()
+					if !ok || username != "user" || password != "pass" {
+						responseWriter.WriteHeader(http.StatusForbidden)
+						return
+					}
+				case "/header":
+					if request.Header.Get("X-Api-Key") != "bar" {
+						responseWriter.WriteHeader(http.StatusForbidden)
+						return
+					}
+				}
+				_, _ = responseWriter.Write([]byte(request.URL.Path))
+			},
+		),
+	)
+	defer server.Close()
+	host := server.Listener.Addr().String()
+	hostname, _, err := net.SplitHostPort(host)
+	require.NoError(t, err)
+
+	dirPath, err := ioutil.TempDir("", "")
+	require.NoError(t, err)
+	defer func() {
+		assert.NoError(t, os.RemoveAll(dirPath))
+	}()
+	netrcFilePath := filepath.Join(dirPath, "netrc")
+	require.NoError(
+		t,
+		ioutil.WriteFile(
+			netrcFilePath,
+			[]byte("# comment\nmachine other.com login other password other\nmachine "+hostname+"\n  login user\n  password pass\n"),
+			0600,
+		),
+	)
+
+	getter := httpget.NewGetter(zap.NewNop(), http.DefaultClient)
+	_, err = getter.Get(context.Background(), server.URL+"/token")
+	assert.Equal(t, errs.CodeUnauthenticated, errs.GetCode(err))
+
+	getter = httpget.NewGetter(
+		zap.NewNop(),
+		http.DefaultClient,
+		httpget.GetterWithAuthenticator(
+			httpget.NewEnvAuthenticator(
+				map[string]string{
+					httpget.NetrcEnvKey:   netrcFilePath,
+					httpget.TokensEnvKey:  "foo@" + host,
+					httpget.HeadersEnvKey: hostname + "=X-Api-Key: bar",
+				},
+			),
+		),
+	)
+	data, err := getter.Get(context.Background(), server.URL+"/token")
+	require.NoError(t, err)
+	assert.Equal(t, "/token", string(data))
+	data, err = getter.Get(context.Background(), server.URL+"/header")
+	require.NoError(t, err)
+	assert.Equal(t, "/header", string(data))
+
+	getter = httpget.NewGetter(
+		zap.NewNop(),
+		http.DefaultClient,
+		httpget.GetterWithAuthenticator(
+			httpget.NewEnvAuthenticator(
+				map[string]string{
+					httpget.NetrcEnvKey: netrcFilePath,
+				},
+			),
+		),
+	)
+	data, err = getter.Get(context.Background(), server.URL+"/netrc")
+	require.NoError(t, err)
+	assert.Equal(t, "/netrc", string(data))
+}
+
+func TestGetAuthenticatedRedirect(t *testing.T) {
+	t.Parallel()
+	targetServer := httptest.NewServer(
+		http.HandlerFunc(
+			func(responseWriter http.ResponseWriter, request *http.Request) {
+				// the credentials for the origin must not be sent to the target
+				if request.Header.Get("Authorization") != "" || request.Header.Get("X-Api-Key") != "" {
+					responseWriter.WriteHeader(http.StatusBadRequest)
+					return
+				}
+				if request.Header.Get("X-Other") != "baz" {
+					responseWriter.WriteHeader(http.StatusForbidden)
+					return
+				}
+				_, _ = responseWriter.Write([]byte("target"))
+			},
+		),
+	)
+	defer targetServer.Close()
+	originServer := httptest.NewServer(
+		http.HandlerFunc(
+			func(responseWriter http.ResponseWriter, request *http.Request) {
+				if request.Header.Get("Authorization") != "Bearer foo" || request.Header.Get("X-Api-Key") != "bar" {
+					responseWriter.WriteHeader(http.StatusUnauthorized)
+					return
+				}
+				switch request.URL.Path {
+				case "/other":
+					http.Redirect(responseWriter, request, targetServer.URL+"/target", http.StatusFound)
+				case "/same":
+					http.Redirect(responseWriter, request, "/origin", http.StatusFound)
+				default:
+					_, _ = responseWriter.Write([]byte("origin"))
+				}
+			},
+		),
+	)
+	defer originServer.Close()
+	originHost := originServer.Listener.Addr().String()
+	targetHost := targetServer.Listener.Addr().String()
+
+	getter := httpget.NewGetter(
+		zap.NewNop(),
+		http.DefaultClient,
+		httpget.GetterWithAuthenticator(
+			httpget.NewEnvAuthenticator(
+				map[string]string{
+					httpget.TokensEnvKey:  "foo@" + originHost,
+					httpget.HeadersEnvKey: originHost + "=X-Api-Key:bar;" + targetHost + "=X-Other:baz",
+				},
+			),
+		),
+	)
+	data, err := getter.Get(context.Background(), originServer.URL+"/other")
+	require.NoError(t, err)
+	assert.Equal(t, "target", string(data))
+	data, err = getter.Get(context.Background(), originServer.URL+"/same")
+	require.NoError(t, err)
+	assert.Equal(t, "origin", string(data))
+	// the given http.Client is not modified
+	assert.Nil(t, http.DefaultClient.CheckRedirect)
+}
+
+func TestGetAuthenticatedInvalidEnv(t *testing.T) {
+	t.Parallel()
+	for _, env := range []map[string]string{
+		{httpget.TokensEnvKey: "foo"},
+		{httpget.TokensEnvKey: "foo@"},
+		{httpget.HeadersEnvKey: "example.com"},
+		{httpget.HeadersEnvKey: "example.com=X-Api-Key"},
+		{httpget.NetrcEnvKey: "/does/not/exist/netrc"},
+	} {
+		getter := httpget.NewGetter(
+			zap.NewNop(),
+			http.DefaultClient,
+			httpget.GetterWithAuthenticator(httpget.NewEnvAuthenticator(env)),
+		)
+		_, err := getter.Get(context.Background(), "http://example.com")
+		assert.Equal(t, errs.CodeInvalidArgument, errs.GetCode(err), env)
+	}
+}
+
+func TestGetCached(t *testing.T) {
+	t.Parallel()
+	var requestCount int32
+	var notModifiedCount int32
+	server := httptest.NewServer(
+		http.HandlerFunc(
+			func(responseWriter http.ResponseWriter, request *http.Request) {
+				atomic.AddInt32(&requestCount, 1)
+				switch request.URL.Path {
+				case "/etag":
+					if request.Header.Get("If-None-Match") == `"v1"` {
+						atomic.AddInt32(&notModifiedCount, 1)
+						responseWriter.WriteHeader(http.StatusNotModified)
+						return
+					}
+					responseWriter.Header().Set("ETag", `"v1"`)
+				case "/last-modified":
+					if request.Header.Get("If-Modified-Since") == "Mon, 02 Jan 2006 15:04:05 GMT" {
+						atomic.AddInt32(&notModifiedCount, 1)
+						responseWriter.WriteHeader(http.StatusNotModified)
+						return
+					}
+					responseWriter.Header().Set("Last-Modified", "Mon, 02 Jan 2006 15:04:05 GMT")
+				case "/none":
+					if request.Header.Get("If-None-Match") != "" || request.Header.Get("If-Modified-Since") != "" {
+						responseWriter.WriteHeader(http.StatusBadRequest)
+						return
+					}
+				}
+				_, _ = responseWriter.Write([]byte(request.URL.Path))
+			},
+		),
+	)
+	defer server.Close()
+	dirPath, err := ioutil.TempDir("", "")
+	require.NoError(t, err)
+	defer func() {
+		assert.NoError(t, os.RemoveAll(dirPath))
+	}()
+
+	getter := httpget.NewGetter(
+		zap.NewNop(),
+		http.DefaultClient,
+		httpget.GetterWithCacheDirPath(filepath.Join(dirPath, "cache")),
+	)
+	for _, path := range []string{"/etag", "/last-modified", "/none"} {
+		for i := 0; i < 3; i++ {
+			data, err := getter.Get(context.Background(), server.URL+path)
+			require.NoError(t, err)
+			assert.Equal(t, path, string(data))
+		}
+	}
+	assert.Equal(t, int32(9), atomic.LoadInt32(&requestCount))
+	assert.Equal(t, int32(4), atomic.LoadInt32(&notModifiedCount))
+
+	// removing the cached data should result in an unconditional request
+	fileInfos, err := ioutil.ReadDir(filepath.Join(dirPath, "cache"))
+	require.NoError(t, err)
+	for _, fileInfo := range fileInfos {
+		if filepath.Ext(fileInfo.Name()) == ".data" {
+			require.NoError(t, os.Remove(filepath.Join(dirPath, "cache", fileInfo.Name())))
+		}
+	}
+	data, err := getter.Get(context.Background(), server.URL+"/etag")
+	require.NoError(t, err)
+	assert.Equal(t, "/etag", string(data))
+	assert.Equal(t, int32(5), atomic.LoadInt32(&notModifiedCount))
+}
+
+func TestGetRetries(t *testing.T) {
+	t.Parallel()
+	var requestCount int32
+	server := httptest.NewServer(
+		http.HandlerFunc(
+			func(responseWriter http.ResponseWriter, request *http.Request) {
+				if atomic.AddInt32(&requestCount, 1) < 3 {
+					responseWriter.WriteHeader(http.StatusServiceUnavailable)
+					return
+				}
+				_, _ = responseWriter.Write([]byte("foo"))
+			},
+		),
+	)
+	defer server.Close()
+
+	getter := httpget.NewGetter(
+		zap.NewNop(),
+		http.DefaultClient,
+		httpget.GetterWithInitialBackoff(time.Millisecond),
+	)
+	data, err := getter.Get(context.Background(), server.URL)
+	require.NoError(t, err)
+	assert.Equal(t, "foo", string(data))
+	assert.Equal(t, int32(3), atomic.LoadInt32(&requestCount))
+
+	atomic.StoreInt32(&requestCount, 0)
+	getter = httpget.NewGetter(
+		zap.NewNop(),
+		http.DefaultClient,
+		httpget.GetterWithInitialBackoff(time.Millisecond),
+		httpget.GetterWithMaxRetries(1),
+	)
+	_, err = getter.Get(context.Background(), server.URL)
+	assert.Equal(t, errs.CodeUnavailable, errs.GetCode(err))
+	assert.Equal(t, int32(2), atomic.LoadInt32(&requestCount))
+}
+
+func TestGetStatusCodes(t *testing.T) {
+	t.Parallel()
+	var requestCount int32
+	var statusCode int32
+	server := httptest.NewServer(
+		http.HandlerFunc(
+			func(responseWriter http.ResponseWriter, request *http.Request) {
+				atomic.AddInt32(&requestCount, 1)
+				responseWriter.WriteHeader(int(atomic.LoadInt32(&statusCode)))
+			},
+		),
+	)
+	defer server.Close()
+
+	getter := httpget.NewGetter(
+		zap.NewNop(),
+		http.DefaultClient,
+		httpget.GetterWithInitialBackoff(time.Millisecond),
+		httpget.GetterWithMaxRetries(2),
+	)
+	for _, testCase := range []struct {
+		statusCode    int
+		expectedCode  errs.Code
+		expectedTries int32
+	}{
+		{http.StatusBadRequest, errs.CodeInvalidArgument, 1},
+		{http.StatusUnauthorized, errs.CodeUnauthenticated, 1},
+		{http.StatusForbidden, errs.CodePermissionDenied, 1},
+		{http.StatusNotFound, errs.CodeNotFound, 1},
+		{http.StatusTooManyRequests, errs.CodeResourceExhausted, 3},
+		{http.StatusInternalServerError, errs.CodeUnavailable, 3},
+		{http.StatusGatewayTimeout, errs.CodeDeadlineExceeded, 3},
+	} {
+		atomic.StoreInt32(&requestCount, 0)
+		atomic.StoreInt32(&statusCode, int32(testCase.statusCode))
+		_, err := getter.Get(context.Background(), server.URL)
+		assert.Equal(t, testCase.expectedCode, errs.GetCode(err), testCase.statusCode)
+		assert.Equal(t, testCase.expectedTries, atomic.LoadInt32(&requestCount), testCase.statusCode)
+	}
+
+	// a closed server should be unavailable
+	server.Close()
+	_, err := getter.Get(context.Background(), server.URL)
+	assert.Equal(t, errs.CodeUnavailable, errs.GetCode(err))
+}
@@ -0,0 +1,94 @@
+package httpget
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+type netrc struct {
+	machines       []*netrcMachine
+	defaultMachine *netrcMachine
+}
+
+type netrcMachine struct {
+	name     string
+	login    string
+	password string
+}
+
+// getMachine gets the machine for the name, or the default machine.
+//
+// Returns nil if there is no matching machine and no default.
+func (n *netrc) getMachine(name string) *netrcMachine {
+	for _, machine := range n.machines {
+		if machine.name == name {
+			return machine
+		}
+	}
+	return n.defaultMachine
+}
+
+// parseNetrc parses netrc data.
+//
+// The account token is parsed but ignored, and macdef definitions are skipped.
+func parseNetrc(data []byte) (*netrc, error) {
+	netrc := &netrc{}
+	var current *netrcMachine
+	inMacdef := false
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if inMacdef {
+			// macdef definitions end at the first empty line
+			if strings.TrimSpace(line) == "" {
+				inMacdef = false
+			}
+			continue
+		}
+		if strings.HasPrefix(strings.TrimSpace(line), "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		for i := 0; i < len(fields); i++ {
+			switch token := fields[i]; token {
+			case "machine":
+				if i+1 >= len(fields) {
+					return nil, errors.New("machine with no name")
+				}
+				i++
+				current = &netrcMachine{name: fields[i]}
+				netrc.machines = append(netrc.machines, current)
+			case "default":
+				current = &netrcMachine{}
+				netrc.defaultMachine = current
+			case "login", "password", "account":
+				if current == nil {
+					return nil, fmt.Errorf("%s specified before machine or default", token)
+				}
+				if i+1 >= len(fields) {
+					return nil, fmt.Errorf("%s with no value", token)
+				}
+				i++
+				switch token {
+				case "login":
+					current.login = fields[i]
+				case "password":
+					current.password = fields[i]
+				}
+			case "macdef":
+				inMacdef = true
+				// the rest of the line is the macro name
+				i = len(fields)
+			default:
+				return nil, fmt.Errorf("unknown token %q", token)
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return netrc, nil
+}
@@ -8,17 +8,20 @@ type enum struct {
 	allowAliasPath []int32
 	reservedRanges []ReservedRange
 	reservedNames  []ReservedName
+	deprecated     bool
 }
 
 func newEnum(
 	namedDescriptor namedDescriptor,
 	allowAlias bool,
 	allowAliasPath []int32,
+	deprecated bool,
 ) *enum {
 	return &enum{
 		namedDescriptor: namedDescriptor,
 		allowAlias:      allowAlias,
 		allowAliasPath:  allowAliasPath,
+		deprecated:      deprecated,
 	}
 }
 
@@ -34,6 +37,10 @@ func (e *enum) AllowAliasLocation() Location {
 	return e.getLocation(e.allowAliasPath)
 }
 
+func (e *enum) Deprecated() bool {
+	return e.deprecated
+}
+
 func (e *enum) ReservedRanges() []ReservedRange {
 	return e.reservedRanges
 }
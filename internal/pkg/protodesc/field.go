@@ -3,23 +3,28 @@ package protodesc
 type field struct {
 	namedDescriptor
 
-	message      Message
-	number       int
-	label        FieldDescriptorProtoLabel
-	typ          FieldDescriptorProtoType
-	typeName     string
-	oneofIndex   *int32
-	jsonName     string
-	jsType       FieldOptionsJSType
-	cType        FieldOptionsCType
-	packed       *bool
-	numberPath   []int32
-	typePath     []int32
-	typeNamePath []int32
-	jsonNamePath []int32
-	jsTypePath   []int32
-	cTypePath    []int32
-	packedPath   []int32
+	message          Message
+	number           int
+	label            FieldDescriptorProtoLabel
+	typ              FieldDescriptorProtoType
+	typeName         string
+	oneofIndex       *int32
+	jsonName         string
+	jsType           FieldOptionsJSType
+	cType            FieldOptionsCType
+	packed           *bool
+	defaultValue     string
+	extendee         string
+	numberPath       []int32
+	typePath         []int32
+	typeNamePath     []int32
+	jsonNamePath     []int32
+	jsTypePath       []int32
+	cTypePath        []int32
+	packedPath       []int32
+	defaultValuePath []int32
+	extendeePath     []int32
+	deprecated       bool
 }
 
 func newField(
@@ -34,6 +39,8 @@ func newField(
 	jsType FieldOptionsJSType,
 	cType FieldOptionsCType,
 	packed *bool,
+	defaultValue string,
+	extendee string,
 	numberPath []int32,
 	typePath []int32,
 	typeNamePath []int32,
@@ -41,29 +48,41 @@ func newField(
 	jsTypePath []int32,
 	cTypePath []int32,
 	packedPath []int32,
+	defaultValuePath []int32,
+	extendeePath []int32,
+	deprecated bool,
 ) *field {
 	return &field{
-		namedDescriptor: namedDescriptor,
-		message:         message,
-		number:          number,
-		label:           label,
-		typ:             typ,
-		typeName:        typeName,
-		oneofIndex:      oneofIndex,
-		jsonName:        jsonName,
-		jsType:          jsType,
-		cType:           cType,
-		packed:          packed,
-		numberPath:      numberPath,
-		typePath:        typePath,
-		typeNamePath:    typeNamePath,
-		jsonNamePath:    jsonNamePath,
-		jsTypePath:      jsTypePath,
-		cTypePath:       cTypePath,
-		packedPath:      packedPath,
+		namedDescriptor:  namedDescriptor,
+		message:          message,
+		number:           number,
+		label:            label,
+		typ:              typ,
+		typeName:         typeName,
+		oneofIndex:       oneofIndex,
+		jsonName:         jsonName,
+		jsType:           jsType,
+		cType:            cType,
+		packed:           packed,
+		defaultValue:     defaultValue,
+		extendee:         extendee,
+		numberPath:       numberPath,
+		typePath:         typePath,
+		typeNamePath:     typeNamePath,
+		jsonNamePath:     jsonNamePath,
+		jsTypePath:       jsTypePath,
+		cTypePath:        cTypePath,
+		packedPath:       packedPath,
+		defaultValuePath: defaultValuePath,
+		extendeePath:     extendeePath,
+		deprecated:       deprecated,
 	}
 }
 
+func (f *field) Deprecated() bool {
+	return f.deprecated
+}
+
 func (f *field) Message() Message {
 	return f.message
 }
@@ -107,6 +126,14 @@ func (f *field) Packed() *bool {
 	return f.packed
 }
 
+func (f *field) DefaultValue() string {
+	return f.defaultValue
+}
+
+func (f *field) Extendee() string {
+	return f.extendee
+}
+
 func (f *field) NumberLocation() Location {
 	return f.getLocation(f.numberPath)
 }
@@ -134,3 +161,11 @@ func (f *field) CTypeLocation() Location {
 func (f *field) PackedLocation() Location {
 	return f.getLocation(f.packedPath)
 }
+
+func (f *field) DefaultValueLocation() Location {
+	return f.getLocation(f.defaultValuePath)
+}
+
+func (f *field) ExtendeeLocation() Location {
+	return f.getLocation(f.extendeePath)
+}
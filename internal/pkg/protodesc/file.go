@@ -12,6 +12,7 @@ type file struct {
 	messages    []Message
 	enums       []Enum
 	services    []Service
+	extensions  []Field
 
 	optimizeMode FileOptionsOptimizeMode
 }
@@ -40,10 +41,18 @@ func (f *file) Services() []Service {
 	return f.services
 }
 
+func (f *file) Extensions() []Field {
+	return f.extensions
+}
+
 func (f *file) CsharpNamespace() string {
 	return f.fileDescriptor.GetOptions().GetCsharpNamespace()
 }
 
+func (f *file) Deprecated() bool {
+	return f.fileDescriptor.GetOptions().GetDeprecated()
+}
+
 func (f *file) GoPackage() string {
 	return f.fileDescriptor.GetOptions().GetGoPackage()
 }
@@ -15,6 +15,7 @@ type fileBuilder struct {
 	messages    []Message
 	enums       []Enum
 	services    []Service
+	extensions  []Field
 }
 
 func newFileBuilder(fileDescriptor protodescpb.FileDescriptor) *fileBuilder {
@@ -110,6 +111,16 @@ func (f *fileBuilder) toFile() (*file, error) {
 		}
 		f.services = append(f.services, service)
 	}
+	for extensionIndex, fieldDescriptorProto := range f.fileDescriptor.GetExtension() {
+		extension, err := f.populateExtension(
+			fieldDescriptorProto,
+			extensionIndex,
+		)
+		if err != nil {
+			return nil, err
+		}
+		f.extensions = append(f.extensions, extension)
+	}
 	optimizeMode, err := getFileOptionsOptimizeMode(f.fileDescriptor.GetOptions().GetOptimizeFor())
 	if err != nil {
 		return nil, err
@@ -122,6 +133,7 @@ func (f *fileBuilder) toFile() (*file, error) {
 		messages:       f.messages,
 		enums:          f.enums,
 		services:       f.services,
+		extensions:     f.extensions,
 		optimizeMode:   optimizeMode,
 	}, nil
 }
@@ -150,6 +162,7 @@ func (f *fileBuilder) populateEnum(
 		enumNamedDescriptor,
 		enumDescriptorProto.GetOptions().GetAllowAlias(),
 		getEnumAllowAliasPath(enumIndex, nestedMessageIndexes...),
+		enumDescriptorProto.GetOptions().GetDeprecated(),
 	)
 
 	for enumValueIndex, enumValueDescriptorProto := range enumDescriptorProto.GetValue() {
@@ -170,6 +183,7 @@ func (f *fileBuilder) populateEnum(
 			enum,
 			int(enumValueDescriptorProto.GetNumber()),
 			getEnumValueNumberPath(enumIndex, enumValueIndex, nestedMessageIndexes...),
+			enumValueDescriptorProto.GetOptions().GetDeprecated(),
 		)
 		enum.addValue(enumValue)
 	}
@@ -234,6 +248,7 @@ func (f *fileBuilder) populateMessage(
 		descriptorProto.GetOptions().GetNoStandardDescriptorAccessor(),
 		getMessageMessageSetWireFormatPath(topLevelMessageIndex, nestedMessageIndexes...),
 		getMessageNoStandardDescriptorAccessorPath(topLevelMessageIndex, nestedMessageIndexes...),
+		descriptorProto.GetOptions().GetDeprecated(),
 	)
 	for fieldIndex, fieldDescriptorProto := range descriptorProto.GetField() {
 		// TODO: not working for map entries
@@ -281,6 +296,8 @@ func (f *fileBuilder) populateMessage(
 			jsType,
 			cType,
 			packed,
+			fieldDescriptorProto.GetDefaultValue(),
+			"",
 			getMessageFieldNumberPath(fieldIndex, topLevelMessageIndex, nestedMessageIndexes...),
 			getMessageFieldTypePath(fieldIndex, topLevelMessageIndex, nestedMessageIndexes...),
 			getMessageFieldTypeNamePath(fieldIndex, topLevelMessageIndex, nestedMessageIndexes...),
@@ -288,6 +305,9 @@ func (f *fileBuilder) populateMessage(
 			getMessageFieldJSTypePath(fieldIndex, topLevelMessageIndex, nestedMessageIndexes...),
 			getMessageFieldCTypePath(fieldIndex, topLevelMessageIndex, nestedMessageIndexes...),
 			getMessageFieldPackedPath(fieldIndex, topLevelMessageIndex, nestedMessageIndexes...),
+			getMessageFieldDefaultValuePath(fieldIndex, topLevelMessageIndex, nestedMessageIndexes...),
+			nil,
+			fieldDescriptorProto.GetOptions().GetDeprecated(),
 		)
 		message.addField(field)
 	}
@@ -337,6 +357,8 @@ func (f *fileBuilder) populateMessage(
 			jsType,
 			cType,
 			packed,
+			fieldDescriptorProto.GetDefaultValue(),
+			fieldDescriptorProto.GetExtendee(),
 			getMessageExtensionNumberPath(fieldIndex, topLevelMessageIndex, nestedMessageIndexes...),
 			getMessageExtensionTypePath(fieldIndex, topLevelMessageIndex, nestedMessageIndexes...),
 			getMessageExtensionTypeNamePath(fieldIndex, topLevelMessageIndex, nestedMessageIndexes...),
@@ -344,6 +366,9 @@ func (f *fileBuilder) populateMessage(
 			getMessageExtensionJSTypePath(fieldIndex, topLevelMessageIndex, nestedMessageIndexes...),
 			getMessageExtensionCTypePath(fieldIndex, topLevelMessageIndex, nestedMessageIndexes...),
 			getMessageExtensionPackedPath(fieldIndex, topLevelMessageIndex, nestedMessageIndexes...),
+			getMessageExtensionDefaultValuePath(fieldIndex, topLevelMessageIndex, nestedMessageIndexes...),
+			getMessageExtensionExtendeePath(fieldIndex, topLevelMessageIndex, nestedMessageIndexes...),
+			fieldDescriptorProto.GetOptions().GetDeprecated(),
 		)
 		message.addExtension(field)
 	}
@@ -453,6 +478,7 @@ func (f *fileBuilder) populateService(
 	}
 	service := newService(
 		serviceNamedDescriptor,
+		serviceDescriptorProto.GetOptions().GetDeprecated(),
 	)
 	for methodIndex, methodDescriptorProto := range serviceDescriptorProto.GetMethod() {
 		methodNamedDescriptor, err := newNamedDescriptor(
@@ -482,6 +508,7 @@ func (f *fileBuilder) populateService(
 			getMethodOutputTypePath(serviceIndex, methodIndex),
 			idempotencyLevel,
 			getMethodIdempotencyLevelPath(serviceIndex, methodIndex),
+			methodDescriptorProto.GetOptions().GetDeprecated(),
 		)
 		if err != nil {
 			return nil, err
@@ -490,3 +517,66 @@ func (f *fileBuilder) populateService(
 	}
 	return service, nil
 }
+
+func (f *fileBuilder) populateExtension(
+	fieldDescriptorProto *protobufdescriptor.FieldDescriptorProto,
+	extensionIndex int,
+) (Field, error) {
+	fieldNamedDescriptor, err := newNamedDescriptor(
+		newLocationDescriptor(
+			f.descriptor,
+			getExtensionPath(extensionIndex),
+		),
+		fieldDescriptorProto.GetName(),
+		getExtensionNamePath(extensionIndex),
+		nil,
+	)
+	if err != nil {
+		return nil, err
+	}
+	var packed *bool
+	if fieldDescriptorProto.Options != nil {
+		packed = fieldDescriptorProto.GetOptions().Packed
+	}
+	label, err := getFieldDescriptorProtoLabel(fieldDescriptorProto.GetLabel())
+	if err != nil {
+		return nil, err
+	}
+	typ, err := getFieldDescriptorProtoType(fieldDescriptorProto.GetType())
+	if err != nil {
+		return nil, err
+	}
+	jsType, err := getFieldOptionsJSType(fieldDescriptorProto.GetOptions().GetJstype())
+	if err != nil {
+		return nil, err
+	}
+	cType, err := getFieldOptionsCType(fieldDescriptorProto.GetOptions().GetCtype())
+	if err != nil {
+		return nil, err
+	}
+	return newField(
+		fieldNamedDescriptor,
+		nil,
+		int(fieldDescriptorProto.GetNumber()),
+		label,
+		typ,
+		fieldDescriptorProto.GetTypeName(),
+		fieldDescriptorProto.OneofIndex,
+		fieldDescriptorProto.GetJsonName(),
+		jsType,
+		cType,
+		packed,
+		fieldDescriptorProto.GetDefaultValue(),
+		fieldDescriptorProto.GetExtendee(),
+		getExtensionNumberPath(extensionIndex),
+		getExtensionTypePath(extensionIndex),
+		getExtensionTypeNamePath(extensionIndex),
+		getExtensionJSONNamePath(extensionIndex),
+		getExtensionJSTypePath(extensionIndex),
+		getExtensionCTypePath(extensionIndex),
+		getExtensionPackedPath(extensionIndex),
+		getExtensionDefaultValuePath(extensionIndex),
+		getExtensionExtendeePath(extensionIndex),
+		fieldDescriptorProto.GetOptions().GetDeprecated(),
+	), nil
+}
@@ -17,6 +17,7 @@ type message struct {
 	noStandardDescriptorAccessor     bool
 	messageSetWireFormatPath         []int32
 	noStandardDescriptorAccessorPath []int32
+	deprecated                       bool
 }
 
 func newMessage(
@@ -27,14 +28,17 @@ func newMessage(
 	noStandardDescriptorAccessor bool,
 	messageSetWireFormatPath []int32,
 	noStandardDescriptorAccessorPath []int32,
+	deprecated bool,
 ) *message {
 	return &message{
 		namedDescriptor:                  namedDescriptor,
+		parent:                           parent,
 		isMapEntry:                       isMapEntry,
 		messageSetWireFormat:             messageSetWireFormat,
 		noStandardDescriptorAccessor:     noStandardDescriptorAccessor,
 		messageSetWireFormatPath:         messageSetWireFormatPath,
 		noStandardDescriptorAccessorPath: noStandardDescriptorAccessorPath,
+		deprecated:                       deprecated,
 	}
 }
 
@@ -74,6 +78,10 @@ func (m *message) Parent() Message {
 	return m.parent
 }
 
+func (m *message) Deprecated() bool {
+	return m.deprecated
+}
+
 func (m *message) IsMapEntry() bool {
 	return m.isMapEntry
 }
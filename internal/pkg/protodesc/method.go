@@ -14,6 +14,7 @@ type method struct {
 	outputTypePath       []int32
 	idempotencyLevel     MethodOptionsIdempotencyLevel
 	idempotencyLevelPath []int32
+	deprecated           bool
 }
 
 func newMethod(
@@ -27,6 +28,7 @@ func newMethod(
 	outputTypePath []int32,
 	idempotencyLevel MethodOptionsIdempotencyLevel,
 	idempotencyLevelPath []int32,
+	deprecated bool,
 ) (*method, error) {
 	if inputTypeName == "" {
 		return nil, errs.NewInternalf("no inputTypeName on %q", namedDescriptor.name)
@@ -45,6 +47,7 @@ func newMethod(
 		outputTypePath:       outputTypePath,
 		idempotencyLevel:     idempotencyLevel,
 		idempotencyLevelPath: idempotencyLevelPath,
+		deprecated:           deprecated,
 	}, nil
 }
 
@@ -83,3 +86,7 @@ func (m *method) IdempotencyLevel() MethodOptionsIdempotencyLevel {
 func (m *method) IdempotencyLevelLocation() Location {
 	return m.getLocation(m.idempotencyLevelPath)
 }
+
+func (m *method) Deprecated() bool {
+	return m.deprecated
+}
@@ -27,6 +27,50 @@ func getDependencyPath(dependencyIndex int) []int32 {
 	return []int32{3, int32(dependencyIndex)}
 }
 
+func getExtensionPath(extensionIndex int) []int32 {
+	return []int32{7, int32(extensionIndex)}
+}
+
+func getExtensionNamePath(extensionIndex int) []int32 {
+	return append(getExtensionPath(extensionIndex), 1)
+}
+
+func getExtensionNumberPath(extensionIndex int) []int32 {
+	return append(getExtensionPath(extensionIndex), 3)
+}
+
+func getExtensionTypePath(extensionIndex int) []int32 {
+	return append(getExtensionPath(extensionIndex), 5)
+}
+
+func getExtensionTypeNamePath(extensionIndex int) []int32 {
+	return append(getExtensionPath(extensionIndex), 6)
+}
+
+func getExtensionJSONNamePath(extensionIndex int) []int32 {
+	return append(getExtensionPath(extensionIndex), 10)
+}
+
+func getExtensionJSTypePath(extensionIndex int) []int32 {
+	return append(getExtensionPath(extensionIndex), 8, 6)
+}
+
+func getExtensionCTypePath(extensionIndex int) []int32 {
+	return append(getExtensionPath(extensionIndex), 8, 1)
+}
+
+func getExtensionPackedPath(extensionIndex int) []int32 {
+	return append(getExtensionPath(extensionIndex), 8, 2)
+}
+
+func getExtensionDefaultValuePath(extensionIndex int) []int32 {
+	return append(getExtensionPath(extensionIndex), 7)
+}
+
+func getExtensionExtendeePath(extensionIndex int) []int32 {
+	return append(getExtensionPath(extensionIndex), 2)
+}
+
 func getMessagePath(topLevelMessageIndex int, nestedMessageIndexes ...int) []int32 {
 	path := []int32{4, int32(topLevelMessageIndex)}
 	for _, nestedMessageIndex := range nestedMessageIndexes {
@@ -83,6 +127,10 @@ func getMessageFieldPackedPath(fieldIndex int, topLevelMessageIndex int, nestedM
 	return append(getMessageFieldPath(fieldIndex, topLevelMessageIndex, nestedMessageIndexes...), 8, 2)
 }
 
+func getMessageFieldDefaultValuePath(fieldIndex int, topLevelMessageIndex int, nestedMessageIndexes ...int) []int32 {
+	return append(getMessageFieldPath(fieldIndex, topLevelMessageIndex, nestedMessageIndexes...), 7)
+}
+
 func getMessageExtensionPath(extensionIndex int, topLevelMessageIndex int, nestedMessageIndexes ...int) []int32 {
 	return append(getMessagePath(topLevelMessageIndex, nestedMessageIndexes...), 6, int32(extensionIndex))
 }
@@ -119,6 +167,14 @@ func getMessageExtensionPackedPath(extensionIndex int, topLevelMessageIndex int,
 	return append(getMessageExtensionPath(extensionIndex, topLevelMessageIndex, nestedMessageIndexes...), 8, 2)
 }
 
+func getMessageExtensionDefaultValuePath(extensionIndex int, topLevelMessageIndex int, nestedMessageIndexes ...int) []int32 {
+	return append(getMessageExtensionPath(extensionIndex, topLevelMessageIndex, nestedMessageIndexes...), 7)
+}
+
+func getMessageExtensionExtendeePath(extensionIndex int, topLevelMessageIndex int, nestedMessageIndexes ...int) []int32 {
+	return append(getMessageExtensionPath(extensionIndex, topLevelMessageIndex, nestedMessageIndexes...), 2)
+}
+
 func getMessageOneofPath(oneofIndex int, topLevelMessageIndex int, nestedMessageIndexes ...int) []int32 {
 	return append(getMessagePath(topLevelMessageIndex, nestedMessageIndexes...), 8, int32(oneofIndex))
 }
@@ -113,15 +113,24 @@ type Location interface {
 	LeadingDetachedComments() []string
 }
 
+// DeprecatedDescriptor is a descriptor that can be deprecated.
+type DeprecatedDescriptor interface {
+	// Deprecated returns true if the deprecated option is set to true.
+	Deprecated() bool
+}
+
 // File is a file descriptor.
 type File interface {
 	Descriptor
 	// Top-level only.
 	ContainerDescriptor
+	DeprecatedDescriptor
 
 	Syntax() Syntax
 	FileImports() []FileImport
 	Services() []Service
+	// Top-level only, Message will return nil for these.
+	Extensions() []Field
 
 	CsharpNamespace() string
 	GoPackage() string
@@ -203,6 +212,7 @@ type ReservedDescriptor interface {
 type Enum interface {
 	NamedDescriptor
 	ReservedDescriptor
+	DeprecatedDescriptor
 
 	Values() []EnumValue
 
@@ -213,6 +223,7 @@ type Enum interface {
 // EnumValue is an enum value descriptor.
 type EnumValue interface {
 	NamedDescriptor
+	DeprecatedDescriptor
 
 	Enum() Enum
 	Number() int
@@ -234,6 +245,7 @@ type Message interface {
 	// Only those directly nested under this message.
 	ContainerDescriptor
 	ReservedDescriptor
+	DeprecatedDescriptor
 
 	// Includes fields in oneofs.
 	Fields() []Field
@@ -254,6 +266,7 @@ type Message interface {
 // Field is a field descriptor.
 type Field interface {
 	NamedDescriptor
+	DeprecatedDescriptor
 
 	Message() Message
 	Number() int
@@ -267,6 +280,15 @@ type Field interface {
 	// Set vs unset matters for packed
 	// See the comments on descriptor.proto
 	Packed() *bool
+	// DefaultValue is the proto2 default value as written in the descriptor.
+	//
+	// For enums, this is the simple name of the enum value.
+	// Empty if there is no default value.
+	DefaultValue() string
+	// Extendee is the fully-qualified name of the extended message.
+	//
+	// Empty if this is not an extension.
+	Extendee() string
 
 	NumberLocation() Location
 	TypeLocation() Location
@@ -275,6 +297,8 @@ type Field interface {
 	JSTypeLocation() Location
 	CTypeLocation() Location
 	PackedLocation() Location
+	DefaultValueLocation() Location
+	ExtendeeLocation() Location
 }
 
 // Oneof is a oneof descriptor.
@@ -287,6 +311,7 @@ type Oneof interface {
 // Service is a service descriptor.
 type Service interface {
 	NamedDescriptor
+	DeprecatedDescriptor
 
 	Methods() []Method
 }
@@ -294,6 +319,7 @@ type Service interface {
 // Method is a method descriptor.
 type Method interface {
 	NamedDescriptor
+	DeprecatedDescriptor
 
 	Service() Service
 	InputTypeName() string
@@ -3,14 +3,17 @@ package protodesc
 type service struct {
 	namedDescriptor
 
-	methods []Method
+	methods    []Method
+	deprecated bool
 }
 
 func newService(
 	namedDescriptor namedDescriptor,
+	deprecated bool,
 ) *service {
 	return &service{
 		namedDescriptor: namedDescriptor,
+		deprecated:      deprecated,
 	}
 }
 
@@ -18,6 +21,10 @@ func (m *service) Methods() []Method {
 	return m.methods
 }
 
+func (m *service) Deprecated() bool {
+	return m.deprecated
+}
+
 func (m *service) addMethod(method Method) {
 	m.methods = append(m.methods, method)
 }
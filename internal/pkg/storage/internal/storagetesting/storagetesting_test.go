@@ -7,6 +7,7 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/bufbuild/buf/internal/pkg/bytepool"
 	"github.com/bufbuild/buf/internal/pkg/bytepool/bytepooltesting"
@@ -20,8 +21,18 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/zap"
+	"gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
 )
 
+const (
+	testArchiveTypeNone testArchiveType = iota
+	testArchiveTypeTar
+	testArchiveTypeZip
+)
+
+type testArchiveType int
+
 const (
 	testProtoContent = `syntax = "proto3";
 
@@ -189,7 +200,7 @@ func TestGitClone(t *testing.T) {
 		context.Background(),
 		zap.NewNop(),
 		"file://"+absGitPath,
-		"master",
+		storagegit.CloneOptions{Branch: "master"},
 		bucket,
 		storagepath.WithExt(".proto"),
 		storagepath.WithExt(".go"),
@@ -207,6 +218,320 @@ func TestGitClone(t *testing.T) {
 	bytepooltesting.AssertAllRecycled(t, segList)
 }
 
+func TestGitCloneOptions(t *testing.T) {
+	t.Parallel()
+	dirPath, firstCommit := testNewGitRepository(t)
+	defer func() {
+		assert.NoError(t, os.RemoveAll(dirPath))
+	}()
+	gitURL := "file://" + dirPath
+
+	testGitClone(
+		t,
+		gitURL,
+		storagegit.CloneOptions{Branch: "master"},
+		map[string]string{
+			"a.proto":     "v2",
+			"sub/b.proto": "v2",
+		},
+	)
+	testGitClone(
+		t,
+		gitURL,
+		storagegit.CloneOptions{Tag: "v1.0.0"},
+		map[string]string{
+			"a.proto": "v1",
+		},
+	)
+	testGitClone(
+		t,
+		gitURL,
+		storagegit.CloneOptions{Ref: firstCommit},
+		map[string]string{
+			"a.proto": "v1",
+		},
+	)
+	testGitClone(
+		t,
+		gitURL,
+		storagegit.CloneOptions{Ref: "HEAD~1"},
+		map[string]string{
+			"a.proto": "v1",
+		},
+	)
+	testGitClone(
+		t,
+		gitURL,
+		storagegit.CloneOptions{Ref: firstCommit, Depth: 2},
+		map[string]string{
+			"a.proto": "v1",
+		},
+	)
+	testGitClone(
+		t,
+		gitURL,
+		storagegit.CloneOptions{Branch: "master", SubDir: "sub"},
+		map[string]string{
+			"b.proto": "v2",
+		},
+	)
+}
+
+func TestGitCloneOptionsError(t *testing.T) {
+	t.Parallel()
+	dirPath, _ := testNewGitRepository(t)
+	defer func() {
+		assert.NoError(t, os.RemoveAll(dirPath))
+	}()
+	gitURL := "file://" + dirPath
+
+	testGitCloneError(t, gitURL, storagegit.CloneOptions{Ref: "0000000000000000000000000000000000000000"})
+	testGitCloneError(t, gitURL, storagegit.CloneOptions{Branch: "master", SubDir: "foo"})
+	testGitCloneError(t, gitURL, storagegit.CloneOptions{Branch: "master", SubDir: "a.proto"})
+	testGitCloneError(t, gitURL, storagegit.CloneOptions{Branch: "master", Tag: "v1.0.0"})
+	testGitCloneError(t, gitURL, storagegit.CloneOptions{})
+}
+
+func testGitClone(
+	t *testing.T,
+	gitURL string,
+	cloneOptions storagegit.CloneOptions,
+	expectedPathToData map[string]string,
+) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	segList := bytepool.NewSegList()
+	defer bytepooltesting.AssertAllRecycled(t, segList)
+	bucket := storagemem.NewBucket(segList)
+	defer func() {
+		assert.NoError(t, bucket.Close())
+	}()
+
+	require.NoError(t, storagegit.Clone(ctx, zap.NewNop(), gitURL, cloneOptions, bucket))
+	pathToData := make(map[string]string)
+	require.NoError(
+		t,
+		bucket.Walk(
+			ctx,
+			"",
+			func(path string) error {
+				data, err := storageutil.ReadPath(ctx, bucket, path)
+				if err != nil {
+					return err
+				}
+				pathToData[path] = string(data)
+				return nil
+			},
+		),
+	)
+	assert.Equal(t, expectedPathToData, pathToData, cloneOptions)
+}
+
+func testGitCloneError(
+	t *testing.T,
+	gitURL string,
+	cloneOptions storagegit.CloneOptions,
+) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	segList := bytepool.NewSegList()
+	defer bytepooltesting.AssertAllRecycled(t, segList)
+	bucket := storagemem.NewBucket(segList)
+	defer func() {
+		assert.NoError(t, bucket.Close())
+	}()
+
+	assert.Error(t, storagegit.Clone(ctx, zap.NewNop(), gitURL, cloneOptions, bucket), cloneOptions)
+}
+
+func TestGitReadBucketForRef(t *testing.T) {
+	t.Parallel()
+	dirPath, firstCommit := testNewGitRepository(t)
+	defer func() {
+		assert.NoError(t, os.RemoveAll(dirPath))
+	}()
+
+	testGitReadBucket(
+		t,
+		func() (storage.ReadBucket, error) {
+			return storagegit.NewReadBucketForRef(dirPath, "refs/heads/master", "")
+		},
+		map[string]string{
+			"a.proto":     "v2",
+			"sub/b.proto": "v2",
+		},
+	)
+	testGitReadBucket(
+		t,
+		func() (storage.ReadBucket, error) {
+			return storagegit.NewReadBucketForRef(filepath.Join(dirPath, ".git"), "HEAD", "sub")
+		},
+		map[string]string{
+			"b.proto": "v2",
+		},
+	)
+	for _, ref := range []string{"refs/tags/v1.0.0", firstCommit, "HEAD~1"} {
+		ref := ref
+		testGitReadBucket(
+			t,
+			func() (storage.ReadBucket, error) {
+				return storagegit.NewReadBucketForRef(dirPath, ref, "")
+			},
+			map[string]string{
+				"a.proto": "v1",
+			},
+		)
+	}
+
+	bucket, err := storagegit.NewReadBucketForRef(dirPath, "HEAD", "")
+	require.NoError(t, err)
+	var paths []string
+	require.NoError(
+		t,
+		bucket.Walk(
+			context.Background(),
+			"sub",
+			func(path string) error {
+				paths = append(paths, path)
+				return nil
+			},
+		),
+	)
+	assert.Equal(t, []string{"sub/b.proto"}, paths)
+	_, err = bucket.Stat(context.Background(), "sub")
+	assert.True(t, storage.IsNotExist(err))
+	_, err = bucket.Get(context.Background(), "c.proto")
+	assert.True(t, storage.IsNotExist(err))
+	assert.NoError(t, bucket.Close())
+
+	_, err = storagegit.NewReadBucketForRef(dirPath, "foo", "")
+	assert.Error(t, err)
+	_, err = storagegit.NewReadBucketForRef(dirPath, "HEAD", "foo")
+	assert.Error(t, err)
+	_, err = storagegit.NewReadBucketForRef(filepath.Join(dirPath, "sub"), "HEAD", "")
+	assert.Error(t, err)
+}
+
+func TestGitReadBucketForWorktree(t *testing.T) {
+	t.Parallel()
+	dirPath, _ := testNewGitRepository(t)
+	defer func() {
+		assert.NoError(t, os.RemoveAll(dirPath))
+	}()
+	// modified
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dirPath, "a.proto"), []byte("v3"), 0644))
+	// deleted
+	require.NoError(t, os.Remove(filepath.Join(dirPath, "sub", "b.proto")))
+	// untracked
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dirPath, "c.proto"), []byte("v3"), 0644))
+	// added
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dirPath, "sub", "d.proto"), []byte("v3"), 0644))
+	repository, err := git.PlainOpen(dirPath)
+	require.NoError(t, err)
+	worktree, err := repository.Worktree()
+	require.NoError(t, err)
+	_, err = worktree.Add("sub/d.proto")
+	require.NoError(t, err)
+
+	testGitReadBucket(
+		t,
+		func() (storage.ReadBucket, error) {
+			return storagegit.NewReadBucketForWorktree(filepath.Join(dirPath, ".git"), "")
+		},
+		map[string]string{
+			"a.proto":     "v3",
+			"sub/d.proto": "v3",
+		},
+	)
+	testGitReadBucket(
+		t,
+		func() (storage.ReadBucket, error) {
+			return storagegit.NewReadBucketForWorktree(dirPath, "sub")
+		},
+		map[string]string{
+			"d.proto": "v3",
+		},
+	)
+	_, err = storagegit.NewReadBucketForWorktree(dirPath, "foo")
+	assert.Error(t, err)
+}
+
+func testGitReadBucket(
+	t *testing.T,
+	newReadBucket func() (storage.ReadBucket, error),
+	expectedPathToData map[string]string,
+) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	bucket, err := newReadBucket()
+	require.NoError(t, err)
+	pathToData := make(map[string]string)
+	require.NoError(
+		t,
+		bucket.Walk(
+			ctx,
+			"",
+			func(path string) error {
+				objectInfo, err := bucket.Stat(ctx, path)
+				if err != nil {
+					return err
+				}
+				data, err := storageutil.ReadPath(ctx, bucket, path)
+				if err != nil {
+					return err
+				}
+				assert.Equal(t, len(data), int(objectInfo.Size), path)
+				pathToData[path] = string(data)
+				return nil
+			},
+		),
+	)
+	assert.Equal(t, expectedPathToData, pathToData)
+	assert.NoError(t, bucket.Close())
+	assert.Equal(t, storage.ErrClosed, bucket.Close())
+}
+
+// testNewGitRepository creates a repository with two commits on master.
+//
+// The first commit has a.proto with "v1" and is tagged v1.0.0.
+// The second commit has a.proto and sub/b.proto with "v2".
+//
+// Returns the directory of the repository, which must be removed by the caller,
+// and the hash of the first commit.
+func testNewGitRepository(t *testing.T) (string, string) {
+	dirPath, err := ioutil.TempDir("", "")
+	require.NoError(t, err)
+	repository, err := git.PlainInit(dirPath, false)
+	require.NoError(t, err)
+	worktree, err := repository.Worktree()
+	require.NoError(t, err)
+	signature := &object.Signature{
+		Name:  "test",
+		Email: "test@example.com",
+		When:  time.Unix(0, 0),
+	}
+
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dirPath, "a.proto"), []byte("v1"), 0644))
+	_, err = worktree.Add("a.proto")
+	require.NoError(t, err)
+	firstCommit, err := worktree.Commit("first", &git.CommitOptions{Author: signature})
+	require.NoError(t, err)
+	_, err = repository.CreateTag("v1.0.0", firstCommit, nil)
+	require.NoError(t, err)
+
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dirPath, "a.proto"), []byte("v2"), 0644))
+	require.NoError(t, os.MkdirAll(filepath.Join(dirPath, "sub"), 0755))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dirPath, "sub", "b.proto"), []byte("v2"), 0644))
+	_, err = worktree.Add("a.proto")
+	require.NoError(t, err)
+	_, err = worktree.Add("sub/b.proto")
+	require.NoError(t, err)
+	_, err = worktree.Commit("second", &git.CommitOptions{Author: signature})
+	require.NoError(t, err)
+
+	return dirPath, firstCommit.String()
+}
+
 func testBasic(
 	t *testing.T,
 	dirPath string,
@@ -220,7 +545,7 @@ func testBasic(
 		testBasicMem(
 			t,
 			dirPath,
-			false,
+			testArchiveTypeNone,
 			walkPrefix,
 			expectedPathToContent,
 			transformerOptions...,
@@ -231,7 +556,7 @@ func testBasic(
 		testBasicOS(
 			t,
 			dirPath,
-			false,
+			testArchiveTypeNone,
 			walkPrefix,
 			expectedPathToContent,
 			transformerOptions...,
@@ -242,7 +567,7 @@ func testBasic(
 		testBasicMem(
 			t,
 			dirPath,
-			true,
+			testArchiveTypeTar,
 			walkPrefix,
 			expectedPathToContent,
 			transformerOptions...,
@@ -253,7 +578,29 @@ func testBasic(
 		testBasicOS(
 			t,
 			dirPath,
-			true,
+			testArchiveTypeTar,
+			walkPrefix,
+			expectedPathToContent,
+			transformerOptions...,
+		)
+	})
+	t.Run("mem-zip", func(t *testing.T) {
+		t.Parallel()
+		testBasicMem(
+			t,
+			dirPath,
+			testArchiveTypeZip,
+			walkPrefix,
+			expectedPathToContent,
+			transformerOptions...,
+		)
+	})
+	t.Run("os-zip", func(t *testing.T) {
+		t.Parallel()
+		testBasicOS(
+			t,
+			dirPath,
+			testArchiveTypeZip,
 			walkPrefix,
 			expectedPathToContent,
 			transformerOptions...,
@@ -264,7 +611,7 @@ func testBasic(
 func testBasicMem(
 	t *testing.T,
 	dirPath string,
-	doAsTar bool,
+	archiveType testArchiveType,
 	walkPrefix string,
 	expectedPathToContent map[string]string,
 	transformerOptions ...storagepath.TransformerOption,
@@ -275,7 +622,7 @@ func testBasicMem(
 		t,
 		bucket,
 		dirPath,
-		doAsTar,
+		archiveType,
 		walkPrefix,
 		expectedPathToContent,
 		transformerOptions...,
@@ -290,7 +637,7 @@ func testBasicMem(
 func testBasicOS(
 	t *testing.T,
 	dirPath string,
-	doAsTar bool,
+	archiveType testArchiveType,
 	walkPrefix string,
 	expectedPathToContent map[string]string,
 	transformerOptions ...storagepath.TransformerOption,
@@ -308,7 +655,7 @@ func testBasicOS(
 		t,
 		bucket,
 		dirPath,
-		doAsTar,
+		archiveType,
 		walkPrefix,
 		expectedPathToContent,
 		transformerOptions...,
@@ -319,14 +666,15 @@ func testBasicBucket(
 	t *testing.T,
 	bucket storage.Bucket,
 	dirPath string,
-	doAsTar bool,
+	archiveType testArchiveType,
 	walkPrefix string,
 	expectedPathToContent map[string]string,
 	transformerOptions ...storagepath.TransformerOption,
 ) {
 	inputBucket, err := storageos.NewBucket(dirPath)
 	require.NoError(t, err)
-	if doAsTar {
+	switch archiveType {
+	case testArchiveTypeTar:
 		buffer := bytes.NewBuffer(nil)
 		require.NoError(t, storageutil.Targz(
 			context.Background(),
@@ -341,7 +689,23 @@ func testBasicBucket(
 			bucket,
 			transformerOptions...,
 		))
-	} else {
+	case testArchiveTypeZip:
+		buffer := bytes.NewBuffer(nil)
+		require.NoError(t, storageutil.Zip(
+			context.Background(),
+			buffer,
+			inputBucket,
+			"",
+		))
+		data := buffer.Bytes()
+		require.NoError(t, storageutil.Unzip(
+			context.Background(),
+			bytes.NewReader(data),
+			int64(len(data)),
+			bucket,
+			transformerOptions...,
+		))
+	default:
 		_, err := storageutil.Copy(
 			context.Background(),
 			inputBucket,
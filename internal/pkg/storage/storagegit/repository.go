@@ -0,0 +1,41 @@
+package storagegit
+
+import (
+	"path/filepath"
+
+	"github.com/bufbuild/buf/internal/pkg/errs"
+	"gopkg.in/src-d/go-git.v4"
+)
+
+// openRepository opens the local repository at repositoryPath.
+//
+// The repositoryPath may be the repository directory or its .git directory.
+func openRepository(repositoryPath string) (*git.Repository, error) {
+	absRepositoryPath, err := filepath.Abs(repositoryPath)
+	if err != nil {
+		return nil, err
+	}
+	// if we are given the .git directory, open the directory that contains
+	// it so that the working tree is available
+	if filepath.Base(absRepositoryPath) == git.GitDirName {
+		absRepositoryPath = filepath.Dir(absRepositoryPath)
+	}
+	repository, err := git.PlainOpen(absRepositoryPath)
+	if err != nil {
+		if err == git.ErrRepositoryNotExists {
+			return nil, errs.NewInvalidArgumentf("%s is not a git repository", repositoryPath)
+		}
+		return nil, err
+	}
+	return repository, nil
+}
+
+// getSubDirPrefix returns the prefix to add to bucket paths for the subDir.
+//
+// Returns "" if subDir is empty or ".".
+func getSubDirPrefix(subDir string) string {
+	if subDir == "" || subDir == "." {
+		return ""
+	}
+	return subDir + "/"
+}
@@ -7,7 +7,9 @@ import (
 	"context"
 	"io"
 	"math"
+	"os"
 	"runtime"
+	"strings"
 	"sync"
 
 	"github.com/bufbuild/buf/internal/pkg/errs"
@@ -22,55 +24,196 @@ import (
 	"gopkg.in/src-d/go-git.v4/storage/memory"
 )
 
+// BucketType is the bucket type.
+const BucketType = "git"
+
+// NewReadBucketForRef returns a new ReadBucket for the ref of the local
+// repository at repositoryPath.
+//
+// The repository is opened in place and files are read directly from the
+// object database, so no clone is done. The repositoryPath may be the
+// repository directory or its .git directory. The ref can be any revision
+// that Clone accepts for CloneOptions.Ref, including fully-qualified branch
+// and tag names such as refs/heads/master.
+//
+// If subDir is not empty, paths in the bucket will be relative to this directory.
+// SubDir must be normalized and validated if set.
+//
+// Only regular and executable files are handled.
+func NewReadBucketForRef(
+	repositoryPath string,
+	ref string,
+	subDir string,
+) (storage.ReadBucket, error) {
+	return newTreeBucket(repositoryPath, ref, subDir)
+}
+
+// NewReadBucketForWorktree returns a new ReadBucket for the working tree of the
+// local repository at repositoryPath.
+//
+// The files are the files in the index, read from the working tree, so that
+// uncommitted changes to tracked files are included. Untracked files are not
+// included until they are added to the index, and files deleted from the
+// working tree are not included.
+//
+// If subDir is not empty, paths in the bucket will be relative to this directory.
+// SubDir must be normalized and validated if set.
+//
+// Only regular and executable files are handled.
+func NewReadBucketForWorktree(
+	repositoryPath string,
+	subDir string,
+) (storage.ReadBucket, error) {
+	return newWorktreeBucket(repositoryPath, subDir)
+}
+
+// CloneOptions are options for Clone.
+//
+// Exactly one of Branch, Tag, or Ref must be set.
+type CloneOptions struct {
+	// Branch is the branch to clone.
+	Branch string
+	// Tag is the tag to clone.
+	Tag string
+	// Ref is the revision to check out, for example a commit SHA.
+	//
+	// Any revision understood by git rev-parse that go-git supports can be
+	// used, for example "HEAD~1" or "origin/master". If Depth is also set,
+	// the revision must be reachable within Depth commits of the remote branches.
+	Ref string
+	// SubDir is the subdirectory of the repository to copy to the bucket.
+	//
+	// Paths in the bucket will be relative to this directory.
+	// If empty, the entire repository is copied.
+	// Must be normalized and validated if set.
+	SubDir string
+	// Depth is the number of commits to clone.
+	//
+	// If 0, this defaults to 1 for Branch and Tag, and to the full history
+	// for Ref, as the history is needed to find the revision.
+	Depth uint32
+}
+
 // Clone clones the url into the bucket.
 //
-// This is roughly equivalent to git clone --branch gitBranch --single-branch --depth 1 gitUrl.
+// For branches and tags, this is roughly equivalent to
+// git clone --branch name --single-branch --depth depth gitUrl.
+// For refs, this is roughly equivalent to git clone gitUrl followed
+// by git checkout ref.
 // Only regular files are added to the bucket.
 //
-// Branch is required.
-//
 // This really needs more testing and cleanup
 // Only use for local CLI checking
 func Clone(
 	ctx context.Context,
 	logger *zap.Logger,
 	gitURL string,
-	gitBranch string,
+	cloneOptions CloneOptions,
 	bucket storage.Bucket,
 	options ...storagepath.TransformerOption,
 ) error {
 	defer logutil.Defer(logger, "git_clone")()
 
-	if gitBranch == "" {
-		// we detect this outside of this function so this is a system error
-		return errs.NewInternal("gitBranch is empty")
+	gitCloneOptions, err := getGitCloneOptions(gitURL, cloneOptions)
+	if err != nil {
+		return err
 	}
 	filesystem := memfs.New()
-	if _, err := git.CloneContext(
+	repository, err := git.CloneContext(
 		ctx,
 		memory.NewStorage(),
 		filesystem,
-		&git.CloneOptions{
-			URL:           gitURL,
-			ReferenceName: plumbing.NewBranchReferenceName(gitBranch),
-			SingleBranch:  true,
-			Depth:         1,
-		},
-	); err != nil {
+		gitCloneOptions,
+	)
+	if err != nil {
+		return err
+	}
+	if cloneOptions.Ref != "" {
+		if err := checkout(repository, cloneOptions.Ref); err != nil {
+			return err
+		}
+	}
+	dirPath := "/"
+	if cloneOptions.SubDir != "" && cloneOptions.SubDir != "." {
+		dirPath = "/" + cloneOptions.SubDir
+		fileInfo, err := filesystem.Stat(dirPath)
+		if err != nil {
+			if os.IsNotExist(err) {
+				return errs.NewInvalidArgumentf("subdirectory %q does not exist", cloneOptions.SubDir)
+			}
+			return err
+		}
+		if !fileInfo.IsDir() {
+			return errs.NewInvalidArgumentf("subdirectory %q is not a directory", cloneOptions.SubDir)
+		}
+	}
+	return copyBillyFilesystemToBucket(ctx, logger, filesystem, dirPath, bucket, options...)
+}
+
+func getGitCloneOptions(gitURL string, cloneOptions CloneOptions) (*git.CloneOptions, error) {
+	var count int
+	for _, value := range []string{cloneOptions.Branch, cloneOptions.Tag, cloneOptions.Ref} {
+		if value != "" {
+			count++
+		}
+	}
+	if count != 1 {
+		// we detect this outside of this function so this is a system error
+		return nil, errs.NewInternalf("exactly one of Branch, Tag, or Ref must be set but %d were set", count)
+	}
+	gitCloneOptions := &git.CloneOptions{
+		URL:   gitURL,
+		Depth: int(cloneOptions.Depth),
+	}
+	switch {
+	case cloneOptions.Branch != "":
+		gitCloneOptions.ReferenceName = plumbing.NewBranchReferenceName(cloneOptions.Branch)
+		gitCloneOptions.SingleBranch = true
+	case cloneOptions.Tag != "":
+		gitCloneOptions.ReferenceName = plumbing.NewTagReferenceName(cloneOptions.Tag)
+		gitCloneOptions.SingleBranch = true
+	case cloneOptions.Ref != "":
+		// we check out the ref after cloning
+		gitCloneOptions.NoCheckout = true
+	}
+	if cloneOptions.Ref == "" && gitCloneOptions.Depth == 0 {
+		gitCloneOptions.Depth = 1
+	}
+	return gitCloneOptions, nil
+}
+
+func checkout(repository *git.Repository, ref string) error {
+	hash, err := repository.ResolveRevision(plumbing.Revision(ref))
+	if err != nil {
+		if err == plumbing.ErrReferenceNotFound {
+			return errs.NewInvalidArgumentf("ref %q not found", ref)
+		}
 		return err
 	}
-	return copyBillyFilesystemToBucket(ctx, logger, filesystem, bucket, options...)
+	worktree, err := repository.Worktree()
+	if err != nil {
+		return err
+	}
+	return worktree.Checkout(
+		&git.CheckoutOptions{
+			Hash:  *hash,
+			Force: true,
+		},
+	)
 }
 
 func copyBillyFilesystemToBucket(
 	ctx context.Context,
 	logger *zap.Logger,
 	filesystem billy.Filesystem,
+	// dirPath will be the billy filesystem path, and paths will be relative to it
+	dirPath string,
 	bucket storage.Bucket,
 	options ...storagepath.TransformerOption,
 ) error {
 	defer logutil.Defer(logger, "git_clone_copy")()
 
+	prefix := strings.TrimSuffix(dirPath, "/") + "/"
 	transformer := storagepath.NewTransformer(options...)
 	semaphoreC := make(chan struct{}, runtime.NumCPU())
 	var retErr error
@@ -80,11 +223,11 @@ func copyBillyFilesystemToBucket(
 		ctx,
 		filesystem,
 		func(regularFilePath string, regularFileSize uint32) error {
-			if regularFilePath == "" || regularFilePath[0] != '/' {
+			if !strings.HasPrefix(regularFilePath, prefix) {
 				return errs.NewInternalf("invalid regularFilePath: %q", regularFilePath)
 			}
 			// just to make sure
-			path, err := storagepath.NormalizeAndValidate(regularFilePath[1:])
+			path, err := storagepath.NormalizeAndValidate(strings.TrimPrefix(regularFilePath, prefix))
 			if err != nil {
 				return err
 			}
@@ -104,7 +247,7 @@ func copyBillyFilesystemToBucket(
 			}()
 			return nil
 		},
-		"/",
+		dirPath,
 	); walkErr != nil {
 		return walkErr
 	}
@@ -0,0 +1,240 @@
+package storagegit
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"math"
+	"sync"
+
+	"github.com/bufbuild/buf/internal/pkg/errs"
+	"github.com/bufbuild/buf/internal/pkg/storage"
+	"github.com/bufbuild/buf/internal/pkg/storage/storagepath"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+	"gopkg.in/src-d/go-git.v4/plumbing/filemode"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+)
+
+type treeBucket struct {
+	tree   *object.Tree
+	closed bool
+	// the object database is not safe for concurrent use, so
+	// this lock is held for all object access
+	lock sync.Mutex
+}
+
+func newTreeBucket(repositoryPath string, ref string, subDir string) (*treeBucket, error) {
+	repository, err := openRepository(repositoryPath)
+	if err != nil {
+		return nil, err
+	}
+	hash, err := repository.ResolveRevision(plumbing.Revision(ref))
+	if err != nil {
+		if err == plumbing.ErrReferenceNotFound {
+			return nil, errs.NewInvalidArgumentf("ref %q not found", ref)
+		}
+		return nil, err
+	}
+	commit, err := repository.CommitObject(*hash)
+	if err != nil {
+		return nil, err
+	}
+	tree, err := commit.Tree()
+	if err != nil {
+		return nil, err
+	}
+	if subDirPrefix := getSubDirPrefix(subDir); subDirPrefix != "" {
+		tree, err = tree.Tree(subDir)
+		if err != nil {
+			if err == object.ErrDirectoryNotFound {
+				return nil, errs.NewInvalidArgumentf("subdirectory %q does not exist at ref %q", subDir, ref)
+			}
+			return nil, err
+		}
+	}
+	return &treeBucket{
+		tree: tree,
+	}, nil
+}
+
+func (b *treeBucket) Type() string {
+	return BucketType
+}
+
+func (b *treeBucket) Get(ctx context.Context, path string) (storage.ReadObject, error) {
+	path, err := storagepath.NormalizeAndValidate(path)
+	if err != nil {
+		return nil, err
+	}
+	if path == "." {
+		return nil, errs.NewInternal("cannot get root")
+	}
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if b.closed {
+		return nil, storage.ErrClosed
+	}
+	file, err := b.getFile(path)
+	if err != nil {
+		return nil, err
+	}
+	// we read the entire blob while holding the lock as the
+	// object database is not safe for concurrent use
+	reader, err := file.Reader()
+	if err != nil {
+		return nil, err
+	}
+	data, err := ioutil.ReadAll(reader)
+	if err = errs.Append(err, reader.Close()); err != nil {
+		return nil, err
+	}
+	return newBytesReadObject(data), nil
+}
+
+func (b *treeBucket) Stat(ctx context.Context, path string) (storage.ObjectInfo, error) {
+	path, err := storagepath.NormalizeAndValidate(path)
+	if err != nil {
+		return storage.ObjectInfo{}, err
+	}
+	if path == "." {
+		return storage.ObjectInfo{}, errs.NewInternal("cannot check root")
+	}
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if b.closed {
+		return storage.ObjectInfo{}, storage.ErrClosed
+	}
+	file, err := b.getFile(path)
+	if err != nil {
+		return storage.ObjectInfo{}, err
+	}
+	if file.Size > math.MaxUint32 {
+		return storage.ObjectInfo{}, errs.NewInternalf("size %d is greater than uint32", file.Size)
+	}
+	return storage.ObjectInfo{
+		Size: uint32(file.Size),
+	}, nil
+}
+
+func (b *treeBucket) Walk(ctx context.Context, prefix string, f func(string) error) error {
+	prefix, err := storagepath.NormalizeAndValidate(prefix)
+	if err != nil {
+		return err
+	}
+	b.lock.Lock()
+	if b.closed {
+		b.lock.Unlock()
+		return storage.ErrClosed
+	}
+	paths, err := b.getPaths(ctx, prefix)
+	b.lock.Unlock()
+	if err != nil {
+		return err
+	}
+	// we call f without holding the lock so that f can call Get
+	for _, path := range paths {
+		if err := f(path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (b *treeBucket) Close() error {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if b.closed {
+		return storage.ErrClosed
+	}
+	b.closed = true
+	return nil
+}
+
+// getFile gets the regular file at the path.
+//
+// Must be called while holding the lock.
+func (b *treeBucket) getFile(path string) (*object.File, error) {
+	file, err := b.tree.File(path)
+	if err != nil {
+		if err == object.ErrFileNotFound || err == object.ErrDirectoryNotFound || err == object.ErrEntryNotFound {
+			return nil, storage.NewErrNotExist(path)
+		}
+		return nil, err
+	}
+	if !isRegularFileMode(file.Mode) {
+		return nil, storage.NewErrNotExist(path)
+	}
+	return file, nil
+}
+
+// getPaths gets the paths of the regular files within the prefix.
+//
+// Must be called while holding the lock.
+func (b *treeBucket) getPaths(ctx context.Context, prefix string) ([]string, error) {
+	tree := b.tree
+	pathPrefix := ""
+	if prefix != "." {
+		var err error
+		tree, err = tree.Tree(prefix)
+		if err != nil {
+			if err == object.ErrDirectoryNotFound {
+				return nil, nil
+			}
+			return nil, err
+		}
+		pathPrefix = prefix + "/"
+	}
+	var paths []string
+	fileCount := 0
+	if err := tree.Files().ForEach(
+		func(file *object.File) error {
+			fileCount++
+			select {
+			case <-ctx.Done():
+				err := ctx.Err()
+				if err == context.DeadlineExceeded {
+					return errs.NewDeadlineExceededf("timed out after walking %d files", fileCount)
+				}
+				return err
+			default:
+			}
+			if !isRegularFileMode(file.Mode) {
+				return nil
+			}
+			path, err := storagepath.NormalizeAndValidate(pathPrefix + file.Name)
+			if err != nil {
+				return err
+			}
+			paths = append(paths, path)
+			return nil
+		},
+	); err != nil {
+		return nil, err
+	}
+	return paths, nil
+}
+
+func isRegularFileMode(fileMode filemode.FileMode) bool {
+	return fileMode == filemode.Regular || fileMode == filemode.Executable || fileMode == filemode.Deprecated
+}
+
+type bytesReadObject struct {
+	*bytes.Reader
+
+	size uint32
+}
+
+func newBytesReadObject(data []byte) *bytesReadObject {
+	return &bytesReadObject{
+		Reader: bytes.NewReader(data),
+		size:   uint32(len(data)),
+	}
+}
+
+func (r *bytesReadObject) Size() uint32 {
+	return r.size
+}
+
+func (r *bytesReadObject) Close() error {
+	return nil
+}
@@ -0,0 +1,222 @@
+package storagegit
+
+import (
+	"context"
+	"math"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/bufbuild/buf/internal/pkg/errs"
+	"github.com/bufbuild/buf/internal/pkg/storage"
+	"github.com/bufbuild/buf/internal/pkg/storage/storagepath"
+	"gopkg.in/src-d/go-billy.v4"
+)
+
+type worktreeBucket struct {
+	filesystem billy.Filesystem
+	// paths are the sorted bucket paths of the files in the index
+	paths []string
+	// pathToFilesystemPath maps bucket paths to paths within filesystem
+	pathToFilesystemPath map[string]string
+	closed               bool
+	lock                 sync.RWMutex
+}
+
+func newWorktreeBucket(repositoryPath string, subDir string) (*worktreeBucket, error) {
+	repository, err := openRepository(repositoryPath)
+	if err != nil {
+		return nil, err
+	}
+	worktree, err := repository.Worktree()
+	if err != nil {
+		return nil, err
+	}
+	gitIndex, err := repository.Storer.Index()
+	if err != nil {
+		return nil, err
+	}
+	subDirPrefix := getSubDirPrefix(subDir)
+	pathToFilesystemPath := make(map[string]string)
+	for _, entry := range gitIndex.Entries {
+		// entries with unmerged changes have one entry per stage with the
+		// same name, we read the working tree so they are all the same to us
+		if entry.SkipWorktree {
+			continue
+		}
+		if !isRegularFileMode(entry.Mode) {
+			continue
+		}
+		if !strings.HasPrefix(entry.Name, subDirPrefix) {
+			continue
+		}
+		path, err := storagepath.NormalizeAndValidate(strings.TrimPrefix(entry.Name, subDirPrefix))
+		if err != nil {
+			return nil, err
+		}
+		pathToFilesystemPath[path] = entry.Name
+	}
+	if subDirPrefix != "" && len(pathToFilesystemPath) == 0 {
+		return nil, errs.NewInvalidArgumentf("subdirectory %q has no files in the index", subDir)
+	}
+	paths := make([]string, 0, len(pathToFilesystemPath))
+	for path := range pathToFilesystemPath {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return &worktreeBucket{
+		filesystem:           worktree.Filesystem,
+		paths:                paths,
+		pathToFilesystemPath: pathToFilesystemPath,
+	}, nil
+}
+
+func (b *worktreeBucket) Type() string {
+	return BucketType
+}
+
+func (b *worktreeBucket) Get(ctx context.Context, path string) (storage.ReadObject, error) {
+	path, err := storagepath.NormalizeAndValidate(path)
+	if err != nil {
+		return nil, err
+	}
+	if path == "." {
+		return nil, errs.NewInternal("cannot get root")
+	}
+	b.lock.RLock()
+	defer b.lock.RUnlock()
+	if b.closed {
+		return nil, storage.ErrClosed
+	}
+	filesystemPath, size, err := b.stat(path)
+	if err != nil {
+		return nil, err
+	}
+	file, err := b.filesystem.Open(filesystemPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, storage.NewErrNotExist(path)
+		}
+		return nil, err
+	}
+	return newFileReadObject(file, size), nil
+}
+
+func (b *worktreeBucket) Stat(ctx context.Context, path string) (storage.ObjectInfo, error) {
+	path, err := storagepath.NormalizeAndValidate(path)
+	if err != nil {
+		return storage.ObjectInfo{}, err
+	}
+	if path == "." {
+		return storage.ObjectInfo{}, errs.NewInternal("cannot check root")
+	}
+	b.lock.RLock()
+	defer b.lock.RUnlock()
+	if b.closed {
+		return storage.ObjectInfo{}, storage.ErrClosed
+	}
+	_, size, err := b.stat(path)
+	if err != nil {
+		return storage.ObjectInfo{}, err
+	}
+	return storage.ObjectInfo{
+		Size: size,
+	}, nil
+}
+
+func (b *worktreeBucket) Walk(ctx context.Context, prefix string, f func(string) error) error {
+	prefix, err := storagepath.NormalizeAndValidate(prefix)
+	if err != nil {
+		return err
+	}
+	// without this, "internal/buf/proto" would call f for "internal/buf/protocompile"
+	if prefix != "." {
+		prefix = prefix + "/"
+	}
+	b.lock.RLock()
+	defer b.lock.RUnlock()
+	if b.closed {
+		return storage.ErrClosed
+	}
+	fileCount := 0
+	for _, path := range b.paths {
+		if prefix != "." && !strings.HasPrefix(path, prefix) {
+			continue
+		}
+		fileCount++
+		select {
+		case <-ctx.Done():
+			err := ctx.Err()
+			if err == context.DeadlineExceeded {
+				return errs.NewDeadlineExceededf("timed out after walking %d files", fileCount)
+			}
+			return err
+		default:
+		}
+		if _, _, err := b.stat(path); err != nil {
+			// files deleted from the working tree are skipped
+			if storage.IsNotExist(err) {
+				continue
+			}
+			return err
+		}
+		if err := f(path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (b *worktreeBucket) Close() error {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if b.closed {
+		return storage.ErrClosed
+	}
+	b.closed = true
+	return nil
+}
+
+// stat returns the filesystem path and size of the regular file at the path.
+//
+// Returns storage.ErrNotExist if the path is not in the index, or if it is
+// not a regular file within the working tree.
+func (b *worktreeBucket) stat(path string) (string, uint32, error) {
+	filesystemPath, ok := b.pathToFilesystemPath[path]
+	if !ok {
+		return "", 0, storage.NewErrNotExist(path)
+	}
+	fileInfo, err := b.filesystem.Lstat(filesystemPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", 0, storage.NewErrNotExist(path)
+		}
+		return "", 0, err
+	}
+	if !fileInfo.Mode().IsRegular() {
+		return "", 0, storage.NewErrNotExist(path)
+	}
+	size := fileInfo.Size()
+	if size > math.MaxUint32 {
+		return "", 0, errs.NewInternalf("size %d is greater than uint32", size)
+	}
+	return filesystemPath, uint32(size), nil
+}
+
+type fileReadObject struct {
+	billy.File
+
+	size uint32
+}
+
+func newFileReadObject(file billy.File, size uint32) *fileReadObject {
+	return &fileReadObject{
+		File: file,
+		size: size,
+	}
+}
+
+func (r *fileReadObject) Size() uint32 {
+	return r.size
+}
@@ -0,0 +1,108 @@
+package storageutil
+
+import (
+	"bytes"
+	"context"
+	"strings"
+
+	"github.com/bufbuild/buf/internal/pkg/storage"
+	"github.com/bufbuild/buf/internal/pkg/storage/storagepath"
+)
+
+type overlayReadBucket struct {
+	delegate   storage.ReadBucket
+	pathToData map[string][]byte
+}
+
+func newOverlayReadBucket(delegate storage.ReadBucket, pathToData map[string][]byte) *overlayReadBucket {
+	return &overlayReadBucket{
+		delegate:   delegate,
+		pathToData: pathToData,
+	}
+}
+
+func (o *overlayReadBucket) Type() string {
+	return o.delegate.Type()
+}
+
+func (o *overlayReadBucket) Get(ctx context.Context, path string) (storage.ReadObject, error) {
+	path, err := storagepath.NormalizeAndValidate(path)
+	if err != nil {
+		return nil, err
+	}
+	if data, ok := o.pathToData[path]; ok {
+		return newOverlayReadObject(data), nil
+	}
+	return o.delegate.Get(ctx, path)
+}
+
+func (o *overlayReadBucket) Stat(ctx context.Context, path string) (storage.ObjectInfo, error) {
+	path, err := storagepath.NormalizeAndValidate(path)
+	if err != nil {
+		return storage.ObjectInfo{}, err
+	}
+	if data, ok := o.pathToData[path]; ok {
+		return storage.ObjectInfo{
+			Size: uint32(len(data)),
+		}, nil
+	}
+	return o.delegate.Stat(ctx, path)
+}
+
+func (o *overlayReadBucket) Walk(ctx context.Context, prefix string, f func(string) error) error {
+	prefix, err := storagepath.NormalizeAndValidate(prefix)
+	if err != nil {
+		return err
+	}
+	seen := make(map[string]struct{})
+	if err := o.delegate.Walk(
+		ctx,
+		prefix,
+		func(path string) error {
+			seen[path] = struct{}{}
+			return f(path)
+		},
+	); err != nil {
+		return err
+	}
+	// paths that do not exist in the delegate
+	//
+	// the prefix is matched as a directory, so that the prefix "foo" does
+	// not match the path "foobar/a.proto"
+	if prefix != "." {
+		prefix = prefix + "/"
+	}
+	for path := range o.pathToData {
+		if _, ok := seen[path]; ok {
+			continue
+		}
+		if prefix == "." || strings.HasPrefix(path, prefix) {
+			if err := f(path); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (o *overlayReadBucket) Close() error {
+	return o.delegate.Close()
+}
+
+type overlayReadObject struct {
+	*bytes.Reader
+}
+
+func newOverlayReadObject(data []byte) *overlayReadObject {
+	return &overlayReadObject{
+		Reader: bytes.NewReader(data),
+	}
+}
+
+func (r *overlayReadObject) Close() error {
+	return nil
+}
+
+func (r *overlayReadObject) Size() uint32 {
+	return uint32(r.Reader.Size())
+}
@@ -3,10 +3,12 @@ package storageutil
 
 import (
 	"archive/tar"
+	"archive/zip"
 	"compress/gzip"
 	"context"
 	"io"
 	"io/ioutil"
+	"math"
 	"runtime"
 	"sync"
 
@@ -212,6 +214,74 @@ func untar(
 	return nil
 }
 
+// Unzip unzips the given zip archive from the reader into the bucket.
+//
+// Only regular files are added to the bucket.
+//
+// Paths from the zip archive will be transformed before adding to the bucket.
+func Unzip(
+	ctx context.Context,
+	readerAt io.ReaderAt,
+	size int64,
+	bucket storage.Bucket,
+	options ...storagepath.TransformerOption,
+) error {
+	transformer := storagepath.NewTransformer(options...)
+	zipReader, err := zip.NewReader(readerAt, size)
+	if err != nil {
+		return err
+	}
+	for i, zipFile := range zipReader.File {
+		select {
+		case <-ctx.Done():
+			err := ctx.Err()
+			if err == context.DeadlineExceeded {
+				return errs.NewDeadlineExceededf("timed out after unzipping %d files", i)
+			}
+			return err
+		default:
+		}
+		path, err := storagepath.NormalizeAndValidate(zipFile.Name)
+		if err != nil {
+			return err
+		}
+		if path == "." {
+			continue
+		}
+		path, ok := transformer.Transform(path)
+		if !ok {
+			continue
+		}
+		if zipFile.FileInfo().Mode().IsRegular() {
+			if zipFile.UncompressedSize64 > math.MaxUint32 {
+				return errs.NewInvalidArgumentf("size %d of %q is greater than uint32", zipFile.UncompressedSize64, zipFile.Name)
+			}
+			if err := unzipFile(ctx, zipFile, bucket, path); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func unzipFile(
+	ctx context.Context,
+	zipFile *zip.File,
+	bucket storage.Bucket,
+	path string,
+) error {
+	readCloser, err := zipFile.Open()
+	if err != nil {
+		return err
+	}
+	writeObject, err := bucket.Put(ctx, path, uint32(zipFile.UncompressedSize64))
+	if err != nil {
+		return errs.Append(err, readCloser.Close())
+	}
+	_, err = io.Copy(writeObject, readCloser)
+	return errs.Append(err, errs.Append(writeObject.Close(), readCloser.Close()))
+}
+
 // Tar tars the given bucket to the writer.
 //
 // Only regular files are added to the writer.
@@ -293,6 +363,60 @@ func doTar(
 	)
 }
 
+// Zip zips the given bucket to the writer.
+//
+// Only regular files are added to the writer.
+// All files are written as 0644.
+//
+// Paths from the bucket will be transformed before adding to the writer.
+func Zip(
+	ctx context.Context,
+	writer io.Writer,
+	bucket storage.Bucket,
+	prefix string,
+	options ...storagepath.TransformerOption,
+) (retErr error) {
+	transformer := storagepath.NewTransformer(options...)
+	zipWriter := zip.NewWriter(writer)
+	defer func() {
+		retErr = errs.Append(retErr, zipWriter.Close())
+	}()
+	return bucket.Walk(
+		ctx,
+		prefix,
+		func(path string) error {
+			newPath, ok := transformer.Transform(path)
+			if !ok {
+				return nil
+			}
+			readObject, err := bucket.Get(ctx, path)
+			if err != nil {
+				return err
+			}
+			zipFileHeader := &zip.FileHeader{
+				Name:   newPath,
+				Method: zip.Deflate,
+			}
+			zipFileHeader.SetMode(0644)
+			fileWriter, err := zipWriter.CreateHeader(zipFileHeader)
+			if err != nil {
+				return errs.Append(err, readObject.Close())
+			}
+			_, err = io.Copy(fileWriter, readObject)
+			return errs.Append(err, readObject.Close())
+		},
+	)
+}
+
+// NewOverlayReadBucket returns a new ReadBucket that reads the data in pathToData
+// instead of the contents of the delegate.
+//
+// The paths in pathToData must be normalized and validated, and do not need to exist in the delegate.
+// Close closes the delegate.
+func NewOverlayReadBucket(delegate storage.ReadBucket, pathToData map[string][]byte) storage.ReadBucket {
+	return newOverlayReadBucket(delegate, pathToData)
+}
+
 // ReadPath is analogous to ioutil.ReadFile.
 //
 // Returns an error that fufills storage.IsNotExist if the path does not exist.
@@ -308,3 +432,15 @@ func ReadPath(ctx context.Context, bucket storage.ReadBucket, path string) (_ []
 	}()
 	return ioutil.ReadAll(readObject)
 }
+
+// WritePath is analogous to ioutil.WriteFile.
+//
+// The path is truncated beforehand.
+func WritePath(ctx context.Context, bucket storage.Bucket, path string, data []byte) error {
+	writeObject, err := bucket.Put(ctx, path, uint32(len(data)))
+	if err != nil {
+		return err
+	}
+	_, err = writeObject.Write(data)
+	return errs.Append(err, writeObject.Close())
+}